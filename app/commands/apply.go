@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"apioak-admin/app/services"
+	"apioak-admin/app/utils"
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const applyUsage = "apply -f file [-prune] [-release] [-yes] [-check]"
+
+var applyActionSymbols = map[string]string{
+	utils.ApplyActionCreate: "+",
+	utils.ApplyActionUpdate: "~",
+	utils.ApplyActionDelete: "-",
+}
+
+// Apply 声明式同步，对比配置文件与控制面数据，确认后按计划执行变更
+func Apply(args []string) error {
+	flagSet := flag.NewFlagSet("apply", flag.ContinueOnError)
+	file := flagSet.String("f", "", "desired state file, yaml or json")
+	prune := flagSet.Bool("prune", false, "delete the data not in the file")
+	release := flagSet.Bool("release", false, "release the resources in the file after applying")
+	yes := flagSet.Bool("yes", false, "apply without confirmation")
+	check := flagSet.Bool("check", false, "only print the plan, exit with an error when there are changes")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	if len(*file) == 0 {
+		return errors.New("missing desired state file, usage: apioak-admin " + applyUsage)
+	}
+
+	content, err := ioutil.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+
+	state, err := services.ApplyStateDecode(content)
+	if err != nil {
+		return err
+	}
+
+	plan, err := services.ApplyDiff(&state, *prune)
+	if err != nil {
+		return err
+	}
+
+	printApplyPlan(&plan)
+
+	if *check {
+		if len(plan.Changes) != 0 {
+			return fmt.Errorf("drift detected: %d changes", len(plan.Changes))
+		}
+		return nil
+	}
+
+	if (len(plan.Changes) != 0) && !*yes && !confirmApply() {
+		fmt.Println("apply cancelled")
+		return nil
+	}
+
+	if len(plan.Changes) != 0 {
+		if err = services.ApplyExecute(&plan); err != nil {
+			return err
+		}
+		fmt.Printf("applied %d changes\n", len(plan.Changes))
+	}
+
+	if !*release {
+		return nil
+	}
+
+	released, err := services.ApplyRelease(&plan, utils.ReleaseOperatorCli)
+	for _, name := range released {
+		fmt.Printf("released %s\n", name)
+	}
+
+	return err
+}
+
+func printApplyPlan(plan *services.ApplyPlan) {
+	if len(plan.Changes) == 0 {
+		fmt.Println("no changes, the data is up to date")
+		return
+	}
+
+	count := make(map[string]int)
+	for _, change := range plan.Changes {
+		count[change.Action]++
+
+		line := fmt.Sprintf("%s %s %s", applyActionSymbols[change.Action], change.Resource, change.Name)
+		if len(change.ResID) != 0 {
+			line = line + " (" + change.ResID + ")"
+		}
+		if len(change.Fields) != 0 {
+			line = line + ": " + strings.Join(change.Fields, ", ")
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Printf("plan: %d to create, %d to update, %d to delete\n",
+		count[utils.ApplyActionCreate], count[utils.ApplyActionUpdate], count[utils.ApplyActionDelete])
+}
+
+func confirmApply() bool {
+	fmt.Print("apply these changes? only 'yes' will be accepted: ")
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	return strings.TrimSpace(answer) == "yes"
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type command struct {
+	Usage string
+	Run   func(args []string) error
+}
+
+var commandList = map[string]command{
+	"apply": {
+		Usage: applyUsage,
+		Run:   Apply,
+	},
+	"migrate": {
+		Usage: migrateUsage,
+		Run:   Migrate,
+	},
+	"rotate-keys": {
+		Usage: rotateKeysUsage,
+		Run:   RotateKeys,
+	},
+	"snapshot": {
+		Usage: snapshotUsage,
+		Run:   Snapshot,
+	},
+}
+
+// Run 执行启动参数对应的子命令
+func Run(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command\n%s", Usage())
+	}
+
+	cmd, ok := commandList[strings.ToLower(strings.TrimSpace(args[0]))]
+	if !ok {
+		return fmt.Errorf("unknown command `%s`\n%s", args[0], Usage())
+	}
+
+	return cmd.Run(args[1:])
+}
+
+// MigrationRequired 除 migrate 外的子命令执行前需要先执行数据表迁移
+func MigrationRequired(args []string) bool {
+	return (len(args) == 0) || (strings.ToLower(strings.TrimSpace(args[0])) != "migrate")
+}
+
+func Usage() string {
+	usage := "usage:"
+	for _, name := range commandNames() {
+		usage = usage + "\n  apioak-admin " + commandList[name].Usage
+	}
+
+	return usage
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commandList))
+	for name := range commandList {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
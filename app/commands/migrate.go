@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"apioak-admin/app/migrations"
+	"apioak-admin/app/packages"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const migrateUsage = "migrate up | down [steps] | status"
+
+// Migrate 数据表迁移 migrate up | down [steps] | status
+func Migrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: apioak-admin " + migrateUsage)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(args[0])) {
+	case "up":
+		return migrateUp()
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			number, err := strconv.Atoi(args[1])
+			if (err != nil) || (number <= 0) {
+				return fmt.Errorf("invalid migrate down steps `%s`", args[1])
+			}
+			steps = number
+		}
+		return migrateDown(steps)
+	case "status":
+		return migrateStatus()
+	}
+
+	return fmt.Errorf("unknown migrate action `%s`, usage: apioak-admin %s", args[0], migrateUsage)
+}
+
+func migrateUp() error {
+	applied, err := migrations.Up(packages.GetDb())
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		fmt.Println("no pending migrations")
+		return nil
+	}
+
+	for _, migration := range applied {
+		fmt.Printf("applied  %04d_%s\n", migration.Version, migration.Name)
+	}
+
+	return nil
+}
+
+func migrateDown(steps int) error {
+	reverted, err := migrations.Down(packages.GetDb(), steps)
+	if err != nil {
+		return err
+	}
+
+	if len(reverted) == 0 {
+		fmt.Println("no applied migrations")
+		return nil
+	}
+
+	for _, migration := range reverted {
+		fmt.Printf("reverted %04d_%s\n", migration.Version, migration.Name)
+	}
+
+	return nil
+}
+
+func migrateStatus() error {
+	statusList, err := migrations.Status(packages.GetDb())
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statusList {
+		if status.Applied {
+			fmt.Printf("applied  %04d_%s  %s\n", status.Version, status.Name, status.AppliedAt.Format("2006-01-02 15:04:05"))
+		} else {
+			fmt.Printf("pending  %04d_%s\n", status.Version, status.Name)
+		}
+	}
+
+	return nil
+}
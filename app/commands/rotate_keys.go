@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"apioak-admin/app/services"
+	"flag"
+	"fmt"
+)
+
+const rotateKeysUsage = "rotate-keys [-dry-run]"
+
+// RotateKeys 使用 encryption.current_version 的主密钥重新加密私钥与插件密钥
+func RotateKeys(args []string) error {
+	flagSet := flag.NewFlagSet("rotate-keys", flag.ContinueOnError)
+	dryRun := flagSet.Bool("dry-run", false, "only count the values to re-encrypt")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	result, err := services.EncryptionRotate(*dryRun)
+	if err != nil {
+		return err
+	}
+
+	action := "rotated"
+	if *dryRun {
+		action = "to rotate"
+	}
+	countList := []struct {
+		name  string
+		count int
+	}{
+		{name: "certificates", count: result.Certificates},
+		{name: "acme_accounts", count: result.AcmeAccounts},
+		{name: "plugin_configs", count: result.PluginConfigs},
+		{name: "release_logs", count: result.ReleaseLogs},
+	}
+	for _, item := range countList {
+		fmt.Printf("%-16s %s %d\n", item.name, action, item.count)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"apioak-admin/app/services"
+	"apioak-admin/app/utils"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const snapshotUsage = "snapshot export [-format json|yaml] [-plaintext] [-o file] | import -f file [-mode merge|replace] [-dry-run]"
+
+// Snapshot 配置快照 snapshot export | import
+func Snapshot(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: apioak-admin " + snapshotUsage)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(args[0])) {
+	case "export":
+		return snapshotExport(args[1:])
+	case "import":
+		return snapshotImport(args[1:])
+	}
+
+	return fmt.Errorf("unknown snapshot action `%s`, usage: apioak-admin %s", args[0], snapshotUsage)
+}
+
+func snapshotExport(args []string) error {
+	flagSet := flag.NewFlagSet("snapshot export", flag.ContinueOnError)
+	format := flagSet.String("format", utils.SnapshotFormatJson, "snapshot format, json or yaml")
+	output := flagSet.String("o", "", "output file, default stdout")
+	plaintext := flagSet.Bool("plaintext", false, "export private keys and plugin secrets decrypted")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	if (*format != utils.SnapshotFormatJson) && (*format != utils.SnapshotFormatYaml) {
+		return fmt.Errorf("invalid snapshot format `%s`", *format)
+	}
+
+	snapshot, err := services.NewSnapshotService().SnapshotExport(*plaintext)
+	if err != nil {
+		return err
+	}
+
+	content, err := services.SnapshotEncode(snapshot, *format)
+	if err != nil {
+		return err
+	}
+
+	if len(*output) == 0 {
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+
+	return ioutil.WriteFile(*output, content, 0600)
+}
+
+func snapshotImport(args []string) error {
+	flagSet := flag.NewFlagSet("snapshot import", flag.ContinueOnError)
+	file := flagSet.String("f", "", "snapshot file, json or yaml")
+	mode := flagSet.String("mode", utils.SnapshotModeMerge, "import mode, merge or replace")
+	dryRun := flagSet.Bool("dry-run", false, "only report the plan of the import, nothing is committed")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	if len(*file) == 0 {
+		return errors.New("missing snapshot file, usage: apioak-admin " + snapshotUsage)
+	}
+
+	content, err := ioutil.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := services.SnapshotDecode(content)
+	if err != nil {
+		return err
+	}
+
+	result, err := services.NewSnapshotService().SnapshotImport(&snapshot, *mode, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("mode: %s, dry run: %t, version: %d\n", result.Mode, result.DryRun, result.Version)
+	planList := []struct {
+		name string
+		plan services.SnapshotImportPlan
+	}{
+		{name: "services", plan: result.Services},
+		{name: "service_domains", plan: result.ServiceDomains},
+		{name: "routers", plan: result.Routers},
+		{name: "upstreams", plan: result.Upstreams},
+		{name: "upstream_nodes", plan: result.UpstreamNodes},
+		{name: "plugin_configs", plan: result.PluginConfigs},
+		{name: "certificates", plan: result.Certificates},
+	}
+	for _, item := range planList {
+		fmt.Printf("%-16s create %d  update %d  delete %d  unchanged %d\n",
+			item.name, item.plan.Create, item.plan.Update, item.plan.Delete, item.plan.Unchanged)
+	}
+
+	return nil
+}
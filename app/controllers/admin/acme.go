@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/services"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+func AcmeCertificateAdd(c *gin.Context) {
+	var request = &validators.AcmeCertificateAdd{}
+	if msg, err := packages.ParseRequestParams(c, request); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	resIds, err := services.AcmeCertificateAdd(request)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, map[string][]string{"res_ids": resIds})
+}
+
+func AcmeCertificateList(c *gin.Context) {
+	var bindParams = validators.AcmeCertificateList{}
+	if msg, err := packages.ParseRequestParams(c, &bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	list, total, err := services.AcmeCertificateList(&bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	result := utils.ResultPage{}
+	result.Param = bindParams
+	result.Page = bindParams.Page
+	result.PageSize = bindParams.PageSize
+	result.Total = total
+	result.Data = list
+
+	utils.Ok(c, result)
+}
+
+func AcmeCertificateRenew(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+	if resId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	err := services.AcmeCertificateRenew(resId)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+func AcmeCertificateDelete(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+	if resId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	err := services.AcmeCertificateDelete(resId)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+// AcmeHttp01Challenge 响应 ACME 服务的 http-01 验证请求，无需登录
+func AcmeHttp01Challenge(c *gin.Context) {
+	response, ok := services.AcmeHttp01Response(c.Param("token"))
+	if !ok {
+		c.String(http.StatusNotFound, "")
+		return
+	}
+
+	c.String(http.StatusOK, response)
+}
@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"apioak-admin/app/packages"
+	"apioak-admin/app/services"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"github.com/gin-gonic/gin"
+)
+
+func AuditLogList(c *gin.Context) {
+	var bindParams = validators.AuditLogList{}
+	if msg, err := packages.ParseRequestParams(c, &bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	list, total, err := services.AuditLogList(&bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	result := utils.ResultPage{}
+	result.Param = bindParams
+	result.Page = bindParams.Page
+	result.PageSize = bindParams.PageSize
+	result.Total = total
+	result.Data = list
+
+	utils.Ok(c, result)
+}
@@ -17,7 +17,7 @@ func CertificateAdd(c *gin.Context) {
 		utils.Error(c, msg)
 		return
 	}
-	err := services.NewCertificateService().CertificateAdd(request)
+	_, err := services.NewCertificateService().CertificateAdd(request)
 
 	if err != nil {
 		utils.Error(c, err.Error())
@@ -84,6 +84,22 @@ func CertificateList(c *gin.Context) {
 	})
 }
 
+func CertificateExpiringList(c *gin.Context) {
+	var bindParams = validators.CertificateExpiring{}
+	if msg, err := packages.ParseRequestParams(c, &bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	list, err := services.CertificateExpiringList(&bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, list)
+}
+
 func CertificateDelete(c *gin.Context) {
 	resID := strings.TrimSpace(c.Param("id"))
 
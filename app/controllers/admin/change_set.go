@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/services"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+func ChangeSetAdd(c *gin.Context) {
+	var request = &validators.ChangeSetAdd{}
+	if msg, err := packages.ParseRequestParams(c, request); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	resId, err := services.NewChangeSetService().ChangeSetAdd(request)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, map[string]string{"res_id": resId})
+}
+
+func ChangeSetList(c *gin.Context) {
+	var bindParams = validators.ChangeSetList{}
+	if msg, err := packages.ParseRequestParams(c, &bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	list, total, err := services.NewChangeSetService().ChangeSetList(&bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	result := utils.ResultPage{}
+	result.Param = bindParams
+	result.Page = bindParams.Page
+	result.PageSize = bindParams.PageSize
+	result.Total = total
+	result.Data = list
+
+	utils.Ok(c, result)
+}
+
+func ChangeSetInfo(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+	if resId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	info, err := services.NewChangeSetService().ChangeSetInfo(resId)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, info)
+}
+
+func ChangeSetDelete(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+	if resId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	err := services.NewChangeSetService().ChangeSetDelete(resId)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+func ChangeSetPreview(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+	if resId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	diffList, err := services.NewChangeSetService().ChangeSetPreview(resId)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, diffList)
+}
+
+func ChangeSetPublish(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+	if resId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	err := services.NewChangeSetService().ChangeSetPublish(resId, c.GetString(utils.ContextKeyUserEmail))
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"apioak-admin/app/services"
+	"apioak-admin/app/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func DriftReport(c *gin.Context) {
+
+	report := services.DriftLastReport()
+	if report == nil {
+		utils.Ok(c, services.DriftReport{Items: []services.DriftItem{}})
+		return
+	}
+
+	utils.Ok(c, report)
+}
+
+func DriftCheck(c *gin.Context) {
+
+	report, err := services.DriftCheck(false)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, report)
+}
+
+func DriftHeal(c *gin.Context) {
+
+	report, err := services.DriftCheck(true)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, report)
+}
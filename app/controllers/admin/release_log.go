@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"apioak-admin/app/packages"
+	"apioak-admin/app/services"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+func releaseLogList(c *gin.Context, resourceType string) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+
+	var bindParams = validators.ReleaseLogList{}
+	if msg, err := packages.ParseRequestParams(c, &bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	list, total, err := services.ReleaseLogList(resourceType, resId, &bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	result := utils.ResultPage{}
+	result.Param = bindParams
+	result.Page = bindParams.Page
+	result.PageSize = bindParams.PageSize
+	result.Total = total
+	result.Data = list
+
+	utils.Ok(c, result)
+}
+
+func releaseRollback(c *gin.Context, resourceType string) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+	releaseId := strings.TrimSpace(c.Param("release_id"))
+
+	err := services.ReleaseRollback(resourceType, resId, releaseId, c.GetString(utils.ContextKeyUserEmail))
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+func ServiceReleaseLogList(c *gin.Context) {
+	releaseLogList(c, utils.ReleaseResourceService)
+}
+
+func ServiceRollback(c *gin.Context) {
+	releaseRollback(c, utils.ReleaseResourceService)
+}
+
+func RouterReleaseLogList(c *gin.Context) {
+	releaseLogList(c, utils.ReleaseResourceRouter)
+}
+
+func RouterRollback(c *gin.Context) {
+	releaseRollback(c, utils.ReleaseResourceRouter)
+}
+
+func UpstreamReleaseLogList(c *gin.Context) {
+	releaseLogList(c, utils.ReleaseResourceUpstream)
+}
+
+func UpstreamRollback(c *gin.Context) {
+	releaseRollback(c, utils.ReleaseResourceUpstream)
+}
@@ -157,8 +157,7 @@ func RouterUpdateName(c *gin.Context) {
 		return
 	}
 
-	routerModel := models.Routers{}
-	updateErr := routerModel.RouterUpdateName(routerResId, bindParams.Name)
+	updateErr := services.RouterUpdateName(serviceResId, routerResId, bindParams.Name)
 	if updateErr != nil {
 		utils.Error(c, updateErr.Error())
 		return
@@ -195,8 +194,7 @@ func RouterSwitchEnable(c *gin.Context) {
 		return
 	}
 
-	routerModel := models.Routers{}
-	updateErr := routerModel.RouterSwitchEnable(routerResId, bindParams.Enable)
+	updateErr := services.RouterSwitchEnable(serviceResId, routerResId, bindParams.Enable)
 	if updateErr != nil {
 		utils.Error(c, updateErr.Error())
 		return
@@ -258,7 +256,7 @@ func RouterSwitchRelease(c *gin.Context) {
 		return
 	}
 
-	serviceRouterReleaseErr := services.RouterRelease([]string{routerResId}, utils.ReleaseTypePush)
+	serviceRouterReleaseErr := services.RouterRelease([]string{routerResId}, utils.ReleaseTypePush, c.GetString(utils.ContextKeyUserEmail))
 	if serviceRouterReleaseErr != nil {
 		utils.Error(c, serviceRouterReleaseErr.Error())
 		return
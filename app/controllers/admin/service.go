@@ -31,7 +31,7 @@ func ServiceAdd(c *gin.Context) {
 		return
 	}
 
-	err = s.ServiceCreate(bindParams)
+	_, err = s.ServiceCreate(bindParams)
 	if err != nil {
 		utils.Error(c, err.Error())
 		return
@@ -208,7 +208,7 @@ func ServiceSwitchRelease(c *gin.Context) {
 		return
 	}
 
-	err = services.NewServicesService().ServiceRelease(serviceId)
+	err = services.NewServicesService().ServiceRelease(serviceId, c.GetString(utils.ContextKeyUserEmail))
 	if err != nil {
 		utils.Error(c, err.Error())
 		return
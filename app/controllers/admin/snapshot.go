@@ -0,0 +1,96 @@
+package admin
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/services"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+func SnapshotExport(c *gin.Context) {
+
+	var request = &validators.SnapshotExport{}
+	if msg, err := packages.ParseRequestParams(c, request); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	format := request.Format
+	if len(format) == 0 {
+		format = utils.SnapshotFormatJson
+	}
+
+	snapshot, err := services.NewSnapshotService().SnapshotExport(request.Plaintext)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	content, err := services.SnapshotEncode(snapshot, format)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	contentType := "application/json; charset=utf-8"
+	if format == utils.SnapshotFormatYaml {
+		contentType = "application/x-yaml; charset=utf-8"
+	}
+
+	fileName := fmt.Sprintf("apioak-snapshot-%s.%s", time.Now().Format("20060102150405"), format)
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	c.Data(http.StatusOK, contentType, content)
+}
+
+func SnapshotImport(c *gin.Context) {
+
+	var request = &validators.SnapshotImport{}
+	if msg, err := packages.ParseRequestParams(c, request); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	mode := request.Mode
+	if len(mode) == 0 {
+		mode = utils.SnapshotModeMerge
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.Error(c, enums.CodeMessages(enums.SnapshotFormatError))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+	defer file.Close()
+
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	snapshot, err := services.SnapshotDecode(content)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	res, err := services.NewSnapshotService().SnapshotImport(&snapshot, mode, request.DryRun)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, res)
+}
@@ -55,7 +55,7 @@ func UpstreamAdd(c *gin.Context) {
 		}
 	}
 
-	err := serviceUpstream.UpstreamCreate(request)
+	_, err := serviceUpstream.UpstreamCreate(request)
 	if err != nil {
 		utils.Error(c, err.Error())
 		return
@@ -179,8 +179,7 @@ func UpstreamUpdateName(c *gin.Context) {
 		}
 	}
 
-	upstreamModel := models.Upstreams{}
-	updateNameErr := upstreamModel.UpstreamUpdateName(resId, request.Name)
+	updateNameErr := serviceUpstream.UpstreamUpdateName(resId, request.Name)
 	if updateNameErr != nil {
 		utils.Error(c, updateNameErr.Error())
 		return
@@ -232,7 +231,7 @@ func UpstreamSwitchRelease(c *gin.Context) {
 		return
 	}
 
-	releaseErr := serviceUpstream.UpstreamSwitchRelease(resId)
+	releaseErr := serviceUpstream.UpstreamSwitchRelease(resId, c.GetString(utils.ContextKeyUserEmail))
 	if releaseErr != nil {
 		utils.Error(c, releaseErr.Error())
 		return
@@ -8,6 +8,7 @@ import (
 	"apioak-admin/app/validators"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strings"
 )
 
 func UserRegister(c *gin.Context) {
@@ -17,6 +18,12 @@ func UserRegister(c *gin.Context) {
 		return
 	}
 
+	checkUserRegisterErr := services.CheckUserRegister()
+	if checkUserRegisterErr != nil {
+		utils.Error(c, checkUserRegisterErr.Error())
+		return
+	}
+
 	checkUserEmailExistErr := services.CheckUserEmailExist(userRegisterValidator.Email, []string{})
 	if checkUserEmailExistErr != nil {
 		utils.Error(c, checkUserEmailExistErr.Error())
@@ -39,23 +46,118 @@ func UserLogin(c *gin.Context) {
 		return
 	}
 
-	checkUserAndPasswordErr := services.CheckUserAndPassword(userLoginValidator.Email, userLoginValidator.Password)
-	if checkUserAndPasswordErr != nil {
-		utils.Error(c, checkUserAndPasswordErr.Error())
+	userInfo, authenticateErr := services.UserLoginAuthenticate(userLoginValidator.Email, userLoginValidator.Password, c.ClientIP())
+	if authenticateErr != nil {
+		utils.Error(c, authenticateErr.Error())
+		return
+	}
+
+	result, issueErr := services.UserLoginIssue(userInfo, userLoginValidator.SessionName, c.Request.UserAgent(), c.ClientIP())
+	if issueErr != nil {
+		utils.Error(c, issueErr.Error())
+		return
+	}
+
+	utils.Ok(c, result)
+}
+
+func UserTotpLogin(c *gin.Context) {
+	var bindParams = &validators.UserTotpLogin{}
+	if msg, err := packages.ParseRequestParams(c, bindParams); err != nil {
+		utils.Error(c, msg)
 		return
 	}
 
-	token, tokenErr := services.UserLogin(userLoginValidator.Email)
-	if tokenErr != nil {
-		utils.Error(c, tokenErr.Error())
+	token, recoveryCodes, err := services.UserTotpLogin(bindParams, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		utils.Error(c, err.Error())
 		return
 	}
 
 	type tokenData struct {
-		Token string `json:"token"`
+		Token         string   `json:"token"`
+		RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	}
+
+	utils.Ok(c, tokenData{
+		Token:         token,
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+func UserTotpLoginEnroll(c *gin.Context) {
+	var bindParams = &validators.UserTotpLoginEnroll{}
+	if msg, err := packages.ParseRequestParams(c, bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	enroll, err := services.UserTotpLoginEnroll(bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, enroll)
+}
+
+func UserOidcLogin(c *gin.Context) {
+	loginUrl, state, expire, err := services.OidcLoginUrl()
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	// 身份提供方跳转回来属于跨站的顶级导航，SameSite=Lax 时浏览器会携带该 cookie
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(services.OidcStateCookie, state, int(expire.Seconds()), "/admin/user/oidc", "", c.Request.TLS != nil, true)
+
+	c.Redirect(http.StatusFound, loginUrl)
+}
+
+func UserOidcCallback(c *gin.Context) {
+	if errMsg := c.Query("error"); len(errMsg) != 0 {
+		packages.Log.Error("oidc callback error", errMsg, c.Query("error_description"))
+		utils.Error(c, enums.CodeMessages(enums.OidcLoginError))
+		return
+	}
+
+	code := strings.TrimSpace(c.Query("code"))
+	state := strings.TrimSpace(c.Query("state"))
+	if (code == "") || (state == "") {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	stateCookie, _ := c.Cookie(services.OidcStateCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(services.OidcStateCookie, "", -1, "/admin/user/oidc", "", c.Request.TLS != nil, true)
+
+	userInfo, err := services.OidcAuthenticate(code, state, stateCookie)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	result, err := services.UserLoginIssue(userInfo, "oidc", c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
 	}
-	result := tokenData{
-		Token: token,
+
+	// 配置了前端地址时跳转回前端，token 放在 fragment 中不会发送到服务端日志。
+	// 需要两步验证时携带 totp_token，由前端调用 /login/totp 完成登录
+	if len(packages.ConfigOidc.LoginRedirect) != 0 {
+		fragment := "#token=" + result.Token
+		if result.TotpRequired {
+			fragment = "#totp_required=true&totp_token=" + result.TotpToken
+			if result.TotpEnroll {
+				fragment += "&totp_enroll=true"
+			}
+		}
+
+		c.Redirect(http.StatusFound, packages.ConfigOidc.LoginRedirect+fragment)
+		return
 	}
 
 	utils.Ok(c, result)
@@ -64,15 +166,312 @@ func UserLogin(c *gin.Context) {
 func UserLogout(c *gin.Context) {
 	token := c.GetHeader("auth-token")
 
-	loginStatus, loginStatusErr := services.CheckUserLoginStatus(token)
-	if (loginStatusErr != nil) || (loginStatus == false) {
+	_, loginStatusErr := services.CheckUserLoginStatus(token)
+	if loginStatusErr != nil {
 		utils.CustomError(c, http.StatusUnauthorized, loginStatusErr.Error())
 		return
 	}
 
 	_, logoutErr := services.UserLogout(token)
 	if logoutErr != nil {
-		utils.Error(c, loginStatusErr.Error())
+		utils.Error(c, logoutErr.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+func UserSessionList(c *gin.Context) {
+	list, err := services.UserSessionList(c.GetString(utils.ContextKeyUserEmail), c.GetString(utils.ContextKeyUserSessionId))
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, list)
+}
+
+func UserSessionRevoke(c *gin.Context) {
+	sessionResId := strings.TrimSpace(c.Param("res_id"))
+	if sessionResId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	err := services.UserSessionRevoke(c.GetString(utils.ContextKeyUserEmail), sessionResId)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+func UserSessionRevokeAll(c *gin.Context) {
+	err := services.UserSessionRevokeAll(c.GetString(utils.ContextKeyUserEmail))
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+func UserApiTokenAdd(c *gin.Context) {
+	var bindParams = &validators.UserApiTokenAdd{}
+	if msg, err := packages.ParseRequestParams(c, bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	resId, token, err := services.UserApiTokenAdd(c.GetString(utils.ContextKeyUserEmail), bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, map[string]string{
+		"res_id": resId,
+		"token":  token,
+	})
+}
+
+func UserApiTokenList(c *gin.Context) {
+	list, err := services.UserApiTokenList(c.GetString(utils.ContextKeyUserEmail))
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, list)
+}
+
+func UserApiTokenDelete(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+	if resId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	err := services.UserApiTokenDelete(c.GetString(utils.ContextKeyUserEmail), resId)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+func UserTotpInfo(c *gin.Context) {
+	info, err := services.UserTotpInfo(c.GetString(utils.ContextKeyUserEmail))
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, info)
+}
+
+func UserTotpEnroll(c *gin.Context) {
+	enroll, err := services.UserTotpEnroll(c.GetString(utils.ContextKeyUserEmail))
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, enroll)
+}
+
+func UserTotpActivate(c *gin.Context) {
+	var bindParams = &validators.UserTotpCode{}
+	if msg, err := packages.ParseRequestParams(c, bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	recoveryCodes, err := services.UserTotpActivate(c.GetString(utils.ContextKeyUserEmail), bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, map[string][]string{
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+func UserTotpDisable(c *gin.Context) {
+	var bindParams = &validators.UserTotpCode{}
+	if msg, err := packages.ParseRequestParams(c, bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	err := services.UserTotpDisable(c.GetString(utils.ContextKeyUserEmail), bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+func UserTotpRecoveryCodes(c *gin.Context) {
+	var bindParams = &validators.UserTotpCode{}
+	if msg, err := packages.ParseRequestParams(c, bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	recoveryCodes, err := services.UserTotpRecoveryCodes(c.GetString(utils.ContextKeyUserEmail), bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, map[string][]string{
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+func UserPasswordChange(c *gin.Context) {
+	var bindParams = &validators.UserPasswordChange{}
+	if msg, err := packages.ParseRequestParams(c, bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	err := services.UserPasswordChange(c.GetString(utils.ContextKeyUserEmail), bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+func UserList(c *gin.Context) {
+	var bindParams = validators.UserList{}
+	if msg, err := packages.ParseRequestParams(c, &bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	list, total, err := services.UserList(&bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	result := utils.ResultPage{}
+	result.Param = bindParams
+	result.Page = bindParams.Page
+	result.PageSize = bindParams.PageSize
+	result.Total = total
+	result.Data = list
+
+	utils.Ok(c, result)
+}
+
+func UserInvite(c *gin.Context) {
+	var bindParams = &validators.UserInvite{}
+	if msg, err := packages.ParseRequestParams(c, bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	resId, password, err := services.UserInvite(bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, map[string]string{
+		"res_id":   resId,
+		"password": password,
+	})
+}
+
+func UserSwitchEnable(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+
+	var bindParams = &validators.UserSwitchEnable{}
+	if msg, err := packages.ParseRequestParams(c, bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	err := services.UserSwitchEnable(c.GetString(utils.ContextKeyUserResId), resId, bindParams.Enable)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+func UserPasswordReset(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+
+	var bindParams = &validators.UserPasswordReset{}
+	if msg, err := packages.ParseRequestParams(c, bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	password, err := services.UserPasswordReset(resId, bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, map[string]string{
+		"password": password,
+	})
+}
+
+func UserTotpReset(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+	if resId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	err := services.UserTotpReset(resId)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+func UserLoginUnlock(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+	if resId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	err := services.UserLoginUnlock(resId, c.GetString(utils.ContextKeyUserEmail))
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+func UserDelete(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+	if resId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	err := services.UserDelete(c.GetString(utils.ContextKeyUserResId), resId)
+	if err != nil {
+		utils.Error(c, err.Error())
 		return
 	}
 
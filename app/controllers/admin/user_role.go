@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/services"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+func UserRoleInfo(c *gin.Context) {
+	userResId := strings.TrimSpace(c.Param("res_id"))
+	if userResId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	info, err := services.UserRoleInfoByUserResId(userResId)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, info)
+}
+
+func UserRoleUpdate(c *gin.Context) {
+	userResId := strings.TrimSpace(c.Param("res_id"))
+
+	var bindParams = &validators.UserRoleUpdate{}
+	if msg, err := packages.ParseRequestParams(c, bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	err := services.UserRoleUpdate(userResId, bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
@@ -0,0 +1,179 @@
+package admin
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/services"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+func WebhookEventList(c *gin.Context) {
+	utils.Ok(c, services.WebhookEventList())
+}
+
+func WebhookAdd(c *gin.Context) {
+	var request = &validators.WebhookAddUpdate{}
+	if msg, err := packages.ParseRequestParams(c, request); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	resId, secret, err := services.WebhookCreate(request)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, map[string]string{"res_id": resId, "secret": secret})
+}
+
+func WebhookList(c *gin.Context) {
+	var bindParams = validators.WebhookList{}
+	if msg, err := packages.ParseRequestParams(c, &bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	list, total, err := services.WebhookList(&bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	result := utils.ResultPage{}
+	result.Param = bindParams
+	result.Page = bindParams.Page
+	result.PageSize = bindParams.PageSize
+	result.Total = total
+	result.Data = list
+
+	utils.Ok(c, result)
+}
+
+func WebhookInfo(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+	if resId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	info, err := services.WebhookInfo(resId)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, info)
+}
+
+func WebhookUpdate(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+
+	var request = &validators.WebhookAddUpdate{}
+	if msg, err := packages.ParseRequestParams(c, request); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	err := services.WebhookUpdate(resId, request)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+func WebhookDelete(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+	if resId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	err := services.WebhookDelete(resId)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+func WebhookSwitchEnable(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+
+	var request = &validators.WebhookSwitchEnable{}
+	if msg, err := packages.ParseRequestParams(c, request); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	err := services.WebhookSwitchEnable(resId, request.Enable)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
+
+func WebhookTest(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+	if resId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	deliveryResId, err := services.WebhookTest(resId)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c, map[string]string{"res_id": deliveryResId})
+}
+
+func WebhookDeliveryList(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+
+	var bindParams = validators.WebhookDeliveryList{}
+	if msg, err := packages.ParseRequestParams(c, &bindParams); err != nil {
+		utils.Error(c, msg)
+		return
+	}
+
+	list, total, err := services.WebhookDeliveryList(resId, &bindParams)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	result := utils.ResultPage{}
+	result.Param = bindParams
+	result.Page = bindParams.Page
+	result.PageSize = bindParams.PageSize
+	result.Total = total
+	result.Data = list
+
+	utils.Ok(c, result)
+}
+
+func WebhookDeliveryRetry(c *gin.Context) {
+	resId := strings.TrimSpace(c.Param("res_id"))
+	if resId == "" {
+		utils.Error(c, enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	err := services.WebhookDeliveryRetry(resId)
+	if err != nil {
+		utils.Error(c, err.Error())
+		return
+	}
+
+	utils.Ok(c)
+}
@@ -26,6 +26,7 @@ const (
 	SyncError            = 110 // 同步失败
 	NameExist            = 111 // 名称已存在
 	RemoteServiceErr     = 112 // 服务异常，请联系管理员
+	SecretPrefixReserved = 113 // 密钥不能以[%s]开头
 
 	ServiceNull          = 10001 // 服务不存在
 	ServiceParamsNull    = 10002 // 服务参数缺失
@@ -62,21 +63,81 @@ const (
 	CertificateDomainExist = 10405 // 证书已被域名绑定，暂不支持该操作
 	CertificateNoRelease   = 10406 // [%s]证书未发布
 	CertificateEnableOff   = 10407 // [%s]证书未开启
+	CertificateKeyError    = 10408 // 私钥格式错误
+	CertificateKeyMismatch = 10409 // 私钥与证书不匹配
+	CertificateChainOrder  = 10410 // 证书链顺序错误，第[%d]个证书不是由第[%d]个证书签发
+	CertificateChainError  = 10411 // 证书链不完整，缺少[%s]签发的证书
+	CertificateSniError    = 10412 // [%s]不在证书的域名[%s]中
+	CertificateExpired     = 10413 // 证书已于[%s]过期
+	CertificateNotYetValid = 10414 // 证书[%s]之后才生效
 
 	ClusterNodeNull  = 10501 // 节点不存在
 	ClusterNodeExist = 10502 // 节点已存在
 
-	UserEmailExist      = 10601 // 邮箱已注册
-	UserNull            = 10602 // 用户不存在
-	UserPasswordError   = 10603 // 用户与密码不匹配
-	UserTokenError      = 10605 // 用户token验证信息失败
-	UserNoLoggingIn     = 10606 // 用户未登录
-	UserLoggingInError  = 10607 // 用户登录失败
-	UserLoggingInExpire = 10608 // 用户登录已过期
+	UserEmailExist         = 10601 // 邮箱已注册
+	UserNull               = 10602 // 用户不存在
+	UserPasswordError      = 10603 // 用户与密码不匹配
+	UserTokenError         = 10605 // 用户token验证信息失败
+	UserNoLoggingIn        = 10606 // 用户未登录
+	UserLoggingInError     = 10607 // 用户登录失败
+	UserLoggingInExpire    = 10608 // 用户登录已过期
+	UserPermissionDenied   = 10609 // 无权限执行该操作
+	UserRoleLastAdmin      = 10610 // 至少需要保留一个管理员
+	UserRegisterClosed     = 10611 // 注册已关闭，请联系管理员邀请
+	UserDisabled           = 10612 // 用户已禁用
+	UserOperateSelf        = 10613 // 不能禁用或删除当前登录的用户
+	UserSessionNull        = 10614 // 会话不存在
+	ApiTokenNull           = 10615 // API Token不存在
+	ApiTokenInvalid        = 10616 // API Token无效
+	ApiTokenExpired        = 10617 // API Token已过期
+	ApiTokenExpiredAtError = 10618 // 过期时间必须晚于当前时间
+	OidcDisabled           = 10619 // 未开启单点登录
+	OidcStateError         = 10620 // 单点登录状态无效或已过期
+	OidcLoginError         = 10621 // 单点登录失败
+	OidcEmailNull          = 10622 // 身份提供方未返回已验证的邮箱
+	LdapUserError          = 10623 // LDAP 账号信息错误
+	TotpChallengeError     = 10624 // 两步验证已过期
+	TotpCodeError          = 10625 // 验证码错误
+	TotpEnabled            = 10626 // 已开启两步验证
+	TotpNotEnabled         = 10627 // 未开启两步验证
+	TotpRequired           = 10628 // 系统要求开启两步验证
+	UserLoginLocked        = 10629 // 登录失败次数过多
 
 	UpstreamNull        = 10701 // 上游不存在
 	UpstreamRouterExist = 10702 // 上游已被路由绑定，暂不允许该操作
 
+	SnapshotVersionError  = 10801 // [%d]快照版本不支持
+	SnapshotFormatError   = 10802 // 快照格式错误
+	SnapshotResIdNull     = 10803 // [%s]资源ID缺失
+	SnapshotResIdRepeat   = 10804 // [%s]资源ID重复
+	SnapshotFieldError    = 10805 // [%s]字段[%s]取值错误
+	SnapshotReferenceNull = 10806 // [%s]引用的[%s]不存在
+	SnapshotKeyError      = 10807 // [%s]密文使用的主密钥版本[%d]未配置或与导出方不一致
+
+	ApplyNameNull      = 10901 // [%s]名称缺失
+	ApplyNameRepeat    = 10902 // [%s]名称重复
+	ApplyReferenceNull = 10903 // [%s]引用的[%s]不存在
+
+	ReleaseLogNull         = 11001 // 发布记录不存在
+	ReleaseLogResourceNull = 11002 // 回滚依赖的[%s]不存在
+
+	ChangeSetNull              = 11101 // 变更集不存在
+	ChangeSetPublished         = 11102 // 变更集已发布
+	ChangeSetItemNull          = 11103 // [%s][%s]不存在
+	ChangeSetDependencyNull    = 11104 // [%s][%s]依赖的[%s]未发布
+	ChangeSetCompensationError = 11105 // 发布失败且部分已推送的配置未能回滚，请执行漂移检测
+
+	WebhookNull         = 11201 // Webhook 不存在
+	WebhookDeliveryNull = 11202 // 投递记录不存在
+	WebhookEventError   = 11203 // [%s]事件不存在
+	WebhookUrlError     = 11204 // Webhook 地址必须是 http 或 https
+
+	AcmeDisabled               = 11301 // ACME 未开启
+	AcmeDomainNotFound         = 11302 // [%s]不是服务域名
+	AcmeCertificateExist       = 11303 // [%s]已申请 ACME 证书
+	AcmeCertificateNull        = 11304 // ACME 证书不存在
+	AcmeWildcardChallengeError = 11305 // 泛域名[%s]只能使用 dns-01 验证
+	AcmeDnsProviderNull        = 11306 // 未配置 dns-01 验证方式
 )
 
 var ZhMapMessages = map[int]string{
@@ -95,6 +156,7 @@ var ZhMapMessages = map[int]string{
 	SyncError:            "同步失败",
 	NameExist:            "名称已存在",
 	RemoteServiceErr:     "服务异常，请联系管理员",
+	SecretPrefixReserved: "密钥不能以[%s]开头",
 
 	ServiceNull:          "服务不存在",
 	ServiceParamsNull:    "服务参数缺失",
@@ -130,20 +192,81 @@ var ZhMapMessages = map[int]string{
 	CertificateDomainExist: "证书已被域名绑定，暂不允许该操作",
 	CertificateNoRelease:   "[%s]证书未发布",
 	CertificateEnableOff:   "[%s]证书未开启",
+	CertificateKeyError:    "私钥格式错误",
+	CertificateKeyMismatch: "私钥与证书不匹配",
+	CertificateChainOrder:  "证书链顺序错误，第[%d]个证书不是由第[%d]个证书签发",
+	CertificateChainError:  "证书链不完整，缺少[%s]签发的证书",
+	CertificateSniError:    "[%s]不在证书的域名[%s]中",
+	CertificateExpired:     "证书已于[%s]过期",
+	CertificateNotYetValid: "证书[%s]之后才生效",
 
 	ClusterNodeNull:  "节点不存在",
 	ClusterNodeExist: "节点已存在",
 
-	UserEmailExist:      "邮箱已注册",
-	UserNull:            "用户不存在",
-	UserPasswordError:   "用户与密码不匹配",
-	UserTokenError:      "用户token验证信息失败",
-	UserNoLoggingIn:     "用户未登录",
-	UserLoggingInError:  "用户登录失败",
-	UserLoggingInExpire: "用户登录已过期",
+	UserEmailExist:         "邮箱已注册",
+	UserNull:               "用户不存在",
+	UserPasswordError:      "用户与密码不匹配",
+	UserTokenError:         "用户token验证信息失败",
+	UserNoLoggingIn:        "用户未登录",
+	UserLoggingInError:     "用户登录失败",
+	UserLoggingInExpire:    "用户登录已过期",
+	UserPermissionDenied:   "无权限执行该操作",
+	UserRoleLastAdmin:      "至少需要保留一个管理员",
+	UserRegisterClosed:     "注册已关闭，请联系管理员邀请",
+	UserDisabled:           "用户已禁用",
+	UserOperateSelf:        "不能禁用或删除当前登录的用户",
+	UserSessionNull:        "会话不存在",
+	ApiTokenNull:           "API Token不存在",
+	ApiTokenInvalid:        "API Token无效",
+	ApiTokenExpired:        "API Token已过期",
+	ApiTokenExpiredAtError: "过期时间必须晚于当前时间",
+	OidcDisabled:           "未开启单点登录",
+	OidcStateError:         "单点登录状态无效或已过期，请重新登录",
+	OidcLoginError:         "单点登录失败",
+	OidcEmailNull:          "身份提供方未返回已验证的邮箱",
+	LdapUserError:          "LDAP 账号信息错误，请联系管理员",
+	TotpChallengeError:     "两步验证已过期或尝试次数过多，请重新登录",
+	TotpCodeError:          "验证码错误",
+	TotpEnabled:            "已开启两步验证",
+	TotpNotEnabled:         "未开启两步验证",
+	TotpRequired:           "系统要求开启两步验证，不能关闭",
+	UserLoginLocked:        "登录失败次数过多，请在[%d]秒后重试",
 
 	UpstreamNull:        "上游不存在",
 	UpstreamRouterExist: "上游已被路由绑定，暂不允许该操作",
+
+	SnapshotVersionError:  "[%d]快照版本不支持",
+	SnapshotFormatError:   "快照格式错误",
+	SnapshotResIdNull:     "[%s]资源ID缺失",
+	SnapshotResIdRepeat:   "[%s]资源ID重复",
+	SnapshotFieldError:    "[%s]字段[%s]取值错误",
+	SnapshotReferenceNull: "[%s]引用的[%s]不存在",
+	SnapshotKeyError:      "[%s]密文使用的主密钥版本[%d]未配置或与导出方不一致，请配置相同的主密钥或以明文导出快照",
+
+	ApplyNameNull:      "[%s]名称缺失",
+	ApplyNameRepeat:    "[%s]名称重复",
+	ApplyReferenceNull: "[%s]引用的[%s]不存在",
+
+	ReleaseLogNull:         "发布记录不存在",
+	ReleaseLogResourceNull: "回滚依赖的[%s]不存在",
+
+	ChangeSetNull:              "变更集不存在",
+	ChangeSetPublished:         "变更集已发布",
+	ChangeSetItemNull:          "[%s][%s]不存在",
+	ChangeSetDependencyNull:    "[%s][%s]依赖的[%s]未发布",
+	ChangeSetCompensationError: "发布失败且部分已推送的配置未能回滚，请执行漂移检测",
+
+	WebhookNull:         "Webhook 不存在",
+	WebhookDeliveryNull: "投递记录不存在",
+	WebhookEventError:   "[%s]事件不存在",
+	WebhookUrlError:     "Webhook 地址必须是 http 或 https",
+
+	AcmeDisabled:               "ACME 未开启",
+	AcmeDomainNotFound:         "[%s]不是服务域名",
+	AcmeCertificateExist:       "[%s]已申请 ACME 证书",
+	AcmeCertificateNull:        "ACME 证书不存在",
+	AcmeWildcardChallengeError: "泛域名[%s]只能使用 dns-01 验证",
+	AcmeDnsProviderNull:        "未配置 dns-01 验证方式",
 }
 
 var EnMapMessages = map[int]string{
@@ -162,6 +285,7 @@ var EnMapMessages = map[int]string{
 	SyncError:            "Sync error",
 	NameExist:            "Name already exists",
 	RemoteServiceErr:     "Service exception, please contact the administrator",
+	SecretPrefixReserved: "Secrets must not start with [%s]",
 
 	ServiceNull:          "Service does not exist",
 	ServiceParamsNull:    "Missing service parameters",
@@ -197,20 +321,81 @@ var EnMapMessages = map[int]string{
 	CertificateDomainExist: "The certificate has been bound by the domain name, operation is not allowed",
 	CertificateNoRelease:   "[%s]Certificate not release",
 	CertificateEnableOff:   "[%s]Certificate not enabled",
+	CertificateKeyError:    "Incorrect private key format",
+	CertificateKeyMismatch: "The private key does not match the certificate",
+	CertificateChainOrder:  "Certificate chain out of order, certificate [%d] is not issued by certificate [%d]",
+	CertificateChainError:  "Certificate chain incomplete, missing the certificate of issuer [%s]",
+	CertificateSniError:    "[%s]is not covered by the certificate names [%s]",
+	CertificateExpired:     "Certificate expired at [%s]",
+	CertificateNotYetValid: "Certificate is not valid until [%s]",
 
 	ClusterNodeNull:  "Node does not exist",
 	ClusterNodeExist: "Node already exists",
 
-	UserEmailExist:      "Email has been registered",
-	UserNull:            "User does not exist",
-	UserPasswordError:   "User and password do not match",
-	UserTokenError:      "User token verification information failed",
-	UserNoLoggingIn:     "User is not logged in",
-	UserLoggingInError:  "User login failed",
-	UserLoggingInExpire: "User login has expired",
+	UserEmailExist:         "Email has been registered",
+	UserNull:               "User does not exist",
+	UserPasswordError:      "User and password do not match",
+	UserTokenError:         "User token verification information failed",
+	UserNoLoggingIn:        "User is not logged in",
+	UserLoggingInError:     "User login failed",
+	UserLoggingInExpire:    "User login has expired",
+	UserPermissionDenied:   "Permission denied",
+	UserRoleLastAdmin:      "At least one admin is required",
+	UserRegisterClosed:     "Registration is closed, please ask an admin for an invitation",
+	UserDisabled:           "User is disabled",
+	UserOperateSelf:        "The current user cannot be disabled or deleted",
+	UserSessionNull:        "Session does not exist",
+	ApiTokenNull:           "API token does not exist",
+	ApiTokenInvalid:        "API token is invalid",
+	ApiTokenExpired:        "API token has expired",
+	ApiTokenExpiredAtError: "Expiration time must be later than the current time",
+	OidcDisabled:           "Single sign-on is not enabled",
+	OidcStateError:         "Single sign-on state is invalid or expired, please log in again",
+	OidcLoginError:         "Single sign-on failed",
+	OidcEmailNull:          "The identity provider did not return a verified email",
+	LdapUserError:          "LDAP account is misconfigured, please contact the administrator",
+	TotpChallengeError:     "Two-factor verification expired or too many attempts, please log in again",
+	TotpCodeError:          "Verification code is incorrect",
+	TotpEnabled:            "Two-factor authentication is already enabled",
+	TotpNotEnabled:         "Two-factor authentication is not enabled",
+	TotpRequired:           "Two-factor authentication is required and cannot be disabled",
+	UserLoginLocked:        "Too many failed login attempts, please try again in [%d] seconds",
 
 	UpstreamNull:        "Upstream does not exist",
 	UpstreamRouterExist: "Upstream has been bound by a route. This operation is not allowed temporarily",
+
+	SnapshotVersionError:  "[%d]Snapshot version is not supported",
+	SnapshotFormatError:   "Snapshot format error",
+	SnapshotResIdNull:     "[%s]Resource id is missing",
+	SnapshotResIdRepeat:   "[%s]Resource id is repeated",
+	SnapshotFieldError:    "[%s]Field [%s] value error",
+	SnapshotReferenceNull: "[%s]The referenced [%s] does not exist",
+	SnapshotKeyError:      "[%s]The value is encrypted with master key version [%d], which is not configured or differs from the exporting side. Configure the same master key or export the snapshot as plaintext",
+
+	ApplyNameNull:      "[%s]Name is missing",
+	ApplyNameRepeat:    "[%s]Name is repeated",
+	ApplyReferenceNull: "[%s]The referenced [%s] does not exist",
+
+	ReleaseLogNull:         "Release log does not exist",
+	ReleaseLogResourceNull: "The [%s] required by the rollback does not exist",
+
+	ChangeSetNull:              "Change set does not exist",
+	ChangeSetPublished:         "Change set has been published",
+	ChangeSetItemNull:          "[%s][%s] does not exist",
+	ChangeSetDependencyNull:    "[%s][%s] depends on the unpublished [%s]",
+	ChangeSetCompensationError: "Publish failed and some pushed configurations could not be rolled back, please run a drift check",
+
+	WebhookNull:         "Webhook does not exist",
+	WebhookDeliveryNull: "Delivery does not exist",
+	WebhookEventError:   "[%s]Event does not exist",
+	WebhookUrlError:     "Webhook url must be http or https",
+
+	AcmeDisabled:               "ACME is not enabled",
+	AcmeDomainNotFound:         "[%s]is not a service domain",
+	AcmeCertificateExist:       "[%s]ACME certificate already exists",
+	AcmeCertificateNull:        "ACME certificate does not exist",
+	AcmeWildcardChallengeError: "Wildcard domain [%s] can only use dns-01 challenge",
+	AcmeDnsProviderNull:        "dns-01 provider is not configured",
 }
 
 func CodeMessages(code int) string {
@@ -0,0 +1,92 @@
+package middlewares
+
+import (
+	"apioak-admin/app/services"
+	"apioak-admin/app/utils"
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// auditResponseWriter 保留响应内容，用于读取响应码与新增资源的ID
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w auditResponseWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w auditResponseWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// AuditLog 记录所有写请求的操作人、资源及其修改前后的内容、客户端 IP 与响应码
+func AuditLog(c *gin.Context) {
+	if c.Request.Method == http.MethodGet {
+		c.Next()
+		return
+	}
+
+	operator := c.GetString(utils.ContextKeyUserEmail)
+	resource := services.AuditResourceByPath(c.FullPath())
+
+	resId := auditResId(c)
+	if resource.Self {
+		resId = services.AuditSelfResId(operator)
+	}
+
+	// 新增资源时请求中没有资源ID，保留请求体作为修改后的内容
+	var requestBody []byte
+	if (len(resId) == 0) && (len(resource.Tables) != 0) && (c.Request.Body != nil) {
+		requestBody, _ = io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	before := services.AuditSnapshot(resource, resId)
+
+	writer := auditResponseWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+	c.Writer = writer
+
+	c.Next()
+
+	response := struct {
+		Code int `json:"code"`
+		Data struct {
+			ResID string `json:"res_id"`
+		} `json:"data"`
+	}{}
+	resultCode := c.Writer.Status()
+	if json.Unmarshal(writer.body.Bytes(), &response) == nil {
+		resultCode = response.Code
+	}
+
+	if len(resId) == 0 {
+		resId = response.Data.ResID
+	}
+
+	after := services.AuditSnapshot(resource, resId)
+	if (len(after) == 0) && (len(before) == 0) && (len(requestBody) != 0) {
+		after = services.AuditRequestBody(requestBody)
+	}
+
+	services.AuditLogAdd(operator, c.Request.Method, c.Request.URL.Path, resource, resId, before, after,
+		c.ClientIP(), resultCode)
+}
+
+// auditResId 路由的资源ID位于路径参数 router_res_id、res_id 或 id 中
+func auditResId(c *gin.Context) string {
+	for _, name := range []string{"router_res_id", "res_id", "id"} {
+		if resId := strings.TrimSpace(c.Param(name)); len(resId) != 0 {
+			return resId
+		}
+	}
+
+	return ""
+}
@@ -1,28 +1,59 @@
 package middlewares
 
 import (
+	"apioak-admin/app/enums"
 	"apioak-admin/app/services"
 	"apioak-admin/app/utils"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
+// CheckUserLogin 同时支持登录会话的 auth-token 与用于自动化调用的 api-token
 func CheckUserLogin(c *gin.Context) {
+	if apiToken := c.GetHeader("api-token"); len(apiToken) != 0 {
+		checkUserApiToken(c, apiToken)
+		return
+	}
+
 	token := c.GetHeader("auth-token")
 
-	loginStatus, loginStatusErr := services.CheckUserLoginStatus(token)
-	if (loginStatusErr != nil) || (loginStatus == false) {
+	session, loginStatusErr := services.CheckUserLoginStatus(token)
+	if loginStatusErr != nil {
 		utils.CustomError(c, http.StatusUnauthorized, loginStatusErr.Error())
 		c.Abort()
 		return
 	}
 
-	refresh, refreshErr := services.UserLoginRefresh(token)
+	refresh, refreshErr := services.UserLoginRefresh(session.ResID)
 	if (refreshErr != nil) || (refresh == false) {
 		utils.CustomError(c, http.StatusUnauthorized, refreshErr.Error())
 		c.Abort()
 		return
 	}
 
+	c.Set(utils.ContextKeyUserEmail, session.UserEmail)
+	c.Set(utils.ContextKeyUserSessionId, session.ResID)
+
+	c.Next()
+}
+
+func checkUserApiToken(c *gin.Context, token string) {
+	apiToken, email, err := services.CheckUserApiToken(token)
+	if err != nil {
+		utils.CustomError(c, http.StatusUnauthorized, err.Error())
+		c.Abort()
+		return
+	}
+
+	// 只读 Token 只允许 GET 请求，读写 Token 的权限由所属用户的角色决定
+	if (apiToken.Scope != utils.ApiTokenScopeWrite) && (c.Request.Method != http.MethodGet) {
+		utils.CustomError(c, http.StatusForbidden, enums.CodeMessages(enums.UserPermissionDenied))
+		c.Abort()
+		return
+	}
+
+	c.Set(utils.ContextKeyUserEmail, email)
+	c.Set(utils.ContextKeyUserApiToken, apiToken.ResID)
+
 	c.Next()
 }
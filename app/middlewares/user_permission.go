@@ -0,0 +1,148 @@
+package middlewares
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/services"
+	"apioak-admin/app/utils"
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ServiceResolver 解析请求所修改的资源所属的服务，用于校验运维的服务级权限
+type ServiceResolver func(c *gin.Context) []string
+
+// CheckUserPermission 读请求所有角色均可访问，写请求需要角色不低于 writeRole，
+// 分配了服务级权限的运维只能修改 resolver 解析出的服务下的资源，resolver 为 nil 时不允许修改
+func CheckUserPermission(writeRole string, resolver ServiceResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permission, ok := loadUserPermission(c)
+		if !ok {
+			return
+		}
+
+		if c.Request.Method == http.MethodGet {
+			c.Next()
+			return
+		}
+
+		serviceResIds := make([]string, 0)
+		if permission.Restricted() && (resolver != nil) {
+			serviceResIds = resolver(c)
+		}
+
+		if !permission.Allow(writeRole, serviceResIds) {
+			utils.CustomError(c, http.StatusForbidden, enums.CodeMessages(enums.UserPermissionDenied))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CheckUserAdmin 读写请求均需要管理员角色
+func CheckUserAdmin(c *gin.Context) {
+	permission, ok := loadUserPermission(c)
+	if !ok {
+		return
+	}
+
+	if !permission.Allow(utils.UserRoleAdmin, []string{}) {
+		utils.CustomError(c, http.StatusForbidden, enums.CodeMessages(enums.UserPermissionDenied))
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
+func loadUserPermission(c *gin.Context) (services.UserPermission, bool) {
+	permission, err := services.UserPermissionByEmail(c.GetString(utils.ContextKeyUserEmail))
+	if err != nil {
+		utils.CustomError(c, http.StatusUnauthorized, err.Error())
+		c.Abort()
+		return permission, false
+	}
+
+	c.Set(utils.ContextKeyUserResId, permission.UserResID)
+	c.Set(utils.ContextKeyUserRole, permission.Role)
+
+	return permission, true
+}
+
+// ServiceFromParam 服务ID位于路径参数 name 中
+func ServiceFromParam(name string) ServiceResolver {
+	return func(c *gin.Context) []string {
+		return nonEmptyStrings(strings.TrimSpace(c.Param(name)))
+	}
+}
+
+// ServiceFromRouter 路由的路径参数中包含服务ID或路由ID，新增与修改时请求体中的服务ID同样需要校验
+func ServiceFromRouter(c *gin.Context) []string {
+	serviceResIds := nonEmptyStrings(strings.TrimSpace(c.Param("service_res_id")))
+	if routerResId := strings.TrimSpace(c.Param("res_id")); len(routerResId) != 0 {
+		serviceResIds = append(serviceResIds, services.ServiceResIdByRouterResId(routerResId))
+	}
+
+	if bodyServiceResId := requestBodyString(c, "service_res_id"); len(bodyServiceResId) != 0 {
+		serviceResIds = append(serviceResIds, bodyServiceResId)
+	}
+
+	return serviceResIds
+}
+
+// ServiceFromServicePluginConfig 新增时服务ID位于请求体的 target_id 中
+func ServiceFromServicePluginConfig(c *gin.Context) []string {
+	if pluginConfigResId := strings.TrimSpace(c.Param("res_id")); len(pluginConfigResId) != 0 {
+		return []string{services.ServiceResIdByPluginConfigResId(pluginConfigResId)}
+	}
+
+	return nonEmptyStrings(requestBodyString(c, "target_id"))
+}
+
+// ServiceFromRouterPluginConfig 新增时路由ID位于请求体的 target_id 中
+func ServiceFromRouterPluginConfig(c *gin.Context) []string {
+	if pluginConfigResId := strings.TrimSpace(c.Param("res_id")); len(pluginConfigResId) != 0 {
+		return []string{services.ServiceResIdByPluginConfigResId(pluginConfigResId)}
+	}
+
+	if routerResId := requestBodyString(c, "target_id"); len(routerResId) != 0 {
+		return []string{services.ServiceResIdByRouterResId(routerResId)}
+	}
+
+	return []string{}
+}
+
+// requestBodyString 读取 JSON 请求体中的字符串字段，读取后恢复请求体供控制器绑定参数
+func requestBodyString(c *gin.Context, key string) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	params := make(map[string]interface{})
+	if json.Unmarshal(body, &params) != nil {
+		return ""
+	}
+
+	value, _ := params[key].(string)
+
+	return strings.TrimSpace(value)
+}
+
+func nonEmptyStrings(value string) []string {
+	if len(value) == 0 {
+		return []string{}
+	}
+
+	return []string{value}
+}
@@ -0,0 +1,300 @@
+package migrations
+
+// migration0001InitSchema 初始数据表结构，与原 config/apioak.sql 一致，已由该文件导入的数据库只创建缺少的数据表
+var migration0001InitSchema = Migration{
+	Version: 1,
+	Name:    "init_schema",
+	MySQL: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_certificates` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Certificate id'," +
+				"`sni` varchar(150) NOT NULL DEFAULT '' COMMENT 'SNI'," +
+				"`certificate` text NOT NULL DEFAULT '' COMMENT 'Certificate content'," +
+				"`private_key` text NOT NULL DEFAULT '' COMMENT 'Private key content'," +
+				"`enable` tinyint(1) unsigned NOT NULL DEFAULT 2 COMMENT 'Certificate enable  1:on  2:off'," +
+				"`expired_at` timestamp NULL DEFAULT NULL COMMENT 'Expiration time'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)," +
+				"KEY `IDX_SNI` (`sni`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Certificates'",
+			"CREATE TABLE IF NOT EXISTS `oak_plugin_configs` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Plugin config id'," +
+				"`name` varchar(50) NOT NULL DEFAULT '' COMMENT 'Plugin config name'," +
+				"`type` tinyint(2) NOT NULL DEFAULT 0 COMMENT 'Plugin relation type 1:service  2:router'," +
+				"`target_id` char(20) NOT NULL DEFAULT '' COMMENT 'Target id'," +
+				"`plugin_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Plugin res id'," +
+				"`plugin_key` varchar(20) NOT NULL DEFAULT '' COMMENT 'Plugin key'," +
+				"`config` text NOT NULL COMMENT 'Plugin configuration'," +
+				"`enable` tinyint(1) unsigned NOT NULL DEFAULT 2 COMMENT 'Plugin config enable  1:on  2:off'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Plugin Configs'",
+			"CREATE TABLE IF NOT EXISTS `oak_plugins` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Plugin id'," +
+				"`plugin_key` varchar(20) NOT NULL DEFAULT '' COMMENT 'Plugin key'," +
+				"`icon` varchar(50) NOT NULL DEFAULT '' COMMENT 'Plugin icon'," +
+				"`type` tinyint(2) NOT NULL DEFAULT 0 COMMENT 'Plugin type'," +
+				"`description` varchar(200) NOT NULL DEFAULT '' COMMENT 'Plugin description'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)," +
+				"UNIQUE KEY `UNIQ_KEY` (`plugin_key`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Plugins'",
+			"CREATE TABLE IF NOT EXISTS `oak_routers` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Router id'," +
+				"`service_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Service id'," +
+				"`upstream_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Upstream id'," +
+				"`router_name` varchar(50) NOT NULL DEFAULT '' COMMENT 'Router name'," +
+				"`request_methods` varchar(150) NOT NULL DEFAULT '' COMMENT 'Request method'," +
+				"`router_path` varchar(200) NOT NULL DEFAULT '' COMMENT 'Routing path'," +
+				"`enable` tinyint(1) unsigned NOT NULL DEFAULT 2 COMMENT 'Router enable  1:on  2:off'," +
+				"`release` tinyint(1) unsigned NOT NULL DEFAULT 1 COMMENT 'Service release status 1:unpublished  2:to be published  3:published'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Routers'",
+			"CREATE TABLE IF NOT EXISTS `oak_service_domains` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Domain id'," +
+				"`service_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Service id'," +
+				"`domain` varchar(50) NOT NULL DEFAULT '' COMMENT 'Domain name'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)," +
+				"UNIQUE KEY `UNIQ_SERVICE_ID_DOMAIN` (`service_res_id`,`domain`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Service domains'",
+			"CREATE TABLE IF NOT EXISTS `oak_services` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Service id'," +
+				"`name` varchar(50) NOT NULL DEFAULT '' COMMENT 'Service name'," +
+				"`protocol` tinyint(1) unsigned NOT NULL DEFAULT 1 COMMENT 'Protocol  1:HTTP  2:HTTPS  3:HTTP&HTTPS'," +
+				"`enable` tinyint(1) unsigned NOT NULL DEFAULT 2 COMMENT 'Service enable  1:on  2:off'," +
+				"`release` tinyint(1) unsigned NOT NULL DEFAULT 1 COMMENT 'Service release status 1:unpublished  2:to be published  3:published'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)," +
+				"KEY `IDX_NAME` (`name`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Services'",
+			"CREATE TABLE IF NOT EXISTS `oak_upstream_nodes` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Service node id'," +
+				"`upstream_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Upstream id'," +
+				"`node_ip` varchar(60) NOT NULL DEFAULT '' COMMENT 'Node IP'," +
+				"`ip_type` tinyint(1) unsigned NOT NULL DEFAULT 1 COMMENT 'IP Type  1:IPV4  2:IPV6'," +
+				"`node_port` smallint(6) unsigned NOT NULL DEFAULT 0 COMMENT 'Node port'," +
+				"`node_weight` tinyint(1) unsigned NOT NULL DEFAULT 0 COMMENT 'Node weight'," +
+				"`health` tinyint(1) unsigned NOT NULL DEFAULT 1 COMMENT 'Health type  1:HEALTH  2:UNHEALTH'," +
+				"`health_check` tinyint(1) unsigned NOT NULL DEFAULT 2 COMMENT 'Health check  1:on  2:off'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)," +
+				"UNIQUE KEY `UNIQ_UPSTREAM_ID_NODE_IP_PORT` (`upstream_res_id`,`node_ip`,`node_port`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Upstream nodes'",
+			"CREATE TABLE IF NOT EXISTS `oak_upstreams` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Upstream id'," +
+				"`name` varchar(50) NOT NULL DEFAULT '' COMMENT 'Upstream name'," +
+				"`algorithm` tinyint(1) unsigned NOT NULL DEFAULT 0 COMMENT 'Load balancing algorithm  1:round robin  2:chash'," +
+				"`connect_timeout` int(10) unsigned NOT NULL DEFAULT 1 COMMENT 'Connect timeout'," +
+				"`write_timeout` int(10) unsigned NOT NULL DEFAULT 1 COMMENT 'Write timeout'," +
+				"`read_timeout` int(10) unsigned NOT NULL DEFAULT 1 COMMENT 'Read timeout'," +
+				"`enable` tinyint(1) unsigned NOT NULL DEFAULT 2 COMMENT 'Enable  1:on  2:off'," +
+				"`release` tinyint(1) unsigned NOT NULL DEFAULT 1 COMMENT 'Release status 1:unpublished  2:to be published  3:published'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Upstreams'",
+			"CREATE TABLE IF NOT EXISTS `oak_user_tokens` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'User tokenID'," +
+				"`token` text NOT NULL DEFAULT '' COMMENT 'Token'," +
+				"`user_email` varchar(80) NOT NULL DEFAULT '' COMMENT 'Email'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"`expired_at` timestamp NULL DEFAULT NULL COMMENT 'Expired time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)," +
+				"UNIQUE KEY `UNIQ_USER_EMAIL` (`user_email`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='User token'",
+			"CREATE TABLE IF NOT EXISTS `oak_users` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'User iD'," +
+				"`name` varchar(50) NOT NULL DEFAULT '' COMMENT 'User name'," +
+				"`password` char(32) NOT NULL DEFAULT '' COMMENT 'Password'," +
+				"`email` varchar(80) NOT NULL DEFAULT '' COMMENT 'Email'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)," +
+				"UNIQUE KEY `UNIQ_EMAIL` (`email`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Users'",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_certificates`",
+			"DROP TABLE IF EXISTS `oak_plugin_configs`",
+			"DROP TABLE IF EXISTS `oak_plugins`",
+			"DROP TABLE IF EXISTS `oak_routers`",
+			"DROP TABLE IF EXISTS `oak_service_domains`",
+			"DROP TABLE IF EXISTS `oak_services`",
+			"DROP TABLE IF EXISTS `oak_upstream_nodes`",
+			"DROP TABLE IF EXISTS `oak_upstreams`",
+			"DROP TABLE IF EXISTS `oak_user_tokens`",
+			"DROP TABLE IF EXISTS `oak_users`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_certificates` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`sni` VARCHAR(150) NOT NULL DEFAULT ''," +
+				"`certificate` TEXT NOT NULL DEFAULT ''," +
+				"`private_key` TEXT NOT NULL DEFAULT ''," +
+				"`enable` TINYINT NOT NULL DEFAULT 2," +
+				"`expired_at` DATETIME NULL DEFAULT NULL," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_certificates_uniq_id` ON `oak_certificates` (`res_id`)",
+			"CREATE INDEX IF NOT EXISTS `oak_certificates_idx_sni` ON `oak_certificates` (`sni`)",
+
+			"CREATE TABLE IF NOT EXISTS `oak_plugin_configs` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`name` VARCHAR(50) NOT NULL DEFAULT ''," +
+				"`type` TINYINT NOT NULL DEFAULT 0," +
+				"`target_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`plugin_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`plugin_key` VARCHAR(20) NOT NULL DEFAULT ''," +
+				"`config` TEXT NOT NULL DEFAULT ''," +
+				"`enable` TINYINT NOT NULL DEFAULT 2," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_plugin_configs_uniq_id` ON `oak_plugin_configs` (`res_id`)",
+
+			"CREATE TABLE IF NOT EXISTS `oak_plugins` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`plugin_key` VARCHAR(20) NOT NULL DEFAULT ''," +
+				"`icon` VARCHAR(50) NOT NULL DEFAULT ''," +
+				"`type` TINYINT NOT NULL DEFAULT 0," +
+				"`description` VARCHAR(200) NOT NULL DEFAULT ''," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_plugins_uniq_id` ON `oak_plugins` (`res_id`)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_plugins_uniq_key` ON `oak_plugins` (`plugin_key`)",
+
+			"CREATE TABLE IF NOT EXISTS `oak_routers` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`service_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`upstream_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`router_name` VARCHAR(50) NOT NULL DEFAULT ''," +
+				"`request_methods` VARCHAR(150) NOT NULL DEFAULT ''," +
+				"`router_path` VARCHAR(200) NOT NULL DEFAULT ''," +
+				"`enable` TINYINT NOT NULL DEFAULT 2," +
+				"`release` TINYINT NOT NULL DEFAULT 1," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_routers_uniq_id` ON `oak_routers` (`res_id`)",
+
+			"CREATE TABLE IF NOT EXISTS `oak_service_domains` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`service_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`domain` VARCHAR(50) NOT NULL DEFAULT ''," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_service_domains_uniq_id` ON `oak_service_domains` (`res_id`)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_service_domains_uniq_service_id_domain` ON `oak_service_domains` (`service_res_id`, `domain`)",
+
+			"CREATE TABLE IF NOT EXISTS `oak_services` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`name` VARCHAR(50) NOT NULL DEFAULT ''," +
+				"`protocol` TINYINT NOT NULL DEFAULT 1," +
+				"`enable` TINYINT NOT NULL DEFAULT 2," +
+				"`release` TINYINT NOT NULL DEFAULT 1," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_services_uniq_id` ON `oak_services` (`res_id`)",
+			"CREATE INDEX IF NOT EXISTS `oak_services_idx_name` ON `oak_services` (`name`)",
+
+			"CREATE TABLE IF NOT EXISTS `oak_upstream_nodes` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`upstream_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`node_ip` VARCHAR(60) NOT NULL DEFAULT ''," +
+				"`ip_type` TINYINT NOT NULL DEFAULT 1," +
+				"`node_port` SMALLINT NOT NULL DEFAULT 0," +
+				"`node_weight` TINYINT NOT NULL DEFAULT 0," +
+				"`health` TINYINT NOT NULL DEFAULT 1," +
+				"`health_check` TINYINT NOT NULL DEFAULT 2," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_upstream_nodes_uniq_id` ON `oak_upstream_nodes` (`res_id`)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_upstream_nodes_uniq_upstream_id_node_ip_port` ON `oak_upstream_nodes` (`upstream_res_id`, `node_ip`, `node_port`)",
+
+			"CREATE TABLE IF NOT EXISTS `oak_upstreams` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`name` VARCHAR(50) NOT NULL DEFAULT ''," +
+				"`algorithm` TINYINT NOT NULL DEFAULT 0," +
+				"`connect_timeout` INTEGER NOT NULL DEFAULT 1," +
+				"`write_timeout` INTEGER NOT NULL DEFAULT 1," +
+				"`read_timeout` INTEGER NOT NULL DEFAULT 1," +
+				"`enable` TINYINT NOT NULL DEFAULT 2," +
+				"`release` TINYINT NOT NULL DEFAULT 1," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_upstreams_uniq_id` ON `oak_upstreams` (`res_id`)",
+
+			"CREATE TABLE IF NOT EXISTS `oak_user_tokens` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`token` TEXT NOT NULL DEFAULT ''," +
+				"`user_email` VARCHAR(80) NOT NULL DEFAULT ''," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`expired_at` DATETIME NULL DEFAULT NULL)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_user_tokens_uniq_id` ON `oak_user_tokens` (`res_id`)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_user_tokens_uniq_user_email` ON `oak_user_tokens` (`user_email`)",
+
+			"CREATE TABLE IF NOT EXISTS `oak_users` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`name` VARCHAR(50) NOT NULL DEFAULT ''," +
+				"`password` CHAR(32) NOT NULL DEFAULT ''," +
+				"`email` VARCHAR(80) NOT NULL DEFAULT ''," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_users_uniq_id` ON `oak_users` (`res_id`)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_users_uniq_email` ON `oak_users` (`email`)",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_certificates`",
+			"DROP TABLE IF EXISTS `oak_plugin_configs`",
+			"DROP TABLE IF EXISTS `oak_plugins`",
+			"DROP TABLE IF EXISTS `oak_routers`",
+			"DROP TABLE IF EXISTS `oak_service_domains`",
+			"DROP TABLE IF EXISTS `oak_services`",
+			"DROP TABLE IF EXISTS `oak_upstream_nodes`",
+			"DROP TABLE IF EXISTS `oak_upstreams`",
+			"DROP TABLE IF EXISTS `oak_user_tokens`",
+			"DROP TABLE IF EXISTS `oak_users`",
+		},
+	},
+}
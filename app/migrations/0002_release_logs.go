@@ -0,0 +1,49 @@
+package migrations
+
+// migration0002ReleaseLogs 发布记录表，保存每次推送至数据面的配置与对应的控制面数据，用于回滚
+var migration0002ReleaseLogs = Migration{
+	Version: 2,
+	Name:    "release_logs",
+	MySQL: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_release_logs` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Release log id'," +
+				"`resource_type` varchar(20) NOT NULL DEFAULT '' COMMENT 'Resource type  service  router  upstream'," +
+				"`resource_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Resource id'," +
+				"`payload` mediumtext NOT NULL COMMENT 'Configuration pushed to the data plane'," +
+				"`data` mediumtext NOT NULL COMMENT 'Control plane rows at release time'," +
+				"`operator` varchar(80) NOT NULL DEFAULT '' COMMENT 'Operator'," +
+				"`rollback_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Rolled back release log id'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)," +
+				"KEY `IDX_RESOURCE` (`resource_type`,`resource_res_id`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Release logs'",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_release_logs`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_release_logs` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`resource_type` VARCHAR(20) NOT NULL DEFAULT ''," +
+				"`resource_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`payload` TEXT NOT NULL DEFAULT ''," +
+				"`data` TEXT NOT NULL DEFAULT ''," +
+				"`operator` VARCHAR(80) NOT NULL DEFAULT ''," +
+				"`rollback_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_release_logs_uniq_id` ON `oak_release_logs` (`res_id`)",
+			"CREATE INDEX IF NOT EXISTS `oak_release_logs_idx_resource` ON `oak_release_logs` (`resource_type`, `resource_res_id`)",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_release_logs`",
+		},
+	},
+}
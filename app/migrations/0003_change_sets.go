@@ -0,0 +1,66 @@
+package migrations
+
+// migration0003ChangeSets 变更集及其包含的资源，用于多个资源的原子发布
+var migration0003ChangeSets = Migration{
+	Version: 3,
+	Name:    "change_sets",
+	MySQL: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_change_sets` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Change set id'," +
+				"`name` varchar(50) NOT NULL DEFAULT '' COMMENT 'Change set name'," +
+				"`status` tinyint(1) unsigned NOT NULL DEFAULT 1 COMMENT 'Status  1:draft  2:published  3:failed'," +
+				"`operator` varchar(80) NOT NULL DEFAULT '' COMMENT 'Last publish operator'," +
+				"`message` varchar(500) NOT NULL DEFAULT '' COMMENT 'Last publish error message'," +
+				"`published_at` timestamp NULL DEFAULT NULL COMMENT 'Publish time'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Change sets'",
+			"CREATE TABLE IF NOT EXISTS `oak_change_set_items` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`change_set_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Change set id'," +
+				"`resource_type` varchar(20) NOT NULL DEFAULT '' COMMENT 'Resource type  upstream  service  router  plugin_config'," +
+				"`resource_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Resource id'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"KEY `IDX_CHANGE_SET_ID` (`change_set_res_id`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Change set items'",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_change_set_items`",
+			"DROP TABLE IF EXISTS `oak_change_sets`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_change_sets` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`name` VARCHAR(50) NOT NULL DEFAULT ''," +
+				"`status` TINYINT NOT NULL DEFAULT 1," +
+				"`operator` VARCHAR(80) NOT NULL DEFAULT ''," +
+				"`message` VARCHAR(500) NOT NULL DEFAULT ''," +
+				"`published_at` DATETIME NULL DEFAULT NULL," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_change_sets_uniq_id` ON `oak_change_sets` (`res_id`)",
+
+			"CREATE TABLE IF NOT EXISTS `oak_change_set_items` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`change_set_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`resource_type` VARCHAR(20) NOT NULL DEFAULT ''," +
+				"`resource_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE INDEX IF NOT EXISTS `oak_change_set_items_idx_change_set_id` ON `oak_change_set_items` (`change_set_res_id`)",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_change_set_items`",
+			"DROP TABLE IF EXISTS `oak_change_sets`",
+		},
+	},
+}
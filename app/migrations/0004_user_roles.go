@@ -0,0 +1,57 @@
+package migrations
+
+// migration0004UserRoles 用户角色与服务级权限，已有用户均初始化为管理员
+var migration0004UserRoles = Migration{
+	Version: 4,
+	Name:    "user_roles",
+	MySQL: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_user_roles` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`user_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'User id'," +
+				"`role` varchar(20) NOT NULL DEFAULT '' COMMENT 'Role  admin  operator  viewer'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_USER_ID` (`user_res_id`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='User roles'",
+			"CREATE TABLE IF NOT EXISTS `oak_user_service_permissions` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`user_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'User id'," +
+				"`service_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Service id'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_USER_ID_SERVICE_ID` (`user_res_id`,`service_res_id`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='User service permissions'",
+			"INSERT INTO `oak_user_roles` (`user_res_id`, `role`) SELECT `res_id`, 'admin' FROM `oak_users`",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_user_service_permissions`",
+			"DROP TABLE IF EXISTS `oak_user_roles`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_user_roles` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`user_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`role` VARCHAR(20) NOT NULL DEFAULT ''," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_user_roles_uniq_user_id` ON `oak_user_roles` (`user_res_id`)",
+			"CREATE TABLE IF NOT EXISTS `oak_user_service_permissions` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`user_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`service_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_user_service_permissions_uniq_user_id_service_id` ON `oak_user_service_permissions` (`user_res_id`, `service_res_id`)",
+			"INSERT INTO `oak_user_roles` (`user_res_id`, `role`) SELECT `res_id`, 'admin' FROM `oak_users`",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_user_service_permissions`",
+			"DROP TABLE IF EXISTS `oak_user_roles`",
+		},
+	},
+}
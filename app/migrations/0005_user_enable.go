@@ -0,0 +1,23 @@
+package migrations
+
+// migration0005UserEnable 用户开关，禁用的用户不允许登录
+var migration0005UserEnable = Migration{
+	Version: 5,
+	Name:    "user_enable",
+	MySQL: Statements{
+		Up: []string{
+			"ALTER TABLE `oak_users` ADD COLUMN `enable` tinyint(1) unsigned NOT NULL DEFAULT 1 COMMENT 'User enable  1:on  2:off' AFTER `email`",
+		},
+		Down: []string{
+			"ALTER TABLE `oak_users` DROP COLUMN `enable`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"ALTER TABLE `oak_users` ADD COLUMN `enable` TINYINT NOT NULL DEFAULT 1",
+		},
+		Down: []string{
+			"ALTER TABLE `oak_users` DROP COLUMN `enable`",
+		},
+	},
+}
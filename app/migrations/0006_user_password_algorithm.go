@@ -0,0 +1,25 @@
+package migrations
+
+// migration0006UserPasswordAlgorithm 记录密码的哈希算法，已有用户为 md5，登录成功后自动升级为 bcrypt。
+// bcrypt 哈希长度超过 32 位，回滚时不会缩短 password 字段
+var migration0006UserPasswordAlgorithm = Migration{
+	Version: 6,
+	Name:    "user_password_algorithm",
+	MySQL: Statements{
+		Up: []string{
+			"ALTER TABLE `oak_users` MODIFY COLUMN `password` varchar(100) NOT NULL DEFAULT '' COMMENT 'Password'",
+			"ALTER TABLE `oak_users` ADD COLUMN `password_algorithm` varchar(20) NOT NULL DEFAULT 'md5' COMMENT 'Password hash algorithm  md5  bcrypt' AFTER `password`",
+		},
+		Down: []string{
+			"ALTER TABLE `oak_users` DROP COLUMN `password_algorithm`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"ALTER TABLE `oak_users` ADD COLUMN `password_algorithm` VARCHAR(20) NOT NULL DEFAULT 'md5'",
+		},
+		Down: []string{
+			"ALTER TABLE `oak_users` DROP COLUMN `password_algorithm`",
+		},
+	},
+}
@@ -0,0 +1,58 @@
+package migrations
+
+// migration0007UserSessions 每个用户可同时保留多个登录会话，会话按 token 的 sha256 查找，不再保存 token 原文。
+// 升级前的会话无法迁移，用户需要重新登录
+var migration0007UserSessions = Migration{
+	Version: 7,
+	Name:    "user_sessions",
+	MySQL: Statements{
+		Up: []string{
+			"DELETE FROM `oak_user_tokens`",
+			"ALTER TABLE `oak_user_tokens` " +
+				"DROP INDEX `UNIQ_USER_EMAIL`," +
+				"ADD COLUMN `name` varchar(50) NOT NULL DEFAULT '' COMMENT 'Session name' AFTER `res_id`," +
+				"ADD COLUMN `token_hash` char(64) NOT NULL DEFAULT '' COMMENT 'Token sha256' AFTER `token`," +
+				"ADD COLUMN `user_agent` varchar(255) NOT NULL DEFAULT '' COMMENT 'User agent' AFTER `user_email`," +
+				"ADD COLUMN `client_ip` varchar(50) NOT NULL DEFAULT '' COMMENT 'Client ip' AFTER `user_agent`," +
+				"ADD COLUMN `last_seen_at` timestamp NULL DEFAULT NULL COMMENT 'Last seen time' AFTER `client_ip`," +
+				"ADD UNIQUE KEY `UNIQ_TOKEN_HASH` (`token_hash`)," +
+				"ADD KEY `IDX_USER_EMAIL` (`user_email`)",
+		},
+		Down: []string{
+			"DELETE FROM `oak_user_tokens`",
+			"ALTER TABLE `oak_user_tokens` " +
+				"DROP INDEX `UNIQ_TOKEN_HASH`," +
+				"DROP INDEX `IDX_USER_EMAIL`," +
+				"DROP COLUMN `name`," +
+				"DROP COLUMN `token_hash`," +
+				"DROP COLUMN `user_agent`," +
+				"DROP COLUMN `client_ip`," +
+				"DROP COLUMN `last_seen_at`," +
+				"ADD UNIQUE KEY `UNIQ_USER_EMAIL` (`user_email`)",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"DELETE FROM `oak_user_tokens`",
+			"DROP INDEX IF EXISTS `oak_user_tokens_uniq_user_email`",
+			"ALTER TABLE `oak_user_tokens` ADD COLUMN `name` VARCHAR(50) NOT NULL DEFAULT ''",
+			"ALTER TABLE `oak_user_tokens` ADD COLUMN `token_hash` CHAR(64) NOT NULL DEFAULT ''",
+			"ALTER TABLE `oak_user_tokens` ADD COLUMN `user_agent` VARCHAR(255) NOT NULL DEFAULT ''",
+			"ALTER TABLE `oak_user_tokens` ADD COLUMN `client_ip` VARCHAR(50) NOT NULL DEFAULT ''",
+			"ALTER TABLE `oak_user_tokens` ADD COLUMN `last_seen_at` DATETIME NULL DEFAULT NULL",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_user_tokens_uniq_token_hash` ON `oak_user_tokens` (`token_hash`)",
+			"CREATE INDEX IF NOT EXISTS `oak_user_tokens_idx_user_email` ON `oak_user_tokens` (`user_email`)",
+		},
+		Down: []string{
+			"DELETE FROM `oak_user_tokens`",
+			"DROP INDEX IF EXISTS `oak_user_tokens_uniq_token_hash`",
+			"DROP INDEX IF EXISTS `oak_user_tokens_idx_user_email`",
+			"ALTER TABLE `oak_user_tokens` DROP COLUMN `name`",
+			"ALTER TABLE `oak_user_tokens` DROP COLUMN `token_hash`",
+			"ALTER TABLE `oak_user_tokens` DROP COLUMN `user_agent`",
+			"ALTER TABLE `oak_user_tokens` DROP COLUMN `client_ip`",
+			"ALTER TABLE `oak_user_tokens` DROP COLUMN `last_seen_at`",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_user_tokens_uniq_user_email` ON `oak_user_tokens` (`user_email`)",
+		},
+	},
+}
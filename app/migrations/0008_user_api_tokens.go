@@ -0,0 +1,53 @@
+package migrations
+
+// migration0008UserApiTokens 用于自动化调用的长期 API Token，只保存 token 的 sha256
+var migration0008UserApiTokens = Migration{
+	Version: 8,
+	Name:    "user_api_tokens",
+	MySQL: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_user_api_tokens` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Api token id'," +
+				"`user_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'User id'," +
+				"`name` varchar(50) NOT NULL DEFAULT '' COMMENT 'Api token name'," +
+				"`token_prefix` varchar(20) NOT NULL DEFAULT '' COMMENT 'Token prefix for display'," +
+				"`token_hash` char(64) NOT NULL DEFAULT '' COMMENT 'Token sha256'," +
+				"`scope` varchar(20) NOT NULL DEFAULT '' COMMENT 'Scope  read  write'," +
+				"`last_used_at` timestamp NULL DEFAULT NULL COMMENT 'Last used time'," +
+				"`expired_at` timestamp NULL DEFAULT NULL COMMENT 'Expired time, NULL never expires'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)," +
+				"UNIQUE KEY `UNIQ_TOKEN_HASH` (`token_hash`)," +
+				"KEY `IDX_USER_ID` (`user_res_id`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='User api tokens'",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_user_api_tokens`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_user_api_tokens` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`user_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`name` VARCHAR(50) NOT NULL DEFAULT ''," +
+				"`token_prefix` VARCHAR(20) NOT NULL DEFAULT ''," +
+				"`token_hash` CHAR(64) NOT NULL DEFAULT ''," +
+				"`scope` VARCHAR(20) NOT NULL DEFAULT ''," +
+				"`last_used_at` DATETIME NULL DEFAULT NULL," +
+				"`expired_at` DATETIME NULL DEFAULT NULL," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_user_api_tokens_uniq_id` ON `oak_user_api_tokens` (`res_id`)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_user_api_tokens_uniq_token_hash` ON `oak_user_api_tokens` (`token_hash`)",
+			"CREATE INDEX IF NOT EXISTS `oak_user_api_tokens_idx_user_id` ON `oak_user_api_tokens` (`user_res_id`)",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_user_api_tokens`",
+		},
+	},
+}
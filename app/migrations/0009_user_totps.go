@@ -0,0 +1,61 @@
+package migrations
+
+// migration0009UserTotps 用户的 TOTP 两步验证密钥与恢复码，恢复码只保存 sha256
+var migration0009UserTotps = Migration{
+	Version: 9,
+	Name:    "user_totps",
+	MySQL: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_user_totps` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`user_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'User id'," +
+				"`secret` varchar(64) NOT NULL DEFAULT '' COMMENT 'TOTP secret, base32'," +
+				"`enable` tinyint(1) unsigned NOT NULL DEFAULT 2 COMMENT 'TOTP enable  1:on  2:off, off until the first code is verified'," +
+				"`last_step` bigint(20) NOT NULL DEFAULT 0 COMMENT 'Time step of the last accepted code'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_USER_ID` (`user_res_id`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='User totps'",
+			"CREATE TABLE IF NOT EXISTS `oak_user_recovery_codes` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`user_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'User id'," +
+				"`code_hash` char(64) NOT NULL DEFAULT '' COMMENT 'Recovery code sha256'," +
+				"`used_at` timestamp NULL DEFAULT NULL COMMENT 'Used time, NULL not used'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"KEY `IDX_USER_ID` (`user_res_id`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='User recovery codes'",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_user_recovery_codes`",
+			"DROP TABLE IF EXISTS `oak_user_totps`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_user_totps` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`user_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`secret` VARCHAR(64) NOT NULL DEFAULT ''," +
+				"`enable` TINYINT NOT NULL DEFAULT 2," +
+				"`last_step` BIGINT NOT NULL DEFAULT 0," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_user_totps_uniq_user_id` ON `oak_user_totps` (`user_res_id`)",
+			"CREATE TABLE IF NOT EXISTS `oak_user_recovery_codes` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`user_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`code_hash` CHAR(64) NOT NULL DEFAULT ''," +
+				"`used_at` DATETIME NULL DEFAULT NULL," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE INDEX IF NOT EXISTS `oak_user_recovery_codes_idx_user_id` ON `oak_user_recovery_codes` (`user_res_id`)",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_user_recovery_codes`",
+			"DROP TABLE IF EXISTS `oak_user_totps`",
+		},
+	},
+}
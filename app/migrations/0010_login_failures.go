@@ -0,0 +1,43 @@
+package migrations
+
+// migration0010LoginFailures 按账号与 IP 记录连续登录失败次数与锁定时间
+var migration0010LoginFailures = Migration{
+	Version: 10,
+	Name:    "login_failures",
+	MySQL: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_login_failures` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`type` varchar(20) NOT NULL DEFAULT '' COMMENT 'Type  account  ip'," +
+				"`target` varchar(255) NOT NULL DEFAULT '' COMMENT 'Login email or client ip'," +
+				"`failures` int(11) unsigned NOT NULL DEFAULT 0 COMMENT 'Consecutive failures'," +
+				"`last_failed_at` timestamp NULL DEFAULT NULL COMMENT 'Last failed time'," +
+				"`locked_until` timestamp NULL DEFAULT NULL COMMENT 'Locked until, NULL not locked'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_TYPE_TARGET` (`type`,`target`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Login failures'",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_login_failures`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_login_failures` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`type` VARCHAR(20) NOT NULL DEFAULT ''," +
+				"`target` VARCHAR(255) NOT NULL DEFAULT ''," +
+				"`failures` INTEGER NOT NULL DEFAULT 0," +
+				"`last_failed_at` DATETIME NULL DEFAULT NULL," +
+				"`locked_until` DATETIME NULL DEFAULT NULL," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_login_failures_uniq_type_target` ON `oak_login_failures` (`type`, `target`)",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_login_failures`",
+		},
+	},
+}
@@ -0,0 +1,55 @@
+package migrations
+
+// migration0011AuditLogs 管理后台写操作的审计日志，只追加不修改
+var migration0011AuditLogs = Migration{
+	Version: 11,
+	Name:    "audit_logs",
+	MySQL: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_audit_logs` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`operator` varchar(80) NOT NULL DEFAULT '' COMMENT 'Operator email'," +
+				"`method` varchar(10) NOT NULL DEFAULT '' COMMENT 'Request method'," +
+				"`path` varchar(255) NOT NULL DEFAULT '' COMMENT 'Request route'," +
+				"`resource_type` varchar(30) NOT NULL DEFAULT '' COMMENT 'Resource type'," +
+				"`resource_res_id` varchar(80) NOT NULL DEFAULT '' COMMENT 'Resource id'," +
+				"`before_data` mediumtext NOT NULL COMMENT 'Resource before the request, JSON'," +
+				"`after_data` mediumtext NOT NULL COMMENT 'Resource after the request, JSON'," +
+				"`client_ip` varchar(64) NOT NULL DEFAULT '' COMMENT 'Client ip'," +
+				"`result_code` int(11) NOT NULL DEFAULT 0 COMMENT 'Response code'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"KEY `IDX_OPERATOR` (`operator`)," +
+				"KEY `IDX_RESOURCE` (`resource_type`,`resource_res_id`)," +
+				"KEY `IDX_CREATED_AT` (`created_at`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Audit logs'",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_audit_logs`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_audit_logs` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`operator` VARCHAR(80) NOT NULL DEFAULT ''," +
+				"`method` VARCHAR(10) NOT NULL DEFAULT ''," +
+				"`path` VARCHAR(255) NOT NULL DEFAULT ''," +
+				"`resource_type` VARCHAR(30) NOT NULL DEFAULT ''," +
+				"`resource_res_id` VARCHAR(80) NOT NULL DEFAULT ''," +
+				"`before_data` TEXT NOT NULL DEFAULT ''," +
+				"`after_data` TEXT NOT NULL DEFAULT ''," +
+				"`client_ip` VARCHAR(64) NOT NULL DEFAULT ''," +
+				"`result_code` INTEGER NOT NULL DEFAULT 0," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE INDEX IF NOT EXISTS `oak_audit_logs_idx_operator` ON `oak_audit_logs` (`operator`)",
+			"CREATE INDEX IF NOT EXISTS `oak_audit_logs_idx_resource` ON `oak_audit_logs` (`resource_type`, `resource_res_id`)",
+			"CREATE INDEX IF NOT EXISTS `oak_audit_logs_idx_created_at` ON `oak_audit_logs` (`created_at`)",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_audit_logs`",
+		},
+	},
+}
@@ -0,0 +1,83 @@
+package migrations
+
+// migration0012Webhooks Webhook 地址与投递队列，投递记录同时作为投递日志
+var migration0012Webhooks = Migration{
+	Version: 12,
+	Name:    "webhooks",
+	MySQL: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_webhooks` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Webhook id'," +
+				"`name` varchar(50) NOT NULL DEFAULT '' COMMENT 'Webhook name'," +
+				"`url` varchar(500) NOT NULL DEFAULT '' COMMENT 'Endpoint url'," +
+				"`secret` varchar(100) NOT NULL DEFAULT '' COMMENT 'HMAC secret'," +
+				"`events` varchar(1000) NOT NULL DEFAULT '' COMMENT 'Subscribed events, comma separated, * for all'," +
+				"`enable` tinyint(1) unsigned NOT NULL DEFAULT 1 COMMENT 'Webhook enable  1:on  2:off'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Webhooks'",
+			"CREATE TABLE IF NOT EXISTS `oak_webhook_deliveries` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Delivery id'," +
+				"`webhook_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Webhook id'," +
+				"`event` varchar(50) NOT NULL DEFAULT '' COMMENT 'Event'," +
+				"`payload` mediumtext NOT NULL COMMENT 'Request body'," +
+				"`status` tinyint(1) unsigned NOT NULL DEFAULT 1 COMMENT 'Status  1:pending  2:success  3:failed'," +
+				"`attempts` int(11) unsigned NOT NULL DEFAULT 0 COMMENT 'Attempts'," +
+				"`next_attempt_at` timestamp NULL DEFAULT NULL COMMENT 'Next attempt time'," +
+				"`response_code` int(11) NOT NULL DEFAULT 0 COMMENT 'Last response status code'," +
+				"`response_message` varchar(1000) NOT NULL DEFAULT '' COMMENT 'Last response body or error'," +
+				"`delivered_at` timestamp NULL DEFAULT NULL COMMENT 'Delivered time'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)," +
+				"KEY `IDX_WEBHOOK_ID` (`webhook_res_id`)," +
+				"KEY `IDX_STATUS_NEXT_ATTEMPT` (`status`,`next_attempt_at`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Webhook deliveries'",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_webhook_deliveries`",
+			"DROP TABLE IF EXISTS `oak_webhooks`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_webhooks` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`name` VARCHAR(50) NOT NULL DEFAULT ''," +
+				"`url` VARCHAR(500) NOT NULL DEFAULT ''," +
+				"`secret` VARCHAR(100) NOT NULL DEFAULT ''," +
+				"`events` VARCHAR(1000) NOT NULL DEFAULT ''," +
+				"`enable` TINYINT NOT NULL DEFAULT 1," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_webhooks_uniq_id` ON `oak_webhooks` (`res_id`)",
+			"CREATE TABLE IF NOT EXISTS `oak_webhook_deliveries` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`webhook_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`event` VARCHAR(50) NOT NULL DEFAULT ''," +
+				"`payload` TEXT NOT NULL DEFAULT ''," +
+				"`status` TINYINT NOT NULL DEFAULT 1," +
+				"`attempts` INTEGER NOT NULL DEFAULT 0," +
+				"`next_attempt_at` DATETIME NULL DEFAULT NULL," +
+				"`response_code` INTEGER NOT NULL DEFAULT 0," +
+				"`response_message` VARCHAR(1000) NOT NULL DEFAULT ''," +
+				"`delivered_at` DATETIME NULL DEFAULT NULL," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_webhook_deliveries_uniq_id` ON `oak_webhook_deliveries` (`res_id`)",
+			"CREATE INDEX IF NOT EXISTS `oak_webhook_deliveries_idx_webhook_id` ON `oak_webhook_deliveries` (`webhook_res_id`)",
+			"CREATE INDEX IF NOT EXISTS `oak_webhook_deliveries_idx_status_next_attempt` ON `oak_webhook_deliveries` (`status`, `next_attempt_at`)",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_webhook_deliveries`",
+			"DROP TABLE IF EXISTS `oak_webhooks`",
+		},
+	},
+}
@@ -0,0 +1,39 @@
+package migrations
+
+// migration0013CertificateExpiryAlerts 记录证书已发送的过期提醒，每个阈值只提醒一次，证书更换后过期时间变化会重新提醒
+var migration0013CertificateExpiryAlerts = Migration{
+	Version: 13,
+	Name:    "certificate_expiry_alerts",
+	MySQL: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_certificate_expiry_alerts` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`certificate_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Certificate id'," +
+				"`expired_at` timestamp NULL DEFAULT NULL COMMENT 'Certificate expiration time'," +
+				"`threshold` int(11) unsigned NOT NULL DEFAULT 0 COMMENT 'Threshold in days'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_CERTIFICATE_EXPIRED_THRESHOLD` (`certificate_res_id`,`expired_at`,`threshold`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Certificate expiry alerts'",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_certificate_expiry_alerts`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_certificate_expiry_alerts` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`certificate_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`expired_at` DATETIME NULL DEFAULT NULL," +
+				"`threshold` INTEGER NOT NULL DEFAULT 0," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_certificate_expiry_alerts_uniq_certificate_expired_threshold` ON `oak_certificate_expiry_alerts` (`certificate_res_id`, `expired_at`, `threshold`)",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_certificate_expiry_alerts`",
+		},
+	},
+}
@@ -0,0 +1,71 @@
+package migrations
+
+// migration0014Acme ACME 账号与自动签发的证书，账号按 ACME 服务地址与邮箱区分
+var migration0014Acme = Migration{
+	Version: 14,
+	Name:    "acme",
+	MySQL: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_acme_accounts` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`directory_url` varchar(255) NOT NULL DEFAULT '' COMMENT 'ACME directory url'," +
+				"`email` varchar(100) NOT NULL DEFAULT '' COMMENT 'Contact email'," +
+				"`private_key` text NOT NULL COMMENT 'Account private key'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_DIRECTORY_EMAIL` (`directory_url`,`email`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='ACME accounts'",
+			"CREATE TABLE IF NOT EXISTS `oak_acme_certificates` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`res_id` char(20) NOT NULL DEFAULT '' COMMENT 'ACME certificate id'," +
+				"`sni` varchar(150) NOT NULL DEFAULT '' COMMENT 'Domain'," +
+				"`challenge` varchar(20) NOT NULL DEFAULT '' COMMENT 'Challenge type  http-01  dns-01'," +
+				"`certificate_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Issued certificate id'," +
+				"`status` tinyint(1) unsigned NOT NULL DEFAULT 1 COMMENT 'Status  1:pending  2:valid  3:invalid'," +
+				"`message` varchar(1000) NOT NULL DEFAULT '' COMMENT 'Last error'," +
+				"`next_attempt_at` timestamp NULL DEFAULT NULL COMMENT 'Next issuance time'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_ID` (`res_id`)," +
+				"UNIQUE KEY `UNIQ_SNI` (`sni`)," +
+				"KEY `IDX_NEXT_ATTEMPT` (`next_attempt_at`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='ACME certificates'",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_acme_certificates`",
+			"DROP TABLE IF EXISTS `oak_acme_accounts`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_acme_accounts` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`directory_url` VARCHAR(255) NOT NULL DEFAULT ''," +
+				"`email` VARCHAR(100) NOT NULL DEFAULT ''," +
+				"`private_key` TEXT NOT NULL DEFAULT ''," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_acme_accounts_uniq_directory_email` ON `oak_acme_accounts` (`directory_url`, `email`)",
+			"CREATE TABLE IF NOT EXISTS `oak_acme_certificates` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`sni` VARCHAR(150) NOT NULL DEFAULT ''," +
+				"`challenge` VARCHAR(20) NOT NULL DEFAULT ''," +
+				"`certificate_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`status` TINYINT NOT NULL DEFAULT 1," +
+				"`message` VARCHAR(1000) NOT NULL DEFAULT ''," +
+				"`next_attempt_at` DATETIME NULL DEFAULT NULL," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_acme_certificates_uniq_id` ON `oak_acme_certificates` (`res_id`)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_acme_certificates_uniq_sni` ON `oak_acme_certificates` (`sni`)",
+			"CREATE INDEX IF NOT EXISTS `oak_acme_certificates_idx_next_attempt` ON `oak_acme_certificates` (`next_attempt_at`)",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_acme_certificates`",
+			"DROP TABLE IF EXISTS `oak_acme_accounts`",
+		},
+	},
+}
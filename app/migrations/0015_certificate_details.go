@@ -0,0 +1,37 @@
+package migrations
+
+// migration0015CertificateDetails 记录上传时解析的证书颁发者、主题、SAN 与指纹，已有证书查询详情时实时解析
+var migration0015CertificateDetails = Migration{
+	Version: 15,
+	Name:    "certificate_details",
+	MySQL: Statements{
+		Up: []string{
+			"ALTER TABLE `oak_certificates` " +
+				"ADD COLUMN `issuer` varchar(500) NOT NULL DEFAULT '' COMMENT 'Issuer' AFTER `private_key`," +
+				"ADD COLUMN `subject` varchar(500) NOT NULL DEFAULT '' COMMENT 'Subject' AFTER `issuer`," +
+				"ADD COLUMN `sans` text NOT NULL DEFAULT '' COMMENT 'Subject alternative names, comma separated' AFTER `subject`," +
+				"ADD COLUMN `fingerprint` char(64) NOT NULL DEFAULT '' COMMENT 'SHA-256 fingerprint' AFTER `sans`",
+		},
+		Down: []string{
+			"ALTER TABLE `oak_certificates` " +
+				"DROP COLUMN `issuer`," +
+				"DROP COLUMN `subject`," +
+				"DROP COLUMN `sans`," +
+				"DROP COLUMN `fingerprint`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"ALTER TABLE `oak_certificates` ADD COLUMN `issuer` VARCHAR(500) NOT NULL DEFAULT ''",
+			"ALTER TABLE `oak_certificates` ADD COLUMN `subject` VARCHAR(500) NOT NULL DEFAULT ''",
+			"ALTER TABLE `oak_certificates` ADD COLUMN `sans` TEXT NOT NULL DEFAULT ''",
+			"ALTER TABLE `oak_certificates` ADD COLUMN `fingerprint` CHAR(64) NOT NULL DEFAULT ''",
+		},
+		Down: []string{
+			"ALTER TABLE `oak_certificates` DROP COLUMN `issuer`",
+			"ALTER TABLE `oak_certificates` DROP COLUMN `subject`",
+			"ALTER TABLE `oak_certificates` DROP COLUMN `sans`",
+			"ALTER TABLE `oak_certificates` DROP COLUMN `fingerprint`",
+		},
+	},
+}
@@ -0,0 +1,43 @@
+package migrations
+
+// migration0016CertificateSnis 证书覆盖的 SNI（来自证书的 SAN），已有证书先记录其 sni，启动时再按证书内容补全
+var migration0016CertificateSnis = Migration{
+	Version: 16,
+	Name:    "certificate_snis",
+	MySQL: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_certificate_snis` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`certificate_res_id` char(20) NOT NULL DEFAULT '' COMMENT 'Certificate id'," +
+				"`sni` varchar(150) NOT NULL DEFAULT '' COMMENT 'SNI'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_CERTIFICATE_SNI` (`certificate_res_id`,`sni`)," +
+				"KEY `IDX_SNI` (`sni`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Certificate SNIs'",
+			"INSERT INTO `oak_certificate_snis` (`certificate_res_id`, `sni`) " +
+				"SELECT `res_id`, LOWER(`sni`) FROM `oak_certificates` WHERE `sni` != ''",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_certificate_snis`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_certificate_snis` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`certificate_res_id` CHAR(20) NOT NULL DEFAULT ''," +
+				"`sni` VARCHAR(150) NOT NULL DEFAULT ''," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_certificate_snis_uniq_certificate_sni` ON `oak_certificate_snis` (`certificate_res_id`, `sni`)",
+			"CREATE INDEX IF NOT EXISTS `oak_certificate_snis_idx_sni` ON `oak_certificate_snis` (`sni`)",
+			"INSERT INTO `oak_certificate_snis` (`certificate_res_id`, `sni`) " +
+				"SELECT `res_id`, LOWER(`sni`) FROM `oak_certificates` WHERE `sni` != ''",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_certificate_snis`",
+		},
+	},
+}
@@ -0,0 +1,53 @@
+package migrations
+
+// migration0017UpstreamHealthChecks 上游的主动健康检查配置，节点的 health_check 开启时按上游的配置检查
+var migration0017UpstreamHealthChecks = Migration{
+	Version: 17,
+	Name:    "upstream_health_checks",
+	MySQL: Statements{
+		Up: []string{
+			"ALTER TABLE `oak_upstreams` " +
+				"ADD COLUMN `check_type` varchar(10) NOT NULL DEFAULT 'http' COMMENT 'Health check type  http  tcp' AFTER `read_timeout`," +
+				"ADD COLUMN `check_method` varchar(10) NOT NULL DEFAULT 'GET' COMMENT 'Health check request method' AFTER `check_type`," +
+				"ADD COLUMN `check_host` varchar(150) NOT NULL DEFAULT '' COMMENT 'Health check request host' AFTER `check_method`," +
+				"ADD COLUMN `check_uri` varchar(200) NOT NULL DEFAULT '/' COMMENT 'Health check request uri' AFTER `check_host`," +
+				"ADD COLUMN `check_interval` int(10) unsigned NOT NULL DEFAULT 10 COMMENT 'Health check interval (seconds)' AFTER `check_uri`," +
+				"ADD COLUMN `check_timeout` int(10) unsigned NOT NULL DEFAULT 3 COMMENT 'Health check timeout (seconds)' AFTER `check_interval`," +
+				"ADD COLUMN `check_healthy` int(10) unsigned NOT NULL DEFAULT 2 COMMENT 'Successes before a node is healthy' AFTER `check_timeout`," +
+				"ADD COLUMN `check_unhealthy` int(10) unsigned NOT NULL DEFAULT 3 COMMENT 'Failures before a node is unhealthy' AFTER `check_healthy`",
+		},
+		Down: []string{
+			"ALTER TABLE `oak_upstreams` " +
+				"DROP COLUMN `check_type`," +
+				"DROP COLUMN `check_method`," +
+				"DROP COLUMN `check_host`," +
+				"DROP COLUMN `check_uri`," +
+				"DROP COLUMN `check_interval`," +
+				"DROP COLUMN `check_timeout`," +
+				"DROP COLUMN `check_healthy`," +
+				"DROP COLUMN `check_unhealthy`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"ALTER TABLE `oak_upstreams` ADD COLUMN `check_type` VARCHAR(10) NOT NULL DEFAULT 'http'",
+			"ALTER TABLE `oak_upstreams` ADD COLUMN `check_method` VARCHAR(10) NOT NULL DEFAULT 'GET'",
+			"ALTER TABLE `oak_upstreams` ADD COLUMN `check_host` VARCHAR(150) NOT NULL DEFAULT ''",
+			"ALTER TABLE `oak_upstreams` ADD COLUMN `check_uri` VARCHAR(200) NOT NULL DEFAULT '/'",
+			"ALTER TABLE `oak_upstreams` ADD COLUMN `check_interval` INTEGER NOT NULL DEFAULT 10",
+			"ALTER TABLE `oak_upstreams` ADD COLUMN `check_timeout` INTEGER NOT NULL DEFAULT 3",
+			"ALTER TABLE `oak_upstreams` ADD COLUMN `check_healthy` INTEGER NOT NULL DEFAULT 2",
+			"ALTER TABLE `oak_upstreams` ADD COLUMN `check_unhealthy` INTEGER NOT NULL DEFAULT 3",
+		},
+		Down: []string{
+			"ALTER TABLE `oak_upstreams` DROP COLUMN `check_type`",
+			"ALTER TABLE `oak_upstreams` DROP COLUMN `check_method`",
+			"ALTER TABLE `oak_upstreams` DROP COLUMN `check_host`",
+			"ALTER TABLE `oak_upstreams` DROP COLUMN `check_uri`",
+			"ALTER TABLE `oak_upstreams` DROP COLUMN `check_interval`",
+			"ALTER TABLE `oak_upstreams` DROP COLUMN `check_timeout`",
+			"ALTER TABLE `oak_upstreams` DROP COLUMN `check_healthy`",
+			"ALTER TABLE `oak_upstreams` DROP COLUMN `check_unhealthy`",
+		},
+	},
+}
@@ -0,0 +1,31 @@
+package migrations
+
+// migration0018UpstreamHashOn 一致性Hash的取值来源，已有的一致性Hash上游按客户端IP计算
+var migration0018UpstreamHashOn = Migration{
+	Version: 18,
+	Name:    "upstream_hash_on",
+	MySQL: Statements{
+		Up: []string{
+			"ALTER TABLE `oak_upstreams` " +
+				"ADD COLUMN `hash_on` varchar(10) NOT NULL DEFAULT '' COMMENT 'CHash key source  ip  header  cookie  query  uri' AFTER `algorithm`," +
+				"ADD COLUMN `hash_key` varchar(100) NOT NULL DEFAULT '' COMMENT 'CHash header, cookie or query argument name' AFTER `hash_on`",
+			"UPDATE `oak_upstreams` SET `hash_on` = 'ip' WHERE `algorithm` = 2",
+		},
+		Down: []string{
+			"ALTER TABLE `oak_upstreams` " +
+				"DROP COLUMN `hash_on`," +
+				"DROP COLUMN `hash_key`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"ALTER TABLE `oak_upstreams` ADD COLUMN `hash_on` VARCHAR(10) NOT NULL DEFAULT ''",
+			"ALTER TABLE `oak_upstreams` ADD COLUMN `hash_key` VARCHAR(100) NOT NULL DEFAULT ''",
+			"UPDATE `oak_upstreams` SET `hash_on` = 'ip' WHERE `algorithm` = 2",
+		},
+		Down: []string{
+			"ALTER TABLE `oak_upstreams` DROP COLUMN `hash_on`",
+			"ALTER TABLE `oak_upstreams` DROP COLUMN `hash_key`",
+		},
+	},
+}
@@ -0,0 +1,51 @@
+package migrations
+
+// migration0019LoginChallenges 登录过程中的 OIDC state 与两步验证 totp_token，多实例部署时回调可以落到任意实例
+var migration0019LoginChallenges = Migration{
+	Version: 19,
+	Name:    "login_challenges",
+	MySQL: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_login_challenges` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`type` varchar(20) NOT NULL DEFAULT '' COMMENT 'Type  oidc  totp'," +
+				"`token_hash` char(64) NOT NULL DEFAULT '' COMMENT 'State or totp token sha256'," +
+				"`user_email` varchar(80) NOT NULL DEFAULT '' COMMENT 'Email'," +
+				"`session_name` varchar(50) NOT NULL DEFAULT '' COMMENT 'Session name'," +
+				"`nonce` varchar(64) NOT NULL DEFAULT '' COMMENT 'OIDC nonce'," +
+				"`code_verifier` varchar(128) NOT NULL DEFAULT '' COMMENT 'OIDC PKCE code verifier'," +
+				"`attempts` int(11) unsigned NOT NULL DEFAULT 0 COMMENT 'Verify attempts'," +
+				"`expired_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Expired time'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_TYPE_TOKEN_HASH` (`type`,`token_hash`)," +
+				"KEY `IDX_EXPIRED_AT` (`expired_at`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Login challenges'",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_login_challenges`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_login_challenges` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`type` VARCHAR(20) NOT NULL DEFAULT ''," +
+				"`token_hash` CHAR(64) NOT NULL DEFAULT ''," +
+				"`user_email` VARCHAR(80) NOT NULL DEFAULT ''," +
+				"`session_name` VARCHAR(50) NOT NULL DEFAULT ''," +
+				"`nonce` VARCHAR(64) NOT NULL DEFAULT ''," +
+				"`code_verifier` VARCHAR(128) NOT NULL DEFAULT ''," +
+				"`attempts` INTEGER NOT NULL DEFAULT 0," +
+				"`expired_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_login_challenges_uniq_type_token_hash` ON `oak_login_challenges` (`type`, `token_hash`)",
+			"CREATE INDEX IF NOT EXISTS `oak_login_challenges_idx_expired_at` ON `oak_login_challenges` (`expired_at`)",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_login_challenges`",
+		},
+	},
+}
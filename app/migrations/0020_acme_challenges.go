@@ -0,0 +1,41 @@
+package migrations
+
+// migration0020AcmeChallenges http-01 验证期间 token 对应的响应内容，多实例部署时 ACME 服务的验证请求可以落到任意实例
+var migration0020AcmeChallenges = Migration{
+	Version: 20,
+	Name:    "acme_challenges",
+	MySQL: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_acme_challenges` (" +
+				"`id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'primary key'," +
+				"`token` varchar(128) NOT NULL DEFAULT '' COMMENT 'Challenge token'," +
+				"`key_authorization` varchar(255) NOT NULL DEFAULT '' COMMENT 'Challenge response'," +
+				"`expired_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Expired time'," +
+				"`created_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Creation time'," +
+				"`updated_at` timestamp NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT 'Update time'," +
+				"PRIMARY KEY (`id`)," +
+				"UNIQUE KEY `UNIQ_TOKEN` (`token`)," +
+				"KEY `IDX_EXPIRED_AT` (`expired_at`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='ACME http-01 challenges'",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_acme_challenges`",
+		},
+	},
+	SQLite: Statements{
+		Up: []string{
+			"CREATE TABLE IF NOT EXISTS `oak_acme_challenges` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT," +
+				"`token` VARCHAR(128) NOT NULL DEFAULT ''," +
+				"`key_authorization` VARCHAR(255) NOT NULL DEFAULT ''," +
+				"`expired_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+				"`updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+			"CREATE UNIQUE INDEX IF NOT EXISTS `oak_acme_challenges_uniq_token` ON `oak_acme_challenges` (`token`)",
+			"CREATE INDEX IF NOT EXISTS `oak_acme_challenges_idx_expired_at` ON `oak_acme_challenges` (`expired_at`)",
+		},
+		Down: []string{
+			"DROP TABLE IF EXISTS `oak_acme_challenges`",
+		},
+	},
+}
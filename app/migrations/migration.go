@@ -0,0 +1,273 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+const (
+	DialectMySQL  = "mysql"
+	DialectSQLite = "sqlite"
+
+	mysqlLockName    = "apioak_admin_schema_migrations"
+	mysqlLockTimeout = 60
+)
+
+type Statements struct {
+	Up   []string
+	Down []string
+}
+
+type Migration struct {
+	Version uint
+	Name    string
+	MySQL   Statements
+	SQLite  Statements
+}
+
+type MigrationStatus struct {
+	Version   uint
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type SchemaMigrations struct {
+	Version   uint      `gorm:"column:version;primaryKey"` // Migration version
+	Name      string    `gorm:"column:name"`               // Migration name
+	AppliedAt time.Time `gorm:"column:applied_at"`         // Applied time
+}
+
+func (m *SchemaMigrations) TableName() string {
+	return "schema_migrations"
+}
+
+// migrationList 所有迁移按版本号顺序注册于此，已发布的迁移不允许修改，表结构变更只能追加新的迁移
+var migrationList = []Migration{
+	migration0001InitSchema,
+	migration0002ReleaseLogs,
+	migration0003ChangeSets,
+	migration0004UserRoles,
+	migration0005UserEnable,
+	migration0006UserPasswordAlgorithm,
+	migration0007UserSessions,
+	migration0008UserApiTokens,
+	migration0009UserTotps,
+	migration0010LoginFailures,
+	migration0011AuditLogs,
+	migration0012Webhooks,
+	migration0013CertificateExpiryAlerts,
+	migration0014Acme,
+	migration0015CertificateDetails,
+	migration0016CertificateSnis,
+	migration0017UpstreamHealthChecks,
+	migration0018UpstreamHashOn,
+	migration0019LoginChallenges,
+	migration0020AcmeChallenges,
+}
+
+var schemaMigrationsTables = map[string]string{
+	DialectMySQL: "CREATE TABLE IF NOT EXISTS `schema_migrations` (" +
+		"`version` bigint(20) unsigned NOT NULL COMMENT 'Migration version'," +
+		"`name` varchar(100) NOT NULL DEFAULT '' COMMENT 'Migration name'," +
+		"`applied_at` timestamp NOT NULL DEFAULT current_timestamp() COMMENT 'Applied time'," +
+		"PRIMARY KEY (`version`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Schema migrations'",
+	DialectSQLite: "CREATE TABLE IF NOT EXISTS `schema_migrations` (" +
+		"`version` INTEGER PRIMARY KEY," +
+		"`name` VARCHAR(100) NOT NULL DEFAULT ''," +
+		"`applied_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+}
+
+func Migrations() []Migration {
+	list := make([]Migration, len(migrationList))
+	copy(list, migrationList)
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+
+	return list
+}
+
+func (m Migration) statements(dialect string) (Statements, error) {
+	switch dialect {
+	case DialectMySQL:
+		return m.MySQL, nil
+	case DialectSQLite:
+		return m.SQLite, nil
+	}
+
+	return Statements{}, fmt.Errorf("migration does not support the current dialect `%s`", dialect)
+}
+
+// Up 按版本号顺序执行所有未执行的迁移，返回本次执行的迁移
+func Up(db *gorm.DB) ([]Migration, error) {
+	applied := make([]Migration, 0)
+
+	err := withLock(db, func(conn *gorm.DB) error {
+		versions, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range Migrations() {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+
+			if err = apply(conn, migration, true); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down 按版本号倒序回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	reverted := make([]Migration, 0)
+	if steps <= 0 {
+		return reverted, nil
+	}
+
+	err := withLock(db, func(conn *gorm.DB) error {
+		versions, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		list := Migrations()
+		for i := len(list) - 1; (i >= 0) && (len(reverted) < steps); i-- {
+			if _, ok := versions[list[i].Version]; !ok {
+				continue
+			}
+
+			if err = apply(conn, list[i], false); err != nil {
+				return err
+			}
+			reverted = append(reverted, list[i])
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status 返回所有迁移的执行状态
+func Status(db *gorm.DB) ([]MigrationStatus, error) {
+	if err := createSchemaMigrationsTable(db); err != nil {
+		return nil, err
+	}
+
+	versions, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statusList := make([]MigrationStatus, 0)
+	for _, migration := range Migrations() {
+		status := MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+		if record, ok := versions[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statusList = append(statusList, status)
+	}
+
+	return statusList, nil
+}
+
+func apply(db *gorm.DB, migration Migration, up bool) error {
+	statements, err := migration.statements(db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+
+	sqlList := statements.Up
+	if !up {
+		sqlList = statements.Down
+	}
+
+	// SQLite 的 DDL 在事务中执行，失败时整个迁移回滚；MySQL 的 DDL 会隐式提交，事务不能保证迁移的原子性，
+	// 迁移中途失败时已执行的语句不会回滚，需要手动修复后重新执行
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, sql := range sqlList {
+			if err := tx.Exec(sql).Error; err != nil {
+				return err
+			}
+		}
+
+		if up {
+			return tx.Create(&SchemaMigrations{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		}
+
+		return tx.Where("version = ?", migration.Version).Delete(&SchemaMigrations{}).Error
+	})
+
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s failed: `%s`", migration.Version, migration.Name, err)
+	}
+
+	return nil
+}
+
+func appliedVersions(db *gorm.DB) (map[uint]SchemaMigrations, error) {
+	records := make([]SchemaMigrations, 0)
+	if err := db.Order("version ASC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	versions := make(map[uint]SchemaMigrations, len(records))
+	for _, record := range records {
+		versions[record.Version] = record
+	}
+
+	return versions, nil
+}
+
+func createSchemaMigrationsTable(db *gorm.DB) error {
+	sql, ok := schemaMigrationsTables[db.Dialector.Name()]
+	if !ok {
+		return fmt.Errorf("migration does not support the current dialect `%s`", db.Dialector.Name())
+	}
+
+	return db.Exec(sql).Error
+}
+
+// withLock 在同一个数据库连接上执行迁移，MySQL 下通过命名锁避免多个实例同时执行迁移
+func withLock(db *gorm.DB, fc func(conn *gorm.DB) error) error {
+	return db.Connection(func(conn *gorm.DB) error {
+		if conn.Dialector.Name() == DialectMySQL {
+			var locked int
+			if err := conn.Raw("SELECT GET_LOCK(?, ?)", mysqlLockName, mysqlLockTimeout).Scan(&locked).Error; err != nil {
+				return err
+			}
+			if locked != 1 {
+				return errors.New("wait for the schema migration lock timeout")
+			}
+			defer conn.Exec("SELECT RELEASE_LOCK(?)", mysqlLockName)
+		}
+
+		if err := createSchemaMigrationsTable(conn); err != nil {
+			return err
+		}
+
+		return fc(conn)
+	})
+}
@@ -0,0 +1,257 @@
+package models
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"errors"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+type AcmeAccounts struct {
+	ID           int    `gorm:"column:id;primary_key"` // primary key
+	DirectoryUrl string `gorm:"column:directory_url"`  // ACME directory url
+	Email        string `gorm:"column:email"`          // Contact email
+	PrivateKey   string `gorm:"column:private_key"`    // Account private key
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *AcmeAccounts) TableName() string {
+	return "oak_acme_accounts"
+}
+
+type AcmeCertificates struct {
+	ID               int        `gorm:"column:id;primary_key"`     // primary key
+	ResID            string     `gorm:"column:res_id"`             // ACME certificate id
+	Sni              string     `gorm:"column:sni"`                // Domain
+	Challenge        string     `gorm:"column:challenge"`          // Challenge type  http-01  dns-01
+	CertificateResID string     `gorm:"column:certificate_res_id"` // Issued certificate id
+	Status           int        `gorm:"column:status"`             // Status  1:pending  2:valid  3:invalid
+	Message          string     `gorm:"column:message"`            // Last error
+	NextAttemptAt    *time.Time `gorm:"column:next_attempt_at"`    // Next issuance time
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *AcmeCertificates) TableName() string {
+	return "oak_acme_certificates"
+}
+
+type AcmeChallenges struct {
+	ID               int       `gorm:"column:id;primary_key"`    // primary key
+	Token            string    `gorm:"column:token"`             // Challenge token
+	KeyAuthorization string    `gorm:"column:key_authorization"` // Challenge response
+	ExpiredAt        time.Time `gorm:"column:expired_at"`        // Expired time
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *AcmeChallenges) TableName() string {
+	return "oak_acme_challenges"
+}
+
+// AcmeAccountInfo 查询 ACME 服务地址与邮箱对应的账号，不存在时返回 gorm.ErrRecordNotFound
+func (m *AcmeAccounts) AcmeAccountInfo(directoryUrl string, email string) (account AcmeAccounts, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("directory_url = ? AND email = ?", directoryUrl, email).
+		First(&account).Error
+
+	return
+}
+
+func (m *AcmeAccounts) AcmeAccountAdd(account *AcmeAccounts) error {
+	return packages.GetDb().
+		Table(m.TableName()).
+		Create(account).Error
+}
+
+func (m *AcmeAccounts) AcmeAccountAllList(tx *gorm.DB) (list []AcmeAccounts, err error) {
+	err = tx.Table(m.TableName()).
+		Order("id ASC").
+		Find(&list).Error
+
+	return
+}
+
+var recursionTimesAcmeCertificates = 1
+
+func (m *AcmeCertificates) ModelUniqueId() (generateId string, err error) {
+	generateId, err = utils.IdGenerate(utils.IdTypeAcmeCertificate)
+	if err != nil {
+		return
+	}
+
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("res_id = ?", generateId).
+		Select("res_id").
+		First(m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+		recursionTimesAcmeCertificates = 1
+		return
+	}
+
+	if err != nil {
+		return
+	}
+
+	if recursionTimesAcmeCertificates == utils.IdGenerateMaxTimes {
+		recursionTimesAcmeCertificates = 1
+		err = errors.New(enums.CodeMessages(enums.IdConflict))
+		return
+	}
+
+	recursionTimesAcmeCertificates++
+	generateId, err = m.ModelUniqueId()
+
+	return
+}
+
+func (m *AcmeCertificates) AcmeCertificatesAdd(certificates []AcmeCertificates) (resIds []string, err error) {
+	resIds = make([]string, 0, len(certificates))
+	err = packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		for i := range certificates {
+			resId, err := m.ModelUniqueId()
+			if err != nil {
+				return err
+			}
+
+			certificates[i].ResID = resId
+			err = tx.Table(m.TableName()).
+				Create(&certificates[i]).Error
+			if err != nil {
+				return err
+			}
+
+			resIds = append(resIds, resId)
+		}
+
+		return nil
+	})
+
+	return
+}
+
+func (m *AcmeCertificates) AcmeCertificateInfoByResId(resId string) (certificate AcmeCertificates, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("res_id = ?", resId).
+		First(&certificate).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.New(enums.CodeMessages(enums.AcmeCertificateNull))
+	}
+
+	return
+}
+
+func (m *AcmeCertificates) AcmeCertificateListBySni(snis []string) (list []AcmeCertificates, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("sni IN ?", snis).
+		Find(&list).Error
+
+	return
+}
+
+func (m *AcmeCertificates) AcmeCertificateListPage(param *validators.AcmeCertificateList) (list []AcmeCertificates, total int, err error) {
+	tx := packages.GetDb().
+		Table(m.TableName())
+
+	if param.Status != 0 {
+		tx = tx.Where("status = ?", param.Status)
+	}
+
+	param.Search = strings.TrimSpace(param.Search)
+	if len(param.Search) != 0 {
+		search := "%" + param.Search + "%"
+		tx = tx.Where("sni LIKE ? OR res_id LIKE ?", search, search)
+	}
+
+	err = ListCount(tx, &total)
+	if err != nil {
+		return
+	}
+
+	tx = tx.Order("id DESC")
+	err = ListPaginate(tx, &list, &param.BaseListPage)
+
+	return
+}
+
+// AcmeCertificateDueList 查询到达签发时间的证书
+func (m *AcmeCertificates) AcmeCertificateDueList(limit int) (list []AcmeCertificates, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("next_attempt_at <= ?", time.Now()).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&list).Error
+
+	return
+}
+
+// AcmeCertificateClaim 推迟下次签发时间以占用该记录，多个实例同时签发时只有一个能占用成功
+func (m *AcmeCertificates) AcmeCertificateClaim(id int, nextAttemptAt time.Time, claimUntil time.Time) (rowsAffected int64, err error) {
+	db := packages.GetDb().
+		Table(m.TableName()).
+		Where("id = ? AND next_attempt_at = ?", id, nextAttemptAt).
+		Update("next_attempt_at", claimUntil)
+
+	return db.RowsAffected, db.Error
+}
+
+func (m *AcmeCertificates) AcmeCertificateUpdateColumns(id int, params map[string]interface{}) error {
+	return packages.GetDb().
+		Table(m.TableName()).
+		Where("id = ?", id).
+		Updates(params).Error
+}
+
+// AcmeCertificateDelete 只删除 ACME 记录，已签发的证书保留在证书管理中
+func (m *AcmeCertificates) AcmeCertificateDelete(resId string) error {
+	return packages.GetDb().
+		Table(m.TableName()).
+		Where("res_id = ?", resId).
+		Delete(&AcmeCertificates{}).Error
+}
+
+// AcmeChallengeAdd 写入前顺带清理已过期的记录，同一 token 重复写入时覆盖
+func (m *AcmeChallenges) AcmeChallengeAdd(challenge *AcmeChallenges) error {
+	return packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(m.TableName()).
+			Where("expired_at < ? OR token = ?", time.Now(), challenge.Token).
+			Delete(&AcmeChallenges{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Table(m.TableName()).Create(challenge).Error
+	})
+}
+
+// AcmeChallengeInfo 不存在或已过期时返回空的 ID
+func (m *AcmeChallenges) AcmeChallengeInfo(token string) (challenge AcmeChallenges, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("token = ? AND expired_at > ?", token, time.Now()).
+		First(&challenge).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	return
+}
+
+func (m *AcmeChallenges) AcmeChallengeDelete(token string) error {
+	return packages.GetDb().
+		Table(m.TableName()).
+		Where("token = ?", token).
+		Delete(&AcmeChallenges{}).Error
+}
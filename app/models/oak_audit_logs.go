@@ -0,0 +1,82 @@
+package models
+
+import (
+	"apioak-admin/app/packages"
+	"apioak-admin/app/validators"
+	"strings"
+	"time"
+)
+
+type AuditLogs struct {
+	ID            int    `gorm:"column:id;primary_key"`  // primary key
+	Operator      string `gorm:"column:operator"`        // Operator email
+	Method        string `gorm:"column:method"`          // Request method
+	Path          string `gorm:"column:path"`            // Request route
+	ResourceType  string `gorm:"column:resource_type"`   // Resource type
+	ResourceResID string `gorm:"column:resource_res_id"` // Resource id
+	BeforeData    string `gorm:"column:before_data"`     // Resource before the request, JSON
+	AfterData     string `gorm:"column:after_data"`      // Resource after the request, JSON
+	ClientIp      string `gorm:"column:client_ip"`       // Client ip
+	ResultCode    int    `gorm:"column:result_code"`     // Response code
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *AuditLogs) TableName() string {
+	return "oak_audit_logs"
+}
+
+// AuditLogAdd 审计日志只追加不修改
+func (m *AuditLogs) AuditLogAdd(auditLog *AuditLogs) error {
+	return packages.GetDb().
+		Table(m.TableName()).
+		Create(auditLog).Error
+}
+
+func (m *AuditLogs) AuditLogListPage(param *validators.AuditLogList) (list []AuditLogs, total int, err error) {
+	tx := packages.GetDb().
+		Table(m.TableName())
+
+	param.Operator = strings.TrimSpace(param.Operator)
+	if len(param.Operator) != 0 {
+		tx = tx.Where("operator = ?", param.Operator)
+	}
+	if len(param.ResourceType) != 0 {
+		tx = tx.Where("resource_type = ?", param.ResourceType)
+	}
+	param.ResourceResID = strings.TrimSpace(param.ResourceResID)
+	if len(param.ResourceResID) != 0 {
+		tx = tx.Where("resource_res_id = ?", param.ResourceResID)
+	}
+	if len(param.Method) != 0 {
+		tx = tx.Where("method = ?", param.Method)
+	}
+	if param.StartAt != 0 {
+		tx = tx.Where("created_at >= ?", time.Unix(param.StartAt, 0))
+	}
+	if param.EndAt != 0 {
+		tx = tx.Where("created_at <= ?", time.Unix(param.EndAt, 0))
+	}
+
+	err = ListCount(tx, &total)
+	if err != nil {
+		return
+	}
+
+	tx = tx.Order("id DESC")
+	err = ListPaginate(tx, &list, &param.BaseListPage)
+
+	return
+}
+
+// ResourceRows 按列查询任意资源表的原始数据，用于记录审计日志中资源修改前后的内容
+func (m *AuditLogs) ResourceRows(table string, column string, value string) (rows []map[string]interface{}, err error) {
+	rows = make([]map[string]interface{}, 0)
+	err = packages.GetDb().
+		Table(table).
+		Where(column+" = ?", value).
+		Order("id ASC").
+		Find(&rows).Error
+
+	return
+}
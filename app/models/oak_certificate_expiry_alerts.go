@@ -0,0 +1,52 @@
+package models
+
+import (
+	"apioak-admin/app/packages"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type CertificateExpiryAlerts struct {
+	ID               int       `gorm:"column:id;primary_key"`     // primary key
+	CertificateResID string    `gorm:"column:certificate_res_id"` // Certificate id
+	ExpiredAt        time.Time `gorm:"column:expired_at"`         // Certificate expiration time
+	Threshold        int       `gorm:"column:threshold"`          // Threshold in days
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *CertificateExpiryAlerts) TableName() string {
+	return "oak_certificate_expiry_alerts"
+}
+
+// CertificateExpiryAlertAdd 记录已发送的提醒，已记录过时 alertId 为 0，多个实例同时检查时只有一个会发送提醒
+func (m *CertificateExpiryAlerts) CertificateExpiryAlertAdd(certificateResId string, expiredAt time.Time, threshold int) (alertId int, err error) {
+	alert := CertificateExpiryAlerts{
+		CertificateResID: certificateResId,
+		ExpiredAt:        expiredAt,
+		Threshold:        threshold,
+	}
+	db := packages.GetDb().
+		Table(m.TableName()).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&alert)
+	if (db.Error != nil) || (db.RowsAffected == 0) {
+		return 0, db.Error
+	}
+
+	return alert.ID, nil
+}
+
+// CertificateExpiryAlertRelease 提醒全部发送失败时删除记录，下次检查时重新发送
+func (m *CertificateExpiryAlerts) CertificateExpiryAlertRelease(alertId int) error {
+	return packages.GetDb().Table(m.TableName()).
+		Where("id = ?", alertId).
+		Delete(&CertificateExpiryAlerts{}).Error
+}
+
+func (m *CertificateExpiryAlerts) CertificateExpiryAlertDelete(tx *gorm.DB, certificateResId string) error {
+	return tx.Table(m.TableName()).
+		Where("certificate_res_id = ?", certificateResId).
+		Delete(&CertificateExpiryAlerts{}).Error
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"apioak-admin/app/packages"
+	"gorm.io/gorm"
+)
+
+type CertificateSnis struct {
+	ID               int    `gorm:"column:id;primary_key"`     // primary key
+	CertificateResID string `gorm:"column:certificate_res_id"` // Certificate id
+	Sni              string `gorm:"column:sni"`                // SNI, lower case
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *CertificateSnis) TableName() string {
+	return "oak_certificate_snis"
+}
+
+// CertificateSnisReplace 将证书的 SNI 替换为 snis
+func (m *CertificateSnis) CertificateSnisReplace(tx *gorm.DB, certificateResId string, snis []string) error {
+	err := m.CertificateSnisDelete(tx, certificateResId)
+	if err != nil {
+		return err
+	}
+
+	if len(snis) == 0 {
+		return nil
+	}
+
+	certificateSnis := make([]CertificateSnis, 0, len(snis))
+	for _, sni := range snis {
+		certificateSnis = append(certificateSnis, CertificateSnis{
+			CertificateResID: certificateResId,
+			Sni:              sni,
+		})
+	}
+
+	return tx.Table(m.TableName()).Create(&certificateSnis).Error
+}
+
+func (m *CertificateSnis) CertificateSnisDelete(tx *gorm.DB, certificateResId string) error {
+	return tx.Table(m.TableName()).
+		Where("certificate_res_id = ?", certificateResId).
+		Delete(&CertificateSnis{}).Error
+}
+
+// CertificateSniMap 查询证书的 SNI，按证书ID分组并保持添加顺序
+func (m *CertificateSnis) CertificateSniMap(tx *gorm.DB, certificateResIds []string) (map[string][]string, error) {
+	sniMap := make(map[string][]string)
+	if len(certificateResIds) == 0 {
+		return sniMap, nil
+	}
+
+	list := make([]CertificateSnis, 0)
+	err := tx.Table(m.TableName()).
+		Where("certificate_res_id IN ?", certificateResIds).
+		Order("id ASC").
+		Find(&list).Error
+	if err != nil {
+		return sniMap, err
+	}
+
+	for _, certificateSni := range list {
+		sniMap[certificateSni.CertificateResID] = append(sniMap[certificateSni.CertificateResID], certificateSni.Sni)
+	}
+
+	return sniMap, nil
+}
+
+func (m *CertificateSnis) CertificateSniListBySnis(snis []string) (list []CertificateSnis, err error) {
+	if len(snis) == 0 {
+		return
+	}
+
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("sni IN ?", snis).
+		Find(&list).Error
+
+	return
+}
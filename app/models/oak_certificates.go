@@ -17,6 +17,10 @@ type Certificates struct {
 	Sni         string    `gorm:"column:sni"`            //SNI
 	Certificate string    `gorm:"column:certificate"`    //Certificate content
 	PrivateKey  string    `gorm:"column:private_key"`    //Private key content
+	Issuer      string    `gorm:"column:issuer"`         //Issuer
+	Subject     string    `gorm:"column:subject"`        //Subject
+	Sans        string    `gorm:"column:sans"`           //Subject alternative names, comma separated
+	Fingerprint string    `gorm:"column:fingerprint"`    //SHA-256 fingerprint
 	Enable      int       `gorm:"column:enable"`         //Certificate enable  1:on  2:off
 	ExpiredAt   time.Time `gorm:"column:expired_at"`     //Expiration time
 	ModelTime
@@ -91,20 +95,26 @@ func (c *Certificates) CertificateInfoById(resID string) (Certificates, error) {
 	return certificateInfo, nil
 }
 
-func (c *Certificates) EnableCertificateInfoBySni(sni string, filterId string) (Certificates, error) {
-	certificateInfo := Certificates{}
-	db := packages.GetDb().Table(c.TableName()).Where("sni = ?", sni)
+// EnableCertificateListBySnis 查询 SNI 与 snis 有重叠的已启用证书
+func (c *Certificates) EnableCertificateListBySnis(tx *gorm.DB, snis []string, filterId string) (list []Certificates, err error) {
+	if len(snis) == 0 {
+		return
+	}
+
+	db := tx.Table(c.TableName()).
+		Where("res_id IN (?)", tx.Table((&CertificateSnis{}).TableName()).
+			Select("certificate_res_id").
+			Where("sni IN ?", snis))
 
 	if filterId != "" {
 		db = db.Where("res_id != ?", filterId)
 	}
 
-	err := db.Where("enable = ?", utils.EnableOn).First(&certificateInfo).Error
+	err = db.Where("enable = ?", utils.EnableOn).
+		Order("id ASC").
+		Find(&list).Error
 
-	if err != nil && err != gorm.ErrRecordNotFound {
-		return certificateInfo, err
-	}
-	return certificateInfo, nil
+	return
 }
 
 func (c *Certificates) CertificateListPage(param *validators.CertificateList) (list []Certificates, total int, listError error) {
@@ -121,7 +131,10 @@ func (c *Certificates) CertificateListPage(param *validators.CertificateList) (l
 		tx = tx.Where(
 			packages.GetDb().Table(c.TableName()).
 				Where("sni LIKE ?", search).
-				Or("res_id LIKE ?", search))
+				Or("res_id LIKE ?", search).
+				Or("res_id IN (?)", packages.GetDb().Table((&CertificateSnis{}).TableName()).
+					Select("certificate_res_id").
+					Where("sni LIKE ?", search)))
 	}
 
 	countError := ListCount(tx, &total)
@@ -135,6 +148,17 @@ func (c *Certificates) CertificateListPage(param *validators.CertificateList) (l
 	return
 }
 
+// CertificateExpiringList 查询在 before 之前过期的已启用证书，包含已经过期的证书
+func (c *Certificates) CertificateExpiringList(before time.Time) (list []Certificates, err error) {
+	err = packages.GetDb().
+		Table(c.TableName()).
+		Where("enable = ? AND expired_at <= ?", utils.EnableOn, before).
+		Order("expired_at ASC").
+		Find(&list).Error
+
+	return
+}
+
 func (c *Certificates) CertificateDelete(tx *gorm.DB, resID string) error {
 	err := tx.Model(&Certificates{}).
 		Where("res_id = ?", resID).
@@ -161,13 +185,89 @@ func (c *Certificates) CertificateSwitchEnable(tx *gorm.DB, resID string, enable
 	return nil
 }
 
-func (c *Certificates) CertificateInfoByDomainSniInfos(domains []string) []Certificates {
-	certificateInfos := []Certificates{}
+// CertificateInfoByDomainSniInfos 按 SNI 为每个域名匹配已启用的证书，与域名相同的 SNI 优先于覆盖该域名的泛域名 SNI，
+// 同一优先级有多个证书时选择过期时间最晚的证书，返回 域名 → 证书，没有匹配证书的域名不在结果中
+func (c *Certificates) CertificateInfoByDomainSniInfos(domains []string) (map[string]Certificates, error) {
+	domainCertificates := make(map[string]Certificates)
 	if len(domains) == 0 {
-		return certificateInfos
+		return domainCertificates, nil
 	}
 
-	packages.GetDb().Table(c.TableName()).Where("sni In ?", domains).Find(&certificateInfos)
+	domainSnis := make(map[string][]string)
+	snis := make([]string, 0)
+	for _, domain := range domains {
+		domainSni := strings.ToLower(domain)
+		domainSnis[domain] = []string{domainSni}
+		if index := strings.Index(domainSni, "."); (index > 0) && !strings.HasPrefix(domainSni, "*.") {
+			domainSnis[domain] = append(domainSnis[domain], "*"+domainSni[index:])
+		}
+		snis = append(snis, domainSnis[domain]...)
+	}
 
-	return certificateInfos
+	certificateSnis, err := (&CertificateSnis{}).CertificateSniListBySnis(snis)
+	if err != nil {
+		return domainCertificates, err
+	}
+	if len(certificateSnis) == 0 {
+		return domainCertificates, nil
+	}
+
+	certificateResIds := make([]string, 0)
+	for _, certificateSni := range certificateSnis {
+		certificateResIds = append(certificateResIds, certificateSni.CertificateResID)
+	}
+
+	certificateList := make([]Certificates, 0)
+	err = packages.GetDb().
+		Table(c.TableName()).
+		Where("res_id IN ? AND enable = ?", certificateResIds, utils.EnableOn).
+		Order("id ASC").
+		Find(&certificateList).Error
+	if err != nil {
+		return domainCertificates, err
+	}
+
+	certificateMap := make(map[string]Certificates)
+	for _, certificateInfo := range certificateList {
+		certificateMap[certificateInfo.ResID] = certificateInfo
+	}
+
+	sniCertificates := make(map[string][]Certificates)
+	for _, certificateSni := range certificateSnis {
+		if certificateInfo, ok := certificateMap[certificateSni.CertificateResID]; ok {
+			sniCertificates[certificateSni.Sni] = append(sniCertificates[certificateSni.Sni], certificateInfo)
+		}
+	}
+
+	for domain, candidateSnis := range domainSnis {
+		for _, sni := range candidateSnis {
+			candidates := sniCertificates[sni]
+			if len(candidates) == 0 {
+				continue
+			}
+
+			best := candidates[0]
+			for _, candidate := range candidates[1:] {
+				if candidate.ExpiredAt.After(best.ExpiredAt) {
+					best = candidate
+				}
+			}
+			domainCertificates[domain] = best
+			break
+		}
+	}
+
+	return domainCertificates, nil
+}
+
+func (c *Certificates) CertificateAllList(tx *gorm.DB) (list []Certificates, err error) {
+	err = tx.Table(c.TableName()).
+		Order("id ASC").
+		Find(&list).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	return
 }
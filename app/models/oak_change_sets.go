@@ -0,0 +1,174 @@
+package models
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"errors"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+type ChangeSets struct {
+	ID          int        `gorm:"column:id;primary_key"` // primary key
+	ResID       string     `gorm:"column:res_id"`         // Change set id
+	Name        string     `gorm:"column:name"`           // Change set name
+	Status      int        `gorm:"column:status"`         // Status  1:draft  2:published  3:failed
+	Operator    string     `gorm:"column:operator"`       // Last publish operator
+	Message     string     `gorm:"column:message"`        // Last publish error message
+	PublishedAt *time.Time `gorm:"column:published_at"`   // Publish time
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *ChangeSets) TableName() string {
+	return "oak_change_sets"
+}
+
+type ChangeSetItems struct {
+	ID             int    `gorm:"column:id;primary_key"`    // primary key
+	ChangeSetResID string `gorm:"column:change_set_res_id"` // Change set id
+	ResourceType   string `gorm:"column:resource_type"`     // Resource type  upstream  service  router  plugin_config
+	ResourceResID  string `gorm:"column:resource_res_id"`   // Resource id
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *ChangeSetItems) TableName() string {
+	return "oak_change_set_items"
+}
+
+var recursionTimesChangeSets = 1
+
+func (m *ChangeSets) ModelUniqueId() (generateId string, err error) {
+	generateId, err = utils.IdGenerate(utils.IdTypeChangeSet)
+	if err != nil {
+		return
+	}
+
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("res_id = ?", generateId).
+		Select("res_id").
+		First(m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+		recursionTimesChangeSets = 1
+		return
+	}
+
+	if err != nil {
+		return
+	}
+
+	if recursionTimesChangeSets == utils.IdGenerateMaxTimes {
+		recursionTimesChangeSets = 1
+		err = errors.New(enums.CodeMessages(enums.IdConflict))
+		return
+	}
+
+	recursionTimesChangeSets++
+	generateId, err = m.ModelUniqueId()
+
+	return
+}
+
+func (m *ChangeSets) ChangeSetAdd(changeSet *ChangeSets, items []ChangeSetItems) (resId string, err error) {
+	resId, err = m.ModelUniqueId()
+	if err != nil {
+		return
+	}
+
+	err = packages.GetDb().Transaction(func(tx *gorm.DB) (err error) {
+		changeSet.ResID = resId
+		if err = tx.Table(m.TableName()).Create(changeSet).Error; err != nil {
+			return
+		}
+
+		for k := range items {
+			items[k].ChangeSetResID = resId
+			if err = tx.Table((&ChangeSetItems{}).TableName()).Create(&items[k]).Error; err != nil {
+				return
+			}
+		}
+
+		return
+	})
+
+	return
+}
+
+func (m *ChangeSets) ChangeSetDetailByResId(resId string) (detail ChangeSets, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("res_id = ?", resId).
+		First(&detail).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.New(enums.CodeMessages(enums.ChangeSetNull))
+	}
+
+	return
+}
+
+func (m *ChangeSets) ChangeSetListPage(param *validators.ChangeSetList) (list []ChangeSets, total int, err error) {
+	tx := packages.GetDb().
+		Table(m.TableName())
+
+	if param.Status != 0 {
+		tx = tx.Where("status = ?", param.Status)
+	}
+
+	param.Search = strings.TrimSpace(param.Search)
+	if len(param.Search) != 0 {
+		search := "%" + param.Search + "%"
+		tx = tx.Where("name LIKE ? OR res_id LIKE ?", search, search)
+	}
+
+	err = ListCount(tx, &total)
+	if err != nil {
+		return
+	}
+
+	tx = tx.Order("id DESC")
+	err = ListPaginate(tx, &list, &param.BaseListPage)
+
+	return
+}
+
+func (m *ChangeSets) ChangeSetUpdateColumns(tx *gorm.DB, resId string, params map[string]interface{}) error {
+	return tx.Table(m.TableName()).
+		Where("res_id = ?", resId).
+		Updates(params).Error
+}
+
+func (m *ChangeSets) ChangeSetDelete(resId string) error {
+	return packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		itemModel := ChangeSetItems{}
+		if err := tx.Table(itemModel.TableName()).
+			Where("change_set_res_id = ?", resId).
+			Delete(&itemModel).Error; err != nil {
+			return err
+		}
+
+		return tx.Table(m.TableName()).
+			Where("res_id = ?", resId).
+			Delete(m).Error
+	})
+}
+
+func (m *ChangeSetItems) ChangeSetItemListByChangeSetResIds(changeSetResIds []string) (list []ChangeSetItems, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("change_set_res_id IN ?", changeSetResIds).
+		Order("id ASC").
+		Find(&list).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	return
+}
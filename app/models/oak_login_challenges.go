@@ -0,0 +1,92 @@
+package models
+
+import (
+	"apioak-admin/app/packages"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+type LoginChallenges struct {
+	ID           int       `gorm:"column:id;primary_key"` // primary key
+	Type         string    `gorm:"column:type"`           // Type  oidc  totp
+	TokenHash    string    `gorm:"column:token_hash"`     // State or totp token sha256
+	UserEmail    string    `gorm:"column:user_email"`     // Email
+	SessionName  string    `gorm:"column:session_name"`   // Session name
+	Nonce        string    `gorm:"column:nonce"`          // OIDC nonce
+	CodeVerifier string    `gorm:"column:code_verifier"`  // OIDC PKCE code verifier
+	Attempts     int       `gorm:"column:attempts"`       // Verify attempts
+	ExpiredAt    time.Time `gorm:"column:expired_at"`     // Expired time
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *LoginChallenges) TableName() string {
+	return "oak_login_challenges"
+}
+
+// LoginChallengeAdd 写入前顺带清理已过期的记录
+func (m *LoginChallenges) LoginChallengeAdd(challenge *LoginChallenges) error {
+	if err := packages.GetDb().
+		Table(m.TableName()).
+		Where("expired_at < ?", time.Now()).
+		Delete(&LoginChallenges{}).Error; err != nil {
+		return err
+	}
+
+	return packages.GetDb().
+		Table(m.TableName()).
+		Create(challenge).Error
+}
+
+// LoginChallengeByTokenHash 不存在或已过期时返回空的 ID
+func (m *LoginChallenges) LoginChallengeByTokenHash(challengeType string, tokenHash string) (challenge LoginChallenges, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("type = ? AND token_hash = ? AND expired_at > ?", challengeType, tokenHash, time.Now()).
+		First(&challenge).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	return
+}
+
+// LoginChallengeTake 只能使用一次，并发的回调中只有删除成功的一方拿到记录
+func (m *LoginChallenges) LoginChallengeTake(challengeType string, tokenHash string) (LoginChallenges, error) {
+	challenge, err := m.LoginChallengeByTokenHash(challengeType, tokenHash)
+	if (err != nil) || (challenge.ID == 0) {
+		return LoginChallenges{}, err
+	}
+
+	db := packages.GetDb().
+		Table(m.TableName()).
+		Where("id = ?", challenge.ID).
+		Delete(&LoginChallenges{})
+	if (db.Error != nil) || (db.RowsAffected == 0) {
+		return LoginChallenges{}, db.Error
+	}
+
+	return challenge, nil
+}
+
+// LoginChallengeAttempt 尝试次数加一，已过期或次数已达 maxAttempts 时返回空的 ID
+func (m *LoginChallenges) LoginChallengeAttempt(challengeType string, tokenHash string, maxAttempts int) (LoginChallenges, error) {
+	db := packages.GetDb().
+		Table(m.TableName()).
+		Where("type = ? AND token_hash = ? AND expired_at > ? AND attempts < ?", challengeType, tokenHash, time.Now(), maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if (db.Error != nil) || (db.RowsAffected == 0) {
+		return LoginChallenges{}, db.Error
+	}
+
+	return m.LoginChallengeByTokenHash(challengeType, tokenHash)
+}
+
+func (m *LoginChallenges) LoginChallengeDelete(challengeType string, tokenHash string) error {
+	return packages.GetDb().
+		Table(m.TableName()).
+		Where("type = ? AND token_hash = ?", challengeType, tokenHash).
+		Delete(&LoginChallenges{}).Error
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"apioak-admin/app/packages"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+type LoginFailures struct {
+	ID           int        `gorm:"column:id;primary_key"` // primary key
+	Type         string     `gorm:"column:type"`           // Type  account  ip
+	Target       string     `gorm:"column:target"`         // Login email or client ip
+	Failures     int        `gorm:"column:failures"`       // Consecutive failures
+	LastFailedAt *time.Time `gorm:"column:last_failed_at"` // Last failed time
+	LockedUntil  *time.Time `gorm:"column:locked_until"`   // Locked until, nil not locked
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *LoginFailures) TableName() string {
+	return "oak_login_failures"
+}
+
+// LoginFailureByTarget 没有失败记录时返回空的 ID
+func (m *LoginFailures) LoginFailureByTarget(failureType string, target string) (loginFailure LoginFailures, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("type = ? AND target = ?", failureType, target).
+		First(&loginFailure).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	return
+}
+
+// LoginFailureIncrease 失败次数加一，上次失败早于 resetBefore 时重新计数，返回更新后的记录
+func (m *LoginFailures) LoginFailureIncrease(failureType string, target string, resetBefore time.Time) (LoginFailures, error) {
+	now := time.Now()
+
+	loginFailure, err := m.LoginFailureByTarget(failureType, target)
+	if err != nil {
+		return LoginFailures{}, err
+	}
+
+	if loginFailure.ID == 0 {
+		err = packages.GetDb().
+			Table(m.TableName()).
+			Create(&LoginFailures{
+				Type:         failureType,
+				Target:       target,
+				Failures:     1,
+				LastFailedAt: &now,
+			}).Error
+	} else if (loginFailure.LastFailedAt == nil) || loginFailure.LastFailedAt.Before(resetBefore) {
+		err = packages.GetDb().
+			Table(m.TableName()).
+			Where("id = ?", loginFailure.ID).
+			Updates(map[string]interface{}{
+				"failures":       1,
+				"last_failed_at": now,
+				"locked_until":   nil,
+			}).Error
+	} else {
+		err = packages.GetDb().
+			Table(m.TableName()).
+			Where("id = ?", loginFailure.ID).
+			Updates(map[string]interface{}{
+				"failures":       gorm.Expr("failures + 1"),
+				"last_failed_at": now,
+			}).Error
+	}
+	if err != nil {
+		return LoginFailures{}, err
+	}
+
+	return m.LoginFailureByTarget(failureType, target)
+}
+
+func (m *LoginFailures) LoginFailureLock(id int, lockedUntil time.Time) error {
+	return packages.GetDb().
+		Table(m.TableName()).
+		Where("id = ?", id).
+		Update("locked_until", lockedUntil).Error
+}
+
+func (m *LoginFailures) LoginFailureDelete(failureType string, target string) (rowsAffected int64, err error) {
+	db := packages.GetDb().
+		Table(m.TableName()).
+		Where("type = ? AND target = ?", failureType, target).
+		Delete(&LoginFailures{})
+
+	return db.RowsAffected, db.Error
+}
+
+// LoginFailureLockedList 查询仍在锁定中的记录
+func (m *LoginFailures) LoginFailureLockedList(failureType string, targets []string) (list []LoginFailures, err error) {
+	list = make([]LoginFailures, 0)
+	if len(targets) == 0 {
+		return
+	}
+
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("type = ? AND target IN ? AND locked_until > ?", failureType, targets, time.Now()).
+		Find(&list).Error
+
+	return
+}
@@ -243,3 +243,15 @@ func (m *PluginConfigs) PluginConfigListByTargetResIds(configType int, targetRes
 
 	return
 }
+
+func (m *PluginConfigs) PluginConfigAllList(tx *gorm.DB) (list []PluginConfigs, err error) {
+	err = tx.Table(m.TableName()).
+		Order("id ASC").
+		Find(&list).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	return
+}
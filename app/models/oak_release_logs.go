@@ -0,0 +1,112 @@
+package models
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"errors"
+	"gorm.io/gorm"
+)
+
+type ReleaseLogs struct {
+	ID            int    `gorm:"column:id;primary_key"`  // primary key
+	ResID         string `gorm:"column:res_id"`          // Release log id
+	ResourceType  string `gorm:"column:resource_type"`   // Resource type  service  router  upstream
+	ResourceResID string `gorm:"column:resource_res_id"` // Resource id
+	Payload       string `gorm:"column:payload"`         // Configuration pushed to the data plane
+	Data          string `gorm:"column:data"`            // Control plane rows at release time
+	Operator      string `gorm:"column:operator"`        // Operator
+	RollbackResID string `gorm:"column:rollback_res_id"` // Rolled back release log id
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *ReleaseLogs) TableName() string {
+	return "oak_release_logs"
+}
+
+var recursionTimesReleaseLogs = 1
+
+func (m *ReleaseLogs) ModelUniqueId() (generateId string, err error) {
+	generateId, err = utils.IdGenerate(utils.IdTypeReleaseLog)
+	if err != nil {
+		return
+	}
+
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("res_id = ?", generateId).
+		Select("res_id").
+		First(m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+		recursionTimesReleaseLogs = 1
+		return
+	}
+
+	if err != nil {
+		return
+	}
+
+	if recursionTimesReleaseLogs == utils.IdGenerateMaxTimes {
+		recursionTimesReleaseLogs = 1
+		err = errors.New(enums.CodeMessages(enums.IdConflict))
+		return
+	}
+
+	recursionTimesReleaseLogs++
+	generateId, err = m.ModelUniqueId()
+
+	return
+}
+
+// ReleaseLogAdd 发布记录只追加不修改
+func (m *ReleaseLogs) ReleaseLogAdd(tx *gorm.DB, releaseLog *ReleaseLogs) (resId string, err error) {
+	resId, err = m.ModelUniqueId()
+	if err != nil {
+		return
+	}
+
+	releaseLog.ResID = resId
+	err = tx.Table(m.TableName()).Create(releaseLog).Error
+
+	return
+}
+
+func (m *ReleaseLogs) ReleaseLogListPage(resourceType string, resourceResId string, param *validators.ReleaseLogList) (list []ReleaseLogs, total int, err error) {
+	tx := packages.GetDb().
+		Table(m.TableName()).
+		Where("resource_type = ? AND resource_res_id = ?", resourceType, resourceResId)
+
+	err = ListCount(tx, &total)
+	if err != nil {
+		return
+	}
+
+	tx = tx.Order("id DESC")
+	err = ListPaginate(tx, &list, &param.BaseListPage)
+
+	return
+}
+
+func (m *ReleaseLogs) ReleaseLogDetail(resourceType string, resourceResId string, resId string) (info ReleaseLogs, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("resource_type = ? AND resource_res_id = ? AND res_id = ?", resourceType, resourceResId, resId).
+		First(&info).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.New(enums.CodeMessages(enums.ReleaseLogNull))
+	}
+
+	return
+}
+
+func (m *ReleaseLogs) ReleaseLogAllList(tx *gorm.DB) (list []ReleaseLogs, err error) {
+	err = tx.Table(m.TableName()).
+		Order("id ASC").
+		Find(&list).Error
+
+	return
+}
@@ -157,7 +157,7 @@ func (r *Routers) RouterInfosByServiceIdReleaseStatus(serviceId string, releaseS
 
 	db := packages.GetDb().
 		Table(r.TableName()).
-		Where("service_id = ?", serviceId)
+		Where("service_res_id = ?", serviceId)
 
 	if len(releaseStatus) != 0 {
 		db = db.Where("release IN ?", releaseStatus)
@@ -281,14 +281,14 @@ func (r *Routers) RouterListPage(serviceResId string, param *validators.Validato
 	return
 }
 
-func (r *Routers) RouterUpdateName(resId string, name string) error {
+func (r *Routers) RouterUpdateName(tx *gorm.DB, resId string, name string) error {
 	resId = strings.TrimSpace(resId)
 	name = strings.TrimSpace(name)
 	if (len(resId) == 0) || (len(name) == 0) {
 		return errors.New(enums.CodeMessages(enums.ServiceParamsNull))
 	}
 
-	updateErr := packages.GetDb().
+	updateErr := tx.
 		Table(r.TableName()).
 		Where("res_id = ?", resId).
 		Update("router_name", name).Error
@@ -300,7 +300,7 @@ func (r *Routers) RouterUpdateName(resId string, name string) error {
 	return nil
 }
 
-func (r *Routers) RouterSwitchEnable(id string, enable int) error {
+func (r *Routers) RouterSwitchEnable(tx *gorm.DB, id string, enable int) error {
 	id = strings.TrimSpace(id)
 	if len(id) == 0 {
 		return errors.New(enums.CodeMessages(enums.ServiceParamsNull))
@@ -316,7 +316,7 @@ func (r *Routers) RouterSwitchEnable(id string, enable int) error {
 		releaseStatus = utils.ReleaseStatusT
 	}
 
-	updateErr := packages.GetDb().
+	updateErr := tx.
 		Table(r.TableName()).
 		Where("res_id = ?", id).
 		Updates(Routers{
@@ -347,3 +347,15 @@ func (r *Routers) RouterSwitchRelease(resId string, releaseStatus int) error {
 
 	return nil
 }
+
+func (r *Routers) RouterAllList(tx *gorm.DB) (list []Routers, err error) {
+	err = tx.Table(r.TableName()).
+		Order("id ASC").
+		Find(&list).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	return
+}
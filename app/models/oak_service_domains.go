@@ -61,7 +61,7 @@ func (s *ServiceDomains) DomainInfosByDomain(domains []string, filterServiceIds
 		Where("domain IN ?", domains)
 
 	if len(filterServiceIds) != 0 {
-		db = db.Where("service_id NOT IN ?", filterServiceIds)
+		db = db.Where("service_res_id NOT IN ?", filterServiceIds)
 	}
 
 	err := db.Find(&domainInfos).Error
@@ -109,3 +109,15 @@ func (s *ServiceDomains) DomainListByLikeSni(sni string) []ServiceDomains {
 
 	return domainList
 }
+
+func (s *ServiceDomains) DomainAllList(tx *gorm.DB) (list []ServiceDomains, err error) {
+	err = tx.Table(s.TableName()).
+		Order("id ASC").
+		Find(&list).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	return
+}
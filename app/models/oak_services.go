@@ -287,3 +287,15 @@ func (s *Services) ServiceNameList() (list []ResIdNameItem, err error) {
 
 	return
 }
+
+func (s *Services) ServiceAllList(tx *gorm.DB) (list []Services, err error) {
+	err = tx.Table(s.TableName()).
+		Order("id ASC").
+		Find(&list).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	return
+}
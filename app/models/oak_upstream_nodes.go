@@ -105,3 +105,15 @@ func (m *UpstreamNodes) NodesListBySearch(searchContent string, ) (list []Upstre
 
 	return
 }
+
+func (m *UpstreamNodes) UpstreamNodeAllList(tx *gorm.DB) (list []UpstreamNodes, err error) {
+	err = tx.Table(m.TableName()).
+		Order("id ASC").
+		Find(&list).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	return
+}
@@ -14,10 +14,20 @@ type Upstreams struct {
 	ID             int    `gorm:"column:id;primary_key"`  // primary key
 	ResID          string `gorm:"column:res_id"`          // Upstream id
 	Name           string `gorm:"column:name"`            // Upstream name
-	Algorithm      int    `gorm:"column:algorithm"`       // Load balancing algorithm  1:round robin  2:chash
+	Algorithm      int    `gorm:"column:algorithm"`       // Load balancing algorithm  1:round robin  2:chash  3:least connections  4:ewma  5:random
+	HashOn         string `gorm:"column:hash_on"`         // CHash key source  ip  header  cookie  query  uri
+	HashKey        string `gorm:"column:hash_key"`        // CHash header, cookie or query argument name
 	ConnectTimeout int    `gorm:"column:connect_timeout"` // Connect timeout
 	WriteTimeout   int    `gorm:"column:write_timeout"`   // Write timeout
 	ReadTimeout    int    `gorm:"column:read_timeout"`    // Read timeout
+	CheckType      string `gorm:"column:check_type"`      // Health check type  http  tcp
+	CheckMethod    string `gorm:"column:check_method"`    // Health check request method
+	CheckHost      string `gorm:"column:check_host"`      // Health check request host
+	CheckUri       string `gorm:"column:check_uri"`       // Health check request uri
+	CheckInterval  int    `gorm:"column:check_interval"`  // Health check interval (seconds)
+	CheckTimeout   int    `gorm:"column:check_timeout"`   // Health check timeout (seconds)
+	CheckHealthy   int    `gorm:"column:check_healthy"`   // Successes before a node is healthy
+	CheckUnhealthy int    `gorm:"column:check_unhealthy"` // Failures before a node is unhealthy
 	Enable         int    `gorm:"column:enable"`          // Enable  1:on  2:off
 	Release        int    `gorm:"column:release"`         // Release status 1:unpublished  2:to be published  3:published
 	ModelTime
@@ -228,14 +238,14 @@ func (m Upstreams) UpstreamReleaseNameList() (list []ResIdNameItem, err error) {
 	return
 }
 
-func (m Upstreams) UpstreamUpdateName(resId string, name string) (err error) {
+func (m Upstreams) UpstreamUpdateName(tx *gorm.DB, resId string, name string) (err error) {
 	name = strings.TrimSpace(name)
 
 	if (len(resId) == 0) || (len(name) == 0) {
 		return errors.New(enums.CodeMessages(enums.ParamsError))
 	}
 
-	err = packages.GetDb().
+	err = tx.
 		Table(m.TableName()).
 		Where("res_id = ?", resId).
 		Update("name", name).Error
@@ -251,3 +261,15 @@ func (m Upstreams) UpstreamUpdateColumns(resId string, updateColumns map[string]
 }
 
 
+
+func (m Upstreams) UpstreamAllList(tx *gorm.DB) (list []Upstreams, err error) {
+	err = tx.Table(m.TableName()).
+		Order("id ASC").
+		Find(&list).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	return
+}
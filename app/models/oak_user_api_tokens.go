@@ -0,0 +1,128 @@
+package models
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+type UserApiTokens struct {
+	ID          int        `gorm:"column:id;primary_key"` // primary key
+	ResID       string     `gorm:"column:res_id"`         // Api token id
+	UserResID   string     `gorm:"column:user_res_id"`    // User id
+	Name        string     `gorm:"column:name"`           // Api token name
+	TokenPrefix string     `gorm:"column:token_prefix"`   // Token prefix for display
+	TokenHash   string     `gorm:"column:token_hash"`     // Token sha256
+	Scope       string     `gorm:"column:scope"`          // Scope  read  write
+	LastUsedAt  *time.Time `gorm:"column:last_used_at"`   // Last used time
+	ExpiredAt   *time.Time `gorm:"column:expired_at"`     // Expired time, nil never expires
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *UserApiTokens) TableName() string {
+	return "oak_user_api_tokens"
+}
+
+var recursionTimesUserApiTokens = 1
+
+func (m *UserApiTokens) ModelUniqueId() (generateId string, err error) {
+	generateId, err = utils.IdGenerate(utils.IdTypeUserApiToken)
+	if err != nil {
+		return
+	}
+
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("res_id = ?", generateId).
+		Select("res_id").
+		First(m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+		recursionTimesUserApiTokens = 1
+		return
+	}
+
+	if err != nil {
+		return
+	}
+
+	if recursionTimesUserApiTokens == utils.IdGenerateMaxTimes {
+		recursionTimesUserApiTokens = 1
+		err = errors.New(enums.CodeMessages(enums.IdConflict))
+		return
+	}
+
+	recursionTimesUserApiTokens++
+	generateId, err = m.ModelUniqueId()
+
+	return
+}
+
+// ApiTokenAdd 只保存 token 的 sha256 与前缀
+func (m *UserApiTokens) ApiTokenAdd(apiToken *UserApiTokens, token string) (resId string, err error) {
+	resId, err = m.ModelUniqueId()
+	if err != nil {
+		return
+	}
+
+	apiToken.ResID = resId
+	apiToken.TokenHash = utils.Sha256(token)
+	apiToken.TokenPrefix = token[:len(utils.ApiTokenPrefix)+4]
+
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Create(apiToken).Error
+
+	return
+}
+
+// ApiTokenByToken token 不存在时返回空的 ResID
+func (m *UserApiTokens) ApiTokenByToken(token string) (apiToken UserApiTokens, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("token_hash = ?", utils.Sha256(token)).
+		First(&apiToken).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	return
+}
+
+func (m *UserApiTokens) ApiTokenListByUserResId(userResId string) (list []UserApiTokens, err error) {
+	list = make([]UserApiTokens, 0)
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("user_res_id = ?", userResId).
+		Order("id DESC").
+		Find(&list).Error
+
+	return
+}
+
+func (m *UserApiTokens) ApiTokenUpdateLastUsed(resId string) error {
+	return packages.GetDb().
+		Table(m.TableName()).
+		Where("res_id = ?", resId).
+		Update("last_used_at", time.Now()).Error
+}
+
+func (m *UserApiTokens) ApiTokenDelete(userResId string, resId string) (rowsAffected int64, err error) {
+	db := packages.GetDb().
+		Table(m.TableName()).
+		Where("user_res_id = ? AND res_id = ?", userResId, resId).
+		Delete(&UserApiTokens{})
+
+	return db.RowsAffected, db.Error
+}
+
+func (m *UserApiTokens) ApiTokenDeleteByUserResId(tx *gorm.DB, userResId string) error {
+	return tx.Table(m.TableName()).
+		Where("user_res_id = ?", userResId).
+		Delete(&UserApiTokens{}).Error
+}
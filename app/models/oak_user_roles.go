@@ -0,0 +1,133 @@
+package models
+
+import (
+	"apioak-admin/app/packages"
+	"errors"
+	"gorm.io/gorm"
+)
+
+type UserRoles struct {
+	ID        int    `gorm:"column:id;primary_key"` // primary key
+	UserResID string `gorm:"column:user_res_id"`    // User id
+	Role      string `gorm:"column:role"`           // Role
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (u *UserRoles) TableName() string {
+	return "oak_user_roles"
+}
+
+type UserServicePermissions struct {
+	ID           int    `gorm:"column:id;primary_key"` // primary key
+	UserResID    string `gorm:"column:user_res_id"`    // User id
+	ServiceResID string `gorm:"column:service_res_id"` // Service id
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (u *UserServicePermissions) TableName() string {
+	return "oak_user_service_permissions"
+}
+
+// UserRoleByUserResId 用户未分配角色时返回空的 Role
+func (u *UserRoles) UserRoleByUserResId(userResId string) (userRole UserRoles, err error) {
+	err = packages.GetDb().
+		Table(u.TableName()).
+		Where("user_res_id = ?", userResId).
+		First(&userRole).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	return
+}
+
+func (u *UserRoles) UserRoleListByUserResIds(userResIds []string) (list []UserRoles, err error) {
+	list = make([]UserRoles, 0)
+	if len(userResIds) == 0 {
+		return
+	}
+
+	err = packages.GetDb().
+		Table(u.TableName()).
+		Where("user_res_id IN ?", userResIds).
+		Find(&list).Error
+
+	return
+}
+
+func (u *UserRoles) UserRoleCount(role string) (count int64, err error) {
+	err = packages.GetDb().
+		Table(u.TableName()).
+		Where("role = ?", role).
+		Count(&count).Error
+
+	return
+}
+
+// UserRoleSave 保存用户角色，并以 serviceResIds 覆盖用户的服务级权限
+func (u *UserRoles) UserRoleSave(tx *gorm.DB, userResId string, role string, serviceResIds []string) error {
+	userRole, err := u.UserRoleByUserResId(userResId)
+	if err != nil {
+		return err
+	}
+
+	if userRole.ID == 0 {
+		err = tx.Table(u.TableName()).Create(&UserRoles{
+			UserResID: userResId,
+			Role:      role,
+		}).Error
+	} else {
+		err = tx.Table(u.TableName()).
+			Where("user_res_id = ?", userResId).
+			Update("role", role).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	permissionModel := UserServicePermissions{}
+	err = tx.Table(permissionModel.TableName()).
+		Where("user_res_id = ?", userResId).
+		Delete(&UserServicePermissions{}).Error
+	if err != nil {
+		return err
+	}
+
+	for _, serviceResId := range serviceResIds {
+		err = tx.Table(permissionModel.TableName()).Create(&UserServicePermissions{
+			UserResID:    userResId,
+			ServiceResID: serviceResId,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *UserRoles) UserRoleDelete(tx *gorm.DB, userResId string) error {
+	err := tx.Table(u.TableName()).
+		Where("user_res_id = ?", userResId).
+		Delete(&UserRoles{}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Table((&UserServicePermissions{}).TableName()).
+		Where("user_res_id = ?", userResId).
+		Delete(&UserServicePermissions{}).Error
+}
+
+func (u *UserServicePermissions) ServiceResIdsByUserResId(userResId string) (serviceResIds []string, err error) {
+	serviceResIds = make([]string, 0)
+	err = packages.GetDb().
+		Table(u.TableName()).
+		Where("user_res_id = ?", userResId).
+		Pluck("service_res_id", &serviceResIds).Error
+
+	return
+}
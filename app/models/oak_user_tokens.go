@@ -10,11 +10,16 @@ import (
 )
 
 type UserTokens struct {
-	ID        int       `gorm:"column:id;primary_key"` //primary key
-	ResID     string    `gorm:"column:res_id"`         //User tokenID
-	Token     string    `gorm:"column:token"`          //Token
-	UserEmail string    `gorm:"column:user_email"`     //Email
-	ExpiredAt time.Time `gorm:"column:expired_at"`     //Expired time
+	ID         int        `gorm:"column:id;primary_key"` //primary key
+	ResID      string     `gorm:"column:res_id"`         //User tokenID
+	Name       string     `gorm:"column:name"`           //Session name
+	Token      string     `gorm:"column:token"`          //Token
+	TokenHash  string     `gorm:"column:token_hash"`     //Token sha256
+	UserEmail  string     `gorm:"column:user_email"`     //Email
+	UserAgent  string     `gorm:"column:user_agent"`     //User agent
+	ClientIp   string     `gorm:"column:client_ip"`      //Client ip
+	LastSeenAt *time.Time `gorm:"column:last_seen_at"`   //Last seen time
+	ExpiredAt  time.Time  `gorm:"column:expired_at"`     //Expired time
 	ModelTime
 }
 
@@ -60,50 +65,72 @@ func (m *UserTokens) ModelUniqueId() (generateId string, err error) {
 	}
 }
 
-func (u *UserTokens) SetTokenExpire(email string, token string, expiredTime time.Time) error {
-	packages.GetDb().
-		Table(u.TableName()).
-		Where("user_email = ?", email).
-		First(u)
-
-	if len(u.UserEmail) != 0 && u.UserEmail == email {
-		updateData := UserTokens{}
-		updateData.Token = token
-		updateData.ExpiredAt = expiredTime
+// SessionAdd 新增登录会话，只保存 token 的 sha256，同时清理该用户已过期的会话
+func (u *UserTokens) SessionAdd(session *UserTokens, token string) error {
+	sessionId, err := u.ModelUniqueId()
+	if err != nil {
+		return err
+	}
 
-		packages.GetDb().
-			Table(u.TableName()).
-			Where("user_email = ?", email).
-			Updates(updateData)
+	now := time.Now()
+	session.ResID = sessionId
+	session.TokenHash = utils.Sha256(token)
+	session.LastSeenAt = &now
 
-	} else {
-		userTokenId, userIdUniqueErr := u.ModelUniqueId()
-		if userIdUniqueErr != nil {
-			return userIdUniqueErr
+	return packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		err := tx.Table(u.TableName()).
+			Where("user_email = ? AND expired_at < ?", session.UserEmail, now).
+			Delete(&UserTokens{}).Error
+		if err != nil {
+			return err
 		}
-		updateData := &UserTokens{}
-		updateData.ResID = userTokenId
-		updateData.UserEmail = email
-		updateData.Token = token
-		updateData.ExpiredAt = expiredTime
-
-		packages.GetDb().
-			Table(u.TableName()).
-			Create(updateData)
+
+		return tx.Table(u.TableName()).Create(session).Error
+	})
+}
+
+// SessionByToken 会话不存在时返回空的 ResID
+func (u *UserTokens) SessionByToken(token string) (session UserTokens, err error) {
+	err = packages.GetDb().
+		Table(u.TableName()).
+		Where("token_hash = ?", utils.Sha256(token)).
+		First(&session).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
 	}
 
-	return nil
+	return
 }
 
-func (u *UserTokens) GetTokenExpireByEmail(email string) *UserTokens {
-	userTokenExpireInfo := UserTokens{}
+func (u *UserTokens) SessionRefresh(resId string, expiredTime time.Time) error {
+	return packages.GetDb().
+		Table(u.TableName()).
+		Where("res_id = ?", resId).
+		Updates(map[string]interface{}{
+			"expired_at":   expiredTime,
+			"last_seen_at": time.Now(),
+		}).Error
+}
 
-	packages.GetDb().
+func (u *UserTokens) SessionListByEmail(email string) (list []UserTokens, err error) {
+	list = make([]UserTokens, 0)
+	err = packages.GetDb().
 		Table(u.TableName()).
-		Where("user_email = ?", email).
-		First(&userTokenExpireInfo)
+		Where("user_email = ? AND expired_at >= ?", email, time.Now()).
+		Order("id DESC").
+		Find(&list).Error
+
+	return
+}
+
+func (u *UserTokens) SessionDelete(email string, resId string) (rowsAffected int64, err error) {
+	db := packages.GetDb().
+		Table(u.TableName()).
+		Where("user_email = ? AND res_id = ?", email, resId).
+		Delete(&UserTokens{})
 
-	return &userTokenExpireInfo
+	return db.RowsAffected, db.Error
 }
 
 func (u *UserTokens) DelTokenExpireByEmail(email string) error {
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+type UserTotps struct {
+	ID        int    `gorm:"column:id;primary_key"` // primary key
+	UserResID string `gorm:"column:user_res_id"`    // User id
+	Secret    string `gorm:"column:secret"`         // TOTP secret, base32
+	Enable    int    `gorm:"column:enable"`         // TOTP enable  1:on  2:off
+	LastStep  int64  `gorm:"column:last_step"`      // Time step of the last accepted code
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *UserTotps) TableName() string {
+	return "oak_user_totps"
+}
+
+type UserRecoveryCodes struct {
+	ID        int        `gorm:"column:id;primary_key"` // primary key
+	UserResID string     `gorm:"column:user_res_id"`    // User id
+	CodeHash  string     `gorm:"column:code_hash"`      // Recovery code sha256
+	UsedAt    *time.Time `gorm:"column:used_at"`        // Used time, nil not used
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *UserRecoveryCodes) TableName() string {
+	return "oak_user_recovery_codes"
+}
+
+// TotpByUserResId 用户未绑定时返回空的 ID
+func (m *UserTotps) TotpByUserResId(userResId string) (totp UserTotps, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("user_res_id = ?", userResId).
+		First(&totp).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	return
+}
+
+// TotpSave 保存新的密钥，验证首个验证码之前为未开启状态
+func (m *UserTotps) TotpSave(userResId string, secret string) error {
+	totp, err := m.TotpByUserResId(userResId)
+	if err != nil {
+		return err
+	}
+
+	if totp.ID == 0 {
+		return packages.GetDb().
+			Table(m.TableName()).
+			Create(&UserTotps{
+				UserResID: userResId,
+				Secret:    secret,
+				Enable:    utils.EnableOff,
+			}).Error
+	}
+
+	return packages.GetDb().
+		Table(m.TableName()).
+		Where("user_res_id = ?", userResId).
+		Updates(map[string]interface{}{
+			"secret":    secret,
+			"enable":    utils.EnableOff,
+			"last_step": 0,
+		}).Error
+}
+
+func (m *UserTotps) TotpEnable(tx *gorm.DB, userResId string, step int64) error {
+	return tx.Table(m.TableName()).
+		Where("user_res_id = ?", userResId).
+		Updates(map[string]interface{}{
+			"enable":    utils.EnableOn,
+			"last_step": step,
+		}).Error
+}
+
+// TotpUpdateLastStep 只在时间步长增大时更新，并发请求中同一验证码只有一个能生效
+func (m *UserTotps) TotpUpdateLastStep(userResId string, step int64) (rowsAffected int64, err error) {
+	db := packages.GetDb().
+		Table(m.TableName()).
+		Where("user_res_id = ? AND last_step < ?", userResId, step).
+		Update("last_step", step)
+
+	return db.RowsAffected, db.Error
+}
+
+// TotpDelete 同时删除用户的恢复码
+func (m *UserTotps) TotpDelete(tx *gorm.DB, userResId string) error {
+	err := tx.Table(m.TableName()).
+		Where("user_res_id = ?", userResId).
+		Delete(&UserTotps{}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Table((&UserRecoveryCodes{}).TableName()).
+		Where("user_res_id = ?", userResId).
+		Delete(&UserRecoveryCodes{}).Error
+}
+
+// RecoveryCodeSave 以新的恢复码覆盖用户原有的恢复码
+func (m *UserRecoveryCodes) RecoveryCodeSave(tx *gorm.DB, userResId string, codes []string) error {
+	err := tx.Table(m.TableName()).
+		Where("user_res_id = ?", userResId).
+		Delete(&UserRecoveryCodes{}).Error
+	if err != nil {
+		return err
+	}
+
+	for _, code := range codes {
+		err = tx.Table(m.TableName()).Create(&UserRecoveryCodes{
+			UserResID: userResId,
+			CodeHash:  utils.Sha256(code),
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecoveryCodeUse 将未使用的恢复码标记为已使用，恢复码不存在或已使用时 rowsAffected 为 0
+func (m *UserRecoveryCodes) RecoveryCodeUse(userResId string, code string) (rowsAffected int64, err error) {
+	db := packages.GetDb().
+		Table(m.TableName()).
+		Where("user_res_id = ? AND code_hash = ? AND used_at IS NULL", userResId, utils.Sha256(code)).
+		Update("used_at", time.Now())
+
+	return db.RowsAffected, db.Error
+}
+
+func (m *UserRecoveryCodes) RecoveryCodeUnusedCount(userResId string) (count int64, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("user_res_id = ? AND used_at IS NULL", userResId).
+		Count(&count).Error
+
+	return
+}
@@ -4,16 +4,21 @@ import (
 	"apioak-admin/app/enums"
 	"apioak-admin/app/packages"
 	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
 	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
 )
 
 type Users struct {
-	ID       int    `gorm:"column:id;primary_key"` // primary key
-	ResID    string `gorm:"column:res_id"`         // User iD
-	Name     string `gorm:"column:name"`           // User name
-	Password string `gorm:"column:password"`       // Password
-	Email    string `gorm:"column:email"`          // Email
+	ID                int    `gorm:"column:id;primary_key"`     // primary key
+	ResID             string `gorm:"column:res_id"`             // User iD
+	Name              string `gorm:"column:name"`               // User name
+	Password          string `gorm:"column:password"`           // Password
+	PasswordAlgorithm string `gorm:"column:password_algorithm"` // Password hash algorithm
+	Email             string `gorm:"column:email"`              // Email
+	Enable            int    `gorm:"column:enable"`             // User enable  1:on  2:off
 	ModelTime
 }
 
@@ -74,15 +79,23 @@ func (u *Users) UserInfosByEmailFilterIds(email string, filterIds []string) []Us
 	return userInfos
 }
 
-func (u *Users) UserAdd(userData *Users) error {
+func (u *Users) UserAdd(tx *gorm.DB, userData *Users) error {
 	userId, userIdUniqueErr := u.ModelUniqueId()
 	if userIdUniqueErr != nil {
 		return userIdUniqueErr
 	}
 	userData.ResID = userId
-	userData.Password = utils.Md5(utils.Md5(userData.Password))
+	passwordHash, passwordHashErr := utils.PasswordHash(userData.Password)
+	if passwordHashErr != nil {
+		return passwordHashErr
+	}
+	userData.Password = passwordHash
+	userData.PasswordAlgorithm = utils.PasswordAlgorithmBcrypt
+	if userData.Enable == 0 {
+		userData.Enable = utils.EnableOn
+	}
 
-	err := packages.GetDb().
+	err := tx.
 		Table(u.TableName()).
 		Create(userData).Error
 
@@ -98,3 +111,84 @@ func (u *Users) UserInfoByEmail(email string) Users {
 
 	return userInfo
 }
+
+func (u *Users) UserInfoByResId(resId string) (userInfo Users, err error) {
+	err = packages.GetDb().
+		Table(u.TableName()).
+		Where("res_id = ?", resId).
+		First(&userInfo).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.New(enums.CodeMessages(enums.UserNull))
+	}
+
+	return
+}
+
+func (u *Users) UserCount() (count int64, err error) {
+	err = packages.GetDb().
+		Table(u.TableName()).
+		Count(&count).Error
+
+	return
+}
+
+// UserCountLock 在事务中加锁统计用户数，并发添加用户时只有一个事务能基于同一结果写入
+func (u *Users) UserCountLock(tx *gorm.DB) (count int64, err error) {
+	err = tx.
+		Table(u.TableName()).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Count(&count).Error
+
+	return
+}
+
+func (u *Users) UserListPage(param *validators.UserList) (list []Users, total int, err error) {
+	tx := packages.GetDb().
+		Table(u.TableName())
+
+	if param.Enable != 0 {
+		tx = tx.Where("enable = ?", param.Enable)
+	}
+
+	param.Search = strings.TrimSpace(param.Search)
+	if len(param.Search) != 0 {
+		search := "%" + param.Search + "%"
+		tx = tx.Where("name LIKE ? OR email LIKE ? OR res_id LIKE ?", search, search, search)
+	}
+
+	err = ListCount(tx, &total)
+	if err != nil {
+		return
+	}
+
+	tx = tx.Order("id DESC")
+	err = ListPaginate(tx, &list, &param.BaseListPage)
+
+	return
+}
+
+func (u *Users) UserUpdateColumns(resId string, params map[string]interface{}) error {
+	return packages.GetDb().
+		Table(u.TableName()).
+		Where("res_id = ?", resId).
+		Updates(params).Error
+}
+
+func (u *Users) UserDelete(tx *gorm.DB, resId string) error {
+	return tx.Table(u.TableName()).
+		Where("res_id = ?", resId).
+		Delete(&Users{}).Error
+}
+
+func (u *Users) UserUpdatePassword(resId string, password string) error {
+	passwordHash, err := utils.PasswordHash(password)
+	if err != nil {
+		return err
+	}
+
+	return u.UserUpdateColumns(resId, map[string]interface{}{
+		"password":           passwordHash,
+		"password_algorithm": utils.PasswordAlgorithmBcrypt,
+	})
+}
@@ -0,0 +1,280 @@
+package models
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"errors"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+type Webhooks struct {
+	ID     int    `gorm:"column:id;primary_key"` // primary key
+	ResID  string `gorm:"column:res_id"`         // Webhook id
+	Name   string `gorm:"column:name"`           // Webhook name
+	Url    string `gorm:"column:url"`            // Endpoint url
+	Secret string `gorm:"column:secret"`         // HMAC secret
+	Events string `gorm:"column:events"`         // Subscribed events, comma separated, * for all
+	Enable int    `gorm:"column:enable"`         // Webhook enable  1:on  2:off
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *Webhooks) TableName() string {
+	return "oak_webhooks"
+}
+
+type WebhookDeliveries struct {
+	ID              int        `gorm:"column:id;primary_key"`   // primary key
+	ResID           string     `gorm:"column:res_id"`           // Delivery id
+	WebhookResID    string     `gorm:"column:webhook_res_id"`   // Webhook id
+	Event           string     `gorm:"column:event"`            // Event
+	Payload         string     `gorm:"column:payload"`          // Request body
+	Status          int        `gorm:"column:status"`           // Status  1:pending  2:success  3:failed
+	Attempts        int        `gorm:"column:attempts"`         // Attempts
+	NextAttemptAt   *time.Time `gorm:"column:next_attempt_at"`  // Next attempt time
+	ResponseCode    int        `gorm:"column:response_code"`    // Last response status code
+	ResponseMessage string     `gorm:"column:response_message"` // Last response body or error
+	DeliveredAt     *time.Time `gorm:"column:delivered_at"`     // Delivered time
+	ModelTime
+}
+
+// TableName sets the insert table name for this struct type
+func (m *WebhookDeliveries) TableName() string {
+	return "oak_webhook_deliveries"
+}
+
+var recursionTimesWebhooks = 1
+
+func (m *Webhooks) ModelUniqueId() (generateId string, err error) {
+	generateId, err = utils.IdGenerate(utils.IdTypeWebhook)
+	if err != nil {
+		return
+	}
+
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("res_id = ?", generateId).
+		Select("res_id").
+		First(m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+		recursionTimesWebhooks = 1
+		return
+	}
+
+	if err != nil {
+		return
+	}
+
+	if recursionTimesWebhooks == utils.IdGenerateMaxTimes {
+		recursionTimesWebhooks = 1
+		err = errors.New(enums.CodeMessages(enums.IdConflict))
+		return
+	}
+
+	recursionTimesWebhooks++
+	generateId, err = m.ModelUniqueId()
+
+	return
+}
+
+var recursionTimesWebhookDeliveries = 1
+
+func (m *WebhookDeliveries) ModelUniqueId() (generateId string, err error) {
+	generateId, err = utils.IdGenerate(utils.IdTypeWebhookDelivery)
+	if err != nil {
+		return
+	}
+
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("res_id = ?", generateId).
+		Select("res_id").
+		First(m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+		recursionTimesWebhookDeliveries = 1
+		return
+	}
+
+	if err != nil {
+		return
+	}
+
+	if recursionTimesWebhookDeliveries == utils.IdGenerateMaxTimes {
+		recursionTimesWebhookDeliveries = 1
+		err = errors.New(enums.CodeMessages(enums.IdConflict))
+		return
+	}
+
+	recursionTimesWebhookDeliveries++
+	generateId, err = m.ModelUniqueId()
+
+	return
+}
+
+func (m *Webhooks) WebhookAdd(webhook *Webhooks) (resId string, err error) {
+	resId, err = m.ModelUniqueId()
+	if err != nil {
+		return
+	}
+
+	webhook.ResID = resId
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Create(webhook).Error
+
+	return
+}
+
+func (m *Webhooks) WebhookUpdateColumns(resId string, params map[string]interface{}) error {
+	return packages.GetDb().
+		Table(m.TableName()).
+		Where("res_id = ?", resId).
+		Updates(params).Error
+}
+
+func (m *Webhooks) WebhookInfoByResId(resId string) (webhook Webhooks, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("res_id = ?", resId).
+		First(&webhook).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.New(enums.CodeMessages(enums.WebhookNull))
+	}
+
+	return
+}
+
+func (m *Webhooks) WebhookListPage(param *validators.WebhookList) (list []Webhooks, total int, err error) {
+	tx := packages.GetDb().
+		Table(m.TableName())
+
+	if param.Enable != 0 {
+		tx = tx.Where("enable = ?", param.Enable)
+	}
+
+	param.Search = strings.TrimSpace(param.Search)
+	if len(param.Search) != 0 {
+		search := "%" + param.Search + "%"
+		tx = tx.Where("name LIKE ? OR url LIKE ? OR res_id LIKE ?", search, search, search)
+	}
+
+	err = ListCount(tx, &total)
+	if err != nil {
+		return
+	}
+
+	tx = tx.Order("id DESC")
+	err = ListPaginate(tx, &list, &param.BaseListPage)
+
+	return
+}
+
+func (m *Webhooks) WebhookEnableList(tx *gorm.DB) (list []Webhooks, err error) {
+	err = tx.Table(m.TableName()).
+		Where("enable = ?", utils.EnableOn).
+		Find(&list).Error
+
+	return
+}
+
+// WebhookDelete 同时删除投递记录
+func (m *Webhooks) WebhookDelete(resId string) error {
+	return packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		err := tx.Table(m.TableName()).
+			Where("res_id = ?", resId).
+			Delete(&Webhooks{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Table((&WebhookDeliveries{}).TableName()).
+			Where("webhook_res_id = ?", resId).
+			Delete(&WebhookDeliveries{}).Error
+	})
+}
+
+// WebhookDeliveryAdd 与资源的修改在同一事务中写入，事务回滚时不会投递
+func (m *WebhookDeliveries) WebhookDeliveryAdd(tx *gorm.DB, delivery *WebhookDeliveries) (resId string, err error) {
+	resId, err = m.ModelUniqueId()
+	if err != nil {
+		return
+	}
+
+	delivery.ResID = resId
+	err = tx.Table(m.TableName()).
+		Create(delivery).Error
+
+	return
+}
+
+func (m *WebhookDeliveries) WebhookDeliveryInfoByResId(resId string) (delivery WebhookDeliveries, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("res_id = ?", resId).
+		First(&delivery).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errors.New(enums.CodeMessages(enums.WebhookDeliveryNull))
+	}
+
+	return
+}
+
+// WebhookDeliveryDueList 查询到达重试时间的待投递记录
+func (m *WebhookDeliveries) WebhookDeliveryDueList(limit int) (list []WebhookDeliveries, err error) {
+	err = packages.GetDb().
+		Table(m.TableName()).
+		Where("status = ? AND next_attempt_at <= ?", utils.WebhookDeliveryStatusPending, time.Now()).
+		Order("id ASC").
+		Limit(limit).
+		Find(&list).Error
+
+	return
+}
+
+// WebhookDeliveryClaim 推迟下次投递时间以占用该记录，多个实例同时投递时只有一个能占用成功
+func (m *WebhookDeliveries) WebhookDeliveryClaim(id int, nextAttemptAt time.Time, claimUntil time.Time) (rowsAffected int64, err error) {
+	db := packages.GetDb().
+		Table(m.TableName()).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", id, utils.WebhookDeliveryStatusPending, nextAttemptAt).
+		Update("next_attempt_at", claimUntil)
+
+	return db.RowsAffected, db.Error
+}
+
+func (m *WebhookDeliveries) WebhookDeliveryUpdateColumns(id int, params map[string]interface{}) error {
+	return packages.GetDb().
+		Table(m.TableName()).
+		Where("id = ?", id).
+		Updates(params).Error
+}
+
+func (m *WebhookDeliveries) WebhookDeliveryListPage(webhookResId string, param *validators.WebhookDeliveryList) (list []WebhookDeliveries, total int, err error) {
+	tx := packages.GetDb().
+		Table(m.TableName()).
+		Where("webhook_res_id = ?", webhookResId)
+
+	if param.Status != 0 {
+		tx = tx.Where("status = ?", param.Status)
+	}
+	if len(param.Event) != 0 {
+		tx = tx.Where("event = ?", param.Event)
+	}
+
+	err = ListCount(tx, &total)
+	if err != nil {
+		return
+	}
+
+	tx = tx.Order("id DESC")
+	err = ListPaginate(tx, &list, &param.BaseListPage)
+
+	return
+}
@@ -21,3 +21,250 @@ func SetConfigApiOak(protocol string, ip string, port int, domain string, secret
 
 	ConfigApiOak = apiOak
 }
+
+type configDrift struct {
+	Interval int
+	AutoHeal bool
+}
+
+var ConfigDrift configDrift
+
+func SetConfigDrift(interval int, autoHeal bool) {
+	ConfigDrift = configDrift{
+		Interval: interval,
+		AutoHeal: autoHeal,
+	}
+}
+
+type configUser struct {
+	Register      string
+	LoginProvider string
+	TotpRequired  bool
+}
+
+var ConfigUser configUser
+
+func SetConfigUser(register string, loginProvider string, totpRequired bool) {
+	ConfigUser = configUser{
+		Register:      register,
+		LoginProvider: loginProvider,
+		TotpRequired:  totpRequired,
+	}
+}
+
+type configLoginLockout struct {
+	MaxFailures   int
+	IpMaxFailures int
+	Duration      int
+	MaxDuration   int
+	ResetInterval int
+}
+
+var ConfigLoginLockout configLoginLockout
+
+func SetConfigLoginLockout(maxFailures int, ipMaxFailures int, duration int, maxDuration int, resetInterval int) {
+	ConfigLoginLockout = configLoginLockout{
+		MaxFailures:   maxFailures,
+		IpMaxFailures: ipMaxFailures,
+		Duration:      duration,
+		MaxDuration:   maxDuration,
+		ResetInterval: resetInterval,
+	}
+}
+
+type configAudit struct {
+	File string
+}
+
+var ConfigAudit configAudit
+
+func SetConfigAudit(file string) {
+	ConfigAudit = configAudit{
+		File: file,
+	}
+}
+
+type configCertificate struct {
+	CaFile string
+}
+
+var ConfigCertificate configCertificate
+
+func SetConfigCertificate(caFile string) {
+	ConfigCertificate = configCertificate{
+		CaFile: caFile,
+	}
+}
+
+type configEncryption struct {
+	Keys           map[int][]byte
+	CurrentVersion int
+}
+
+var ConfigEncryption configEncryption
+
+func SetConfigEncryption(keys map[int][]byte, currentVersion int) {
+	ConfigEncryption = configEncryption{
+		Keys:           keys,
+		CurrentVersion: currentVersion,
+	}
+}
+
+type configWebhook struct {
+	MaxAttempts   int
+	Timeout       int
+	RetryInterval int
+}
+
+var ConfigWebhook configWebhook
+
+func SetConfigWebhook(maxAttempts int, timeout int, retryInterval int) {
+	ConfigWebhook = configWebhook{
+		MaxAttempts:   maxAttempts,
+		Timeout:       timeout,
+		RetryInterval: retryInterval,
+	}
+}
+
+type configCertificateExpiry struct {
+	Interval   int
+	Thresholds []int
+	Notifiers  []string
+	EmailTo    []string
+}
+
+var ConfigCertificateExpiry configCertificateExpiry
+
+func SetConfigCertificateExpiry(interval int, thresholds []int, notifiers []string, emailTo []string) {
+	ConfigCertificateExpiry = configCertificateExpiry{
+		Interval:   interval,
+		Thresholds: thresholds,
+		Notifiers:  notifiers,
+		EmailTo:    emailTo,
+	}
+}
+
+type configSmtp struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+var ConfigSmtp configSmtp
+
+func SetConfigSmtp(host string, port int, username string, password string, from string) {
+	ConfigSmtp = configSmtp{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		From:     from,
+	}
+}
+
+type configAcme struct {
+	Enable             bool
+	DirectoryUrl       string
+	Email              string
+	CaFile             string
+	InsecureSkipVerify bool
+	RenewBefore        int
+	CheckInterval      int
+	RetryInterval      int
+	Timeout            int
+	Http01Listen       string
+	DnsProvider        string
+	DnsExec            string
+	DnsPropagationWait int
+}
+
+var ConfigAcme configAcme
+
+func SetConfigAcme(enable bool, directoryUrl string, email string, caFile string, insecureSkipVerify bool,
+	renewBefore int, checkInterval int, retryInterval int, timeout int, http01Listen string, dnsProvider string,
+	dnsExec string, dnsPropagationWait int) {
+	ConfigAcme = configAcme{
+		Enable:             enable,
+		DirectoryUrl:       directoryUrl,
+		Email:              email,
+		CaFile:             caFile,
+		InsecureSkipVerify: insecureSkipVerify,
+		RenewBefore:        renewBefore,
+		CheckInterval:      checkInterval,
+		RetryInterval:      retryInterval,
+		Timeout:            timeout,
+		Http01Listen:       http01Listen,
+		DnsProvider:        dnsProvider,
+		DnsExec:            dnsExec,
+		DnsPropagationWait: dnsPropagationWait,
+	}
+}
+
+type configOidc struct {
+	Enable         bool
+	Issuer         string
+	ClientId       string
+	ClientSecret   string
+	RedirectUrl    string
+	Scopes         []string
+	GroupsClaim    string
+	AdminGroups    []string
+	OperatorGroups []string
+	LoginRedirect  string
+}
+
+var ConfigOidc configOidc
+
+func SetConfigOidc(enable bool, issuer string, clientId string, clientSecret string, redirectUrl string,
+	scopes []string, groupsClaim string, adminGroups []string, operatorGroups []string, loginRedirect string) {
+	ConfigOidc = configOidc{
+		Enable:         enable,
+		Issuer:         issuer,
+		ClientId:       clientId,
+		ClientSecret:   clientSecret,
+		RedirectUrl:    redirectUrl,
+		Scopes:         scopes,
+		GroupsClaim:    groupsClaim,
+		AdminGroups:    adminGroups,
+		OperatorGroups: operatorGroups,
+		LoginRedirect:  loginRedirect,
+	}
+}
+
+type configLdap struct {
+	Url                string
+	StartTls           bool
+	InsecureSkipVerify bool
+	BindDn             string
+	BindPassword       string
+	BaseDn             string
+	UserFilter         string
+	EmailAttribute     string
+	NameAttribute      string
+	GroupAttribute     string
+	AdminGroups        []string
+	OperatorGroups     []string
+}
+
+var ConfigLdap configLdap
+
+func SetConfigLdap(url string, startTls bool, insecureSkipVerify bool, bindDn string, bindPassword string,
+	baseDn string, userFilter string, emailAttribute string, nameAttribute string, groupAttribute string,
+	adminGroups []string, operatorGroups []string) {
+	ConfigLdap = configLdap{
+		Url:                url,
+		StartTls:           startTls,
+		InsecureSkipVerify: insecureSkipVerify,
+		BindDn:             bindDn,
+		BindPassword:       bindPassword,
+		BaseDn:             baseDn,
+		UserFilter:         userFilter,
+		EmailAttribute:     emailAttribute,
+		NameAttribute:      nameAttribute,
+		GroupAttribute:     groupAttribute,
+		AdminGroups:        adminGroups,
+		OperatorGroups:     operatorGroups,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	"strings"
@@ -38,29 +39,40 @@ func Translate(errs validator.ValidationErrors) string {
 
 func ParseRequestParams(c *gin.Context, request interface{}) (string, error) {
 	if err := c.ShouldBind(request); err != nil {
+		return requestParamsErrMessage(err), err
+	}
+	return "", nil
+}
 
-		var errStr string
-		switch err.(type) {
-		case validator.ValidationErrors:
-			errStr = Translate(err.(validator.ValidationErrors))
-		case *json.UnmarshalTypeError:
-			unmarshalTypeError := err.(*json.UnmarshalTypeError)
-			errStr = fmt.Errorf("[%s]类型错误，期望类型:%s", unmarshalTypeError.Field, unmarshalTypeError.Type.String()).Error()
-		default:
-			errStr = err.Error()
-		}
+// ValidateRequestParams 校验非 HTTP 请求（如命令行读取的配置文件）构造的参数，校验规则与 ParseRequestParams 一致
+func ValidateRequestParams(request interface{}) (string, error) {
+	if err := binding.Validator.ValidateStruct(request); err != nil {
+		return requestParamsErrMessage(err), err
+	}
+	return "", nil
+}
+
+func requestParamsErrMessage(err error) string {
+	var errStr string
+	switch err.(type) {
+	case validator.ValidationErrors:
+		errStr = Translate(err.(validator.ValidationErrors))
+	case *json.UnmarshalTypeError:
+		unmarshalTypeError := err.(*json.UnmarshalTypeError)
+		errStr = fmt.Errorf("[%s]类型错误，期望类型:%s", unmarshalTypeError.Field, unmarshalTypeError.Type.String()).Error()
+	default:
+		errStr = err.Error()
+	}
 
-		if len(allRegisterValidatorErrMessages) > 0 {
-			for funcName, errorMessage := range allRegisterValidatorErrMessages{
-				if strings.Contains(errStr, funcName) {
-					errStr = errorMessage
-				}
+	if len(allRegisterValidatorErrMessages) > 0 {
+		for funcName, errorMessage := range allRegisterValidatorErrMessages{
+			if strings.Contains(errStr, funcName) {
+				errStr = errorMessage
 			}
 		}
-
-		return errStr, err
 	}
-	return "", nil
+
+	return errStr
 }
 
 func SetCustomizeValidator(validator *validator.Validate) {
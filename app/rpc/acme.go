@@ -0,0 +1,210 @@
+package rpc
+
+import (
+	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/acme"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AcmeSolver 完成 ACME 验证，value 为 http-01 的响应内容或 dns-01 的 TXT 记录值
+type AcmeSolver interface {
+	// Present 在通知 ACME 服务验证之前发布验证内容
+	Present(domain string, token string, value string) error
+	// CleanUp 验证结束后清理验证内容
+	CleanUp(domain string, token string, value string) error
+}
+
+type Acme struct {
+	client *acme.Client
+}
+
+// NewAcme 使用账号私钥连接 acme.directory_url，配置 acme.ca_file 时以该 CA 校验 ACME 服务的证书（例如 Pebble）
+func NewAcme(accountKey crypto.Signer) (*Acme, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: packages.ConfigAcme.InsecureSkipVerify,
+	}
+
+	if len(packages.ConfigAcme.CaFile) != 0 {
+		caPem, err := ioutil.ReadFile(packages.ConfigAcme.CaFile)
+		if err != nil {
+			return nil, err
+		}
+
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caPem) {
+			return nil, fmt.Errorf("acme ca file %s has no certificate", packages.ConfigAcme.CaFile)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &Acme{
+		client: &acme.Client{
+			Key:          accountKey,
+			DirectoryURL: packages.ConfigAcme.DirectoryUrl,
+			HTTPClient:   &http.Client{Transport: transport, Timeout: time.Second * 30},
+			UserAgent:    "apioak-admin",
+		},
+	}, nil
+}
+
+// AcmeAccountKeyGenerate 生成 PEM 格式的 ECDSA P-256 账号私钥
+func AcmeAccountKeyGenerate() (string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	return acmeKeyPem(key)
+}
+
+// AcmeAccountKeyParse 解析 PEM 格式的 ECDSA 账号私钥
+func AcmeAccountKeyParse(keyPem string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(keyPem))
+	if block == nil {
+		return nil, errors.New("acme account key is not pem")
+	}
+
+	return x509.ParseECPrivateKey(block.Bytes)
+}
+
+// Register 注册账号并同意服务条款，账号已存在时直接使用
+func (a *Acme) Register(ctx context.Context, email string) error {
+	account := &acme.Account{}
+	if len(email) != 0 {
+		account.Contact = []string{"mailto:" + email}
+	}
+
+	_, err := a.client.Register(ctx, account, acme.AcceptTOS)
+	if errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return nil
+	}
+
+	return err
+}
+
+// Obtain 为域名下单并完成验证，返回 PEM 格式的证书链与新生成的私钥
+func (a *Acme) Obtain(ctx context.Context, domain string, challengeType string, solver AcmeSolver) (certificate string, privateKey string, err error) {
+	order, err := a.client.AuthorizeOrder(ctx, acme.DomainIDs(domain))
+	if err != nil {
+		return
+	}
+
+	for _, authzUrl := range order.AuthzURLs {
+		if err = a.authorize(ctx, authzUrl, challengeType, solver); err != nil {
+			return
+		}
+	}
+
+	order, err = a.client.WaitOrder(ctx, order.URI)
+	if err != nil {
+		return
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: strings.TrimPrefix(domain, "*.")},
+		DNSNames: []string{domain},
+	}, key)
+	if err != nil {
+		return
+	}
+
+	derList, _, err := a.client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return
+	}
+
+	certificatePem := strings.Builder{}
+	for _, der := range derList {
+		certificatePem.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	}
+
+	privateKey, err = acmeKeyPem(key)
+	if err != nil {
+		return
+	}
+
+	return certificatePem.String(), privateKey, nil
+}
+
+// authorize 完成一个授权，已经有效的授权（例如近期验证过的域名）直接跳过
+func (a *Acme) authorize(ctx context.Context, authzUrl string, challengeType string, solver AcmeSolver) error {
+	authz, err := a.client.GetAuthorization(ctx, authzUrl)
+	if err != nil {
+		return err
+	}
+
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+
+	var challenge *acme.Challenge
+	for _, authzChallenge := range authz.Challenges {
+		if authzChallenge.Type == challengeType {
+			challenge = authzChallenge
+			break
+		}
+	}
+	if challenge == nil {
+		return fmt.Errorf("acme challenge %s is not offered for %s", challengeType, authz.Identifier.Value)
+	}
+
+	var value string
+	if challengeType == utils.AcmeChallengeDns01 {
+		value, err = a.client.DNS01ChallengeRecord(challenge.Token)
+	} else {
+		value, err = a.client.HTTP01ChallengeResponse(challenge.Token)
+	}
+	if err != nil {
+		return err
+	}
+
+	domain := authz.Identifier.Value
+	if err = solver.Present(domain, challenge.Token, value); err != nil {
+		return err
+	}
+	defer func() {
+		if err := solver.CleanUp(domain, challenge.Token, value); err != nil {
+			packages.Log.Error("acme challenge cleanup error ", domain, ": ", err.Error())
+		}
+	}()
+
+	if _, err = a.client.Accept(ctx, challenge); err != nil {
+		return err
+	}
+
+	_, err = a.client.WaitAuthorization(ctx, authz.URI)
+
+	return err
+}
+
+func acmeKeyPem(key *ecdsa.PrivateKey) (string, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})), nil
+}
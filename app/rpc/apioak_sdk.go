@@ -70,13 +70,15 @@ type UpstreamNodeList struct {
 }
 
 type HealthCheck struct {
-	Enabled  bool   `json:"enabled"`
-	Tcp      bool   `json:"tcp"`
-	Method   string `json:"method"`
-	Host     string `json:"host"`
-	Uri      string `json:"uri"`
-	Interval int    `json:"interval"`
-	Timeout  int    `json:"timeout"`
+	Enabled   bool   `json:"enabled"`
+	Tcp       bool   `json:"tcp"`
+	Method    string `json:"method"`
+	Host      string `json:"host"`
+	Uri       string `json:"uri"`
+	Interval  int    `json:"interval"`
+	Timeout   int    `json:"timeout"`
+	Healthy   int    `json:"healthy"`
+	Unhealthy int    `json:"unhealthy"`
 }
 
 type UpstreamNodeConfig struct {
@@ -307,6 +309,8 @@ func (m *ApiOak) UpstreamNodeDeleteByIds(upstreamNodeIds []string) (err error) {
 type UpstreamConfig struct {
 	Name           string             `json:"name"`
 	Algorithm      string             `json:"algorithm"`
+	HashOn         string             `json:"hash_on,omitempty"`
+	HashKey        string             `json:"hash_key,omitempty"`
 	ConnectTimeout int                `json:"connect_timeout"`
 	WriteTimeout   int                `json:"write_timeout"`
 	ReadTimeout    int                `json:"read_timeout"`
@@ -576,7 +580,7 @@ func (m *ApiOak) RouterDelete(routerResIds []string) (err error) {
 type CertificateGetResponse struct {
 	ID   string   `json:"id"`
 	Name string   `json:"name"`
-	Sni  []string `json:"sni"`
+	Sni  []string `json:"snis"`
 	Cert string   `json:"cert"`
 	Key  string   `json:"key"`
 }
@@ -592,11 +596,18 @@ func (m *ApiOak) CertificateGet(resID string) (CertificateGetResponse, error) {
 	uri := m.Address + certificateUri + "/" + resID
 
 	httpResp, err := utils.Get(uri, params, headers, timeOut)
-	if err != nil || httpResp.StatusCode != 200 {
+	if err != nil {
 		packages.Log.Error("Failed to obtain the data side certificate information", err)
 		return CertificateGetResponse{}, errors.New(enums.CodeMessages(enums.RemoteServiceErr))
 	}
 
+	if httpResp.StatusCode == 404 {
+		return CertificateGetResponse{}, nil
+	} else if httpResp.StatusCode != 200 {
+		packages.Log.Error(string(httpResp.Body))
+		return CertificateGetResponse{}, errors.New(enums.CodeMessages(enums.RemoteServiceErr))
+	}
+
 	var body CertificateGetResponse
 	err = json.Unmarshal(httpResp.Body, &body)
 
@@ -745,13 +756,10 @@ func (m *ApiOak) ServicePut(request *ServicePutRequest) error {
 }
 
 type PluginResponse struct {
-	ID        string             `json:"id"`
-	Name      string             `json:"name"`
-	Protocols []string           `json:"protocols"`
-	Hosts     []string           `json:"hosts"`
-	Ports     []string           `json:"ports"`
-	Plugins   []ConfigObjectName `json:"plugins"`
-	Enabled   bool               `json:"enabled"`
+	ID     string      `json:"id"`
+	Name   string      `json:"name"`
+	Key    string      `json:"key"`
+	Config interface{} `json:"config"`
 }
 
 func (m *ApiOak) PluginGet(resID string) (PluginResponse, error) {
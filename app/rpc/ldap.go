@@ -0,0 +1,185 @@
+package rpc
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/packages"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/go-ldap/ldap/v3"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Ldap struct {
+	Url                string
+	StartTls           bool
+	InsecureSkipVerify bool
+	BindDn             string
+	BindPassword       string
+	BaseDn             string
+	UserFilter         string
+	EmailAttribute     string
+	NameAttribute      string
+	GroupAttribute     string
+}
+
+type LdapUser struct {
+	Dn     string
+	Email  string
+	Name   string
+	Groups []string
+}
+
+var (
+	ldapClient     *Ldap
+	ldapClientOnce sync.Once
+
+	ldapTimeOut = time.Second * 5
+)
+
+func NewLdap() *Ldap {
+
+	ldapClientOnce.Do(func() {
+		ldapClient = &Ldap{
+			Url:                packages.ConfigLdap.Url,
+			StartTls:           packages.ConfigLdap.StartTls,
+			InsecureSkipVerify: packages.ConfigLdap.InsecureSkipVerify,
+			BindDn:             packages.ConfigLdap.BindDn,
+			BindPassword:       packages.ConfigLdap.BindPassword,
+			BaseDn:             packages.ConfigLdap.BaseDn,
+			UserFilter:         packages.ConfigLdap.UserFilter,
+			EmailAttribute:     packages.ConfigLdap.EmailAttribute,
+			NameAttribute:      packages.ConfigLdap.NameAttribute,
+			GroupAttribute:     packages.ConfigLdap.GroupAttribute,
+		}
+	})
+
+	return ldapClient
+}
+
+func (m *Ldap) dial() (*ldap.Conn, error) {
+	ldapUrl, err := url.Parse(m.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         ldapUrl.Hostname(),
+		InsecureSkipVerify: m.InsecureSkipVerify,
+	}
+
+	conn, err := ldap.DialURL(m.Url, ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(ldapTimeOut)
+
+	if m.StartTls && (ldapUrl.Scheme == "ldap") {
+		if err = conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+// Authenticate 使用查找账号按 user_filter 查找用户，再以用户的 DN 与密码绑定校验密码
+func (m *Ldap) Authenticate(login string, password string) (ldapUser LdapUser, err error) {
+	// 空密码在 LDAP 中是匿名绑定，会直接绑定成功
+	if len(password) == 0 {
+		err = errors.New(enums.CodeMessages(enums.UserPasswordError))
+		return
+	}
+
+	conn, err := m.dial()
+	if err != nil {
+		packages.Log.Error("ldap dial error", err.Error())
+		err = errors.New(enums.CodeMessages(enums.RemoteServiceErr))
+		return
+	}
+	defer conn.Close()
+
+	if len(m.BindDn) != 0 {
+		if err = conn.Bind(m.BindDn, m.BindPassword); err != nil {
+			packages.Log.Error("ldap bind error", err.Error())
+			err = errors.New(enums.CodeMessages(enums.RemoteServiceErr))
+			return
+		}
+	}
+
+	searchRequest := ldap.NewSearchRequest(
+		m.BaseDn,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2,
+		int(ldapTimeOut.Seconds()),
+		false,
+		fmt.Sprintf(m.UserFilter, ldap.EscapeFilter(login)),
+		[]string{m.EmailAttribute, m.NameAttribute, m.GroupAttribute},
+		nil,
+	)
+
+	searchResult, err := conn.Search(searchRequest)
+	if err != nil {
+		packages.Log.Error("ldap search error", err.Error())
+		err = errors.New(enums.CodeMessages(enums.RemoteServiceErr))
+		return
+	}
+
+	if len(searchResult.Entries) == 0 {
+		err = errors.New(enums.CodeMessages(enums.UserNull))
+		return
+	}
+
+	if len(searchResult.Entries) > 1 {
+		packages.Log.Error("ldap search error", "multiple entries match ", login)
+		err = errors.New(enums.CodeMessages(enums.LdapUserError))
+		return
+	}
+
+	entry := searchResult.Entries[0]
+	if err = conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			err = errors.New(enums.CodeMessages(enums.UserPasswordError))
+			return
+		}
+
+		packages.Log.Error("ldap user bind error", err.Error())
+		err = errors.New(enums.CodeMessages(enums.RemoteServiceErr))
+		return
+	}
+
+	ldapUser = LdapUser{
+		Dn:     entry.DN,
+		Email:  entry.GetAttributeValue(m.EmailAttribute),
+		Name:   entry.GetAttributeValue(m.NameAttribute),
+		Groups: make([]string, 0),
+	}
+
+	if len(ldapUser.Email) == 0 {
+		packages.Log.Error("ldap user error", "email attribute is empty ", entry.DN)
+		err = errors.New(enums.CodeMessages(enums.LdapUserError))
+		return
+	}
+
+	// memberOf 的值为用户组的 DN，同时保留其 CN 以便按名称配置用户组
+	for _, group := range entry.GetAttributeValues(m.GroupAttribute) {
+		ldapUser.Groups = append(ldapUser.Groups, group)
+
+		groupDn, parseErr := ldap.ParseDN(group)
+		if (parseErr != nil) || (len(groupDn.RDNs) == 0) {
+			continue
+		}
+		for _, attribute := range groupDn.RDNs[0].Attributes {
+			if strings.EqualFold(attribute.Type, "cn") {
+				ldapUser.Groups = append(ldapUser.Groups, attribute.Value)
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,361 @@
+package rpc
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Oidc struct {
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+
+	mutex     sync.Mutex
+	discovery *OidcDiscovery
+	keys      map[string]*rsa.PublicKey
+}
+
+type OidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+type oidcJwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+type oidcTokenResponse struct {
+	IdToken string `json:"id_token"`
+}
+
+var (
+	oidc     *Oidc
+	oidcOnce sync.Once
+
+	oidcTimeOut = time.Second * 5
+
+	// 校验 id_token 过期时间时允许的时钟偏差
+	oidcClockSkew = time.Minute
+
+	oidcDiscoveryUri = "/.well-known/openid-configuration"
+
+	oidcSignHashes = map[string]crypto.Hash{
+		"RS256": crypto.SHA256,
+		"RS384": crypto.SHA384,
+		"RS512": crypto.SHA512,
+	}
+)
+
+func NewOidc() *Oidc {
+
+	oidcOnce.Do(func() {
+		oidc = &Oidc{
+			Issuer:       packages.ConfigOidc.Issuer,
+			ClientId:     packages.ConfigOidc.ClientId,
+			ClientSecret: packages.ConfigOidc.ClientSecret,
+			RedirectUrl:  packages.ConfigOidc.RedirectUrl,
+			Scopes:       packages.ConfigOidc.Scopes,
+			keys:         make(map[string]*rsa.PublicKey),
+		}
+	})
+
+	return oidc
+}
+
+// Discovery 获取身份提供方的配置，获取成功后缓存
+func (m *Oidc) Discovery() (discovery OidcDiscovery, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.discovery != nil {
+		return *m.discovery, nil
+	}
+
+	httpResp, err := utils.Get(m.Issuer+oidcDiscoveryUri, url.Values{}, http.Header{}, oidcTimeOut)
+	if err != nil {
+		packages.Log.Error("oidc discovery error", err.Error())
+		err = errors.New(enums.CodeMessages(enums.RemoteServiceErr))
+		return
+	}
+
+	if httpResp.StatusCode != 200 {
+		packages.Log.Error("oidc discovery error", string(httpResp.Body))
+		err = errors.New(enums.CodeMessages(enums.RemoteServiceErr))
+		return
+	}
+
+	if err = json.Unmarshal(httpResp.Body, &discovery); err != nil {
+		packages.Log.Error("oidc discovery error", err.Error())
+		err = errors.New(enums.CodeMessages(enums.RemoteServiceErr))
+		return
+	}
+
+	if strings.TrimRight(discovery.Issuer, "/") != m.Issuer {
+		packages.Log.Error("oidc discovery issuer mismatch", discovery.Issuer)
+		err = errors.New(enums.CodeMessages(enums.OidcLoginError))
+		return
+	}
+
+	m.discovery = &discovery
+
+	return
+}
+
+// AuthCodeUrl 授权码模式的登录地址，codeChallenge 为 PKCE 的 S256 摘要
+func (m *Oidc) AuthCodeUrl(state string, nonce string, codeChallenge string) (string, error) {
+	discovery, err := m.Discovery()
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", m.ClientId)
+	params.Set("redirect_uri", m.RedirectUrl)
+	params.Set("scope", strings.Join(m.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return discovery.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange 使用授权码换取 id_token
+func (m *Oidc) Exchange(code string, codeVerifier string) (idToken string, err error) {
+	discovery, err := m.Discovery()
+	if err != nil {
+		return
+	}
+
+	params := url.Values{}
+	params.Set("grant_type", "authorization_code")
+	params.Set("code", code)
+	params.Set("redirect_uri", m.RedirectUrl)
+	params.Set("code_verifier", codeVerifier)
+
+	headers := http.Header{}
+	headers.Set("Accept", "application/json")
+	headers.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString(
+		[]byte(url.QueryEscape(m.ClientId)+":"+url.QueryEscape(m.ClientSecret))))
+
+	httpResp, err := utils.PostForm(discovery.TokenEndpoint, params, headers, oidcTimeOut)
+	if err != nil {
+		packages.Log.Error("oidc token exchange error", err.Error())
+		err = errors.New(enums.CodeMessages(enums.RemoteServiceErr))
+		return
+	}
+
+	if httpResp.StatusCode != 200 {
+		packages.Log.Error("oidc token exchange error", string(httpResp.Body))
+		err = errors.New(enums.CodeMessages(enums.OidcLoginError))
+		return
+	}
+
+	tokenResponse := oidcTokenResponse{}
+	if err = json.Unmarshal(httpResp.Body, &tokenResponse); err != nil {
+		packages.Log.Error("oidc token exchange error", err.Error())
+		err = errors.New(enums.CodeMessages(enums.OidcLoginError))
+		return
+	}
+
+	if len(tokenResponse.IdToken) == 0 {
+		packages.Log.Error("oidc token exchange error", "id_token is empty")
+		err = errors.New(enums.CodeMessages(enums.OidcLoginError))
+		return
+	}
+
+	return tokenResponse.IdToken, nil
+}
+
+// VerifyIdToken 校验 id_token 的签名、签发方、受众、过期时间与 nonce，返回其中的声明
+func (m *Oidc) VerifyIdToken(idToken string, nonce string) (claims map[string]interface{}, err error) {
+	claims, err = m.verifyIdToken(idToken, nonce)
+	if err != nil {
+		packages.Log.Error("oidc id_token verify error", err.Error())
+		err = errors.New(enums.CodeMessages(enums.OidcLoginError))
+	}
+
+	return
+}
+
+func (m *Oidc) verifyIdToken(idToken string, nonce string) (claims map[string]interface{}, err error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		err = errors.New("id_token format error")
+		return
+	}
+
+	headerJson, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return
+	}
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+	if err = json.Unmarshal(headerJson, &header); err != nil {
+		return
+	}
+
+	hash, ok := oidcSignHashes[header.Alg]
+	if !ok {
+		err = errors.New("id_token alg not supported: " + header.Alg)
+		return
+	}
+
+	publicKey, err := m.publicKey(header.Kid, false)
+	if err != nil {
+		return
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return
+	}
+
+	hasher := hash.New()
+	hasher.Write([]byte(parts[0] + "." + parts[1]))
+	digest := hasher.Sum(nil)
+	if err = rsa.VerifyPKCS1v15(publicKey, hash, digest, signature); err != nil {
+		// 身份提供方轮换密钥时可能沿用原来的 kid，重新获取 jwks 后再校验一次
+		if publicKey, err = m.publicKey(header.Kid, true); err != nil {
+			return
+		}
+		if err = rsa.VerifyPKCS1v15(publicKey, hash, digest, signature); err != nil {
+			return
+		}
+	}
+
+	claimsJson, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(claimsJson, &claims); err != nil {
+		return
+	}
+
+	discovery, err := m.Discovery()
+	if err != nil {
+		return
+	}
+
+	if iss, _ := claims["iss"].(string); iss != discovery.Issuer {
+		err = errors.New("id_token iss mismatch: " + iss)
+		return
+	}
+
+	audienceMatch := false
+	switch aud := claims["aud"].(type) {
+	case string:
+		audienceMatch = aud == m.ClientId
+	case []interface{}:
+		for _, audience := range aud {
+			if audience == m.ClientId {
+				audienceMatch = true
+			}
+		}
+	}
+	if !audienceMatch {
+		err = errors.New("id_token aud mismatch")
+		return
+	}
+
+	exp, _ := claims["exp"].(float64)
+	if time.Unix(int64(exp), 0).Add(oidcClockSkew).Before(time.Now()) {
+		err = errors.New("id_token expired")
+		return
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		err = errors.New("id_token nonce mismatch")
+		return
+	}
+
+	return
+}
+
+// publicKey 按 kid 查找签名公钥，找不到或 refresh 时重新获取 jwks 以支持密钥轮换
+func (m *Oidc) publicKey(kid string, refresh bool) (*rsa.PublicKey, error) {
+	m.mutex.Lock()
+	publicKey, ok := m.keys[kid]
+	m.mutex.Unlock()
+	if ok && !refresh {
+		return publicKey, nil
+	}
+
+	discovery, err := m.Discovery()
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := utils.Get(discovery.JwksUri, url.Values{}, http.Header{}, oidcTimeOut)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode != 200 {
+		return nil, errors.New("oidc jwks error: " + string(httpResp.Body))
+	}
+
+	jwks := oidcJwks{}
+	if err = json.Unmarshal(httpResp.Body, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+
+		n, nErr := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, eErr := base64.RawURLEncoding.DecodeString(jwk.E)
+		if (nErr != nil) || (eErr != nil) {
+			continue
+		}
+
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	m.mutex.Lock()
+	m.keys = keys
+	m.mutex.Unlock()
+
+	publicKey, ok = keys[kid]
+	if !ok {
+		return nil, errors.New("oidc jwks kid not found: " + kid)
+	}
+
+	return publicKey, nil
+}
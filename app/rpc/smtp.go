@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"apioak-admin/app/packages"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SmtpSend 通过配置的 SMTP 服务发送纯文本邮件，未配置用户名时不认证，适用于本机的邮件中继
+func SmtpSend(to []string, subject string, body string) error {
+	if len(to) == 0 {
+		return errors.New("smtp recipients are empty")
+	}
+
+	from := packages.ConfigSmtp.From
+	addr := net.JoinHostPort(packages.ConfigSmtp.Host, strconv.Itoa(packages.ConfigSmtp.Port))
+
+	var auth smtp.Auth
+	if len(packages.ConfigSmtp.Username) != 0 {
+		auth = smtp.PlainAuth("", packages.ConfigSmtp.Username, packages.ConfigSmtp.Password, packages.ConfigSmtp.Host)
+	}
+
+	message := strings.Builder{}
+	message.WriteString(fmt.Sprintf("From: %s\r\n", from))
+	message.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(to, ", ")))
+	message.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
+	message.WriteString(fmt.Sprintf("Date: %s\r\n", time.Now().Format(time.RFC1123Z)))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return smtp.SendMail(addr, auth, from, to, []byte(message.String()))
+}
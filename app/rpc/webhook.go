@@ -0,0 +1,24 @@
+package rpc
+
+import (
+	"apioak-admin/app/utils"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// WebhookSend 以 POST 发送事件，X-Apioak-Signature 为 HMAC-SHA256(secret, timestamp + "." + body)，
+// 接收方可以同时校验 X-Apioak-Timestamp 防止重放
+func WebhookSend(url string, secret string, event string, deliveryResId string, payload string, timeout time.Duration) (utils.HttpResp, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("User-Agent", "APIOAK-Webhook")
+	header.Set("X-Apioak-Event", event)
+	header.Set("X-Apioak-Delivery", deliveryResId)
+	header.Set("X-Apioak-Timestamp", timestamp)
+	header.Set("X-Apioak-Signature", "sha256="+utils.HmacSha256(secret, timestamp+"."+payload))
+
+	return utils.HttpDo(http.MethodPost, url, payload, header, timeout)
+}
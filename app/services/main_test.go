@@ -0,0 +1,51 @@
+package services
+
+import (
+	"apioak-admin/app/migrations"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
+	"fmt"
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain 在临时目录的 SQLite 数据库上执行全部迁移，测试结束后删除
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "apioak-admin-services")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	code, err := testMainRun(m, filepath.Join(dir, "oak.db"))
+	os.RemoveAll(dir)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	os.Exit(code)
+}
+
+func testMainRun(m *testing.M, dbFile string) (int, error) {
+	db, err := gorm.Open(sqlite.Open(dbFile + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"))
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = migrations.Up(db); err != nil {
+		return 0, err
+	}
+
+	packages.SetDb(db)
+	packages.SetLogger(zap.NewNop().Sugar())
+	packages.SetToken("apioak-admin-test", utils.RandomStrGenerate(32), 60)
+	packages.SetConfigUser(utils.UserRegisterOpen, utils.UserLoginProviderLocal, false)
+
+	return m.Run(), nil
+}
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/rpc"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+var (
+	acmeWakeup = make(chan struct{}, 1)
+
+	// http-01 验证的响应内容在数据库中保留的最长时间
+	acmeHttp01Expire = time.Hour
+
+	// 每轮最多签发的证书数
+	acmeRenewLimit = 10
+
+	acmeMessageMaxLength = 1000
+)
+
+type AcmeCertificateItem struct {
+	ResID            string `json:"res_id"`
+	Sni              string `json:"sni"`
+	Challenge        string `json:"challenge"`
+	CertificateResID string `json:"certificate_res_id"`
+	Status           int    `json:"status"`
+	Message          string `json:"message"`
+	NextAttemptAt    int64  `json:"next_attempt_at"`
+	CreatedAt        int64  `json:"created_at"`
+	UpdatedAt        int64  `json:"updated_at"`
+}
+
+// AcmeCertificateAdd 为服务域名申请证书，签发由后台任务完成
+func AcmeCertificateAdd(request *validators.AcmeCertificateAdd) ([]string, error) {
+	if !packages.ConfigAcme.Enable {
+		return nil, errors.New(enums.CodeMessages(enums.AcmeDisabled))
+	}
+
+	if (request.Challenge == utils.AcmeChallengeDns01) && (len(packages.ConfigAcme.DnsProvider) == 0) {
+		return nil, errors.New(enums.CodeMessages(enums.AcmeDnsProviderNull))
+	}
+
+	domains := make([]string, 0, len(request.Domains))
+	domainMap := make(map[string]bool)
+	for _, domain := range request.Domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domainMap[domain] {
+			continue
+		}
+		domainMap[domain] = true
+
+		if strings.HasPrefix(domain, "*.") && (request.Challenge != utils.AcmeChallengeDns01) {
+			return nil, fmt.Errorf(enums.CodeMessages(enums.AcmeWildcardChallengeError), domain)
+		}
+		domains = append(domains, domain)
+	}
+
+	domainInfos, err := (&models.ServiceDomains{}).DomainInfosByDomain(domains, []string{})
+	if err != nil {
+		return nil, err
+	}
+	serviceDomainMap := make(map[string]bool)
+	for _, domainInfo := range domainInfos {
+		serviceDomainMap[domainInfo.Domain] = true
+	}
+	for _, domain := range domains {
+		if !serviceDomainMap[domain] {
+			return nil, fmt.Errorf(enums.CodeMessages(enums.AcmeDomainNotFound), domain)
+		}
+	}
+
+	existList, err := (&models.AcmeCertificates{}).AcmeCertificateListBySni(domains)
+	if err != nil {
+		return nil, err
+	}
+	if len(existList) != 0 {
+		return nil, fmt.Errorf(enums.CodeMessages(enums.AcmeCertificateExist), existList[0].Sni)
+	}
+
+	nextAttemptAt := time.Now()
+	certificates := make([]models.AcmeCertificates, 0, len(domains))
+	for _, domain := range domains {
+		certificates = append(certificates, models.AcmeCertificates{
+			Sni:           domain,
+			Challenge:     request.Challenge,
+			Status:        utils.AcmeStatusPending,
+			NextAttemptAt: &nextAttemptAt,
+		})
+	}
+
+	resIds, err := (&models.AcmeCertificates{}).AcmeCertificatesAdd(certificates)
+	if err != nil {
+		return nil, err
+	}
+
+	acmeWake()
+
+	return resIds, nil
+}
+
+func AcmeCertificateList(request *validators.AcmeCertificateList) ([]AcmeCertificateItem, int, error) {
+	certificateList, total, err := (&models.AcmeCertificates{}).AcmeCertificateListPage(request)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	list := make([]AcmeCertificateItem, 0)
+	for _, certificate := range certificateList {
+		item := AcmeCertificateItem{
+			ResID:            certificate.ResID,
+			Sni:              certificate.Sni,
+			Challenge:        certificate.Challenge,
+			CertificateResID: certificate.CertificateResID,
+			Status:           certificate.Status,
+			Message:          certificate.Message,
+			CreatedAt:        certificate.CreatedAt.Unix(),
+			UpdatedAt:        certificate.UpdatedAt.Unix(),
+		}
+		if certificate.NextAttemptAt != nil {
+			item.NextAttemptAt = certificate.NextAttemptAt.Unix()
+		}
+
+		list = append(list, item)
+	}
+
+	return list, total, nil
+}
+
+// AcmeCertificateRenew 立即重新签发，用于签发失败后修复了域名解析或验证配置的情况
+func AcmeCertificateRenew(resId string) error {
+	if !packages.ConfigAcme.Enable {
+		return errors.New(enums.CodeMessages(enums.AcmeDisabled))
+	}
+
+	certificate, err := (&models.AcmeCertificates{}).AcmeCertificateInfoByResId(resId)
+	if err != nil {
+		return err
+	}
+
+	err = (&models.AcmeCertificates{}).AcmeCertificateUpdateColumns(certificate.ID, map[string]interface{}{
+		"next_attempt_at": time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	acmeWake()
+
+	return nil
+}
+
+// AcmeCertificateDelete 停止签发与续期，已签发的证书保留在证书管理中
+func AcmeCertificateDelete(resId string) error {
+	_, err := (&models.AcmeCertificates{}).AcmeCertificateInfoByResId(resId)
+	if err != nil {
+		return err
+	}
+
+	return (&models.AcmeCertificates{}).AcmeCertificateDelete(resId)
+}
+
+// AcmeHttp01Response 返回 http-01 验证 token 的响应内容，响应内容保存在数据库中，任意实例都可以响应验证请求
+func AcmeHttp01Response(token string) (string, bool) {
+	challenge, err := (&models.AcmeChallenges{}).AcmeChallengeInfo(token)
+	if err != nil {
+		packages.Log.Error("acme challenge info error", err.Error())
+		return "", false
+	}
+	if challenge.ID == 0 {
+		return "", false
+	}
+
+	return challenge.KeyAuthorization, true
+}
+
+// AcmeWakeup 有新的待签发证书时收到通知，签发任务无需等待下一个周期
+func AcmeWakeup() <-chan struct{} {
+	return acmeWakeup
+}
+
+// AcmeRenew 签发到达签发时间的证书，首次签发新增证书，续期时更新原证书，均通过证书管理同步至数据面
+func AcmeRenew() {
+	certificateList, err := (&models.AcmeCertificates{}).AcmeCertificateDueList(acmeRenewLimit)
+	if err != nil {
+		packages.Log.Error("acme certificate list error", err.Error())
+		return
+	}
+
+	if len(certificateList) == 0 {
+		return
+	}
+
+	client, err := acmeClient()
+	if err != nil {
+		packages.Log.Error("acme account error", err.Error())
+		return
+	}
+
+	timeout := time.Second * time.Duration(packages.ConfigAcme.Timeout)
+	for _, certificate := range certificateList {
+		// 占用到超时之后，签发过程中实例退出时该证书会在之后重新签发
+		rowsAffected, err := (&models.AcmeCertificates{}).AcmeCertificateClaim(certificate.ID, *certificate.NextAttemptAt, time.Now().Add(timeout*2))
+		if (err != nil) || (rowsAffected == 0) {
+			continue
+		}
+
+		acmeObtain(client, certificate, timeout)
+	}
+}
+
+func acmeObtain(client *rpc.Acme, acmeCertificate models.AcmeCertificates, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var solver rpc.AcmeSolver = acmeHttp01Solver{}
+	if acmeCertificate.Challenge == utils.AcmeChallengeDns01 {
+		solver = acmeDnsExecSolver{}
+	}
+
+	updateParams := map[string]interface{}{}
+
+	certificatePem, privateKey, err := client.Obtain(ctx, acmeCertificate.Sni, acmeCertificate.Challenge, solver)
+	if err == nil {
+		var certificateResId string
+		certificateResId, err = acmeCertificateSave(acmeCertificate, certificatePem, privateKey)
+		if err == nil {
+			updateParams["certificate_res_id"] = certificateResId
+		}
+	}
+
+	if err == nil {
+		certificateInfo, _ := utils.DiscernCertificate(&certificatePem)
+
+		// 证书有效期短于续期时间时（例如测试环境签发的短期证书）至少间隔 retry_interval 再续期
+		nextAttemptAt := certificateInfo.NotAfter.AddDate(0, 0, -packages.ConfigAcme.RenewBefore)
+		retryAt := time.Now().Add(time.Second * time.Duration(packages.ConfigAcme.RetryInterval))
+		if nextAttemptAt.Before(retryAt) {
+			nextAttemptAt = retryAt
+		}
+
+		updateParams["status"] = utils.AcmeStatusValid
+		updateParams["message"] = ""
+		updateParams["next_attempt_at"] = nextAttemptAt
+	} else {
+		packages.Log.Error("acme obtain error ", acmeCertificate.Sni, ": ", err.Error())
+
+		updateParams["status"] = utils.AcmeStatusInvalid
+		updateParams["message"] = acmeMessage(err.Error())
+		updateParams["next_attempt_at"] = time.Now().Add(time.Second * time.Duration(packages.ConfigAcme.RetryInterval))
+	}
+
+	if err = (&models.AcmeCertificates{}).AcmeCertificateUpdateColumns(acmeCertificate.ID, updateParams); err != nil {
+		packages.Log.Error("acme certificate update error", err.Error())
+	}
+}
+
+// acmeCertificateSave 续期时保留原证书的启用状态，原证书不存在（首次签发或已被删除）时新增并启用
+func acmeCertificateSave(acmeCertificate models.AcmeCertificates, certificatePem string, privateKey string) (string, error) {
+	request := &validators.CertificateAddUpdate{
+		Sni:         acmeCertificate.Sni,
+		Certificate: certificatePem,
+		PrivateKey:  privateKey,
+		Enable:      utils.EnableOn,
+	}
+
+	if len(acmeCertificate.CertificateResID) != 0 {
+		certificate, err := (&models.Certificates{}).CertificateInfoById(acmeCertificate.CertificateResID)
+		if err == nil {
+			request.Enable = certificate.Enable
+
+			return certificate.ResID, NewCertificateService().CertificateUpdate(certificate.ResID, request)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", err
+		}
+	}
+
+	return NewCertificateService().CertificateAdd(request)
+}
+
+// acmeClient 加载 acme.directory_url 与 acme.email 对应的账号，不存在时生成私钥并注册
+func acmeClient() (*rpc.Acme, error) {
+	account, err := (&models.AcmeAccounts{}).AcmeAccountInfo(packages.ConfigAcme.DirectoryUrl, packages.ConfigAcme.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		account = models.AcmeAccounts{
+			DirectoryUrl: packages.ConfigAcme.DirectoryUrl,
+			Email:        packages.ConfigAcme.Email,
+		}
+		account.PrivateKey, err = rpc.AcmeAccountKeyGenerate()
+		if err != nil {
+			return nil, err
+		}
+		account.PrivateKey, err = utils.Encrypt(account.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = (&models.AcmeAccounts{}).AcmeAccountAdd(&account); err != nil {
+			// 其他实例同时注册时使用已保存的账号
+			account, err = (&models.AcmeAccounts{}).AcmeAccountInfo(packages.ConfigAcme.DirectoryUrl, packages.ConfigAcme.Email)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	accountKeyPem, err := utils.Decrypt(account.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	accountKey, err := rpc.AcmeAccountKeyParse(accountKeyPem)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := rpc.NewAcme(accountKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(packages.ConfigAcme.Timeout))
+	defer cancel()
+
+	if err = client.Register(ctx, packages.ConfigAcme.Email); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// acmeHttp01Solver 由 /.well-known/acme-challenge/:token 响应验证内容
+type acmeHttp01Solver struct{}
+
+func (s acmeHttp01Solver) Present(domain string, token string, value string) error {
+	return (&models.AcmeChallenges{}).AcmeChallengeAdd(&models.AcmeChallenges{
+		Token:            token,
+		KeyAuthorization: value,
+		ExpiredAt:        time.Now().Add(acmeHttp01Expire),
+	})
+}
+
+func (s acmeHttp01Solver) CleanUp(domain string, token string, value string) error {
+	return (&models.AcmeChallenges{}).AcmeChallengeDelete(token)
+}
+
+// acmeDnsExecSolver 执行 acme.dns_exec 发布与清理 TXT 记录，参数为 present|cleanup <fqdn> <value>
+type acmeDnsExecSolver struct{}
+
+func (s acmeDnsExecSolver) Present(domain string, token string, value string) error {
+	if err := acmeDnsExec("present", domain, value); err != nil {
+		return err
+	}
+
+	time.Sleep(time.Second * time.Duration(packages.ConfigAcme.DnsPropagationWait))
+
+	return nil
+}
+
+func (s acmeDnsExecSolver) CleanUp(domain string, token string, value string) error {
+	return acmeDnsExec("cleanup", domain, value)
+}
+
+func acmeDnsExec(action string, domain string, value string) error {
+	command := strings.Fields(packages.ConfigAcme.DnsExec)
+	if len(command) == 0 {
+		return errors.New(enums.CodeMessages(enums.AcmeDnsProviderNull))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(packages.ConfigAcme.Timeout))
+	defer cancel()
+
+	fqdn := "_acme-challenge." + strings.TrimPrefix(domain, "*.") + "."
+	args := append(command[1:], action, fqdn, value)
+
+	output, err := exec.CommandContext(ctx, command[0], args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("acme dns exec %s %s: %s %s", action, fqdn, err.Error(), strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+func acmeWake() {
+	select {
+	case acmeWakeup <- struct{}{}:
+	default:
+	}
+}
+
+func acmeMessage(message string) string {
+	runes := []rune(message)
+	if len(runes) > acmeMessageMaxLength {
+		runes = runes[:acmeMessageMaxLength]
+	}
+
+	return string(runes)
+}
@@ -0,0 +1,915 @@
+package services
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/services/plugins"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type ApplyPluginConfig struct {
+	Key    string      `json:"key" yaml:"key"`
+	Name   string      `json:"name" yaml:"name"`
+	Enable int         `json:"enable" yaml:"enable"`
+	Config interface{} `json:"config" yaml:"config"`
+}
+
+type ApplyUpstreamNode struct {
+	NodeIP      string `json:"node_ip" yaml:"node_ip"`
+	NodePort    int    `json:"node_port" yaml:"node_port"`
+	NodeWeight  int    `json:"node_weight" yaml:"node_weight"`
+	Health      int    `json:"health" yaml:"health"`
+	HealthCheck int    `json:"health_check" yaml:"health_check"`
+}
+
+type ApplyUpstream struct {
+	Name           string                  `json:"name" yaml:"name"`
+	LoadBalance    int                     `json:"load_balance" yaml:"load_balance"`
+	HashOn         string                  `json:"hash_on" yaml:"hash_on"`
+	HashKey        string                  `json:"hash_key" yaml:"hash_key"`
+	ConnectTimeout int                     `json:"connect_timeout" yaml:"connect_timeout"`
+	WriteTimeout   int                     `json:"write_timeout" yaml:"write_timeout"`
+	ReadTimeout    int                     `json:"read_timeout" yaml:"read_timeout"`
+	Enable         int                     `json:"enable" yaml:"enable"`
+	HealthCheck    UpstreamHealthCheckItem `json:"health_check" yaml:"health_check"`
+	Nodes          []ApplyUpstreamNode     `json:"nodes" yaml:"nodes"`
+}
+
+type ApplyRouter struct {
+	Name     string              `json:"name" yaml:"name"`
+	Path     string              `json:"path" yaml:"path"`
+	Methods  string              `json:"methods" yaml:"methods"`
+	Upstream string              `json:"upstream" yaml:"upstream"`
+	Enable   int                 `json:"enable" yaml:"enable"`
+	Plugins  []ApplyPluginConfig `json:"plugins" yaml:"plugins"`
+}
+
+type ApplyService struct {
+	Name     string              `json:"name" yaml:"name"`
+	Protocol int                 `json:"protocol" yaml:"protocol"`
+	Enable   int                 `json:"enable" yaml:"enable"`
+	Domains  []string            `json:"domains" yaml:"domains"`
+	Plugins  []ApplyPluginConfig `json:"plugins" yaml:"plugins"`
+	Routers  []ApplyRouter       `json:"routers" yaml:"routers"`
+}
+
+// ApplyState 声明式配置文件，服务与上游以名称标识，路由以服务名称+路由路径标识，插件以所属资源+插件标识标识
+type ApplyState struct {
+	Upstreams []ApplyUpstream `json:"upstreams" yaml:"upstreams"`
+	Services  []ApplyService  `json:"services" yaml:"services"`
+}
+
+type ApplyChange struct {
+	Action   string   `json:"action"`
+	Resource string   `json:"resource"`
+	Name     string   `json:"name"`
+	ResID    string   `json:"res_id"`
+	Fields   []string `json:"fields"`
+
+	run func(ctx *applyContext) error
+}
+
+type ApplyPlan struct {
+	Changes []ApplyChange `json:"changes"`
+
+	upstreamNames []string
+	serviceNames  []string
+	routerKeys    []string
+}
+
+// applyContext 执行变更时记录名称与 res_id 的对应关系，新建的资源在执行后才能拿到 res_id
+type applyContext struct {
+	upstreamResIds map[string]string
+	serviceResIds  map[string]string
+	routerResIds   map[string]string
+}
+
+func ApplyStateDecode(content []byte) (state ApplyState, err error) {
+	err = yaml.Unmarshal(content, &state)
+
+	return
+}
+
+func applyRouterKey(serviceName string, routerPath string) string {
+	return serviceName + ":" + routerPath
+}
+
+func applyPluginConfigKey(configType int, targetId string, pluginKey string) string {
+	return strconv.Itoa(configType) + ":" + targetId + ":" + pluginKey
+}
+
+func applyNodeKey(nodeIp string, nodePort int) string {
+	return nodeIp + "-" + strconv.Itoa(nodePort)
+}
+
+func applyEnableDefault(enable int) int {
+	if enable == 0 {
+		return utils.EnableOn
+	}
+
+	return enable
+}
+
+// applyDataState 控制面当前数据
+type applyDataState struct {
+	upstreams      map[string]models.Upstreams
+	upstreamNodes  map[string][]models.UpstreamNodes
+	services       map[string]models.Services
+	serviceDomains map[string][]string
+	routers        map[string]models.Routers
+	pluginConfigs  map[string]models.PluginConfigs
+	plugins        map[string]models.Plugins
+
+	upstreamList      []models.Upstreams
+	serviceList       []models.Services
+	routerList        []models.Routers
+	pluginConfigList  []models.PluginConfigs
+	upstreamNameByIds map[string]string
+	serviceNameByIds  map[string]string
+}
+
+func loadApplyDataState() (data *applyDataState, err error) {
+	db := packages.GetDb()
+	data = &applyDataState{
+		upstreams:         make(map[string]models.Upstreams),
+		upstreamNodes:     make(map[string][]models.UpstreamNodes),
+		services:          make(map[string]models.Services),
+		serviceDomains:    make(map[string][]string),
+		routers:           make(map[string]models.Routers),
+		pluginConfigs:     make(map[string]models.PluginConfigs),
+		plugins:           make(map[string]models.Plugins),
+		upstreamNameByIds: make(map[string]string),
+		serviceNameByIds:  make(map[string]string),
+	}
+
+	if data.upstreamList, err = (models.Upstreams{}).UpstreamAllList(db); err != nil {
+		return
+	}
+	for _, upstreamInfo := range data.upstreamList {
+		if _, ok := data.upstreams[upstreamInfo.Name]; !ok {
+			data.upstreams[upstreamInfo.Name] = upstreamInfo
+		}
+		data.upstreamNameByIds[upstreamInfo.ResID] = upstreamInfo.Name
+	}
+
+	upstreamNodeList, err := (&models.UpstreamNodes{}).UpstreamNodeAllList(db)
+	if err != nil {
+		return
+	}
+	for _, upstreamNodeInfo := range upstreamNodeList {
+		data.upstreamNodes[upstreamNodeInfo.UpstreamResID] = append(data.upstreamNodes[upstreamNodeInfo.UpstreamResID], upstreamNodeInfo)
+	}
+
+	if data.serviceList, err = (&models.Services{}).ServiceAllList(db); err != nil {
+		return
+	}
+	for _, serviceInfo := range data.serviceList {
+		if _, ok := data.services[serviceInfo.Name]; !ok {
+			data.services[serviceInfo.Name] = serviceInfo
+		}
+		data.serviceNameByIds[serviceInfo.ResID] = serviceInfo.Name
+	}
+
+	domainList, err := (&models.ServiceDomains{}).DomainAllList(db)
+	if err != nil {
+		return
+	}
+	for _, domainInfo := range domainList {
+		data.serviceDomains[domainInfo.ServiceResID] = append(data.serviceDomains[domainInfo.ServiceResID], domainInfo.Domain)
+	}
+
+	if data.routerList, err = (&models.Routers{}).RouterAllList(db); err != nil {
+		return
+	}
+	for _, routerInfo := range data.routerList {
+		data.routers[applyRouterKey(data.serviceNameByIds[routerInfo.ServiceResID], routerInfo.RouterPath)] = routerInfo
+	}
+
+	if data.pluginConfigList, err = (&models.PluginConfigs{}).PluginConfigAllList(db); err != nil {
+		return
+	}
+	for _, pluginConfigInfo := range data.pluginConfigList {
+		data.pluginConfigs[applyPluginConfigKey(pluginConfigInfo.Type, pluginConfigInfo.TargetID, pluginConfigInfo.PluginKey)] = pluginConfigInfo
+	}
+
+	pluginList, err := (&models.Plugins{}).PluginAllList()
+	if err != nil {
+		return
+	}
+	for _, pluginInfo := range pluginList {
+		data.plugins[pluginInfo.PluginKey] = pluginInfo
+	}
+
+	return
+}
+
+// ApplyDiff 对比声明式配置与控制面数据，生成按依赖顺序排列的变更计划，prune 为 true 时删除配置文件外的数据
+func ApplyDiff(state *ApplyState, prune bool) (plan ApplyPlan, err error) {
+	// 命令行执行时管理服务可能未启动过，先同步插件基础信息
+	PluginBasicInfoMaintain()
+
+	data, err := loadApplyDataState()
+	if err != nil {
+		return
+	}
+
+	plan.Changes = make([]ApplyChange, 0)
+
+	upstreamNames := make(map[string]byte)
+	for _, upstream := range state.Upstreams {
+		if len(strings.TrimSpace(upstream.Name)) == 0 {
+			err = fmt.Errorf(enums.CodeMessages(enums.ApplyNameNull), utils.ApplyResourceUpstream)
+			return
+		}
+		if _, ok := upstreamNames[upstream.Name]; ok {
+			err = fmt.Errorf(enums.CodeMessages(enums.ApplyNameRepeat), upstream.Name)
+			return
+		}
+		upstreamNames[upstream.Name] = 0
+
+		var change *ApplyChange
+		change, err = applyUpstreamChange(upstream, data)
+		if err != nil {
+			return
+		}
+		if change != nil {
+			plan.Changes = append(plan.Changes, *change)
+		}
+		plan.upstreamNames = append(plan.upstreamNames, upstream.Name)
+	}
+
+	if !prune {
+		for name := range data.upstreams {
+			upstreamNames[name] = 0
+		}
+	}
+
+	serviceNames := make(map[string]byte)
+	domains := make(map[string]byte)
+	routerKeys := make(map[string]byte)
+	pluginConfigKeys := make(map[string]byte)
+	for _, service := range state.Services {
+		if len(strings.TrimSpace(service.Name)) == 0 {
+			err = fmt.Errorf(enums.CodeMessages(enums.ApplyNameNull), utils.ApplyResourceService)
+			return
+		}
+		if _, ok := serviceNames[service.Name]; ok {
+			err = fmt.Errorf(enums.CodeMessages(enums.ApplyNameRepeat), service.Name)
+			return
+		}
+		serviceNames[service.Name] = 0
+
+		for _, domain := range service.Domains {
+			domain = strings.TrimSpace(domain)
+			if _, ok := domains[domain]; ok {
+				err = fmt.Errorf(enums.CodeMessages(enums.ApplyNameRepeat), domain)
+				return
+			}
+			domains[domain] = 0
+		}
+
+		var change *ApplyChange
+		change, err = applyServiceChange(service, data)
+		if err != nil {
+			return
+		}
+		if change != nil {
+			plan.Changes = append(plan.Changes, *change)
+		}
+		plan.serviceNames = append(plan.serviceNames, service.Name)
+
+		serviceInfo := data.services[service.Name]
+		for _, pluginConfig := range service.Plugins {
+			change, err = applyPluginConfigChange(models.PluginConfigsTypeService, service.Name, serviceInfo.ResID, pluginConfig, data, pluginConfigKeys)
+			if err != nil {
+				return
+			}
+			if change != nil {
+				plan.Changes = append(plan.Changes, *change)
+			}
+		}
+
+		for _, router := range service.Routers {
+			routerKey := applyRouterKey(service.Name, strings.TrimSpace(router.Path))
+			if _, ok := routerKeys[routerKey]; ok {
+				err = fmt.Errorf(enums.CodeMessages(enums.ApplyNameRepeat), routerKey)
+				return
+			}
+			routerKeys[routerKey] = 0
+
+			if len(router.Upstream) != 0 {
+				if _, ok := upstreamNames[router.Upstream]; !ok {
+					err = fmt.Errorf(enums.CodeMessages(enums.ApplyReferenceNull), routerKey, router.Upstream)
+					return
+				}
+			}
+
+			change, err = applyRouterChange(service.Name, router, data)
+			if err != nil {
+				return
+			}
+			if change != nil {
+				plan.Changes = append(plan.Changes, *change)
+			}
+			plan.routerKeys = append(plan.routerKeys, routerKey)
+
+			routerInfo := data.routers[routerKey]
+			for _, pluginConfig := range router.Plugins {
+				change, err = applyPluginConfigChange(models.PluginConfigsTypeRouter, routerKey, routerInfo.ResID, pluginConfig, data, pluginConfigKeys)
+				if err != nil {
+					return
+				}
+				if change != nil {
+					plan.Changes = append(plan.Changes, *change)
+				}
+			}
+		}
+	}
+
+	if prune {
+		plan.Changes = append(plan.Changes, applyPruneChanges(data, upstreamNames, serviceNames, routerKeys, pluginConfigKeys)...)
+	}
+
+	return
+}
+
+func applyUpstreamChange(upstream ApplyUpstream, data *applyDataState) (change *ApplyChange, err error) {
+	request := validators.UpstreamAddUpdate{
+		Name:        upstream.Name,
+		LoadBalance: upstream.LoadBalance,
+		HashOn:      upstream.HashOn,
+		HashKey:     upstream.HashKey,
+		Enable:      applyEnableDefault(upstream.Enable),
+		UpstreamTimeout: validators.UpstreamTimeout{
+			ReadTimeout:    upstream.ReadTimeout,
+			WriteTimeout:   upstream.WriteTimeout,
+			ConnectTimeout: upstream.ConnectTimeout,
+		},
+		HealthCheck:   validators.UpstreamHealthCheck(upstream.HealthCheck),
+		UpstreamNodes: make([]validators.UpstreamNodeAddUpdate, 0),
+	}
+	for _, node := range upstream.Nodes {
+		request.UpstreamNodes = append(request.UpstreamNodes, validators.UpstreamNodeAddUpdate{
+			NodeIp:      strings.TrimSpace(node.NodeIP),
+			NodePort:    node.NodePort,
+			NodeWeight:  node.NodeWeight,
+			Health:      node.Health,
+			HealthCheck: node.HealthCheck,
+		})
+	}
+
+	validators.CorrectUpstreamDefault(&request)
+	validators.CorrectUpstreamAddNodes(&request.UpstreamNodes)
+	if msg, validateErr := packages.ValidateRequestParams(&request); validateErr != nil {
+		err = fmt.Errorf("[%s]%s", upstream.Name, msg)
+		return
+	}
+
+	upstreamInfo, ok := data.upstreams[upstream.Name]
+	if !ok {
+		change = &ApplyChange{
+			Action:   utils.ApplyActionCreate,
+			Resource: utils.ApplyResourceUpstream,
+			Name:     upstream.Name,
+			run: func(ctx *applyContext) error {
+				if err := NewServiceUpstream().CheckExistName([]string{request.Name}, []string{}); err != nil {
+					return err
+				}
+
+				resId, err := NewServiceUpstream().UpstreamCreate(&request)
+				ctx.upstreamResIds[upstream.Name] = resId
+
+				return err
+			},
+		}
+		return
+	}
+
+	fields := make([]string, 0)
+	if upstreamInfo.Algorithm != request.LoadBalance {
+		fields = append(fields, "load_balance")
+	}
+	if (upstreamInfo.HashOn != request.HashOn) || (upstreamInfo.HashKey != request.HashKey) {
+		fields = append(fields, "hash_on")
+	}
+	if upstreamInfo.ConnectTimeout != request.ConnectTimeout {
+		fields = append(fields, "connect_timeout")
+	}
+	if upstreamInfo.WriteTimeout != request.WriteTimeout {
+		fields = append(fields, "write_timeout")
+	}
+	if upstreamInfo.ReadTimeout != request.ReadTimeout {
+		fields = append(fields, "read_timeout")
+	}
+	if upstreamHealthCheckItem(upstreamInfo) != UpstreamHealthCheckItem(request.HealthCheck) {
+		fields = append(fields, "health_check")
+	}
+
+	nodeList := data.upstreamNodes[upstreamInfo.ResID]
+	nodeChanged := len(nodeList) != len(request.UpstreamNodes)
+	nodeMap := make(map[string]models.UpstreamNodes)
+	for _, nodeInfo := range nodeList {
+		nodeMap[applyNodeKey(nodeInfo.NodeIP, nodeInfo.NodePort)] = nodeInfo
+	}
+	for _, node := range request.UpstreamNodes {
+		nodeInfo, exist := nodeMap[applyNodeKey(node.NodeIp, node.NodePort)]
+		if !exist || (nodeInfo.NodeWeight != node.NodeWeight) || (nodeInfo.Health != node.Health) ||
+			(nodeInfo.HealthCheck != node.HealthCheck) {
+			nodeChanged = true
+		}
+	}
+	if nodeChanged {
+		fields = append(fields, "nodes")
+	}
+
+	enableChanged := upstreamInfo.Enable != request.Enable
+	if enableChanged {
+		fields = append(fields, "enable")
+	}
+
+	if len(fields) == 0 {
+		return
+	}
+
+	configChanged := len(fields) > 1 || !enableChanged
+	change = &ApplyChange{
+		Action:   utils.ApplyActionUpdate,
+		Resource: utils.ApplyResourceUpstream,
+		Name:     upstream.Name,
+		ResID:    upstreamInfo.ResID,
+		Fields:   fields,
+		run: func(ctx *applyContext) error {
+			if configChanged {
+				if err := NewServiceUpstream().UpstreamUpdate(upstreamInfo.ResID, &request); err != nil {
+					return err
+				}
+			}
+			if enableChanged {
+				return NewServiceUpstream().UpstreamSwitchEnable(upstreamInfo.ResID, request.Enable)
+			}
+
+			return nil
+		},
+	}
+
+	return
+}
+
+func applyServiceChange(service ApplyService, data *applyDataState) (change *ApplyChange, err error) {
+	request := validators.ServiceAddUpdate{
+		Name:           service.Name,
+		Enable:         applyEnableDefault(service.Enable),
+		Release:        utils.ReleaseN,
+		Protocol:       service.Protocol,
+		ServiceDomains: make([]string, 0),
+	}
+	domainMap := make(map[string]byte)
+	for _, domain := range service.Domains {
+		domain = strings.TrimSpace(domain)
+		if _, ok := domainMap[domain]; ok || (len(domain) == 0) {
+			continue
+		}
+		domainMap[domain] = 0
+		request.ServiceDomains = append(request.ServiceDomains, domain)
+	}
+
+	validators.CorrectServiceAttributesDefault(&request)
+	if msg, validateErr := packages.ValidateRequestParams(&request); validateErr != nil {
+		err = fmt.Errorf("[%s]%s", service.Name, msg)
+		return
+	}
+
+	serviceInfo, ok := data.services[service.Name]
+	if !ok {
+		change = &ApplyChange{
+			Action:   utils.ApplyActionCreate,
+			Resource: utils.ApplyResourceService,
+			Name:     service.Name,
+			run: func(ctx *applyContext) error {
+				if err := NewServicesService().CheckExistDomain(request.ServiceDomains, []string{}); err != nil {
+					return err
+				}
+
+				resId, err := NewServicesService().ServiceCreate(&request)
+				ctx.serviceResIds[service.Name] = resId
+
+				return err
+			},
+		}
+		return
+	}
+
+	fields := make([]string, 0)
+	if serviceInfo.Protocol != request.Protocol {
+		fields = append(fields, "protocol")
+	}
+	if serviceInfo.Enable != request.Enable {
+		fields = append(fields, "enable")
+	}
+
+	existDomains := append([]string{}, data.serviceDomains[serviceInfo.ResID]...)
+	requestDomains := append([]string{}, request.ServiceDomains...)
+	sort.Strings(existDomains)
+	sort.Strings(requestDomains)
+	if strings.Join(existDomains, ",") != strings.Join(requestDomains, ",") {
+		fields = append(fields, "domains")
+	}
+
+	if len(fields) == 0 {
+		return
+	}
+
+	change = &ApplyChange{
+		Action:   utils.ApplyActionUpdate,
+		Resource: utils.ApplyResourceService,
+		Name:     service.Name,
+		ResID:    serviceInfo.ResID,
+		Fields:   fields,
+		run: func(ctx *applyContext) error {
+			if err := NewServicesService().CheckExistDomain(request.ServiceDomains, []string{serviceInfo.ResID}); err != nil {
+				return err
+			}
+
+			return NewServicesService().ServiceUpdate(serviceInfo.ResID, &request)
+		},
+	}
+
+	return
+}
+
+func applyRouterChange(serviceName string, router ApplyRouter, data *applyDataState) (change *ApplyChange, err error) {
+	routerKey := applyRouterKey(serviceName, strings.TrimSpace(router.Path))
+
+	request := validators.ValidatorRouterAddUpdate{
+		RouterName:     router.Name,
+		RequestMethods: router.Methods,
+		RouterPath:     router.Path,
+		Enable:         applyEnableDefault(router.Enable),
+	}
+	if len(strings.TrimSpace(request.RequestMethods)) == 0 {
+		request.RequestMethods = utils.RequestMethodALL
+	}
+
+	validators.GetRouterAttributesDefault(&request)
+	if msg, validateErr := packages.ValidateRequestParams(&request); validateErr != nil {
+		err = fmt.Errorf("[%s]%s", routerKey, msg)
+		return
+	}
+
+	resolveRequest := func(ctx *applyContext) validators.ValidatorRouterAddUpdate {
+		resolved := request
+		resolved.ServiceResID = ctx.serviceResIds[serviceName]
+		if len(router.Upstream) != 0 {
+			resolved.UpstreamResID = ctx.upstreamResIds[router.Upstream]
+		}
+
+		return resolved
+	}
+
+	routerInfo, ok := data.routers[routerKey]
+	if !ok {
+		change = &ApplyChange{
+			Action:   utils.ApplyActionCreate,
+			Resource: utils.ApplyResourceRouter,
+			Name:     routerKey,
+			run: func(ctx *applyContext) error {
+				resolved := resolveRequest(ctx)
+				if err := CheckExistServiceRouterPath(resolved.ServiceResID, resolved.RouterPath, []string{}); err != nil {
+					return err
+				}
+
+				resId, err := RouterCreate(&resolved)
+				ctx.routerResIds[routerKey] = resId
+
+				return err
+			},
+		}
+		return
+	}
+
+	fields := make([]string, 0)
+	if (len(request.RouterName) != 0) && (routerInfo.RouterName != request.RouterName) {
+		fields = append(fields, "name")
+	}
+	if routerInfo.RequestMethods != request.RequestMethods {
+		fields = append(fields, "methods")
+	}
+	if data.upstreamNameByIds[routerInfo.UpstreamResID] != router.Upstream {
+		fields = append(fields, "upstream")
+	}
+	if routerInfo.Enable != request.Enable {
+		fields = append(fields, "enable")
+	}
+
+	if len(fields) == 0 {
+		return
+	}
+
+	change = &ApplyChange{
+		Action:   utils.ApplyActionUpdate,
+		Resource: utils.ApplyResourceRouter,
+		Name:     routerKey,
+		ResID:    routerInfo.ResID,
+		Fields:   fields,
+		run: func(ctx *applyContext) error {
+			return RouterUpdate(routerInfo.ResID, resolveRequest(ctx))
+		},
+	}
+
+	return
+}
+
+func applyPluginConfigChange(configType int, targetName string, targetId string, pluginConfig ApplyPluginConfig,
+	data *applyDataState, pluginConfigKeys map[string]byte) (change *ApplyChange, err error) {
+	name := targetName + ":" + pluginConfig.Key
+
+	if _, ok := pluginConfigKeys[applyPluginConfigKey(configType, targetName, pluginConfig.Key)]; ok {
+		err = fmt.Errorf(enums.CodeMessages(enums.ApplyNameRepeat), name)
+		return
+	}
+	pluginConfigKeys[applyPluginConfigKey(configType, targetName, pluginConfig.Key)] = 0
+
+	pluginInfo, ok := data.plugins[pluginConfig.Key]
+	if !ok {
+		err = fmt.Errorf(enums.CodeMessages(enums.ApplyReferenceNull), name, pluginConfig.Key)
+		return
+	}
+
+	pluginContext, err := plugins.NewPluginContext(pluginConfig.Key)
+	if err != nil {
+		return
+	}
+	if err = pluginContext.StrategyPluginCheck(pluginConfig.Config); err != nil {
+		err = fmt.Errorf("[%s]%s", name, err.Error())
+		return
+	}
+	config, _ := pluginContext.StrategyPluginParse(pluginConfig.Config)
+	configJson, err := json.Marshal(config)
+	if err != nil {
+		return
+	}
+
+	enable := applyEnableDefault(pluginConfig.Enable)
+	resolveTargetId := func(ctx *applyContext) string {
+		if configType == models.PluginConfigsTypeService {
+			return ctx.serviceResIds[targetName]
+		}
+
+		return ctx.routerResIds[targetName]
+	}
+
+	pluginConfigInfo, ok := data.pluginConfigs[applyPluginConfigKey(configType, targetId, pluginConfig.Key)]
+	if (len(targetId) == 0) || !ok {
+		change = &ApplyChange{
+			Action:   utils.ApplyActionCreate,
+			Resource: utils.ApplyResourcePluginConfig,
+			Name:     name,
+			run: func(ctx *applyContext) error {
+				_, err := NewPluginsService().PluginConfigAdd(&validators.ValidatorPluginConfigAdd{
+					Name:     pluginConfig.Name,
+					PluginID: pluginInfo.ResID,
+					Type:     configType,
+					TargetID: resolveTargetId(ctx),
+					Enable:   enable,
+					Config:   pluginConfig.Config,
+				})
+
+				return err
+			},
+		}
+		return
+	}
+
+	fields := make([]string, 0)
+	if (len(pluginConfig.Name) != 0) && (pluginConfigInfo.Name != pluginConfig.Name) {
+		fields = append(fields, "name")
+	}
+	existConfigJson, err := pluginConfigPlaintext(pluginConfigInfo.PluginKey, pluginConfigInfo.Config)
+	if err != nil {
+		return
+	}
+	configChanged := existConfigJson != string(configJson)
+	if configChanged {
+		fields = append(fields, "config")
+	}
+	enableChanged := pluginConfigInfo.Enable != enable
+	if enableChanged {
+		fields = append(fields, "enable")
+	}
+
+	if len(fields) == 0 {
+		return
+	}
+
+	change = &ApplyChange{
+		Action:   utils.ApplyActionUpdate,
+		Resource: utils.ApplyResourcePluginConfig,
+		Name:     name,
+		ResID:    pluginConfigInfo.ResID,
+		Fields:   fields,
+		run: func(ctx *applyContext) error {
+			if (len(fields) > 1) || !enableChanged {
+				pluginConfigName := pluginConfig.Name
+				if len(pluginConfigName) == 0 {
+					pluginConfigName = pluginConfigInfo.Name
+				}
+
+				err := NewPluginsService().PluginConfigUpdate(&validators.ValidatorPluginConfigUpdate{
+					PluginConfigId: pluginConfigInfo.ResID,
+					Name:           pluginConfigName,
+					Config:         pluginConfig.Config,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if enableChanged {
+				return NewPluginsService().PluginConfigSwitchEnable(pluginConfigInfo.ResID, enable)
+			}
+
+			return nil
+		},
+	}
+
+	return
+}
+
+// applyPruneChanges 配置文件外的数据按 插件配置 → 路由 → 服务 → 上游 的顺序删除
+func applyPruneChanges(data *applyDataState, upstreamNames map[string]byte, serviceNames map[string]byte,
+	routerKeys map[string]byte, pluginConfigKeys map[string]byte) []ApplyChange {
+	changes := make([]ApplyChange, 0)
+
+	routerKeyByIds := make(map[string]string)
+	for _, routerInfo := range data.routerList {
+		routerKeyByIds[routerInfo.ResID] = applyRouterKey(data.serviceNameByIds[routerInfo.ServiceResID], routerInfo.RouterPath)
+	}
+
+	for _, pluginConfigInfo := range data.pluginConfigList {
+		targetName := data.serviceNameByIds[pluginConfigInfo.TargetID]
+		if pluginConfigInfo.Type == models.PluginConfigsTypeRouter {
+			targetName = routerKeyByIds[pluginConfigInfo.TargetID]
+		}
+		if _, ok := pluginConfigKeys[applyPluginConfigKey(pluginConfigInfo.Type, targetName, pluginConfigInfo.PluginKey)]; ok {
+			continue
+		}
+
+		resId := pluginConfigInfo.ResID
+		changes = append(changes, ApplyChange{
+			Action:   utils.ApplyActionDelete,
+			Resource: utils.ApplyResourcePluginConfig,
+			Name:     targetName + ":" + pluginConfigInfo.PluginKey,
+			ResID:    resId,
+			run: func(ctx *applyContext) error {
+				return NewPluginsService().PluginConfigDelete(resId)
+			},
+		})
+	}
+
+	for _, routerInfo := range data.routerList {
+		routerKey := routerKeyByIds[routerInfo.ResID]
+		if _, ok := routerKeys[routerKey]; ok {
+			continue
+		}
+
+		resId := routerInfo.ResID
+		changes = append(changes, ApplyChange{
+			Action:   utils.ApplyActionDelete,
+			Resource: utils.ApplyResourceRouter,
+			Name:     routerKey,
+			ResID:    resId,
+			run: func(ctx *applyContext) error {
+				return RouterDelete(resId)
+			},
+		})
+	}
+
+	for _, serviceInfo := range data.serviceList {
+		if _, ok := serviceNames[serviceInfo.Name]; ok && (data.services[serviceInfo.Name].ResID == serviceInfo.ResID) {
+			continue
+		}
+
+		resId := serviceInfo.ResID
+		changes = append(changes, ApplyChange{
+			Action:   utils.ApplyActionDelete,
+			Resource: utils.ApplyResourceService,
+			Name:     serviceInfo.Name,
+			ResID:    resId,
+			run: func(ctx *applyContext) error {
+				return NewServicesService().ServiceDelete(resId)
+			},
+		})
+	}
+
+	for _, upstreamInfo := range data.upstreamList {
+		if _, ok := upstreamNames[upstreamInfo.Name]; ok && (data.upstreams[upstreamInfo.Name].ResID == upstreamInfo.ResID) {
+			continue
+		}
+
+		resId := upstreamInfo.ResID
+		changes = append(changes, ApplyChange{
+			Action:   utils.ApplyActionDelete,
+			Resource: utils.ApplyResourceUpstream,
+			Name:     upstreamInfo.Name,
+			ResID:    resId,
+			run: func(ctx *applyContext) error {
+				return NewServiceUpstream().UpstreamDelete(resId)
+			},
+		})
+	}
+
+	return changes
+}
+
+// ApplyExecute 按计划顺序通过服务层执行变更，遇到错误立即停止，已执行的变更不会回滚
+func ApplyExecute(plan *ApplyPlan) error {
+	data, err := loadApplyDataState()
+	if err != nil {
+		return err
+	}
+
+	ctx := &applyContext{
+		upstreamResIds: make(map[string]string),
+		serviceResIds:  make(map[string]string),
+		routerResIds:   make(map[string]string),
+	}
+	for name, upstreamInfo := range data.upstreams {
+		ctx.upstreamResIds[name] = upstreamInfo.ResID
+	}
+	for name, serviceInfo := range data.services {
+		ctx.serviceResIds[name] = serviceInfo.ResID
+	}
+	for routerKey, routerInfo := range data.routers {
+		ctx.routerResIds[routerKey] = routerInfo.ResID
+	}
+
+	for _, change := range plan.Changes {
+		if err = change.run(ctx); err != nil {
+			return fmt.Errorf("%s %s [%s] failed: %s", change.Action, change.Resource, change.Name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// ApplyRelease 按 上游 → 服务 → 路由 的顺序发布配置文件中未发布或待发布的资源，返回发布的资源名称
+func ApplyRelease(plan *ApplyPlan, operator string) (released []string, err error) {
+	data, err := loadApplyDataState()
+	if err != nil {
+		return
+	}
+
+	released = make([]string, 0)
+	for _, name := range plan.upstreamNames {
+		upstreamInfo, ok := data.upstreams[name]
+		if !ok || (upstreamInfo.Release == utils.ReleaseStatusY) {
+			continue
+		}
+		if err = NewServiceUpstream().UpstreamSwitchRelease(upstreamInfo.ResID, operator); err != nil {
+			err = fmt.Errorf("release %s [%s] failed: %s", utils.ApplyResourceUpstream, name, err.Error())
+			return
+		}
+		released = append(released, utils.ApplyResourceUpstream+" "+name)
+	}
+
+	for _, name := range plan.serviceNames {
+		serviceInfo, ok := data.services[name]
+		if !ok || (serviceInfo.Release == utils.ReleaseStatusY) {
+			continue
+		}
+		if err = NewServicesService().ServiceRelease(serviceInfo.ResID, operator); err != nil {
+			err = fmt.Errorf("release %s [%s] failed: %s", utils.ApplyResourceService, name, err.Error())
+			return
+		}
+		released = append(released, utils.ApplyResourceService+" "+name)
+	}
+
+	routerResIds := make([]string, 0)
+	routerNames := make([]string, 0)
+	for _, routerKey := range plan.routerKeys {
+		routerInfo, ok := data.routers[routerKey]
+		if !ok || (routerInfo.Release == utils.ReleaseStatusY) {
+			continue
+		}
+		routerResIds = append(routerResIds, routerInfo.ResID)
+		routerNames = append(routerNames, utils.ApplyResourceRouter+" "+routerKey)
+	}
+	if len(routerResIds) == 0 {
+		return
+	}
+	if err = RouterRelease(routerResIds, utils.ReleaseTypePush, operator); err != nil {
+		err = errors.New("release routers failed: " + err.Error())
+		return
+	}
+	released = append(released, routerNames...)
+
+	return
+}
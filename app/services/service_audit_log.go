@@ -0,0 +1,302 @@
+package services
+
+import (
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+)
+
+// auditTable 资源在表 Table 中按列 Column 与资源ID关联的数据
+type auditTable struct {
+	Table  string
+	Column string
+}
+
+// AuditResource 审计日志中的资源，Self 表示修改的是当前登录用户自身
+type AuditResource struct {
+	Type   string
+	Tables []auditTable
+	Self   bool
+}
+
+type auditRoute struct {
+	Prefix   string
+	Resource AuditResource
+}
+
+type AuditLogItem struct {
+	ID            int         `json:"id"`
+	Operator      string      `json:"operator"`
+	Method        string      `json:"method"`
+	Path          string      `json:"path"`
+	ResourceType  string      `json:"resource_type"`
+	ResourceResID string      `json:"resource_res_id"`
+	Before        interface{} `json:"before"`
+	After         interface{} `json:"after"`
+	ClientIp      string      `json:"client_ip"`
+	ResultCode    int         `json:"result_code"`
+	CreatedAt     int64       `json:"created_at"`
+}
+
+var (
+	// auditRoutes 按路由前缀匹配资源，较长的前缀需要排在前面
+	auditRoutes = []auditRoute{
+		{"/admin/user/api-token", AuditResource{Type: "user_api_token", Tables: []auditTable{
+			{(&models.UserApiTokens{}).TableName(), "res_id"},
+		}}},
+		{"/admin/user/session", AuditResource{Type: "user_session", Tables: []auditTable{
+			{(&models.UserTokens{}).TableName(), "res_id"},
+		}}},
+		{"/admin/user/totp", AuditResource{Type: "user_totp", Self: true, Tables: []auditTable{
+			{(&models.UserTotps{}).TableName(), "user_res_id"},
+		}}},
+		{"/admin/user/manage/reset/totp", AuditResource{Type: "user_totp", Tables: []auditTable{
+			{(&models.UserTotps{}).TableName(), "user_res_id"},
+		}}},
+		{"/admin/user/manage", AuditResource{Type: "user", Tables: []auditTable{
+			{(&models.Users{}).TableName(), "res_id"},
+			{(&models.UserRoles{}).TableName(), "user_res_id"},
+		}}},
+		{"/admin/user/role", AuditResource{Type: "user_role", Tables: []auditTable{
+			{(&models.UserRoles{}).TableName(), "user_res_id"},
+			{(&models.UserServicePermissions{}).TableName(), "user_res_id"},
+		}}},
+		{"/admin/user", AuditResource{Type: "user", Self: true, Tables: []auditTable{
+			{(&models.Users{}).TableName(), "res_id"},
+		}}},
+		{"/admin/service/plugin/config", AuditResource{Type: "service_plugin_config", Tables: []auditTable{
+			{(&models.PluginConfigs{}).TableName(), "res_id"},
+		}}},
+		{"/admin/service", AuditResource{Type: "service", Tables: []auditTable{
+			{(&models.Services{}).TableName(), "res_id"},
+			{(&models.ServiceDomains{}).TableName(), "service_res_id"},
+		}}},
+		{"/admin/router/plugin/config", AuditResource{Type: "router_plugin_config", Tables: []auditTable{
+			{(&models.PluginConfigs{}).TableName(), "res_id"},
+		}}},
+		{"/admin/router", AuditResource{Type: "router", Tables: []auditTable{
+			{(&models.Routers{}).TableName(), "res_id"},
+		}}},
+		{"/admin/upstream", AuditResource{Type: "upstream", Tables: []auditTable{
+			{(&models.Upstreams{}).TableName(), "res_id"},
+			{(&models.UpstreamNodes{}).TableName(), "upstream_res_id"},
+		}}},
+		{"/admin/certificate", AuditResource{Type: "certificate", Tables: []auditTable{
+			{(&models.Certificates{}).TableName(), "res_id"},
+		}}},
+		{"/admin/cluster-node", AuditResource{Type: "cluster_node", Tables: []auditTable{
+			{(&models.ClusterNodes{}).TableName(), "id"},
+		}}},
+		{"/admin/change-set", AuditResource{Type: "change_set", Tables: []auditTable{
+			{(&models.ChangeSets{}).TableName(), "res_id"},
+		}}},
+		{"/admin/webhook/delivery", AuditResource{Type: "webhook_delivery", Tables: []auditTable{
+			{(&models.WebhookDeliveries{}).TableName(), "res_id"},
+		}}},
+		{"/admin/webhook", AuditResource{Type: "webhook", Tables: []auditTable{
+			{(&models.Webhooks{}).TableName(), "res_id"},
+		}}},
+		{"/admin/acme", AuditResource{Type: "acme_certificate", Tables: []auditTable{
+			{(&models.AcmeCertificates{}).TableName(), "res_id"},
+		}}},
+		{"/admin/snapshot", AuditResource{Type: "snapshot"}},
+		{"/admin/drift", AuditResource{Type: "drift"}},
+	}
+
+	// auditSensitiveKeys 审计日志中不记录原文的字段
+	auditSensitiveKeys = map[string]bool{
+		"password":      true,
+		"old_password":  true,
+		"private_key":   true,
+		"secret":        true,
+		"jwt_key":       true,
+		"client_secret": true,
+		"token":         true,
+		"token_hash":    true,
+		"code":          true,
+		"code_hash":     true,
+	}
+
+	auditFile      *os.File
+	auditFileMutex sync.Mutex
+)
+
+// AuditResourceByPath 按路由匹配审计的资源，未登记的路由资源类型为空
+func AuditResourceByPath(path string) AuditResource {
+	for _, route := range auditRoutes {
+		if (path == route.Prefix) || strings.HasPrefix(path, route.Prefix+"/") {
+			return route.Resource
+		}
+	}
+
+	return AuditResource{}
+}
+
+// AuditSelfResId 修改当前登录用户自身时，资源ID为该用户的ID
+func AuditSelfResId(email string) string {
+	return (&models.Users{}).UserInfoByEmail(email).ResID
+}
+
+// AuditSnapshot 按表名记录资源的数据，敏感字段脱敏，资源不存在时返回空字符串
+func AuditSnapshot(resource AuditResource, resId string) string {
+	if (len(resource.Tables) == 0) || (len(resId) == 0) {
+		return ""
+	}
+
+	snapshot := make(map[string]interface{})
+	for _, table := range resource.Tables {
+		rows, err := (&models.AuditLogs{}).ResourceRows(table.Table, table.Column, resId)
+		if err != nil {
+			packages.Log.Error("audit snapshot error", err.Error())
+			return ""
+		}
+
+		if len(rows) != 0 {
+			snapshot[table.Table] = auditMask(rows)
+		}
+	}
+
+	if len(snapshot) == 0 {
+		return ""
+	}
+
+	snapshotJson, err := json.Marshal(snapshot)
+	if err != nil {
+		return ""
+	}
+
+	return string(snapshotJson)
+}
+
+// AuditRequestBody 新增资源时请求中没有资源ID，以脱敏后的请求体作为修改后的内容
+func AuditRequestBody(body []byte) string {
+	var params interface{}
+	if json.Unmarshal(body, &params) != nil {
+		return ""
+	}
+
+	bodyJson, err := json.Marshal(map[string]interface{}{"request": auditMask(params)})
+	if err != nil {
+		return ""
+	}
+
+	return string(bodyJson)
+}
+
+// AuditLogAdd 写入审计日志，配置了 audit.file 时同时以 JSON Lines 格式追加写入文件
+func AuditLogAdd(operator string, method string, path string, resource AuditResource, resId string,
+	before string, after string, clientIp string, resultCode int) {
+	auditLog := &models.AuditLogs{
+		Operator:      operator,
+		Method:        method,
+		Path:          path,
+		ResourceType:  resource.Type,
+		ResourceResID: resId,
+		BeforeData:    before,
+		AfterData:     after,
+		ClientIp:      clientIp,
+		ResultCode:    resultCode,
+	}
+	if err := (&models.AuditLogs{}).AuditLogAdd(auditLog); err != nil {
+		packages.Log.Error("audit log add error", err.Error())
+	}
+
+	if len(packages.ConfigAudit.File) == 0 {
+		return
+	}
+
+	line, err := json.Marshal(auditLogItem(*auditLog))
+	if err != nil {
+		return
+	}
+
+	auditFileMutex.Lock()
+	defer auditFileMutex.Unlock()
+
+	if auditFile == nil {
+		auditFile, err = os.OpenFile(packages.ConfigAudit.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+		if err != nil {
+			auditFile = nil
+			packages.Log.Error("audit file open error", err.Error())
+			return
+		}
+	}
+
+	if _, err = auditFile.Write(append(line, '\n')); err != nil {
+		packages.Log.Error("audit file write error", err.Error())
+	}
+}
+
+func AuditLogList(param *validators.AuditLogList) (list []AuditLogItem, total int, err error) {
+	auditLogList, total, err := (&models.AuditLogs{}).AuditLogListPage(param)
+	if err != nil {
+		return
+	}
+
+	list = make([]AuditLogItem, 0)
+	for _, auditLog := range auditLogList {
+		list = append(list, auditLogItem(auditLog))
+	}
+
+	return
+}
+
+func auditLogItem(auditLog models.AuditLogs) AuditLogItem {
+	item := AuditLogItem{
+		ID:            auditLog.ID,
+		Operator:      auditLog.Operator,
+		Method:        auditLog.Method,
+		Path:          auditLog.Path,
+		ResourceType:  auditLog.ResourceType,
+		ResourceResID: auditLog.ResourceResID,
+		ClientIp:      auditLog.ClientIp,
+		ResultCode:    auditLog.ResultCode,
+		CreatedAt:     auditLog.CreatedAt.Unix(),
+	}
+
+	if len(auditLog.BeforeData) != 0 {
+		_ = json.Unmarshal([]byte(auditLog.BeforeData), &item.Before)
+	}
+	if len(auditLog.AfterData) != 0 {
+		_ = json.Unmarshal([]byte(auditLog.AfterData), &item.After)
+	}
+
+	return item
+}
+
+// auditMask 递归替换敏感字段的值，MySQL 驱动返回的 []byte 转换为字符串
+func auditMask(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []map[string]interface{}:
+		list := make([]interface{}, 0)
+		for _, item := range v {
+			list = append(list, auditMask(item))
+		}
+		return list
+	case []interface{}:
+		list := make([]interface{}, 0)
+		for _, item := range v {
+			list = append(list, auditMask(item))
+		}
+		return list
+	case map[string]interface{}:
+		masked := make(map[string]interface{})
+		for key, item := range v {
+			if auditSensitiveKeys[strings.ToLower(key)] {
+				masked[key] = utils.SecretMask
+				continue
+			}
+			masked[key] = auditMask(item)
+		}
+		return masked
+	case []byte:
+		return string(v)
+	}
+
+	return value
+}
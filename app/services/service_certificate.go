@@ -9,6 +9,7 @@ import (
 	"apioak-admin/app/validators"
 	"errors"
 	"gorm.io/gorm"
+	"strings"
 	"sync"
 )
 
@@ -29,70 +30,146 @@ func NewCertificateService() *CertificateService {
 	return certificateService
 }
 
+// generateCertificatePutRequest 私钥加密存储，只在同步至数据面时解密，SNI 为证书覆盖的全部 SNI
+func generateCertificatePutRequest(tx *gorm.DB, certificate models.Certificates) (rpc.CertificatePutRequest, error) {
+	privateKey, err := utils.Decrypt(certificate.PrivateKey)
+	if err != nil {
+		return rpc.CertificatePutRequest{}, err
+	}
+
+	sniMap, err := (&models.CertificateSnis{}).CertificateSniMap(tx, []string{certificate.ResID})
+	if err != nil {
+		return rpc.CertificatePutRequest{}, err
+	}
+	snis, ok := sniMap[certificate.ResID]
+	if !ok {
+		snis = []string{certificate.Sni}
+	}
+
+	return rpc.CertificatePutRequest{
+		Name: certificate.ResID,
+		Sni:  snis,
+		Cert: certificate.Certificate,
+		Key:  privateKey,
+	}, nil
+}
+
+// syncDataSideCertificate 同步证书至数据面，SNI 全部被新证书覆盖的已启用证书会被替换，
+// 部分 SNI 重叠的证书保持启用，域名按 CertificateInfoByDomainSniInfos 的规则选择证书
 func syncDataSideCertificate(tx *gorm.DB, new *models.Certificates, filterID string) error {
 
-	existSniCertificate, err := (&models.Certificates{}).EnableCertificateInfoBySni(new.Sni, filterID)
+	sniMap, err := (&models.CertificateSnis{}).CertificateSniMap(tx, []string{new.ResID})
 	if err != nil {
 		return err
 	}
+	newSnis, ok := sniMap[new.ResID]
+	if !ok {
+		newSnis = []string{new.Sni}
+	}
 
-	tmpDeleteRes := false
-	// 相同域名的证书已启用时需要将已启用的证书关闭，并同步至数据面
-	if existSniCertificate.Enable == utils.EnableOn {
-		// 修改控制面旧证书启用状态
-		err := (&models.Certificates{}).CertificateSwitchEnable(tx, existSniCertificate.ResID, utils.EnableOff)
+	existCertificates, err := (&models.Certificates{}).EnableCertificateListBySnis(tx, newSnis, filterID)
+	if err != nil {
+		return err
+	}
+
+	existResIds := make([]string, 0, len(existCertificates))
+	for _, existCertificate := range existCertificates {
+		existResIds = append(existResIds, existCertificate.ResID)
+	}
+	existSniMap, err := (&models.CertificateSnis{}).CertificateSniMap(tx, existResIds)
+	if err != nil {
+		return err
+	}
 
+	newSniMap := make(map[string]bool, len(newSnis))
+	for _, sni := range newSnis {
+		newSniMap[sni] = true
+	}
+
+	replacedCertificates := make([]models.Certificates, 0)
+	// 旧证书的 SNI 全部被新证书覆盖时需要将旧证书关闭，并同步至数据面
+	for _, existCertificate := range existCertificates {
+		covered := true
+		for _, sni := range existSniMap[existCertificate.ResID] {
+			if !newSniMap[sni] {
+				covered = false
+				break
+			}
+		}
+		if !covered {
+			continue
+		}
+
+		// 修改控制面旧证书启用状态
+		err = (&models.Certificates{}).CertificateSwitchEnable(tx, existCertificate.ResID, utils.EnableOff)
 		if err != nil {
-			return nil
+			return err
 		}
 		// 同步数据面旧证书启用状态
-		err = rpc.NewApiOak().CertificateDelete(existSniCertificate.ResID)
+		err = rpc.NewApiOak().CertificateDelete(existCertificate.ResID)
 		if err != nil {
-			return nil
+			rollbackDataSideCertificates(tx, replacedCertificates)
+			return err
 		}
-		tmpDeleteRes = true
+		replacedCertificates = append(replacedCertificates, existCertificate)
+		packages.Log.Info("certificate replaced ", existCertificate.ResID, " by ", new.ResID)
 	}
 
 	// 新增数据面证书信息
-	request := &rpc.CertificatePutRequest{
-		Name: new.ResID,
-		Sni:  []string{new.Sni},
-		Cert: new.Certificate,
-		Key:  new.PrivateKey,
+	request, err := generateCertificatePutRequest(tx, *new)
+	if err != nil {
+		rollbackDataSideCertificates(tx, replacedCertificates)
+		return err
 	}
-	err = rpc.NewApiOak().CertificatePut(request)
+	err = rpc.NewApiOak().CertificatePut(&request)
 	if err != nil {
-		// 同步删除过数据面旧证书信息时需要回滚，控制面数据根据事务自动回滚
-		if tmpDeleteRes != false {
-			request := &rpc.CertificatePutRequest{
-				Name: existSniCertificate.ResID,
-				Sni:  []string{existSniCertificate.Sni},
-				Cert: existSniCertificate.Certificate,
-				Key:  existSniCertificate.PrivateKey,
-			}
-			err = rpc.NewApiOak().CertificatePut(request)
-			if err != nil {
-				packages.Log.Error("rollback old data side certificate error")
-				return err
-			}
-		}
+		rollbackDataSideCertificates(tx, replacedCertificates)
 		return err
 	}
 
 	return nil
 }
 
+// rollbackDataSideCertificates 同步删除过数据面旧证书信息时需要回滚，控制面数据根据事务自动回滚
+func rollbackDataSideCertificates(tx *gorm.DB, certificates []models.Certificates) {
+	for _, certificate := range certificates {
+		request, err := generateCertificatePutRequest(tx, certificate)
+		if err == nil {
+			err = rpc.NewApiOak().CertificatePut(&request)
+		}
+		if err != nil {
+			packages.Log.Error("rollback old data side certificate [", certificate.ResID, "] error: ", err)
+		}
+	}
+}
+
 // CertificateAdd
-func (s *CertificateService) CertificateAdd(request *validators.CertificateAddUpdate) error {
-	certificateInfo, err := utils.DiscernCertificate(&request.Certificate)
+func (s *CertificateService) CertificateAdd(request *validators.CertificateAddUpdate) (string, error) {
+	certificateInfo, err := utils.VerifyCertificate(request.Certificate, request.PrivateKey, request.Sni)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	_, err = utils.EncryptionPlaintextCheck(request.PrivateKey)
+	if err != nil {
+		return "", err
 	}
+
+	privateKey, err := utils.Encrypt(request.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	certificateResId := ""
 	err = packages.GetDb().Transaction(func(tx *gorm.DB) error {
 
 		certificates := &models.Certificates{
 			Certificate: request.Certificate,
-			PrivateKey:  request.PrivateKey,
+			PrivateKey:  privateKey,
+			Issuer:      certificateInfo.Issuer,
+			Subject:     certificateInfo.Subject,
+			Sans:        strings.Join(certificateInfo.Sans, ","),
+			Fingerprint: certificateInfo.Fingerprint,
 			ExpiredAt:   certificateInfo.NotAfter,
 			Enable:      request.Enable,
 			Sni:         request.Sni,
@@ -104,6 +181,11 @@ func (s *CertificateService) CertificateAdd(request *validators.CertificateAddUp
 			return err
 		}
 		certificates.ResID = resID
+
+		err = (&models.CertificateSnis{}).CertificateSnisReplace(tx, resID, utils.CertificateSnis(request.Sni, certificateInfo.Sans))
+		if err != nil {
+			return err
+		}
 		// 当前证书设置为启用状态
 		if request.Enable == utils.EnableOn {
 			err = syncDataSideCertificate(tx, certificates, "")
@@ -113,14 +195,21 @@ func (s *CertificateService) CertificateAdd(request *validators.CertificateAddUp
 			}
 		}
 
+		certificateResId = resID
+
 		return nil
 	})
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	WebhookEmit(utils.WebhookEventCertificateCreated, map[string]interface{}{
+		"res_id": certificateResId,
+		"sni":    request.Sni,
+	})
+
+	return certificateResId, nil
 }
 
 // CertificateUpdate
@@ -132,7 +221,23 @@ func (s *CertificateService) CertificateUpdate(resID string, request *validators
 		return errors.New(enums.CodeMessages(enums.CertificateNull))
 	}
 
-	discernCertificateInfo, err := utils.DiscernCertificate(&request.Certificate)
+	// 详情中的私钥已脱敏，原样提交脱敏内容时沿用已保存的私钥
+	privateKey := request.PrivateKey
+	if privateKey == utils.SecretMask {
+		privateKey, err = utils.Decrypt(certificates.PrivateKey)
+	} else {
+		_, err = utils.EncryptionPlaintextCheck(privateKey)
+	}
+	if err != nil {
+		return err
+	}
+
+	discernCertificateInfo, err := utils.VerifyCertificate(request.Certificate, privateKey, request.Sni)
+	if err != nil {
+		return err
+	}
+
+	privateKey, err = utils.Encrypt(privateKey)
 	if err != nil {
 		return err
 	}
@@ -140,7 +245,11 @@ func (s *CertificateService) CertificateUpdate(resID string, request *validators
 	err = packages.GetDb().Transaction(func(tx *gorm.DB) error {
 
 		certificates.Certificate = request.Certificate
-		certificates.PrivateKey = request.PrivateKey
+		certificates.PrivateKey = privateKey
+		certificates.Issuer = discernCertificateInfo.Issuer
+		certificates.Subject = discernCertificateInfo.Subject
+		certificates.Sans = strings.Join(discernCertificateInfo.Sans, ",")
+		certificates.Fingerprint = discernCertificateInfo.Fingerprint
 		certificates.ExpiredAt = discernCertificateInfo.NotAfter
 		certificates.Enable = request.Enable
 		certificates.Sni = request.Sni
@@ -151,6 +260,11 @@ func (s *CertificateService) CertificateUpdate(resID string, request *validators
 			return err
 		}
 
+		err = (&models.CertificateSnis{}).CertificateSnisReplace(tx, resID, utils.CertificateSnis(request.Sni, discernCertificateInfo.Sans))
+		if err != nil {
+			return err
+		}
+
 		if request.Enable == utils.EnableOn {
 			err = syncDataSideCertificate(tx, &certificates, certificates.ResID)
 
@@ -160,16 +274,30 @@ func (s *CertificateService) CertificateUpdate(resID string, request *validators
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	WebhookEmit(utils.WebhookEventCertificateUpdated, map[string]interface{}{
+		"res_id": resID,
+		"sni":    request.Sni,
+	})
 
 	return nil
 }
 
 type CertificateInfo struct {
-	ResID       string `json:"res_id"`
-	Sni         string `json:"sni"`
-	Certificate string `json:"certificate"`
-	PrivateKey  string `json:"private_key"`
-	Enable      int    `json:"enable"`
+	ResID       string   `json:"res_id"`
+	Sni         string   `json:"sni"`
+	Snis        []string `json:"snis"`
+	Certificate string   `json:"certificate"`
+	PrivateKey  string   `json:"private_key"`
+	Enable      int      `json:"enable"`
+	Issuer      string   `json:"issuer"`
+	Subject     string   `json:"subject"`
+	Sans        []string `json:"sans"`
+	Fingerprint string   `json:"fingerprint"`
+	ExpiredAt   int64    `json:"expired_at"`
 }
 
 // CertificateInfo
@@ -183,21 +311,54 @@ func (s *CertificateService) CertificateInfo(id string) (CertificateInfo, error)
 		return CertificateInfo{}, errors.New(enums.CodeMessages(enums.CertificateNull))
 	}
 
-	return CertificateInfo{
+	info := CertificateInfo{
 		ResID:       certificateInfo.ResID,
 		Sni:         certificateInfo.Sni,
 		Certificate: certificateInfo.Certificate,
-		PrivateKey:  certificateInfo.PrivateKey,
+		PrivateKey:  utils.SecretMask,
 		Enable:      certificateInfo.Enable,
-	}, nil
+		Issuer:      certificateInfo.Issuer,
+		Subject:     certificateInfo.Subject,
+		Sans:        make([]string, 0),
+		Fingerprint: certificateInfo.Fingerprint,
+		ExpiredAt:   certificateInfo.ExpiredAt.Unix(),
+	}
+	if len(certificateInfo.Sans) != 0 {
+		info.Sans = strings.Split(certificateInfo.Sans, ",")
+	}
+
+	sniMap, err := (&models.CertificateSnis{}).CertificateSniMap(packages.GetDb(), []string{certificateInfo.ResID})
+	if err != nil {
+		return CertificateInfo{}, err
+	}
+	info.Snis = sniMap[certificateInfo.ResID]
+	if len(info.Snis) == 0 {
+		info.Snis = []string{certificateInfo.Sni}
+	}
+
+	// 记录证书详情之前上传的证书实时解析
+	if len(certificateInfo.Fingerprint) == 0 {
+		if discernCertificateInfo, err := utils.DiscernCertificate(&certificateInfo.Certificate); err == nil {
+			info.Issuer = discernCertificateInfo.Issuer
+			info.Subject = discernCertificateInfo.Subject
+			if len(discernCertificateInfo.Sans) != 0 {
+				info.Sans = discernCertificateInfo.Sans
+			}
+			info.Fingerprint = discernCertificateInfo.Fingerprint
+		}
+	}
+
+	return info, nil
 
 }
 
 type CertificateItem struct {
-	ResID     string `json:"res_id"`
-	Sni       string `json:"sni"`
-	ExpiredAt int64  `json:"expired_at"`
-	Enable    int    `json:"enable"`
+	ResID     string   `json:"res_id"`
+	Sni       string   `json:"sni"`
+	Snis      []string `json:"snis"`
+	ExpiredAt int64    `json:"expired_at"`
+	Enable    int      `json:"enable"`
+	Expiring  bool     `json:"expiring"`
 }
 
 // CertificateListPage
@@ -212,14 +373,30 @@ func (s *CertificateService) CertificateListPage(param *validators.CertificateLi
 	if len(certificateList) == 0 {
 		return []CertificateItem{}, 0, nil
 	}
+	certificateResIds := make([]string, 0)
+	for _, v := range certificateList {
+		certificateResIds = append(certificateResIds, v.ResID)
+	}
+	sniMap, err := (&models.CertificateSnis{}).CertificateSniMap(packages.GetDb(), certificateResIds)
+	if err != nil {
+		return []CertificateItem{}, 0, err
+	}
+
 	list := []CertificateItem{}
 
 	for _, v := range certificateList {
+		snis, ok := sniMap[v.ResID]
+		if !ok {
+			snis = []string{v.Sni}
+		}
+
 		list = append(list, CertificateItem{
 			ResID:     v.ResID,
 			Sni:       v.Sni,
+			Snis:      snis,
 			ExpiredAt: v.ExpiredAt.Unix(),
 			Enable:    v.Enable,
+			Expiring:  CertificateExpiring(v.Enable, v.ExpiredAt),
 		})
 	}
 
@@ -229,7 +406,7 @@ func (s *CertificateService) CertificateListPage(param *validators.CertificateLi
 // CertificateDelete
 func (s *CertificateService) CertificateDelete(resID string) error {
 
-	_, err := (&models.Certificates{}).CertificateInfoById(resID)
+	certificates, err := (&models.Certificates{}).CertificateInfoById(resID)
 
 	if err != nil {
 		return errors.New(enums.CodeMessages(enums.CertificateNull))
@@ -243,6 +420,18 @@ func (s *CertificateService) CertificateDelete(resID string) error {
 			return err
 		}
 
+		err = (&models.CertificateExpiryAlerts{}).CertificateExpiryAlertDelete(tx, resID)
+
+		if err != nil {
+			return err
+		}
+
+		err = (&models.CertificateSnis{}).CertificateSnisDelete(tx, resID)
+
+		if err != nil {
+			return err
+		}
+
 		err = rpc.NewApiOak().CertificateDelete(resID)
 
 		if err != nil {
@@ -254,6 +443,11 @@ func (s *CertificateService) CertificateDelete(resID string) error {
 		return err
 	}
 
+	WebhookEmit(utils.WebhookEventCertificateDeleted, map[string]interface{}{
+		"res_id": resID,
+		"sni":    certificates.Sni,
+	})
+
 	return nil
 }
 
@@ -292,5 +486,68 @@ func (s *CertificateService) CertificateSwitchEnable(resID string, enable int) e
 	if err != nil {
 		return err
 	}
+
+	WebhookEmit(utils.WebhookEventCertificateUpdated, map[string]interface{}{
+		"res_id": resID,
+		"sni":    certificates.Sni,
+		"enable": enable,
+	})
+
 	return nil
 }
+
+// CertificateSniMaintain 补全证书的 SNI，升级前添加的证书只有 sni，需要按证书的 SAN 补全
+func CertificateSniMaintain() error {
+	return packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		certificateList, err := (&models.Certificates{}).CertificateAllList(tx)
+		if err != nil {
+			return err
+		}
+
+		certificateResIds := make([]string, 0, len(certificateList))
+		for _, certificateInfo := range certificateList {
+			certificateResIds = append(certificateResIds, certificateInfo.ResID)
+		}
+
+		certificateSniModel := models.CertificateSnis{}
+		sniMap, err := certificateSniModel.CertificateSniMap(tx, certificateResIds)
+		if err != nil {
+			return err
+		}
+
+		for _, certificateInfo := range certificateList {
+			certificateContent, err := utils.DiscernCertificate(&certificateInfo.Certificate)
+			if err != nil {
+				packages.Log.Warn("certificate sni maintain skip", certificateInfo.ResID, err.Error())
+				continue
+			}
+
+			snis := utils.CertificateSnis(certificateInfo.Sni, certificateContent.Sans)
+			if sniContains(sniMap[certificateInfo.ResID], snis) {
+				continue
+			}
+
+			err = certificateSniModel.CertificateSnisReplace(tx, certificateInfo.ResID, snis)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func sniContains(snis []string, items []string) bool {
+	sniMap := make(map[string]byte, len(snis))
+	for _, sni := range snis {
+		sniMap[sni] = 0
+	}
+
+	for _, item := range items {
+		if _, ok := sniMap[item]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/rpc"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// CertificateExpiryAlert 证书剩余天数首次低于某个阈值时发送的提醒
+type CertificateExpiryAlert struct {
+	ResID     string   `json:"res_id"`
+	Sni       string   `json:"sni"`
+	Snis      []string `json:"snis"`
+	ExpiredAt int64    `json:"expired_at"`
+	DaysLeft  int      `json:"days_left"`
+	Threshold int      `json:"threshold"`
+}
+
+// CertificateExpiryNotifier 证书过期提醒方式，由 certificate_expiry.notifiers 配置选择
+type CertificateExpiryNotifier interface {
+	Notify(alert CertificateExpiryAlert) error
+}
+
+func NewCertificateExpiryNotifiers() []CertificateExpiryNotifier {
+	notifiers := make([]CertificateExpiryNotifier, 0)
+	for _, name := range packages.ConfigCertificateExpiry.Notifiers {
+		switch name {
+		case utils.CertificateExpiryNotifierLog:
+			notifiers = append(notifiers, LogExpiryNotifier{})
+		case utils.CertificateExpiryNotifierWebhook:
+			notifiers = append(notifiers, WebhookExpiryNotifier{})
+		case utils.CertificateExpiryNotifierSmtp:
+			notifiers = append(notifiers, SmtpExpiryNotifier{})
+		default:
+			packages.Log.Warn("unknown certificate expiry notifier ", name)
+		}
+	}
+
+	return notifiers
+}
+
+// LogExpiryNotifier 写入告警日志
+type LogExpiryNotifier struct{}
+
+func (n LogExpiryNotifier) Notify(alert CertificateExpiryAlert) error {
+	packages.Log.Warn(certificateExpiryMessage(alert))
+
+	return nil
+}
+
+// WebhookExpiryNotifier 向订阅了 certificate.expiring 的 Webhook 发送事件
+type WebhookExpiryNotifier struct{}
+
+func (n WebhookExpiryNotifier) Notify(alert CertificateExpiryAlert) error {
+	return webhookEmit(packages.GetDb(), utils.WebhookEventCertificateExpiring, alert)
+}
+
+// SmtpExpiryNotifier 通过 SMTP 向 certificate_expiry.email_to 发送邮件
+type SmtpExpiryNotifier struct{}
+
+func (n SmtpExpiryNotifier) Notify(alert CertificateExpiryAlert) error {
+	subject := fmt.Sprintf("[APIOAK] Certificate %s expires in %d days", alert.Sni, alert.DaysLeft)
+	if alert.DaysLeft <= 0 {
+		subject = fmt.Sprintf("[APIOAK] Certificate %s has expired", alert.Sni)
+	}
+
+	return rpc.SmtpSend(packages.ConfigCertificateExpiry.EmailTo, subject, certificateExpiryMessage(alert))
+}
+
+type CertificateExpiringItem struct {
+	ResID     string `json:"res_id"`
+	Sni       string `json:"sni"`
+	ExpiredAt int64  `json:"expired_at"`
+	DaysLeft  int    `json:"days_left"`
+}
+
+// CertificateExpiringList 查询指定天数内过期的已启用证书，未指定时使用最大的提醒阈值
+func CertificateExpiringList(request *validators.CertificateExpiring) ([]CertificateExpiringItem, error) {
+	days := request.Days
+	if days == 0 {
+		days = certificateExpiryMaxThreshold()
+	}
+
+	certificateList, err := (&models.Certificates{}).CertificateExpiringList(time.Now().AddDate(0, 0, days))
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]CertificateExpiringItem, 0)
+	for _, certificate := range certificateList {
+		list = append(list, CertificateExpiringItem{
+			ResID:     certificate.ResID,
+			Sni:       certificate.Sni,
+			ExpiredAt: certificate.ExpiredAt.Unix(),
+			DaysLeft:  certificateDaysLeft(certificate.ExpiredAt),
+		})
+	}
+
+	return list, nil
+}
+
+// CertificateExpiryCheck 为剩余天数低于阈值的已启用证书发送提醒，同一证书的每个阈值只提醒一次，
+// 跨过多个阈值时只按最小的阈值提醒。全部提醒方式都发送失败时删除提醒记录，下次检查时重试
+func CertificateExpiryCheck() {
+	certificateList, err := (&models.Certificates{}).CertificateExpiringList(time.Now().AddDate(0, 0, certificateExpiryMaxThreshold()))
+	if err != nil {
+		packages.Log.Error("certificate expiring list error", err.Error())
+		return
+	}
+
+	if len(certificateList) == 0 {
+		return
+	}
+
+	certificateResIds := make([]string, 0)
+	for _, certificate := range certificateList {
+		certificateResIds = append(certificateResIds, certificate.ResID)
+	}
+	sniMap, err := (&models.CertificateSnis{}).CertificateSniMap(packages.GetDb(), certificateResIds)
+	if err != nil {
+		packages.Log.Error("certificate sni list error", err.Error())
+		return
+	}
+
+	notifiers := NewCertificateExpiryNotifiers()
+	for _, certificate := range certificateList {
+		daysLeft := certificateDaysLeft(certificate.ExpiredAt)
+		threshold := certificateExpiryThreshold(daysLeft)
+		if threshold == 0 {
+			continue
+		}
+
+		alertId, err := (&models.CertificateExpiryAlerts{}).CertificateExpiryAlertAdd(certificate.ResID, certificate.ExpiredAt, threshold)
+		if err != nil {
+			packages.Log.Error("certificate expiry alert add error", err.Error())
+			continue
+		}
+		if alertId == 0 {
+			continue
+		}
+
+		snis := sniMap[certificate.ResID]
+		if len(snis) == 0 {
+			snis = []string{certificate.Sni}
+		}
+
+		alert := CertificateExpiryAlert{
+			ResID:     certificate.ResID,
+			Sni:       certificate.Sni,
+			Snis:      snis,
+			ExpiredAt: certificate.ExpiredAt.Unix(),
+			DaysLeft:  daysLeft,
+			Threshold: threshold,
+		}
+
+		notified := 0
+		for _, notifier := range notifiers {
+			if err = notifier.Notify(alert); err != nil {
+				packages.Log.Error("certificate expiry notify error ", certificate.ResID, ": ", err.Error())
+				continue
+			}
+			notified++
+		}
+
+		if (len(notifiers) != 0) && (notified == 0) {
+			err = (&models.CertificateExpiryAlerts{}).CertificateExpiryAlertRelease(alertId)
+			if err != nil {
+				packages.Log.Error("certificate expiry alert release error", err.Error())
+			}
+		}
+	}
+}
+
+// CertificateExpiring 证书已启用且剩余天数不超过最大的提醒阈值
+func CertificateExpiring(enable int, expiredAt time.Time) bool {
+	return (enable == utils.EnableOn) && (certificateDaysLeft(expiredAt) <= certificateExpiryMaxThreshold())
+}
+
+// certificateDaysLeft 剩余天数向上取整，已过期的证书小于等于 0
+func certificateDaysLeft(expiredAt time.Time) int {
+	return int(math.Ceil(time.Until(expiredAt).Hours() / 24))
+}
+
+// certificateExpiryThreshold 返回不小于剩余天数的最小阈值，未达到任何阈值时返回 0
+func certificateExpiryThreshold(daysLeft int) int {
+	for _, threshold := range packages.ConfigCertificateExpiry.Thresholds {
+		if daysLeft <= threshold {
+			return threshold
+		}
+	}
+
+	return 0
+}
+
+func certificateExpiryMaxThreshold() int {
+	thresholds := packages.ConfigCertificateExpiry.Thresholds
+	if len(thresholds) == 0 {
+		return 0
+	}
+
+	return thresholds[len(thresholds)-1]
+}
+
+func certificateExpiryMessage(alert CertificateExpiryAlert) string {
+	if alert.DaysLeft <= 0 {
+		return fmt.Sprintf("certificate %s (%s) has expired at %s, snis: %s",
+			alert.Sni, alert.ResID, time.Unix(alert.ExpiredAt, 0).Format(time.RFC3339), strings.Join(alert.Snis, ", "))
+	}
+
+	return fmt.Sprintf("certificate %s (%s) expires in %d days at %s, snis: %s",
+		alert.Sni, alert.ResID, alert.DaysLeft, time.Unix(alert.ExpiredAt, 0).Format(time.RFC3339), strings.Join(alert.Snis, ", "))
+}
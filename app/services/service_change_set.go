@@ -0,0 +1,857 @@
+package services
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/rpc"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type ChangeSetService struct {
+}
+
+var (
+	changeSetService *ChangeSetService
+	changeSetOnce    sync.Once
+
+	// 同一时间只允许发布一个变更集，避免多个变更集交错推送
+	changeSetPublishMutex sync.Mutex
+)
+
+func NewChangeSetService() *ChangeSetService {
+
+	changeSetOnce.Do(func() {
+		changeSetService = &ChangeSetService{}
+	})
+
+	return changeSetService
+}
+
+type ChangeSetItemInfo struct {
+	ResourceType string `json:"resource_type"`
+	ResID        string `json:"res_id"`
+	Name         string `json:"name"`
+}
+
+type ChangeSetInfo struct {
+	ResID       string              `json:"res_id"`
+	Name        string              `json:"name"`
+	Status      int                 `json:"status"`
+	Operator    string              `json:"operator"`
+	Message     string              `json:"message"`
+	PublishedAt int64               `json:"published_at"`
+	CreatedAt   int64               `json:"created_at"`
+	Items       []ChangeSetItemInfo `json:"items"`
+}
+
+type ChangeSetDiffItem struct {
+	Resource string      `json:"resource"`
+	ResID    string      `json:"res_id"`
+	Action   string      `json:"action"`
+	Fields   []string    `json:"fields"`
+	Expected interface{} `json:"expected"`
+	Actual   interface{} `json:"actual"`
+}
+
+type changeSetStep struct {
+	diff       ChangeSetDiffItem
+	push       func() error
+	compensate func() error
+}
+
+// changeSetPlan 变更集中资源发布时需要推送的配置，steps 按 节点 → 上游 → 插件 → 服务 → 路由 → 删除插件 的顺序排列
+type changeSetPlan struct {
+	upstreams       []models.Upstreams
+	upstreamNodes   map[string][]models.UpstreamNodes
+	upstreamConfigs map[string]rpc.UpstreamConfig
+	services        []models.Services
+	serviceDomains  map[string][]models.ServiceDomains
+	serviceRequests map[string]rpc.ServicePutRequest
+	routers         []models.Routers
+	routerConfigs   map[string]rpc.RouterConfig
+	pluginConfigs   map[string][]models.PluginConfigs
+	stalePlugins    []string
+	stalePluginMap  map[string]bool
+	steps           []changeSetStep
+}
+
+// addStalePlugin 记录数据面上需要删除的插件，同一插件只删除一次
+func (p *changeSetPlan) addStalePlugin(name string) {
+	if p.stalePluginMap[name] {
+		return
+	}
+	p.stalePluginMap[name] = true
+	p.stalePlugins = append(p.stalePlugins, name)
+}
+
+// addStep 数据面配置与控制面一致的资源无需推送
+func (p *changeSetPlan) addStep(resource string, resId string, expected interface{}, actual interface{}, exist bool, fields []string, push func() error, compensate func() error) {
+	diff := ChangeSetDiffItem{
+		Resource: resource,
+		ResID:    resId,
+		Fields:   fields,
+		Expected: expected,
+	}
+
+	if !exist {
+		diff.Action = utils.ChangeSetActionCreate
+		diff.Fields = []string{}
+	} else if len(fields) != 0 {
+		diff.Action = utils.ChangeSetActionUpdate
+		diff.Actual = actual
+	} else {
+		return
+	}
+
+	p.steps = append(p.steps, changeSetStep{
+		diff:       diff,
+		push:       push,
+		compensate: compensate,
+	})
+}
+
+func (s *ChangeSetService) ChangeSetAdd(request *validators.ChangeSetAdd) (resId string, err error) {
+	items := make([]models.ChangeSetItems, 0)
+	itemExist := make(map[string]bool)
+	for _, item := range request.Items {
+		key := item.ResourceType + ":" + item.ResID
+		if itemExist[key] {
+			continue
+		}
+		itemExist[key] = true
+
+		var name string
+		name, err = changeSetItemName(item.ResourceType, item.ResID)
+		if err != nil {
+			return
+		}
+		if len(name) == 0 {
+			err = fmt.Errorf(enums.CodeMessages(enums.ChangeSetItemNull), item.ResourceType, item.ResID)
+			return
+		}
+
+		items = append(items, models.ChangeSetItems{
+			ResourceType:  item.ResourceType,
+			ResourceResID: item.ResID,
+		})
+	}
+
+	return (&models.ChangeSets{}).ChangeSetAdd(&models.ChangeSets{
+		Name:   request.Name,
+		Status: utils.ChangeSetStatusDraft,
+	}, items)
+}
+
+func (s *ChangeSetService) ChangeSetList(request *validators.ChangeSetList) (list []ChangeSetInfo, total int, err error) {
+	changeSetList, total, err := (&models.ChangeSets{}).ChangeSetListPage(request)
+	if err != nil {
+		return
+	}
+
+	list = make([]ChangeSetInfo, 0)
+	if len(changeSetList) == 0 {
+		return
+	}
+
+	changeSetResIds := make([]string, 0)
+	for _, changeSetInfo := range changeSetList {
+		changeSetResIds = append(changeSetResIds, changeSetInfo.ResID)
+	}
+
+	itemList, err := (&models.ChangeSetItems{}).ChangeSetItemListByChangeSetResIds(changeSetResIds)
+	if err != nil {
+		return
+	}
+
+	for _, changeSetInfo := range changeSetList {
+		var info ChangeSetInfo
+		info, err = generateChangeSetInfo(changeSetInfo, itemList)
+		if err != nil {
+			return
+		}
+		list = append(list, info)
+	}
+
+	return
+}
+
+func (s *ChangeSetService) ChangeSetInfo(resId string) (info ChangeSetInfo, err error) {
+	changeSetInfo, err := (&models.ChangeSets{}).ChangeSetDetailByResId(resId)
+	if err != nil {
+		return
+	}
+
+	itemList, err := (&models.ChangeSetItems{}).ChangeSetItemListByChangeSetResIds([]string{resId})
+	if err != nil {
+		return
+	}
+
+	return generateChangeSetInfo(changeSetInfo, itemList)
+}
+
+func (s *ChangeSetService) ChangeSetDelete(resId string) error {
+	_, err := (&models.ChangeSets{}).ChangeSetDetailByResId(resId)
+	if err != nil {
+		return err
+	}
+
+	return (&models.ChangeSets{}).ChangeSetDelete(resId)
+}
+
+// ChangeSetPreview 返回发布变更集时需要推送至数据面的差异
+func (s *ChangeSetService) ChangeSetPreview(resId string) ([]ChangeSetDiffItem, error) {
+	if _, err := (&models.ChangeSets{}).ChangeSetDetailByResId(resId); err != nil {
+		return nil, err
+	}
+
+	plan, err := buildChangeSetPlan(resId)
+	if err != nil {
+		return nil, err
+	}
+
+	diffList := make([]ChangeSetDiffItem, 0)
+	for _, step := range plan.steps {
+		diffList = append(diffList, step.diff)
+	}
+
+	return diffList, nil
+}
+
+// ChangeSetPublish 按依赖顺序推送变更集中的资源，任一推送失败时按相反顺序恢复已推送的配置
+func (s *ChangeSetService) ChangeSetPublish(resId string, operator string) (err error) {
+	changeSetPublishMutex.Lock()
+	defer changeSetPublishMutex.Unlock()
+
+	changeSetModel := models.ChangeSets{}
+	changeSetInfo, err := changeSetModel.ChangeSetDetailByResId(resId)
+	if err != nil {
+		return
+	}
+
+	if changeSetInfo.Status == utils.ChangeSetStatusPublished {
+		err = errors.New(enums.CodeMessages(enums.ChangeSetPublished))
+		return
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		message := err.Error()
+		if len(message) > 500 {
+			message = message[:500]
+		}
+
+		updateErr := changeSetModel.ChangeSetUpdateColumns(packages.GetDb(), resId, map[string]interface{}{
+			"status":   utils.ChangeSetStatusFailed,
+			"operator": operator,
+			"message":  message,
+		})
+		if updateErr != nil {
+			packages.Log.Error("change set update status error", updateErr.Error())
+		}
+	}()
+
+	plan, err := buildChangeSetPlan(resId)
+	if err != nil {
+		return
+	}
+
+	pushed := make([]changeSetStep, 0)
+	for _, step := range plan.steps {
+		if err = step.push(); err != nil {
+			packages.Log.Error("change set push error", step.diff.Resource, step.diff.ResID, err.Error())
+			if compensateErr := compensateChangeSet(pushed); compensateErr != nil {
+				err = errors.New(enums.CodeMessages(enums.ChangeSetCompensationError))
+			}
+			return
+		}
+		pushed = append(pushed, step)
+	}
+
+	err = packages.GetDb().Transaction(func(tx *gorm.DB) (err error) {
+		for _, upstreamInfo := range plan.upstreams {
+			if err = tx.Table(upstreamInfo.TableName()).
+				Where("res_id = ?", upstreamInfo.ResID).
+				Update("release", utils.ReleaseStatusY).Error; err != nil {
+				return
+			}
+
+			err = releaseLogUpstream(tx, upstreamInfo, plan.upstreamConfigs[upstreamInfo.ResID], plan.upstreamNodes[upstreamInfo.ResID], operator)
+			if err != nil {
+				return
+			}
+		}
+
+		for _, serviceInfo := range plan.services {
+			if err = tx.Table(serviceInfo.TableName()).
+				Where("res_id = ?", serviceInfo.ResID).
+				Update("release", utils.ReleaseStatusY).Error; err != nil {
+				return
+			}
+
+			err = releaseLogService(tx, serviceInfo, plan.serviceDomains[serviceInfo.ResID], plan.serviceRequests[serviceInfo.ResID], plan.pluginConfigs[serviceInfo.ResID], operator)
+			if err != nil {
+				return
+			}
+		}
+
+		for _, routerInfo := range plan.routers {
+			if err = tx.Table(routerInfo.TableName()).
+				Where("res_id = ?", routerInfo.ResID).
+				Update("release", utils.ReleaseStatusY).Error; err != nil {
+				return
+			}
+
+			err = releaseLogRouter(tx, routerInfo, plan.routerConfigs[routerInfo.ResID], plan.pluginConfigs[routerInfo.ResID], operator)
+			if err != nil {
+				return
+			}
+		}
+
+		return changeSetModel.ChangeSetUpdateColumns(tx, resId, map[string]interface{}{
+			"status":       utils.ChangeSetStatusPublished,
+			"operator":     operator,
+			"message":      "",
+			"published_at": time.Now(),
+		})
+	})
+
+	if err != nil {
+		packages.Log.Error("change set update release status error", err.Error())
+		if compensateErr := compensateChangeSet(pushed); compensateErr != nil {
+			err = errors.New(enums.CodeMessages(enums.ChangeSetCompensationError))
+		}
+	}
+
+	return
+}
+
+func compensateChangeSet(pushed []changeSetStep) (err error) {
+	for i := len(pushed) - 1; i >= 0; i-- {
+		if compensateErr := pushed[i].compensate(); compensateErr != nil {
+			packages.Log.Error("change set compensate error", pushed[i].diff.Resource, pushed[i].diff.ResID, compensateErr.Error())
+			err = compensateErr
+		}
+	}
+
+	return
+}
+
+func generateChangeSetInfo(changeSetInfo models.ChangeSets, itemList []models.ChangeSetItems) (info ChangeSetInfo, err error) {
+	info = ChangeSetInfo{
+		ResID:     changeSetInfo.ResID,
+		Name:      changeSetInfo.Name,
+		Status:    changeSetInfo.Status,
+		Operator:  changeSetInfo.Operator,
+		Message:   changeSetInfo.Message,
+		CreatedAt: changeSetInfo.CreatedAt.Unix(),
+		Items:     make([]ChangeSetItemInfo, 0),
+	}
+	if changeSetInfo.PublishedAt != nil {
+		info.PublishedAt = changeSetInfo.PublishedAt.Unix()
+	}
+
+	for _, item := range itemList {
+		if item.ChangeSetResID != changeSetInfo.ResID {
+			continue
+		}
+
+		var name string
+		name, err = changeSetItemName(item.ResourceType, item.ResourceResID)
+		if err != nil {
+			return
+		}
+
+		info.Items = append(info.Items, ChangeSetItemInfo{
+			ResourceType: item.ResourceType,
+			ResID:        item.ResourceResID,
+			Name:         name,
+		})
+	}
+
+	return
+}
+
+// changeSetItemName 返回变更资源的名称，资源不存在时返回空字符串
+func changeSetItemName(resourceType string, resId string) (string, error) {
+	switch resourceType {
+	case utils.ChangeSetResourceUpstream:
+		upstreamInfo, err := models.Upstreams{}.UpstreamDetailByResId(resId)
+		return upstreamInfo.Name, err
+	case utils.ChangeSetResourceService:
+		serviceInfo, err := (&models.Services{}).ServiceInfoById(resId)
+		if err != nil {
+			return "", nil
+		}
+		return serviceInfo.Name, nil
+	case utils.ChangeSetResourceRouter:
+		routerInfo, err := (&models.Routers{}).RouterDetailByResId(resId)
+		return routerInfo.RouterName, err
+	case utils.ChangeSetResourcePluginConfig:
+		pluginConfigInfo, err := (&models.PluginConfigs{}).PluginConfigInfoByResId(resId)
+		if (err == nil) && (len(pluginConfigInfo.ResID) != 0) && (len(pluginConfigInfo.Name) == 0) {
+			return pluginConfigInfo.PluginKey, nil
+		}
+		return pluginConfigInfo.Name, err
+	}
+
+	return "", nil
+}
+
+func buildChangeSetPlan(changeSetResId string) (*changeSetPlan, error) {
+	itemList, err := (&models.ChangeSetItems{}).ChangeSetItemListByChangeSetResIds([]string{changeSetResId})
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &changeSetPlan{
+		upstreamNodes:   make(map[string][]models.UpstreamNodes),
+		upstreamConfigs: make(map[string]rpc.UpstreamConfig),
+		serviceDomains:  make(map[string][]models.ServiceDomains),
+		serviceRequests: make(map[string]rpc.ServicePutRequest),
+		routerConfigs:   make(map[string]rpc.RouterConfig),
+		pluginConfigs:   make(map[string][]models.PluginConfigs),
+		stalePlugins:    make([]string, 0),
+		stalePluginMap:  make(map[string]bool),
+		steps:           make([]changeSetStep, 0),
+	}
+
+	if err = resolveChangeSetItems(plan, itemList); err != nil {
+		return nil, err
+	}
+
+	stepBuilders := []func(plan *changeSetPlan) error{
+		buildChangeSetUpstreamSteps,
+		buildChangeSetPluginSteps,
+		buildChangeSetServiceSteps,
+		buildChangeSetRouterSteps,
+		buildChangeSetStalePluginSteps,
+	}
+
+	for _, stepBuilder := range stepBuilders {
+		if err = stepBuilder(plan); err != nil {
+			return nil, err
+		}
+	}
+
+	return plan, nil
+}
+
+// resolveChangeSetItems 加载变更资源，插件配置会将其所属的服务或路由一并发布，已删除的插件配置从数据面删除
+func resolveChangeSetItems(plan *changeSetPlan, itemList []models.ChangeSetItems) error {
+	upstreamResIds := make([]string, 0)
+	serviceResIds := make([]string, 0)
+	routerResIds := make([]string, 0)
+	exist := make(map[string]bool)
+	addResId := func(resIds *[]string, resourceType string, resId string) {
+		if exist[resourceType+":"+resId] {
+			return
+		}
+		exist[resourceType+":"+resId] = true
+		*resIds = append(*resIds, resId)
+	}
+
+	for _, item := range itemList {
+		switch item.ResourceType {
+		case utils.ChangeSetResourceUpstream:
+			addResId(&upstreamResIds, item.ResourceType, item.ResourceResID)
+		case utils.ChangeSetResourceService:
+			addResId(&serviceResIds, item.ResourceType, item.ResourceResID)
+		case utils.ChangeSetResourceRouter:
+			addResId(&routerResIds, item.ResourceType, item.ResourceResID)
+		case utils.ChangeSetResourcePluginConfig:
+			pluginConfigInfo, err := (&models.PluginConfigs{}).PluginConfigInfoByResId(item.ResourceResID)
+			if err != nil {
+				return err
+			}
+			if len(pluginConfigInfo.ResID) == 0 {
+				plan.addStalePlugin(item.ResourceResID)
+				continue
+			}
+
+			if pluginConfigInfo.Type == models.PluginConfigsTypeService {
+				addResId(&serviceResIds, utils.ChangeSetResourceService, pluginConfigInfo.TargetID)
+			} else {
+				addResId(&routerResIds, utils.ChangeSetResourceRouter, pluginConfigInfo.TargetID)
+			}
+		}
+	}
+
+	for _, upstreamResId := range upstreamResIds {
+		upstreamInfo, err := models.Upstreams{}.UpstreamDetailByResId(upstreamResId)
+		if err != nil {
+			return err
+		}
+		if upstreamInfo.ResID != upstreamResId {
+			return fmt.Errorf(enums.CodeMessages(enums.ChangeSetItemNull), utils.ChangeSetResourceUpstream, upstreamResId)
+		}
+		plan.upstreams = append(plan.upstreams, upstreamInfo)
+	}
+
+	for _, serviceResId := range serviceResIds {
+		serviceInfo, err := (&models.Services{}).ServiceInfoById(serviceResId)
+		if err != nil {
+			return fmt.Errorf(enums.CodeMessages(enums.ChangeSetItemNull), utils.ChangeSetResourceService, serviceResId)
+		}
+		plan.services = append(plan.services, serviceInfo)
+	}
+
+	for _, routerResId := range routerResIds {
+		routerInfo, err := (&models.Routers{}).RouterDetailByResId(routerResId)
+		if err != nil {
+			return err
+		}
+		if routerInfo.ResID != routerResId {
+			return fmt.Errorf(enums.CodeMessages(enums.ChangeSetItemNull), utils.ChangeSetResourceRouter, routerResId)
+		}
+
+		// 路由依赖的服务与上游需要已发布或在同一变更集中发布
+		if !exist[utils.ChangeSetResourceService+":"+routerInfo.ServiceResID] {
+			serviceInfo, err := (&models.Services{}).ServiceInfoById(routerInfo.ServiceResID)
+			if (err != nil) || (serviceInfo.Release == utils.ReleaseStatusU) {
+				return fmt.Errorf(enums.CodeMessages(enums.ChangeSetDependencyNull), utils.ChangeSetResourceRouter, routerResId, utils.ChangeSetResourceService)
+			}
+		}
+
+		if (len(routerInfo.UpstreamResID) != 0) && !exist[utils.ChangeSetResourceUpstream+":"+routerInfo.UpstreamResID] {
+			upstreamInfo, err := models.Upstreams{}.UpstreamDetailByResId(routerInfo.UpstreamResID)
+			if err != nil {
+				return err
+			}
+			if (upstreamInfo.ResID != routerInfo.UpstreamResID) || (upstreamInfo.Release == utils.ReleaseStatusU) {
+				return fmt.Errorf(enums.CodeMessages(enums.ChangeSetDependencyNull), utils.ChangeSetResourceRouter, routerResId, utils.ChangeSetResourceUpstream)
+			}
+		}
+
+		plan.routers = append(plan.routers, routerInfo)
+	}
+
+	return nil
+}
+
+func buildChangeSetUpstreamSteps(plan *changeSetPlan) error {
+	if len(plan.upstreams) == 0 {
+		return nil
+	}
+
+	newApiOak := rpc.NewApiOak()
+	cloudNodeList, err := newApiOak.UpstreamNodeList(nil)
+	if err != nil {
+		return err
+	}
+
+	cloudNodeMap := make(map[string]rpc.UpstreamNodeConfig)
+	for _, cloudNodeInfo := range cloudNodeList {
+		cloudNodeMap[cloudNodeInfo.Name] = cloudNodeInfo
+	}
+
+	for _, upstreamInfo := range plan.upstreams {
+		upstreamNodeList, err := (&models.UpstreamNodes{}).UpstreamNodeListByUpstreamResIds([]string{upstreamInfo.ResID})
+		if err != nil {
+			return err
+		}
+		plan.upstreamNodes[upstreamInfo.ResID] = upstreamNodeList
+
+		for _, upstreamNodeInfo := range upstreamNodeList {
+			expected, err := generateUpstreamNodeConfig(upstreamInfo, upstreamNodeInfo)
+			if err != nil {
+				return err
+			}
+
+			actual, exist := cloudNodeMap[expected.Name]
+			plan.addStep(utils.ChangeSetResourceUpstreamNode, expected.Name, expected, actual, exist, diffUpstreamNodeConfig(expected, actual),
+				func() error {
+					return newApiOak.UpstreamNodePut([]rpc.UpstreamNodeConfig{expected})
+				},
+				func() error {
+					if exist {
+						return newApiOak.UpstreamNodePut([]rpc.UpstreamNodeConfig{actual})
+					}
+					return newApiOak.UpstreamNodeDelete([]string{expected.Name})
+				})
+		}
+	}
+
+	// 上游不再引用的节点需要在上游推送之后删除
+	staleNodeSteps := make([]changeSetStep, 0)
+	for _, upstreamInfo := range plan.upstreams {
+		expected, err := generateUpstreamConfig(upstreamInfo)
+		if err != nil {
+			return err
+		}
+		plan.upstreamConfigs[upstreamInfo.ResID] = expected
+
+		cloudUpstreamList, err := newApiOak.UpstreamGet([]string{upstreamInfo.ResID})
+		if err != nil {
+			return err
+		}
+
+		var actual rpc.UpstreamConfig
+		exist := len(cloudUpstreamList) != 0
+		if exist {
+			actual = cloudUpstreamList[0]
+		}
+
+		plan.addStep(utils.ChangeSetResourceUpstream, expected.Name, expected, actual, exist, diffUpstreamConfig(expected, actual),
+			func() error {
+				return newApiOak.UpstreamPut([]rpc.UpstreamConfig{expected})
+			},
+			func() error {
+				if exist {
+					return newApiOak.UpstreamPut([]rpc.UpstreamConfig{actual})
+				}
+				return newApiOak.UpstreamDelete([]string{expected.Name})
+			})
+
+		expectedNodes := make(map[string]bool)
+		for _, node := range expected.Nodes {
+			expectedNodes[node.Name] = true
+		}
+
+		for _, node := range actual.Nodes {
+			nodeName := driftObjectName(node)
+			if expectedNodes[nodeName] {
+				continue
+			}
+
+			cloudNode, cloudNodeExist := cloudNodeMap[nodeName]
+			staleNodeSteps = append(staleNodeSteps, changeSetStep{
+				diff: ChangeSetDiffItem{
+					Resource: utils.ChangeSetResourceUpstreamNode,
+					ResID:    nodeName,
+					Action:   utils.ChangeSetActionDelete,
+					Fields:   []string{},
+					Actual:   cloudNode,
+				},
+				push: func() error {
+					return newApiOak.UpstreamNodeDelete([]string{nodeName})
+				},
+				compensate: func() error {
+					if cloudNodeExist {
+						return newApiOak.UpstreamNodePut([]rpc.UpstreamNodeConfig{cloudNode})
+					}
+					return nil
+				},
+			})
+		}
+	}
+
+	plan.steps = append(plan.steps, staleNodeSteps...)
+
+	return nil
+}
+
+func buildChangeSetPluginSteps(plan *changeSetPlan) error {
+	targets := make([][2]interface{}, 0)
+	for _, serviceInfo := range plan.services {
+		targets = append(targets, [2]interface{}{models.PluginConfigsTypeService, serviceInfo.ResID})
+	}
+	for _, routerInfo := range plan.routers {
+		targets = append(targets, [2]interface{}{models.PluginConfigsTypeRouter, routerInfo.ResID})
+	}
+
+	newApiOak := rpc.NewApiOak()
+	for _, target := range targets {
+		targetId := target[1].(string)
+		pluginConfigList, err := (&models.PluginConfigs{}).PluginConfigList(packages.GetDb(), target[0].(int), targetId, utils.EnableOn)
+		if err != nil {
+			return err
+		}
+		plan.pluginConfigs[targetId] = pluginConfigList
+
+		disablePluginConfigList, err := (&models.PluginConfigs{}).PluginConfigList(packages.GetDb(), target[0].(int), targetId, utils.EnableOff)
+		if err != nil {
+			return err
+		}
+		for _, pluginConfigInfo := range disablePluginConfigList {
+			plan.addStalePlugin(pluginConfigInfo.ResID)
+		}
+
+		for _, pluginConfigInfo := range pluginConfigList {
+			expected, err := generatePluginPutRequest(pluginConfigInfo)
+			if err != nil {
+				return err
+			}
+
+			actual, err := newApiOak.PluginGet(pluginConfigInfo.ResID)
+			if err != nil {
+				return err
+			}
+
+			exist := driftExist(actual.ID, actual.Name)
+			actualItem := actual
+			actualItem.Config, _ = pluginRequestConfigSecret(actual.Key, actual.Config, pluginSecretMask)
+			plan.addStep(utils.ChangeSetResourcePluginConfig, expected.Name, pluginPutRequestMask(expected), actualItem, exist, diffPluginConfig(expected, actual),
+				func() error {
+					return newApiOak.PluginPut(&expected)
+				},
+				func() error {
+					if exist {
+						return newApiOak.PluginPut(&rpc.PluginPutRequest{
+							Name:   expected.Name,
+							Key:    actual.Key,
+							Config: actual.Config,
+						})
+					}
+					return newApiOak.PluginDelete(expected.Name)
+				})
+		}
+	}
+
+	return nil
+}
+
+func buildChangeSetServiceSteps(plan *changeSetPlan) error {
+	newApiOak := rpc.NewApiOak()
+	for _, serviceInfo := range plan.services {
+		serviceDomains, err := (&models.ServiceDomains{}).DomainInfosByServiceIds([]string{serviceInfo.ResID})
+		if err != nil {
+			return err
+		}
+		plan.serviceDomains[serviceInfo.ResID] = serviceDomains
+
+		expected := genServiceReleaseSyncRequest(serviceInfo, serviceDomains, plan.pluginConfigs[serviceInfo.ResID])
+		plan.serviceRequests[serviceInfo.ResID] = expected
+
+		actual, err := newApiOak.ServiceGet(serviceInfo.ResID)
+		if err != nil {
+			return err
+		}
+
+		exist := driftExist(actual.ID, actual.Name)
+		plan.addStep(utils.ChangeSetResourceService, expected.Name, expected, actual, exist, diffServiceConfig(expected, actual),
+			func() error {
+				return newApiOak.ServicePut(&expected)
+			},
+			func() error {
+				if exist {
+					previous := servicePutRequestFromResponse(expected.Name, actual)
+					return newApiOak.ServicePut(&previous)
+				}
+				return newApiOak.ServiceDelete(expected.Name)
+			})
+
+		addChangeSetStalePlugins(plan, expected.Plugins, actual.Plugins)
+	}
+
+	return nil
+}
+
+func buildChangeSetRouterSteps(plan *changeSetPlan) error {
+	newApiOak := rpc.NewApiOak()
+	for _, routerInfo := range plan.routers {
+		expected, err := generateRouterConfig(routerInfo)
+		if err != nil {
+			return err
+		}
+		plan.routerConfigs[routerInfo.ResID] = expected
+
+		cloudRouterList, err := newApiOak.RouterGet([]string{routerInfo.ResID})
+		if err != nil {
+			return err
+		}
+
+		var actual rpc.RouterConfig
+		exist := len(cloudRouterList) != 0
+		if exist {
+			actual = cloudRouterList[0]
+		}
+
+		plan.addStep(utils.ChangeSetResourceRouter, expected.Name, expected, actual, exist, diffRouterConfig(expected, actual),
+			func() error {
+				return newApiOak.RouterPut([]rpc.RouterConfig{expected})
+			},
+			func() error {
+				if exist {
+					return newApiOak.RouterPut([]rpc.RouterConfig{actual})
+				}
+				return newApiOak.RouterDelete([]string{expected.Name})
+			})
+
+		addChangeSetStalePlugins(plan, expected.Plugins, actual.Plugins)
+	}
+
+	return nil
+}
+
+// addChangeSetStalePlugins 数据面服务或路由引用、但发布后不再引用的插件需要删除
+func addChangeSetStalePlugins(plan *changeSetPlan, expected []rpc.ConfigObjectName, actual []rpc.ConfigObjectName) {
+	expectedPlugins := make(map[string]bool)
+	for _, plugin := range expected {
+		expectedPlugins[driftObjectName(plugin)] = true
+	}
+
+	for _, plugin := range actual {
+		pluginName := driftObjectName(plugin)
+		if !expectedPlugins[pluginName] {
+			plan.addStalePlugin(pluginName)
+		}
+	}
+}
+
+// buildChangeSetStalePluginSteps 停用或删除的插件需要在服务与路由推送之后从数据面删除
+func buildChangeSetStalePluginSteps(plan *changeSetPlan) error {
+	newApiOak := rpc.NewApiOak()
+	for _, stalePlugin := range plan.stalePlugins {
+		pluginName := stalePlugin
+		actual, err := newApiOak.PluginGet(pluginName)
+		if err != nil {
+			return err
+		}
+		if !driftExist(actual.ID, actual.Name) {
+			continue
+		}
+
+		actualItem := actual
+		actualItem.Config, _ = pluginRequestConfigSecret(actual.Key, actual.Config, pluginSecretMask)
+		plan.steps = append(plan.steps, changeSetStep{
+			diff: ChangeSetDiffItem{
+				Resource: utils.ChangeSetResourcePluginConfig,
+				ResID:    pluginName,
+				Action:   utils.ChangeSetActionDelete,
+				Fields:   []string{},
+				Actual:   actualItem,
+			},
+			push: func() error {
+				return newApiOak.PluginDelete(pluginName)
+			},
+			compensate: func() error {
+				return newApiOak.PluginPut(&rpc.PluginPutRequest{
+					Name:   pluginName,
+					Key:    actual.Key,
+					Config: actual.Config,
+				})
+			},
+		})
+	}
+
+	return nil
+}
+
+func servicePutRequestFromResponse(name string, response rpc.ServiceResponse) rpc.ServicePutRequest {
+	ports := make([]int, 0)
+	for _, port := range response.Ports {
+		if portNumber, err := strconv.Atoi(port); err == nil {
+			ports = append(ports, portNumber)
+		}
+	}
+
+	return rpc.ServicePutRequest{
+		Name:      name,
+		Protocols: response.Protocols,
+		Hosts:     response.Hosts,
+		Ports:     ports,
+		Plugins:   response.Plugins,
+		Enabled:   response.Enabled,
+	}
+}
@@ -0,0 +1,616 @@
+package services
+
+import (
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/rpc"
+	"apioak-admin/app/utils"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type DriftItem struct {
+	Resource  string      `json:"resource"`
+	ResID     string      `json:"res_id"`
+	Status    string      `json:"status"`
+	Fields    []string    `json:"fields"`
+	Expected  interface{} `json:"expected"`
+	Actual    interface{} `json:"actual"`
+	Healed    bool        `json:"healed"`
+	HealError string      `json:"heal_error"`
+
+	heal func() error
+}
+
+type DriftReport struct {
+	CheckedAt time.Time   `json:"checked_at"`
+	Checked   int         `json:"checked"`
+	Drifted   int         `json:"drifted"`
+	Healed    int         `json:"healed"`
+	Items     []DriftItem `json:"items"`
+}
+
+var (
+	driftMutex      sync.Mutex
+	driftLastMutex  sync.RWMutex
+	driftLastReport *DriftReport
+)
+
+// DriftLastReport 返回最近一次漂移检测结果，尚未检测过时返回 nil
+func DriftLastReport() *DriftReport {
+	driftLastMutex.RLock()
+	defer driftLastMutex.RUnlock()
+
+	return driftLastReport
+}
+
+// DriftCheck 对比控制面已发布的配置与数据面实际配置，heal 为 true 时将存在差异的配置重新推送至数据面
+func DriftCheck(heal bool) (DriftReport, error) {
+	driftMutex.Lock()
+	defer driftMutex.Unlock()
+
+	report := DriftReport{
+		CheckedAt: time.Now(),
+		Items:     make([]DriftItem, 0),
+	}
+
+	checkList := []func(report *DriftReport) error{
+		driftCheckUpstreams,
+		driftCheckPluginConfigs,
+		driftCheckServices,
+		driftCheckRouters,
+		driftCheckStalePluginConfigs,
+		driftCheckCertificates,
+	}
+
+	for _, check := range checkList {
+		if err := check(&report); err != nil {
+			return report, err
+		}
+	}
+
+	report.Drifted = len(report.Items)
+
+	// 按 节点 → 上游 → 插件 → 服务 → 路由 → 停用插件 → 证书 的顺序修复，保证被引用的资源先于引用方推送、引用方不再引用后再删除
+	if heal {
+		for k, item := range report.Items {
+			if err := item.heal(); err != nil {
+				report.Items[k].HealError = err.Error()
+				continue
+			}
+			report.Items[k].Healed = true
+			report.Healed++
+		}
+	}
+
+	driftLastMutex.Lock()
+	driftLastReport = &report
+	driftLastMutex.Unlock()
+
+	return report, nil
+}
+
+func (r *DriftReport) add(resource string, resId string, expected interface{}, actual interface{}, fields []string, exist bool, heal func() error) {
+	r.Checked++
+
+	item := DriftItem{
+		Resource: resource,
+		ResID:    resId,
+		Fields:   fields,
+		Expected: expected,
+		heal:     heal,
+	}
+
+	if !exist {
+		item.Status = utils.DriftStatusMissing
+		item.Fields = []string{}
+	} else if len(fields) != 0 {
+		item.Status = utils.DriftStatusChanged
+		item.Actual = actual
+	} else {
+		return
+	}
+
+	r.Items = append(r.Items, item)
+}
+
+// addStale 控制面已停用、数据面仍存在的资源，修复时从数据面删除
+func (r *DriftReport) addStale(resource string, resId string, actual interface{}, heal func() error) {
+	r.Checked++
+
+	r.Items = append(r.Items, DriftItem{
+		Resource: resource,
+		ResID:    resId,
+		Status:   utils.DriftStatusStale,
+		Fields:   []string{},
+		Actual:   actual,
+		heal:     heal,
+	})
+}
+
+func driftCheckUpstreams(report *DriftReport) error {
+	upstreamList, err := models.Upstreams{}.UpstreamAllList(packages.GetDb())
+	if err != nil {
+		return err
+	}
+
+	upstreamNodeList, err := (&models.UpstreamNodes{}).UpstreamNodeAllList(packages.GetDb())
+	if err != nil {
+		return err
+	}
+
+	upstreamNodesMap := make(map[string][]models.UpstreamNodes)
+	for _, upstreamNodeInfo := range upstreamNodeList {
+		upstreamNodesMap[upstreamNodeInfo.UpstreamResID] = append(upstreamNodesMap[upstreamNodeInfo.UpstreamResID], upstreamNodeInfo)
+	}
+
+	newApiOak := rpc.NewApiOak()
+	cloudNodeList, err := newApiOak.UpstreamNodeList(nil)
+	if err != nil {
+		return err
+	}
+
+	cloudNodeMap := make(map[string]rpc.UpstreamNodeConfig)
+	for _, cloudNodeInfo := range cloudNodeList {
+		cloudNodeMap[cloudNodeInfo.Name] = cloudNodeInfo
+	}
+
+	for _, upstreamInfo := range upstreamList {
+		if upstreamInfo.Release != utils.ReleaseStatusY {
+			continue
+		}
+
+		for _, upstreamNodeInfo := range upstreamNodesMap[upstreamInfo.ResID] {
+			expected, err := generateUpstreamNodeConfig(upstreamInfo, upstreamNodeInfo)
+			if err != nil {
+				return err
+			}
+
+			actual, exist := cloudNodeMap[expected.Name]
+
+			fields := diffUpstreamNodeConfig(expected, actual)
+
+			report.add(utils.DriftResourceUpstreamNode, expected.Name, expected, actual, fields, exist, func() error {
+				return newApiOak.UpstreamNodePut([]rpc.UpstreamNodeConfig{expected})
+			})
+		}
+
+		expected, err := generateUpstreamConfig(upstreamInfo)
+		if err != nil {
+			return err
+		}
+
+		cloudUpstreamList, err := newApiOak.UpstreamGet([]string{upstreamInfo.ResID})
+		if err != nil {
+			return err
+		}
+
+		var actual rpc.UpstreamConfig
+		exist := len(cloudUpstreamList) != 0
+		if exist {
+			actual = cloudUpstreamList[0]
+		}
+
+		fields := diffUpstreamConfig(expected, actual)
+
+		report.add(utils.DriftResourceUpstream, expected.Name, expected, actual, fields, exist, func() error {
+			return newApiOak.UpstreamPut([]rpc.UpstreamConfig{expected})
+		})
+	}
+
+	return nil
+}
+
+func driftCheckPluginConfigs(report *DriftReport) error {
+	publishedTargets, err := driftPublishedTargets()
+	if err != nil {
+		return err
+	}
+
+	pluginConfigList, err := (&models.PluginConfigs{}).PluginConfigAllList(packages.GetDb())
+	if err != nil {
+		return err
+	}
+
+	newApiOak := rpc.NewApiOak()
+	for _, pluginConfigInfo := range pluginConfigList {
+		if pluginConfigInfo.Enable != utils.EnableOn {
+			continue
+		}
+		if !publishedTargets[pluginConfigInfo.Type][pluginConfigInfo.TargetID] {
+			continue
+		}
+
+		expected, err := generatePluginPutRequest(pluginConfigInfo)
+		if err != nil {
+			packages.Log.Error("drift check parse plugin config error", err.Error())
+			continue
+		}
+
+		actual, err := newApiOak.PluginGet(pluginConfigInfo.ResID)
+		if err != nil {
+			return err
+		}
+
+		fields := diffPluginConfig(expected, actual)
+
+		// 报告中不返回插件密钥
+		expectedItem, actualItem := pluginPutRequestMask(expected), actual
+		actualItem.Config, _ = pluginRequestConfigSecret(actual.Key, actual.Config, pluginSecretMask)
+
+		report.add(utils.DriftResourcePluginConfig, expected.Name, expectedItem, actualItem, fields, driftExist(actual.ID, actual.Name), func() error {
+			return newApiOak.PluginPut(&expected)
+		})
+	}
+
+	return nil
+}
+
+func driftCheckStalePluginConfigs(report *DriftReport) error {
+	publishedTargets, err := driftPublishedTargets()
+	if err != nil {
+		return err
+	}
+
+	pluginConfigList, err := (&models.PluginConfigs{}).PluginConfigAllList(packages.GetDb())
+	if err != nil {
+		return err
+	}
+
+	newApiOak := rpc.NewApiOak()
+	for _, pluginConfigInfo := range pluginConfigList {
+		if pluginConfigInfo.Enable == utils.EnableOn {
+			continue
+		}
+		if !publishedTargets[pluginConfigInfo.Type][pluginConfigInfo.TargetID] {
+			continue
+		}
+
+		actual, err := newApiOak.PluginGet(pluginConfigInfo.ResID)
+		if err != nil {
+			return err
+		}
+		if !driftExist(actual.ID, actual.Name) {
+			report.Checked++
+			continue
+		}
+
+		pluginName := pluginConfigInfo.ResID
+		actualItem := actual
+		actualItem.Config, _ = pluginRequestConfigSecret(actual.Key, actual.Config, pluginSecretMask)
+
+		report.addStale(utils.DriftResourcePluginConfig, pluginName, actualItem, func() error {
+			return newApiOak.PluginDelete(pluginName)
+		})
+	}
+
+	return nil
+}
+
+func driftCheckServices(report *DriftReport) error {
+	serviceList, err := (&models.Services{}).ServiceAllList(packages.GetDb())
+	if err != nil {
+		return err
+	}
+
+	domainList, err := (&models.ServiceDomains{}).DomainAllList(packages.GetDb())
+	if err != nil {
+		return err
+	}
+
+	serviceDomainsMap := make(map[string][]models.ServiceDomains)
+	for _, domainInfo := range domainList {
+		serviceDomainsMap[domainInfo.ServiceResID] = append(serviceDomainsMap[domainInfo.ServiceResID], domainInfo)
+	}
+
+	newApiOak := rpc.NewApiOak()
+	for _, serviceInfo := range serviceList {
+		if serviceInfo.Release != utils.ReleaseStatusY {
+			continue
+		}
+
+		pluginConfigList, err := (&models.PluginConfigs{}).PluginConfigList(packages.GetDb(), models.PluginConfigsTypeService, serviceInfo.ResID, utils.EnableOn)
+		if err != nil {
+			return err
+		}
+
+		expected := genServiceReleaseSyncRequest(serviceInfo, serviceDomainsMap[serviceInfo.ResID], pluginConfigList)
+
+		actual, err := newApiOak.ServiceGet(serviceInfo.ResID)
+		if err != nil {
+			return err
+		}
+
+		fields := diffServiceConfig(expected, actual)
+
+		report.add(utils.DriftResourceService, expected.Name, expected, actual, fields, driftExist(actual.ID, actual.Name), func() error {
+			return newApiOak.ServicePut(&expected)
+		})
+	}
+
+	return nil
+}
+
+func driftCheckRouters(report *DriftReport) error {
+	routerList, err := (&models.Routers{}).RouterAllList(packages.GetDb())
+	if err != nil {
+		return err
+	}
+
+	newApiOak := rpc.NewApiOak()
+	for _, routerInfo := range routerList {
+		if routerInfo.Release != utils.ReleaseStatusY {
+			continue
+		}
+
+		expected, err := generateRouterConfig(routerInfo)
+		if err != nil {
+			return err
+		}
+
+		cloudRouterList, err := newApiOak.RouterGet([]string{routerInfo.ResID})
+		if err != nil {
+			return err
+		}
+
+		var actual rpc.RouterConfig
+		exist := len(cloudRouterList) != 0
+		if exist {
+			actual = cloudRouterList[0]
+		}
+
+		fields := diffRouterConfig(expected, actual)
+
+		report.add(utils.DriftResourceRouter, expected.Name, expected, actual, fields, exist, func() error {
+			return newApiOak.RouterPut([]rpc.RouterConfig{expected})
+		})
+	}
+
+	return nil
+}
+
+func driftCheckCertificates(report *DriftReport) error {
+	certificateList, err := (&models.Certificates{}).CertificateAllList(packages.GetDb())
+	if err != nil {
+		return err
+	}
+
+	newApiOak := rpc.NewApiOak()
+	for _, certificateInfo := range certificateList {
+		if certificateInfo.Enable != utils.EnableOn {
+			continue
+		}
+
+		expected, err := generateCertificatePutRequest(packages.GetDb(), certificateInfo)
+		if err != nil {
+			return err
+		}
+
+		actual, err := newApiOak.CertificateGet(certificateInfo.ResID)
+		if err != nil {
+			return err
+		}
+
+		fields := make([]string, 0)
+		if !driftSameStrings(expected.Sni, actual.Sni) {
+			fields = append(fields, "snis")
+		}
+		if strings.TrimSpace(expected.Cert) != strings.TrimSpace(actual.Cert) {
+			fields = append(fields, "cert")
+		}
+		if strings.TrimSpace(expected.Key) != strings.TrimSpace(actual.Key) {
+			fields = append(fields, "key")
+		}
+
+		// 报告中不返回证书私钥
+		expectedItem, actualItem := expected, actual
+		expectedItem.Key, actualItem.Key = "", ""
+
+		report.add(utils.DriftResourceCertificate, expected.Name, expectedItem, actualItem, fields, driftExist(actual.ID, actual.Name), func() error {
+			return newApiOak.CertificatePut(&expected)
+		})
+	}
+
+	return nil
+}
+
+// diffUpstreamNodeConfig 节点健康状态由数据面健康检查维护，不参与对比
+func diffUpstreamNodeConfig(expected rpc.UpstreamNodeConfig, actual rpc.UpstreamNodeConfig) []string {
+	fields := make([]string, 0)
+	if expected.Address != actual.Address {
+		fields = append(fields, "address")
+	}
+	if expected.Port != actual.Port {
+		fields = append(fields, "port")
+	}
+	if expected.Weight != actual.Weight {
+		fields = append(fields, "weight")
+	}
+	if expected.Check != actual.Check {
+		fields = append(fields, "check")
+	}
+
+	return fields
+}
+
+func diffUpstreamConfig(expected rpc.UpstreamConfig, actual rpc.UpstreamConfig) []string {
+	fields := make([]string, 0)
+	if expected.Algorithm != actual.Algorithm {
+		fields = append(fields, "algorithm")
+	}
+	if (expected.HashOn != actual.HashOn) || (expected.HashKey != actual.HashKey) {
+		fields = append(fields, "hash_on")
+	}
+	if expected.ConnectTimeout != actual.ConnectTimeout {
+		fields = append(fields, "connect_timeout")
+	}
+	if expected.WriteTimeout != actual.WriteTimeout {
+		fields = append(fields, "write_timeout")
+	}
+	if expected.ReadTimeout != actual.ReadTimeout {
+		fields = append(fields, "read_timeout")
+	}
+	if expected.Enabled != actual.Enabled {
+		fields = append(fields, "enabled")
+	}
+	if !driftSameObjectNames(expected.Nodes, actual.Nodes) {
+		fields = append(fields, "nodes")
+	}
+
+	return fields
+}
+
+func diffPluginConfig(expected rpc.PluginPutRequest, actual rpc.PluginResponse) []string {
+	fields := make([]string, 0)
+	if expected.Key != actual.Key {
+		fields = append(fields, "key")
+	}
+	if !driftSameJson(expected.Config, actual.Config) {
+		fields = append(fields, "config")
+	}
+
+	return fields
+}
+
+func diffServiceConfig(expected rpc.ServicePutRequest, actual rpc.ServiceResponse) []string {
+	fields := make([]string, 0)
+	if !driftSameStrings(expected.Protocols, actual.Protocols) {
+		fields = append(fields, "protocols")
+	}
+	if !driftSameStrings(expected.Hosts, actual.Hosts) {
+		fields = append(fields, "hosts")
+	}
+	if !driftSameObjectNames(expected.Plugins, actual.Plugins) {
+		fields = append(fields, "plugins")
+	}
+	if expected.Enabled != actual.Enabled {
+		fields = append(fields, "enabled")
+	}
+
+	return fields
+}
+
+func diffRouterConfig(expected rpc.RouterConfig, actual rpc.RouterConfig) []string {
+	fields := make([]string, 0)
+	if !driftSameStrings(expected.Methods, actual.Methods) {
+		fields = append(fields, "methods")
+	}
+	if !driftSameStrings(expected.Paths, actual.Paths) {
+		fields = append(fields, "paths")
+	}
+	if expected.Enabled != actual.Enabled {
+		fields = append(fields, "enabled")
+	}
+	if driftObjectName(expected.Service) != driftObjectName(actual.Service) {
+		fields = append(fields, "service")
+	}
+	if driftObjectName(expected.Upstream) != driftObjectName(actual.Upstream) {
+		fields = append(fields, "upstream")
+	}
+	if !driftSameObjectNames(expected.Plugins, actual.Plugins) {
+		fields = append(fields, "plugins")
+	}
+
+	return fields
+}
+
+// driftPublishedTargets 返回已发布的服务、路由，插件配置仅在其绑定的资源已发布时才会存在于数据面
+func driftPublishedTargets() (map[int]map[string]bool, error) {
+	publishedTargets := map[int]map[string]bool{
+		models.PluginConfigsTypeService: make(map[string]bool),
+		models.PluginConfigsTypeRouter:  make(map[string]bool),
+	}
+
+	serviceList, err := (&models.Services{}).ServiceAllList(packages.GetDb())
+	if err != nil {
+		return nil, err
+	}
+	for _, serviceInfo := range serviceList {
+		if serviceInfo.Release == utils.ReleaseStatusY {
+			publishedTargets[models.PluginConfigsTypeService][serviceInfo.ResID] = true
+		}
+	}
+
+	routerList, err := (&models.Routers{}).RouterAllList(packages.GetDb())
+	if err != nil {
+		return nil, err
+	}
+	for _, routerInfo := range routerList {
+		if routerInfo.Release == utils.ReleaseStatusY {
+			publishedTargets[models.PluginConfigsTypeRouter][routerInfo.ResID] = true
+		}
+	}
+
+	return publishedTargets, nil
+}
+
+// driftExist 数据面资源不存在时 GET 接口返回空结构体
+func driftExist(id string, name string) bool {
+	return (len(id) != 0) || (len(name) != 0)
+}
+
+// driftObjectName 数据面返回的引用对象可能只携带 id 或 name
+func driftObjectName(object rpc.ConfigObjectName) string {
+	if len(object.Id) != 0 {
+		return object.Id
+	}
+
+	return object.Name
+}
+
+func driftSameObjectNames(expected []rpc.ConfigObjectName, actual []rpc.ConfigObjectName) bool {
+	expectedNames := make([]string, 0, len(expected))
+	for _, object := range expected {
+		expectedNames = append(expectedNames, driftObjectName(object))
+	}
+
+	actualNames := make([]string, 0, len(actual))
+	for _, object := range actual {
+		actualNames = append(actualNames, driftObjectName(object))
+	}
+
+	return driftSameStrings(expectedNames, actualNames)
+}
+
+// driftSameStrings 忽略顺序对比两个字符串列表
+func driftSameStrings(expected []string, actual []string) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+
+	expectedSorted := append([]string{}, expected...)
+	actualSorted := append([]string{}, actual...)
+	sort.Strings(expectedSorted)
+	sort.Strings(actualSorted)
+
+	for k := range expectedSorted {
+		if expectedSorted[k] != actualSorted[k] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// driftSameJson 将两份配置统一序列化为 JSON 后对比，避免数值类型、字段顺序差异造成误报
+func driftSameJson(expected interface{}, actual interface{}) bool {
+	expectedJson, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+	actualJson, err := json.Marshal(actual)
+	if err != nil {
+		return false
+	}
+
+	var expectedValue, actualValue interface{}
+	if json.Unmarshal(expectedJson, &expectedValue) != nil || json.Unmarshal(actualJson, &actualValue) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(expectedValue, actualValue)
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
+	"encoding/json"
+	"errors"
+	"gorm.io/gorm"
+)
+
+type EncryptionRotateResult struct {
+	Certificates  int `json:"certificates"`
+	AcmeAccounts  int `json:"acme_accounts"`
+	PluginConfigs int `json:"plugin_configs"`
+	ReleaseLogs   int `json:"release_logs"`
+}
+
+// EncryptionRotate 使用当前版本的主密钥重新加密未加密或由其他版本主密钥加密的证书私钥、ACME 账号私钥、
+// 插件密钥与发布记录中的插件密钥，所有修改在同一事务中完成，dryRun 时只统计数量
+func EncryptionRotate(dryRun bool) (result EncryptionRotateResult, err error) {
+	if packages.ConfigEncryption.CurrentVersion == 0 {
+		err = errors.New("encryption keys are not configured")
+		return
+	}
+
+	err = packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		var err error
+		if result.Certificates, err = rotateCertificates(tx, dryRun); err != nil {
+			return err
+		}
+		if result.AcmeAccounts, err = rotateAcmeAccounts(tx, dryRun); err != nil {
+			return err
+		}
+		if result.PluginConfigs, err = rotatePluginConfigs(tx, dryRun); err != nil {
+			return err
+		}
+		if result.ReleaseLogs, err = rotateReleaseLogs(tx, dryRun); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	return
+}
+
+// encryptionRotator 记录是否有字段需要重新加密，dryRun 时只记录不修改
+type encryptionRotator struct {
+	dryRun  bool
+	changed bool
+}
+
+func (r *encryptionRotator) rotate(value string) (string, error) {
+	if !utils.EncryptionRotateNeeded(value) {
+		return value, nil
+	}
+
+	r.changed = true
+	if r.dryRun {
+		return value, nil
+	}
+
+	return utils.EncryptionRotate(value)
+}
+
+func rotateCertificates(tx *gorm.DB, dryRun bool) (count int, err error) {
+	certificateModel := models.Certificates{}
+	certificateList, err := certificateModel.CertificateAllList(tx)
+	if err != nil {
+		return
+	}
+
+	for _, certificateInfo := range certificateList {
+		rotator := &encryptionRotator{dryRun: dryRun}
+		privateKey, err := rotator.rotate(certificateInfo.PrivateKey)
+		if err != nil {
+			return count, err
+		}
+		if !rotator.changed {
+			continue
+		}
+
+		count++
+		if dryRun {
+			continue
+		}
+		err = tx.Table(certificateModel.TableName()).Where("id = ?", certificateInfo.ID).
+			Update("private_key", privateKey).Error
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return
+}
+
+func rotateAcmeAccounts(tx *gorm.DB, dryRun bool) (count int, err error) {
+	accountModel := models.AcmeAccounts{}
+	accountList, err := accountModel.AcmeAccountAllList(tx)
+	if err != nil {
+		return
+	}
+
+	for _, accountInfo := range accountList {
+		rotator := &encryptionRotator{dryRun: dryRun}
+		privateKey, err := rotator.rotate(accountInfo.PrivateKey)
+		if err != nil {
+			return count, err
+		}
+		if !rotator.changed {
+			continue
+		}
+
+		count++
+		if dryRun {
+			continue
+		}
+		err = tx.Table(accountModel.TableName()).Where("id = ?", accountInfo.ID).
+			Update("private_key", privateKey).Error
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return
+}
+
+func rotatePluginConfigs(tx *gorm.DB, dryRun bool) (count int, err error) {
+	pluginConfigModel := models.PluginConfigs{}
+	pluginConfigList, err := pluginConfigModel.PluginConfigAllList(tx)
+	if err != nil {
+		return
+	}
+
+	for _, pluginConfigInfo := range pluginConfigList {
+		rotator := &encryptionRotator{dryRun: dryRun}
+		config, err := pluginConfigConvert(pluginConfigInfo.PluginKey, pluginConfigInfo.Config, rotator.rotate)
+		if err != nil {
+			return count, err
+		}
+		if !rotator.changed {
+			continue
+		}
+
+		count++
+		if dryRun {
+			continue
+		}
+		err = tx.Table(pluginConfigModel.TableName()).Where("id = ?", pluginConfigInfo.ID).
+			Update("config", config).Error
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return
+}
+
+// rotateReleaseLogs 发布记录中推送的插件配置与回滚时恢复的插件配置都包含插件密钥
+func rotateReleaseLogs(tx *gorm.DB, dryRun bool) (count int, err error) {
+	releaseLogModel := models.ReleaseLogs{}
+	releaseLogList, err := releaseLogModel.ReleaseLogAllList(tx)
+	if err != nil {
+		return
+	}
+
+	for _, releaseLogInfo := range releaseLogList {
+		var payload ReleasePayload
+		if err = json.Unmarshal([]byte(releaseLogInfo.Payload), &payload); err != nil {
+			return
+		}
+
+		var data ReleaseData
+		if err = json.Unmarshal([]byte(releaseLogInfo.Data), &data); err != nil {
+			return
+		}
+
+		rotator := &encryptionRotator{dryRun: dryRun}
+		for k, pluginInfo := range payload.Plugins {
+			payload.Plugins[k].Config, err = pluginRequestConfigSecret(pluginInfo.Key, pluginInfo.Config, rotator.rotate)
+			if err != nil {
+				return
+			}
+		}
+		for k, pluginConfigInfo := range data.PluginConfigs {
+			data.PluginConfigs[k].Config, err = pluginConfigConvert(pluginConfigInfo.PluginKey, pluginConfigInfo.Config, rotator.rotate)
+			if err != nil {
+				return
+			}
+		}
+		if !rotator.changed {
+			continue
+		}
+
+		count++
+		if dryRun {
+			continue
+		}
+
+		payloadJson, err := json.Marshal(payload)
+		if err != nil {
+			return count, err
+		}
+		dataJson, err := json.Marshal(data)
+		if err != nil {
+			return count, err
+		}
+
+		err = tx.Table(releaseLogModel.TableName()).Where("id = ?", releaseLogInfo.ID).
+			Updates(map[string]interface{}{
+				"payload": string(payloadJson),
+				"data":    string(dataJson),
+			}).Error
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return
+}
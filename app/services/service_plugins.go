@@ -11,6 +11,7 @@ import (
 	"encoding/json"
 	"errors"
 	"gorm.io/gorm"
+	"strings"
 	"sync"
 )
 
@@ -139,6 +140,7 @@ func (s *PluginsService) PluginConfigList(resType int, resId string) (*PluginCon
 
 		if err == nil {
 			item.Config, _ = configContext.StrategyPluginParse(v.Config)
+			item.Config, _ = pluginConfigSecret(item.Config, pluginSecretMask)
 		}
 
 		list = append(list, item)
@@ -175,6 +177,7 @@ func (s *PluginsService) PluginConfigInfoByResId(resId string) (*PluginConfigLis
 
 	if err == nil {
 		res.Config, _ = configContext.StrategyPluginParse(pluginConfig.Config)
+		res.Config, _ = pluginConfigSecret(res.Config, pluginSecretMask)
 	}
 
 	return res, nil
@@ -219,6 +222,15 @@ func (s *PluginsService) PluginConfigAdd(request *validators.ValidatorPluginConf
 	}
 
 	request.Config, _ = pluginContext.StrategyPluginParse(request.Config)
+	_, err = pluginConfigSecret(request.Config, utils.EncryptionPlaintextCheck)
+	if err != nil {
+		return
+	}
+	request.Config, err = pluginConfigSecret(request.Config, utils.Encrypt)
+	if err != nil {
+		return
+	}
+
 	config, err := json.Marshal(request.Config)
 
 	if err != nil {
@@ -272,6 +284,23 @@ func (s *PluginsService) PluginConfigUpdate(request *validators.ValidatorPluginC
 	}
 
 	request.Config, _ = pluginContext.StrategyPluginParse(request.Config)
+	_, err = pluginConfigSecret(request.Config, utils.EncryptionPlaintextCheck)
+	if err != nil {
+		return err
+	}
+
+	// 详情中的密钥已脱敏，原样提交脱敏内容时沿用已保存的密钥
+	existConfig, err := pluginContext.StrategyPluginParse(pluginConfigInfo.Config)
+	if err != nil {
+		return err
+	}
+	request.Config = pluginConfigSecretKeep(request.Config, existConfig)
+
+	request.Config, err = pluginConfigSecret(request.Config, utils.Encrypt)
+	if err != nil {
+		return err
+	}
+
 	config, err := json.Marshal(request.Config)
 
 	if err != nil {
@@ -356,23 +385,12 @@ func SyncPluginToDataSide(tx *gorm.DB, resType int, targetId string) ([]models.P
 	// 同步服务插件
 	for k, v := range pluginConfigList {
 
-		pluginContext, err := plugins.NewPluginContext(v.PluginKey)
+		pluginPutRequest, err := generatePluginPutRequest(v)
 
 		if err != nil {
 			continue
 		}
-
-		config, err := pluginContext.StrategyPluginParse(v.Config)
-
-		if err != nil {
-			continue
-		}
-		pluginPutRequest := &rpc.PluginPutRequest{
-			Name:   v.ResID,
-			Key:    v.PluginKey,
-			Config: config,
-		}
-		err = rpc.NewApiOak().PluginPut(pluginPutRequest)
+		err = rpc.NewApiOak().PluginPut(&pluginPutRequest)
 
 		if err != nil {
 			continue
@@ -384,6 +402,124 @@ func SyncPluginToDataSide(tx *gorm.DB, resType int, targetId string) ([]models.P
 	return success, nil
 }
 
+func generatePluginPutRequest(pluginConfig models.PluginConfigs) (rpc.PluginPutRequest, error) {
+	pluginContext, err := plugins.NewPluginContext(pluginConfig.PluginKey)
+	if err != nil {
+		return rpc.PluginPutRequest{}, err
+	}
+
+	config, err := pluginContext.StrategyPluginParse(pluginConfig.Config)
+	if err != nil {
+		return rpc.PluginPutRequest{}, err
+	}
+
+	// 插件密钥加密存储，只在同步至数据面时解密
+	config, err = pluginConfigSecret(config, utils.Decrypt)
+	if err != nil {
+		return rpc.PluginPutRequest{}, err
+	}
+
+	return rpc.PluginPutRequest{
+		Name:   pluginConfig.ResID,
+		Key:    pluginConfig.PluginKey,
+		Config: config,
+	}, nil
+}
+
+// pluginSecretKeys 配置中包含密钥字段的插件
+var pluginSecretKeys = map[string]bool{
+	utils.PluginKeyKeyAuth: true,
+	utils.PluginKeyJwtAuth: true,
+}
+
+// pluginConfigSecret 对解析后的插件配置中的密钥字段（key-auth 的 secret 与 jwt-auth 的 jwt_key）执行加密、解密或脱敏
+func pluginConfigSecret(config interface{}, convert func(value string) (string, error)) (interface{}, error) {
+	var err error
+	switch pluginConfig := config.(type) {
+	case plugins.PluginKeyAuth:
+		pluginConfig.Secret, err = convert(pluginConfig.Secret)
+		return pluginConfig, err
+	case plugins.PluginJwtAuth:
+		pluginConfig.JwtKey, err = convert(pluginConfig.JwtKey)
+		return pluginConfig, err
+	}
+
+	return config, nil
+}
+
+// pluginConfigPlaintext 已保存的插件配置解密密钥后的 JSON，用于与配置文件或快照中的明文配置对比
+func pluginConfigPlaintext(pluginKey string, config string) (string, error) {
+	return pluginConfigConvert(pluginKey, config, utils.Decrypt)
+}
+
+// pluginConfigConvert 对插件配置 JSON 中的密钥字段执行 convert，返回新的配置 JSON
+func pluginConfigConvert(pluginKey string, config string, convert func(value string) (string, error)) (string, error) {
+	if !pluginSecretKeys[strings.ToLower(pluginKey)] {
+		return config, nil
+	}
+
+	parseConfig, err := pluginRequestConfigSecret(pluginKey, config, convert)
+	if err != nil {
+		return "", err
+	}
+
+	configJson, err := json.Marshal(parseConfig)
+
+	return string(configJson), err
+}
+
+// pluginRequestConfigSecret 按插件解析配置（例如发布记录与数据面返回的配置）后对密钥字段执行 convert
+func pluginRequestConfigSecret(pluginKey string, config interface{}, convert func(value string) (string, error)) (interface{}, error) {
+	if !pluginSecretKeys[strings.ToLower(pluginKey)] {
+		return config, nil
+	}
+
+	pluginContext, err := plugins.NewPluginContext(pluginKey)
+	if err != nil {
+		return nil, err
+	}
+
+	parseConfig, err := pluginContext.StrategyPluginParse(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return pluginConfigSecret(parseConfig, convert)
+}
+
+// pluginConfigSecretKeep 提交的密钥为脱敏内容时替换为已保存的密钥
+func pluginConfigSecretKeep(config interface{}, existConfig interface{}) interface{} {
+	switch pluginConfig := config.(type) {
+	case plugins.PluginKeyAuth:
+		if exist, ok := existConfig.(plugins.PluginKeyAuth); ok && (pluginConfig.Secret == utils.SecretMask) {
+			pluginConfig.Secret = exist.Secret
+		}
+		return pluginConfig
+	case plugins.PluginJwtAuth:
+		if exist, ok := existConfig.(plugins.PluginJwtAuth); ok && (pluginConfig.JwtKey == utils.SecretMask) {
+			pluginConfig.JwtKey = exist.JwtKey
+		}
+		return pluginConfig
+	}
+
+	return config
+}
+
+// pluginPutRequestMask 返回密钥脱敏后的插件配置，用于漂移报告与变更预览
+func pluginPutRequestMask(request rpc.PluginPutRequest) rpc.PluginPutRequest {
+	request.Config, _ = pluginConfigSecret(request.Config, pluginSecretMask)
+
+	return request
+}
+
+func pluginSecretMask(value string) (string, error) {
+	if len(value) == 0 {
+		return value, nil
+	}
+
+	return utils.SecretMask, nil
+}
+
 func PluginBasicInfoMaintain() {
 
 	pluginModel := models.Plugins{}
@@ -0,0 +1,490 @@
+package services
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/rpc"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ReleasePayload 发布时推送至数据面的配置
+type ReleasePayload struct {
+	Service  *rpc.ServicePutRequest   `json:"service,omitempty"`
+	Router   *rpc.RouterConfig        `json:"router,omitempty"`
+	Upstream *rpc.UpstreamConfig      `json:"upstream,omitempty"`
+	Nodes    []rpc.UpstreamNodeConfig `json:"nodes,omitempty"`
+	Plugins  []rpc.PluginPutRequest   `json:"plugins,omitempty"`
+}
+
+// ReleaseData 发布时的控制面数据，回滚时按此恢复
+type ReleaseData struct {
+	Service       *models.Services        `json:"service,omitempty"`
+	Domains       []models.ServiceDomains `json:"domains,omitempty"`
+	Router        *models.Routers         `json:"router,omitempty"`
+	Upstream      *models.Upstreams       `json:"upstream,omitempty"`
+	Nodes         []models.UpstreamNodes  `json:"nodes,omitempty"`
+	PluginConfigs []models.PluginConfigs  `json:"plugin_configs,omitempty"`
+}
+
+type ReleaseLogItem struct {
+	ResID         string         `json:"res_id"`
+	ResourceType  string         `json:"resource_type"`
+	ResourceResID string         `json:"resource_res_id"`
+	Payload       ReleasePayload `json:"payload"`
+	Operator      string         `json:"operator"`
+	RollbackResID string         `json:"rollback_res_id"`
+	CreatedAt     int64          `json:"created_at"`
+}
+
+func releaseLogAdd(tx *gorm.DB, resourceType string, resourceResId string, payload ReleasePayload, data ReleaseData, operator string, rollbackResId string) error {
+	payloadJson, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	dataJson, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	releaseResId, err := (&models.ReleaseLogs{}).ReleaseLogAdd(tx, &models.ReleaseLogs{
+		ResourceType:  resourceType,
+		ResourceResID: resourceResId,
+		Payload:       string(payloadJson),
+		Data:          string(dataJson),
+		Operator:      operator,
+		RollbackResID: rollbackResId,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 发布与回滚都会产生 <resource>.released 事件，与发布记录在同一事务中写入
+	return webhookEmit(tx, resourceType+".released", map[string]interface{}{
+		"res_id":          resourceResId,
+		"name":            releaseDataName(data),
+		"release_res_id":  releaseResId,
+		"operator":        operator,
+		"rollback_res_id": rollbackResId,
+	})
+}
+
+func releaseDataName(data ReleaseData) string {
+	switch {
+	case data.Service != nil:
+		return data.Service.Name
+	case data.Router != nil:
+		return data.Router.RouterName
+	case data.Upstream != nil:
+		return data.Upstream.Name
+	}
+
+	return ""
+}
+
+// releasePluginPayload 生成已同步至数据面的插件配置，发布记录中的密钥保持加密
+func releasePluginPayload(pluginConfigs []models.PluginConfigs) []rpc.PluginPutRequest {
+	plugins := make([]rpc.PluginPutRequest, 0)
+	for _, pluginConfigInfo := range pluginConfigs {
+		pluginPutRequest, err := generatePluginPutRequest(pluginConfigInfo)
+		if err != nil {
+			continue
+		}
+		pluginPutRequest.Config, err = pluginConfigSecret(pluginPutRequest.Config, utils.Encrypt)
+		if err != nil {
+			continue
+		}
+		plugins = append(plugins, pluginPutRequest)
+	}
+
+	return plugins
+}
+
+func releaseLogRouter(tx *gorm.DB, routerInfo models.Routers, routerConfig rpc.RouterConfig, pluginConfigs []models.PluginConfigs, operator string) error {
+	allPluginConfigs, err := (&models.PluginConfigs{}).PluginConfigList(tx, models.PluginConfigsTypeRouter, routerInfo.ResID, 0)
+	if err != nil {
+		return err
+	}
+
+	routerInfo.Release = utils.ReleaseStatusY
+
+	payload := ReleasePayload{Router: &routerConfig, Plugins: releasePluginPayload(pluginConfigs)}
+	data := ReleaseData{Router: &routerInfo, PluginConfigs: allPluginConfigs}
+
+	return releaseLogAdd(tx, utils.ReleaseResourceRouter, routerInfo.ResID, payload, data, operator, "")
+}
+
+func releaseLogService(tx *gorm.DB, serviceInfo models.Services, serviceDomains []models.ServiceDomains, request rpc.ServicePutRequest, pluginConfigs []models.PluginConfigs, operator string) error {
+	allPluginConfigs, err := (&models.PluginConfigs{}).PluginConfigList(tx, models.PluginConfigsTypeService, serviceInfo.ResID, 0)
+	if err != nil {
+		return err
+	}
+
+	serviceInfo.Release = utils.ReleaseStatusY
+
+	payload := ReleasePayload{Service: &request, Plugins: releasePluginPayload(pluginConfigs)}
+	data := ReleaseData{Service: &serviceInfo, Domains: serviceDomains, PluginConfigs: allPluginConfigs}
+
+	return releaseLogAdd(tx, utils.ReleaseResourceService, serviceInfo.ResID, payload, data, operator, "")
+}
+
+func releaseLogUpstream(tx *gorm.DB, upstreamInfo models.Upstreams, upstreamConfig rpc.UpstreamConfig, upstreamNodes []models.UpstreamNodes, operator string) error {
+	nodes := make([]rpc.UpstreamNodeConfig, 0)
+	for _, upstreamNodeInfo := range upstreamNodes {
+		upstreamNodeConfig, err := generateUpstreamNodeConfig(upstreamInfo, upstreamNodeInfo)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, upstreamNodeConfig)
+	}
+
+	upstreamInfo.Release = utils.ReleaseStatusY
+
+	payload := ReleasePayload{Upstream: &upstreamConfig, Nodes: nodes}
+	data := ReleaseData{Upstream: &upstreamInfo, Nodes: upstreamNodes}
+
+	return releaseLogAdd(tx, utils.ReleaseResourceUpstream, upstreamInfo.ResID, payload, data, operator, "")
+}
+
+func ReleaseLogList(resourceType string, resourceResId string, param *validators.ReleaseLogList) (list []ReleaseLogItem, total int, err error) {
+	releaseLogList, total, err := (&models.ReleaseLogs{}).ReleaseLogListPage(resourceType, resourceResId, param)
+	if err != nil {
+		return
+	}
+
+	list = make([]ReleaseLogItem, 0)
+	for _, releaseLogInfo := range releaseLogList {
+		item := ReleaseLogItem{
+			ResID:         releaseLogInfo.ResID,
+			ResourceType:  releaseLogInfo.ResourceType,
+			ResourceResID: releaseLogInfo.ResourceResID,
+			Operator:      releaseLogInfo.Operator,
+			RollbackResID: releaseLogInfo.RollbackResID,
+			CreatedAt:     releaseLogInfo.CreatedAt.Unix(),
+		}
+
+		if err = json.Unmarshal([]byte(releaseLogInfo.Payload), &item.Payload); err != nil {
+			return
+		}
+		for k, pluginInfo := range item.Payload.Plugins {
+			item.Payload.Plugins[k].Config, err = pluginRequestConfigSecret(pluginInfo.Key, pluginInfo.Config, pluginSecretMask)
+			if err != nil {
+				return
+			}
+		}
+
+		list = append(list, item)
+	}
+
+	return
+}
+
+// ReleaseRollback 将指定发布记录中的配置重新推送至数据面，并恢复对应的控制面数据，回滚本身也会追加一条发布记录
+func ReleaseRollback(resourceType string, resourceResId string, releaseResId string, operator string) error {
+	releaseLogInfo, err := (&models.ReleaseLogs{}).ReleaseLogDetail(resourceType, resourceResId, releaseResId)
+	if err != nil {
+		return err
+	}
+
+	var payload ReleasePayload
+	if err = json.Unmarshal([]byte(releaseLogInfo.Payload), &payload); err != nil {
+		return err
+	}
+
+	var data ReleaseData
+	if err = json.Unmarshal([]byte(releaseLogInfo.Data), &data); err != nil {
+		return err
+	}
+
+	if err = checkReleaseRollback(resourceType, resourceResId, data); err != nil {
+		return err
+	}
+
+	// 回滚前控制面的插件配置，发布记录中没有的需要从数据面删除
+	currentPluginConfigs := make([]models.PluginConfigs, 0)
+	switch resourceType {
+	case utils.ReleaseResourceService:
+		currentPluginConfigs, err = (&models.PluginConfigs{}).PluginConfigList(packages.GetDb(), models.PluginConfigsTypeService, resourceResId, 0)
+	case utils.ReleaseResourceRouter:
+		currentPluginConfigs, err = (&models.PluginConfigs{}).PluginConfigList(packages.GetDb(), models.PluginConfigsTypeRouter, resourceResId, 0)
+	}
+	if err != nil {
+		return err
+	}
+
+	return packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		var err error
+		switch resourceType {
+		case utils.ReleaseResourceService:
+			err = rollbackServiceData(tx, data)
+		case utils.ReleaseResourceRouter:
+			err = rollbackRouterData(tx, data)
+		case utils.ReleaseResourceUpstream:
+			err = rollbackUpstreamData(tx, data)
+		default:
+			err = errors.New(enums.CodeMessages(enums.ReleaseLogNull))
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = releaseLogAdd(tx, resourceType, resourceResId, payload, data, operator, releaseLogInfo.ResID); err != nil {
+			return err
+		}
+
+		// 数据面推送失败时控制面数据随事务回滚
+		return rollbackPush(resourceResId, payload, currentPluginConfigs)
+	})
+}
+
+func checkReleaseRollback(resourceType string, resourceResId string, data ReleaseData) error {
+	switch resourceType {
+	case utils.ReleaseResourceService:
+		if data.Service == nil {
+			return errors.New(enums.CodeMessages(enums.ReleaseLogNull))
+		}
+
+		domains := make([]string, 0)
+		for _, domainInfo := range data.Domains {
+			domains = append(domains, domainInfo.Domain)
+		}
+
+		return NewServicesService().CheckExistDomain(domains, []string{resourceResId})
+
+	case utils.ReleaseResourceRouter:
+		if data.Router == nil {
+			return errors.New(enums.CodeMessages(enums.ReleaseLogNull))
+		}
+
+		serviceInfo, err := (&models.Services{}).ServiceInfoById(data.Router.ServiceResID)
+		if err != nil {
+			return fmt.Errorf(enums.CodeMessages(enums.ReleaseLogResourceNull), utils.ReleaseResourceService)
+		}
+		if serviceInfo.Release == utils.ReleaseStatusU {
+			return errors.New(enums.CodeMessages(enums.ServiceUnpublished))
+		}
+
+		if len(data.Router.UpstreamResID) != 0 {
+			upstreamInfo, err := models.Upstreams{}.UpstreamDetailByResId(data.Router.UpstreamResID)
+			if err != nil {
+				return err
+			}
+			if upstreamInfo.ResID != data.Router.UpstreamResID {
+				return fmt.Errorf(enums.CodeMessages(enums.ReleaseLogResourceNull), utils.ReleaseResourceUpstream)
+			}
+		}
+
+	case utils.ReleaseResourceUpstream:
+		if data.Upstream == nil {
+			return errors.New(enums.CodeMessages(enums.ReleaseLogNull))
+		}
+	}
+
+	return nil
+}
+
+func rollbackServiceData(tx *gorm.DB, data ReleaseData) (err error) {
+	serviceModel := models.Services{}
+	if err = tx.Table(serviceModel.TableName()).Where("res_id = ?", data.Service.ResID).Delete(&serviceModel).Error; err != nil {
+		return
+	}
+
+	data.Service.UpdatedAt = time.Now()
+	if err = tx.Table(serviceModel.TableName()).Create(data.Service).Error; err != nil {
+		return
+	}
+
+	domainModel := models.ServiceDomains{}
+	if err = tx.Table(domainModel.TableName()).Where("service_res_id = ?", data.Service.ResID).Delete(&domainModel).Error; err != nil {
+		return
+	}
+	for k := range data.Domains {
+		if err = tx.Table(domainModel.TableName()).Create(&data.Domains[k]).Error; err != nil {
+			return
+		}
+	}
+
+	return rollbackPluginConfigData(tx, models.PluginConfigsTypeService, data.Service.ResID, data.PluginConfigs)
+}
+
+func rollbackRouterData(tx *gorm.DB, data ReleaseData) (err error) {
+	routerModel := models.Routers{}
+	if err = tx.Table(routerModel.TableName()).Where("res_id = ?", data.Router.ResID).Delete(&routerModel).Error; err != nil {
+		return
+	}
+
+	data.Router.UpdatedAt = time.Now()
+	if err = tx.Table(routerModel.TableName()).Create(data.Router).Error; err != nil {
+		return
+	}
+
+	return rollbackPluginConfigData(tx, models.PluginConfigsTypeRouter, data.Router.ResID, data.PluginConfigs)
+}
+
+func rollbackUpstreamData(tx *gorm.DB, data ReleaseData) (err error) {
+	upstreamModel := models.Upstreams{}
+	if err = tx.Table(upstreamModel.TableName()).Where("res_id = ?", data.Upstream.ResID).Delete(&upstreamModel).Error; err != nil {
+		return
+	}
+
+	data.Upstream.UpdatedAt = time.Now()
+	if err = tx.Table(upstreamModel.TableName()).Create(data.Upstream).Error; err != nil {
+		return
+	}
+
+	upstreamNodeModel := models.UpstreamNodes{}
+	if err = tx.Table(upstreamNodeModel.TableName()).Where("upstream_res_id = ?", data.Upstream.ResID).Delete(&upstreamNodeModel).Error; err != nil {
+		return
+	}
+	for k := range data.Nodes {
+		if err = tx.Table(upstreamNodeModel.TableName()).Create(&data.Nodes[k]).Error; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func rollbackPluginConfigData(tx *gorm.DB, configType int, targetId string, pluginConfigs []models.PluginConfigs) (err error) {
+	pluginConfigModel := models.PluginConfigs{}
+	if err = tx.Table(pluginConfigModel.TableName()).
+		Where("type = ? AND target_id = ?", configType, targetId).
+		Delete(&pluginConfigModel).Error; err != nil {
+		return
+	}
+
+	for k := range pluginConfigs {
+		// 早于加密存储的发布记录中的密钥为明文，恢复时重新加密
+		pluginConfigs[k].Config, err = pluginConfigConvert(pluginConfigs[k].PluginKey, pluginConfigs[k].Config, utils.Encrypt)
+		if err != nil {
+			return
+		}
+		if err = tx.Table(pluginConfigModel.TableName()).Create(&pluginConfigs[k]).Error; err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func rollbackPush(resourceResId string, payload ReleasePayload, currentPluginConfigs []models.PluginConfigs) (err error) {
+	newApiOak := rpc.NewApiOak()
+
+	// 回滚前控制面与数据面的插件，服务或路由不再引用后再删除发布记录中没有的插件
+	currentPlugins := make([]string, 0)
+	for _, pluginConfigInfo := range currentPluginConfigs {
+		currentPlugins = append(currentPlugins, pluginConfigInfo.ResID)
+	}
+
+	if payload.Service != nil {
+		var cloudServiceInfo rpc.ServiceResponse
+		cloudServiceInfo, err = newApiOak.ServiceGet(resourceResId)
+		if err != nil {
+			return
+		}
+		for _, pluginInfo := range cloudServiceInfo.Plugins {
+			currentPlugins = append(currentPlugins, driftObjectName(pluginInfo))
+		}
+	}
+
+	if payload.Router != nil {
+		var cloudRouterList []rpc.RouterConfig
+		cloudRouterList, err = newApiOak.RouterGet([]string{resourceResId})
+		if err != nil {
+			return
+		}
+		for _, cloudRouterInfo := range cloudRouterList {
+			for _, pluginInfo := range cloudRouterInfo.Plugins {
+				currentPlugins = append(currentPlugins, driftObjectName(pluginInfo))
+			}
+		}
+	}
+
+	for _, pluginInfo := range payload.Plugins {
+		pluginInfo.Config, err = pluginRequestConfigSecret(pluginInfo.Key, pluginInfo.Config, utils.Decrypt)
+		if err != nil {
+			return
+		}
+		if err = newApiOak.PluginPut(&pluginInfo); err != nil {
+			return
+		}
+	}
+
+	if payload.Service != nil {
+		if err = newApiOak.ServicePut(payload.Service); err != nil {
+			return
+		}
+		return rollbackDeletePlugins(currentPlugins, payload.Plugins)
+	}
+
+	if payload.Router != nil {
+		if err = newApiOak.RouterPut([]rpc.RouterConfig{*payload.Router}); err != nil {
+			return
+		}
+		return rollbackDeletePlugins(currentPlugins, payload.Plugins)
+	}
+
+	if payload.Upstream != nil {
+		var cloudUpstreamList []rpc.UpstreamConfig
+		cloudUpstreamList, err = newApiOak.UpstreamGet([]string{resourceResId})
+		if err != nil {
+			return
+		}
+
+		if err = newApiOak.UpstreamNodePut(payload.Nodes); err != nil {
+			return
+		}
+
+		if err = newApiOak.UpstreamPut([]rpc.UpstreamConfig{*payload.Upstream}); err != nil {
+			return
+		}
+
+		// 上游不再引用后再删除数据面中多余的节点
+		payloadNodeIds := make(map[string]bool)
+		for _, nodeInfo := range payload.Nodes {
+			payloadNodeIds[nodeInfo.Name] = true
+		}
+
+		deleteNodeIds := make([]string, 0)
+		for _, cloudUpstreamInfo := range cloudUpstreamList {
+			for _, nodeInfo := range cloudUpstreamInfo.Nodes {
+				if !payloadNodeIds[nodeInfo.Id] {
+					deleteNodeIds = append(deleteNodeIds, nodeInfo.Id)
+				}
+			}
+		}
+
+		return NodeRelease(deleteNodeIds, utils.ReleaseTypeDelete)
+	}
+
+	return
+}
+
+// rollbackDeletePlugins 删除数据面中发布记录没有的插件
+func rollbackDeletePlugins(currentPlugins []string, payloadPlugins []rpc.PluginPutRequest) error {
+	payloadPluginNames := make(map[string]bool)
+	for _, pluginInfo := range payloadPlugins {
+		payloadPluginNames[pluginInfo.Name] = true
+	}
+
+	newApiOak := rpc.NewApiOak()
+	for _, pluginName := range currentPlugins {
+		if payloadPluginNames[pluginName] {
+			continue
+		}
+		payloadPluginNames[pluginName] = true
+
+		if err := newApiOak.PluginDelete(pluginName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -109,6 +109,12 @@ func RouterCreate(routerData *validators.ValidatorRouterAddUpdate) (routerResId
 		return
 	}
 
+	WebhookEmit(utils.WebhookEventRouterCreated, map[string]interface{}{
+		"res_id":         routerResId,
+		"service_res_id": routerData.ServiceResID,
+		"name":           routerData.RouterName,
+	})
+
 	return
 }
 
@@ -306,9 +312,43 @@ func RouterUpdate(routerResId string, routerData validators.ValidatorRouterAddUp
 		return
 	}
 
+	WebhookEmit(utils.WebhookEventRouterUpdated, map[string]interface{}{
+		"res_id":         routerResId,
+		"service_res_id": routerDetail.ServiceResID,
+		"name":           routerDetail.RouterName,
+	})
+
 	return
 }
 
+func RouterUpdateName(serviceResId string, routerResId string, name string) error {
+	return packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := (&models.Routers{}).RouterUpdateName(tx, routerResId, name); err != nil {
+			return err
+		}
+
+		return webhookEmit(tx, utils.WebhookEventRouterUpdated, map[string]interface{}{
+			"res_id":         routerResId,
+			"service_res_id": serviceResId,
+			"name":           name,
+		})
+	})
+}
+
+func RouterSwitchEnable(serviceResId string, routerResId string, enable int) error {
+	return packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := (&models.Routers{}).RouterSwitchEnable(tx, routerResId, enable); err != nil {
+			return err
+		}
+
+		return webhookEmit(tx, utils.WebhookEventRouterUpdated, map[string]interface{}{
+			"res_id":         routerResId,
+			"service_res_id": serviceResId,
+			"enable":         enable,
+		})
+	})
+}
+
 func filterPushedServiceRouterResIds(routerResIds []string) (opRoutersResIds []string, publishedRouterResIds []string, err error) {
 	if len(routerResIds) == 0 {
 		return
@@ -361,7 +401,7 @@ func filterPushedServiceRouterResIds(routerResIds []string) (opRoutersResIds []s
 	return
 }
 
-func RouterRelease(routerResIds []string, releaseType string) (err error) {
+func RouterRelease(routerResIds []string, releaseType string, operator string) (err error) {
 	if len(routerResIds) == 0 {
 		return
 	}
@@ -391,12 +431,15 @@ func RouterRelease(routerResIds []string, releaseType string) (err error) {
 	newApiOak := rpc.NewApiOak()
 	if releaseType == utils.ReleaseTypePush {
 
+		releasedRouters := make([]models.Routers, 0)
+		routerConfigList := make([]rpc.RouterConfig, 0)
+		routerPluginConfigs := make(map[string][]models.PluginConfigs)
 		err = packages.GetDb().Transaction(func(tx *gorm.DB) (err error) {
 
-			routerConfigList := make([]rpc.RouterConfig, 0)
 			for _, routerInfo := range routerList {
 
-				_, err = SyncPluginToDataSide(tx, models.PluginConfigsTypeRouter, routerInfo.ResID)
+				var successPluginConfig []models.PluginConfigs
+				successPluginConfig, err = SyncPluginToDataSide(tx, models.PluginConfigsTypeRouter, routerInfo.ResID)
 
 				if err != nil {
 					return
@@ -417,7 +460,9 @@ func RouterRelease(routerResIds []string, releaseType string) (err error) {
 					return
 				}
 
+				releasedRouters = append(releasedRouters, routerInfo)
 				routerConfigList = append(routerConfigList, routerConfig)
+				routerPluginConfigs[routerInfo.ResID] = successPluginConfig
 			}
 
 			err = newApiOak.RouterPut(routerConfigList)
@@ -427,6 +472,21 @@ func RouterRelease(routerResIds []string, releaseType string) (err error) {
 
 			return
 		})
+		if err != nil {
+			return
+		}
+
+		// 推送成功后写入发布记录
+		err = packages.GetDb().Transaction(func(tx *gorm.DB) (err error) {
+			for k, routerInfo := range releasedRouters {
+				err = releaseLogRouter(tx, routerInfo, routerConfigList[k], routerPluginConfigs[routerInfo.ResID], operator)
+				if err != nil {
+					return
+				}
+			}
+
+			return
+		})
 
 	} else {
 		err = newApiOak.RouterDelete(publishedRouterResIds)
@@ -500,11 +560,17 @@ func RouterDelete(routerResId string) (err error) {
 		return
 	}
 
-	err = RouterRelease([]string{routerResId}, utils.ReleaseTypeDelete)
+	err = RouterRelease([]string{routerResId}, utils.ReleaseTypeDelete, "")
 	if err != nil {
 		return
 	}
 
+	WebhookEmit(utils.WebhookEventRouterDeleted, map[string]interface{}{
+		"res_id":         routerResId,
+		"service_res_id": routerDetail.ServiceResID,
+		"name":           routerDetail.RouterName,
+	})
+
 	return
 }
 
@@ -523,19 +589,22 @@ func RouterCopy(routerResId string) (err error) {
 		return
 	}
 
+	newRouterResId := ""
+	newRouterName := ""
 	err = packages.GetDb().Transaction(func(tx *gorm.DB) (err error) {
-		newRouterResId, err := routerModel.ModelUniqueId()
+		newRouterResId, err = routerModel.ModelUniqueId()
 		if err != nil {
 			return
 		}
 
 		randomStr := utils.RandomStrGenerate(4)
+		newRouterName = routerDetail.RouterName + "-copy-" + randomStr
 		err = tx.Table(routerModel.TableName()).Create(&models.Routers{
 			ResID:          newRouterResId,
 			ServiceResID:   routerDetail.ServiceResID,
 			UpstreamResID:  routerDetail.UpstreamResID,
 			RequestMethods: routerDetail.RequestMethods,
-			RouterName:     routerDetail.RouterName + "-copy-" + randomStr,
+			RouterName:     newRouterName,
 			RouterPath:     routerDetail.RouterPath + "-copy-" + randomStr,
 			Enable:         routerDetail.Enable,
 			Release:        utils.ReleaseStatusU,
@@ -573,6 +642,15 @@ func RouterCopy(routerResId string) (err error) {
 
 		return
 	})
+	if err != nil {
+		return
+	}
+
+	WebhookEmit(utils.WebhookEventRouterCreated, map[string]interface{}{
+		"res_id":         newRouterResId,
+		"service_res_id": routerDetail.ServiceResID,
+		"name":           newRouterName,
+	})
 
 	return
 }
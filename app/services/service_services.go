@@ -42,7 +42,7 @@ func CheckServiceExist(serviceResId string) error {
 	return nil
 }
 
-func (s *ServicesService) ServiceCreate(request *validators.ServiceAddUpdate) error {
+func (s *ServicesService) ServiceCreate(request *validators.ServiceAddUpdate) (serviceResId string, err error) {
 
 	createServiceData := &models.Services{
 		Name:     request.Name,
@@ -51,13 +51,17 @@ func (s *ServicesService) ServiceCreate(request *validators.ServiceAddUpdate) er
 		Release:  utils.ReleaseStatusU,
 	}
 
-	_, err := (&models.Services{}).ServiceAdd(createServiceData, request.ServiceDomains)
-
+	serviceResId, err = (&models.Services{}).ServiceAdd(createServiceData, request.ServiceDomains)
 	if err != nil {
-		return err
+		return
 	}
 
-	return nil
+	WebhookEmit(utils.WebhookEventServiceCreated, map[string]interface{}{
+		"res_id": serviceResId,
+		"name":   request.Name,
+	})
+
+	return
 }
 
 func (s *ServicesService) ServiceUpdate(serviceId string, request *validators.ServiceAddUpdate) error {
@@ -78,7 +82,17 @@ func (s *ServicesService) ServiceUpdate(serviceId string, request *validators.Se
 		updateServiceData.Release = utils.ReleaseStatusT
 	}
 
-	return serviceModel.ServiceUpdate(serviceId, &updateServiceData, request.ServiceDomains)
+	err = serviceModel.ServiceUpdate(serviceId, &updateServiceData, request.ServiceDomains)
+	if err != nil {
+		return err
+	}
+
+	WebhookEmit(utils.WebhookEventServiceUpdated, map[string]interface{}{
+		"res_id": serviceId,
+		"name":   request.Name,
+	})
+
+	return nil
 }
 
 func (s *ServicesService) ServiceUpdateName(serviceId string, request *validators.ServiceUpdateName) error {
@@ -96,7 +110,17 @@ func (s *ServicesService) ServiceUpdateName(serviceId string, request *validator
 		updateParam["release"] = utils.ReleaseStatusT
 	}
 
-	return serviceModel.ServiceUpdateColumns(serviceId, updateParam)
+	err = serviceModel.ServiceUpdateColumns(serviceId, updateParam)
+	if err != nil {
+		return err
+	}
+
+	WebhookEmit(utils.WebhookEventServiceUpdated, map[string]interface{}{
+		"res_id": serviceId,
+		"name":   request.Name,
+	})
+
+	return nil
 }
 
 func checkServiceEnableChange(serviceId string, enable int) error {
@@ -131,7 +155,18 @@ func (s *ServicesService) ServiceSwitchEnable(serviceId string, enable int) erro
 		updateParam["release"] = utils.ReleaseStatusT
 	}
 
-	return serviceModel.ServiceUpdateColumns(serviceId, updateParam)
+	err = serviceModel.ServiceUpdateColumns(serviceId, updateParam)
+	if err != nil {
+		return err
+	}
+
+	WebhookEmit(utils.WebhookEventServiceUpdated, map[string]interface{}{
+		"res_id": serviceId,
+		"name":   service.Name,
+		"enable": enable,
+	})
+
+	return nil
 }
 
 type StructServiceInfo struct {
@@ -176,6 +211,11 @@ func (s *ServicesService) ServiceInfoById(serviceId string) (StructServiceInfo,
 
 func (s *ServicesService) ServiceDelete(serviceId string) error {
 
+	service, err := (&models.Services{}).ServiceInfoById(serviceId)
+	if err != nil {
+		return err
+	}
+
 	routeModel := models.Routers{}
 	routerList := routeModel.RouterInfosByServiceIdReleaseStatus(serviceId, []int{})
 
@@ -183,7 +223,7 @@ func (s *ServicesService) ServiceDelete(serviceId string) error {
 		return errors.New(enums.CodeMessages(enums.ServiceBindingRouter))
 	}
 
-	err := packages.GetDb().Transaction(func(tx *gorm.DB) error {
+	err = packages.GetDb().Transaction(func(tx *gorm.DB) error {
 
 		// 删除service 信息
 		err := (&models.Services{}).ServiceDelete(serviceId)
@@ -238,6 +278,11 @@ func (s *ServicesService) ServiceDelete(serviceId string) error {
 		return err
 	}
 
+	WebhookEmit(utils.WebhookEventServiceDeleted, map[string]interface{}{
+		"res_id": serviceId,
+		"name":   service.Name,
+	})
+
 	return nil
 }
 
@@ -435,7 +480,7 @@ func genServiceReleaseSyncRequest(service models.Services, serviceDomains []mode
 	return servicePutRequest
 }
 
-func (s *ServicesService) ServiceRelease(serviceId string) error {
+func (s *ServicesService) ServiceRelease(serviceId string, operator string) error {
 
 	serviceInfo, err := (&models.Services{}).ServiceInfoById(serviceId)
 
@@ -481,6 +526,12 @@ func (s *ServicesService) ServiceRelease(serviceId string) error {
 			return err
 		}
 
+		err = releaseLogService(tx, serviceInfo, serviceDomain, request, successPluginConfig, operator)
+		if err != nil {
+			packages.Log.Error("service release log error", err.Error())
+			return err
+		}
+
 		return nil
 	})
 
@@ -525,30 +576,20 @@ func CheckDomainCertificate(protocol int, domains []string) error {
 		return nil
 	}
 
-	domainSniInfos, err := utils.InterceptSni(domains)
+	_, err := utils.InterceptSni(domains)
 	if err != nil {
 		return err
 	}
 
-	certificatesModel := models.Certificates{}
-	domainCertificateInfos := certificatesModel.CertificateInfoByDomainSniInfos(domainSniInfos)
-	if len(domainCertificateInfos) == len(domainSniInfos) {
-		return nil
+	domainCertificateInfos, err := (&models.Certificates{}).CertificateInfoByDomainSniInfos(domains)
+	if err != nil {
+		return err
 	}
 
 	nullCertificateDomains := make([]string, 0)
 	for _, domainInfo := range domains {
-		if len(domainCertificateInfos) == 0 {
-
+		if _, ok := domainCertificateInfos[domainInfo]; !ok {
 			nullCertificateDomains = append(nullCertificateDomains, domainInfo)
-		} else {
-			for _, domainCertificateInfo := range domainCertificateInfos {
-
-				domainSni := strings.ReplaceAll(domainCertificateInfo.Sni, "*", "")
-				if domainInfo[len(domainInfo)-len(domainSni):] != domainSni {
-					nullCertificateDomains = append(nullCertificateDomains, domainInfo)
-				}
-			}
 		}
 	}
 
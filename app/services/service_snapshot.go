@@ -0,0 +1,1349 @@
+package services
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/services/plugins"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SnapshotService struct {
+}
+
+var (
+	snapshotService *SnapshotService
+	snapshotOnce    sync.Once
+
+	errSnapshotDryRun = errors.New("snapshot dry run")
+)
+
+func NewSnapshotService() *SnapshotService {
+
+	snapshotOnce.Do(func() {
+		snapshotService = &SnapshotService{}
+	})
+
+	return snapshotService
+}
+
+type SnapshotServiceItem struct {
+	ResID    string `json:"res_id" yaml:"res_id"`
+	Name     string `json:"name" yaml:"name"`
+	Protocol int    `json:"protocol" yaml:"protocol"`
+	Enable   int    `json:"enable" yaml:"enable"`
+}
+
+type SnapshotServiceDomainItem struct {
+	ResID        string `json:"res_id" yaml:"res_id"`
+	ServiceResID string `json:"service_res_id" yaml:"service_res_id"`
+	Domain       string `json:"domain" yaml:"domain"`
+}
+
+type SnapshotRouterItem struct {
+	ResID          string `json:"res_id" yaml:"res_id"`
+	ServiceResID   string `json:"service_res_id" yaml:"service_res_id"`
+	UpstreamResID  string `json:"upstream_res_id" yaml:"upstream_res_id"`
+	RouterName     string `json:"router_name" yaml:"router_name"`
+	RequestMethods string `json:"request_methods" yaml:"request_methods"`
+	RouterPath     string `json:"router_path" yaml:"router_path"`
+	Enable         int    `json:"enable" yaml:"enable"`
+}
+
+type SnapshotUpstreamItem struct {
+	ResID          string `json:"res_id" yaml:"res_id"`
+	Name           string `json:"name" yaml:"name"`
+	Algorithm      int    `json:"algorithm" yaml:"algorithm"`
+	HashOn         string `json:"hash_on" yaml:"hash_on"`
+	HashKey        string `json:"hash_key" yaml:"hash_key"`
+	ConnectTimeout int    `json:"connect_timeout" yaml:"connect_timeout"`
+	WriteTimeout   int    `json:"write_timeout" yaml:"write_timeout"`
+	ReadTimeout    int    `json:"read_timeout" yaml:"read_timeout"`
+	Enable         int    `json:"enable" yaml:"enable"`
+
+	HealthCheck UpstreamHealthCheckItem `json:"health_check" yaml:"health_check"`
+}
+
+type SnapshotUpstreamNodeItem struct {
+	ResID         string `json:"res_id" yaml:"res_id"`
+	UpstreamResID string `json:"upstream_res_id" yaml:"upstream_res_id"`
+	NodeIP        string `json:"node_ip" yaml:"node_ip"`
+	NodePort      int    `json:"node_port" yaml:"node_port"`
+	NodeWeight    int    `json:"node_weight" yaml:"node_weight"`
+	Health        int    `json:"health" yaml:"health"`
+	HealthCheck   int    `json:"health_check" yaml:"health_check"`
+}
+
+type SnapshotPluginConfigItem struct {
+	ResID       string      `json:"res_id" yaml:"res_id"`
+	Name        string      `json:"name" yaml:"name"`
+	Type        int         `json:"type" yaml:"type"`
+	TargetID    string      `json:"target_id" yaml:"target_id"`
+	PluginResID string      `json:"plugin_res_id" yaml:"plugin_res_id"`
+	PluginKey   string      `json:"plugin_key" yaml:"plugin_key"`
+	Config      interface{} `json:"config" yaml:"config"`
+	Enable      int         `json:"enable" yaml:"enable"`
+}
+
+type SnapshotCertificateItem struct {
+	ResID       string `json:"res_id" yaml:"res_id"`
+	Sni         string `json:"sni" yaml:"sni"`
+	Certificate string `json:"certificate" yaml:"certificate"`
+	PrivateKey  string `json:"private_key" yaml:"private_key"`
+	Enable      int    `json:"enable" yaml:"enable"`
+}
+
+type Snapshot struct {
+	Version        int                         `json:"version" yaml:"version"`
+	ExportedAt     string                      `json:"exported_at" yaml:"exported_at"`
+	Services       []SnapshotServiceItem       `json:"services" yaml:"services"`
+	ServiceDomains []SnapshotServiceDomainItem `json:"service_domains" yaml:"service_domains"`
+	Routers        []SnapshotRouterItem        `json:"routers" yaml:"routers"`
+	Upstreams      []SnapshotUpstreamItem      `json:"upstreams" yaml:"upstreams"`
+	UpstreamNodes  []SnapshotUpstreamNodeItem  `json:"upstream_nodes" yaml:"upstream_nodes"`
+	PluginConfigs  []SnapshotPluginConfigItem  `json:"plugin_configs" yaml:"plugin_configs"`
+	Certificates   []SnapshotCertificateItem   `json:"certificates" yaml:"certificates"`
+}
+
+type SnapshotImportPlan struct {
+	Create    int `json:"create"`
+	Update    int `json:"update"`
+	Delete    int `json:"delete"`
+	Unchanged int `json:"unchanged"`
+}
+
+type SnapshotImportResult struct {
+	Mode           string             `json:"mode"`
+	DryRun         bool               `json:"dry_run"`
+	Version        int                `json:"version"`
+	Services       SnapshotImportPlan `json:"services"`
+	ServiceDomains SnapshotImportPlan `json:"service_domains"`
+	Routers        SnapshotImportPlan `json:"routers"`
+	Upstreams      SnapshotImportPlan `json:"upstreams"`
+	UpstreamNodes  SnapshotImportPlan `json:"upstream_nodes"`
+	PluginConfigs  SnapshotImportPlan `json:"plugin_configs"`
+	Certificates   SnapshotImportPlan `json:"certificates"`
+}
+
+// SnapshotExport 导出服务、路由、上游、插件配置与证书的全量快照，密钥默认以密文导出，导入方需要相同的主密钥；plaintext 时解密后导出
+func (s *SnapshotService) SnapshotExport(plaintext bool) (snapshot Snapshot, err error) {
+	db := packages.GetDb()
+
+	snapshot = Snapshot{
+		Version:        utils.SnapshotVersion,
+		ExportedAt:     time.Now().Format(time.RFC3339),
+		Services:       make([]SnapshotServiceItem, 0),
+		ServiceDomains: make([]SnapshotServiceDomainItem, 0),
+		Routers:        make([]SnapshotRouterItem, 0),
+		Upstreams:      make([]SnapshotUpstreamItem, 0),
+		UpstreamNodes:  make([]SnapshotUpstreamNodeItem, 0),
+		PluginConfigs:  make([]SnapshotPluginConfigItem, 0),
+		Certificates:   make([]SnapshotCertificateItem, 0),
+	}
+
+	serviceList, err := (&models.Services{}).ServiceAllList(db)
+	if err != nil {
+		return
+	}
+	for _, serviceInfo := range serviceList {
+		snapshot.Services = append(snapshot.Services, SnapshotServiceItem{
+			ResID:    serviceInfo.ResID,
+			Name:     serviceInfo.Name,
+			Protocol: serviceInfo.Protocol,
+			Enable:   serviceInfo.Enable,
+		})
+	}
+
+	domainList, err := (&models.ServiceDomains{}).DomainAllList(db)
+	if err != nil {
+		return
+	}
+	for _, domainInfo := range domainList {
+		snapshot.ServiceDomains = append(snapshot.ServiceDomains, SnapshotServiceDomainItem{
+			ResID:        domainInfo.ResID,
+			ServiceResID: domainInfo.ServiceResID,
+			Domain:       domainInfo.Domain,
+		})
+	}
+
+	routerList, err := (&models.Routers{}).RouterAllList(db)
+	if err != nil {
+		return
+	}
+	for _, routerInfo := range routerList {
+		snapshot.Routers = append(snapshot.Routers, SnapshotRouterItem{
+			ResID:          routerInfo.ResID,
+			ServiceResID:   routerInfo.ServiceResID,
+			UpstreamResID:  routerInfo.UpstreamResID,
+			RouterName:     routerInfo.RouterName,
+			RequestMethods: routerInfo.RequestMethods,
+			RouterPath:     routerInfo.RouterPath,
+			Enable:         routerInfo.Enable,
+		})
+	}
+
+	upstreamList, err := models.Upstreams{}.UpstreamAllList(db)
+	if err != nil {
+		return
+	}
+	for _, upstreamInfo := range upstreamList {
+		snapshot.Upstreams = append(snapshot.Upstreams, SnapshotUpstreamItem{
+			ResID:          upstreamInfo.ResID,
+			Name:           upstreamInfo.Name,
+			Algorithm:      upstreamInfo.Algorithm,
+			HashOn:         upstreamInfo.HashOn,
+			HashKey:        upstreamInfo.HashKey,
+			ConnectTimeout: upstreamInfo.ConnectTimeout,
+			WriteTimeout:   upstreamInfo.WriteTimeout,
+			ReadTimeout:    upstreamInfo.ReadTimeout,
+			Enable:         upstreamInfo.Enable,
+			HealthCheck:    upstreamHealthCheckItem(upstreamInfo),
+		})
+	}
+
+	upstreamNodeList, err := (&models.UpstreamNodes{}).UpstreamNodeAllList(db)
+	if err != nil {
+		return
+	}
+	for _, upstreamNodeInfo := range upstreamNodeList {
+		snapshot.UpstreamNodes = append(snapshot.UpstreamNodes, SnapshotUpstreamNodeItem{
+			ResID:         upstreamNodeInfo.ResID,
+			UpstreamResID: upstreamNodeInfo.UpstreamResID,
+			NodeIP:        upstreamNodeInfo.NodeIP,
+			NodePort:      upstreamNodeInfo.NodePort,
+			NodeWeight:    upstreamNodeInfo.NodeWeight,
+			Health:        upstreamNodeInfo.Health,
+			HealthCheck:   upstreamNodeInfo.HealthCheck,
+		})
+	}
+
+	pluginConfigList, err := (&models.PluginConfigs{}).PluginConfigAllList(db)
+	if err != nil {
+		return
+	}
+	for _, pluginConfigInfo := range pluginConfigList {
+		configJson := pluginConfigInfo.Config
+		if plaintext {
+			if configJson, err = pluginConfigPlaintext(pluginConfigInfo.PluginKey, configJson); err != nil {
+				return
+			}
+		}
+
+		var config interface{}
+		if err = json.Unmarshal([]byte(configJson), &config); err != nil {
+			return
+		}
+
+		snapshot.PluginConfigs = append(snapshot.PluginConfigs, SnapshotPluginConfigItem{
+			ResID:       pluginConfigInfo.ResID,
+			Name:        pluginConfigInfo.Name,
+			Type:        pluginConfigInfo.Type,
+			TargetID:    pluginConfigInfo.TargetID,
+			PluginResID: pluginConfigInfo.PluginResID,
+			PluginKey:   pluginConfigInfo.PluginKey,
+			Config:      config,
+			Enable:      pluginConfigInfo.Enable,
+		})
+	}
+
+	certificateList, err := (&models.Certificates{}).CertificateAllList(db)
+	if err != nil {
+		return
+	}
+	for _, certificateInfo := range certificateList {
+		privateKey := certificateInfo.PrivateKey
+		if plaintext {
+			if privateKey, err = utils.Decrypt(privateKey); err != nil {
+				return
+			}
+		}
+
+		snapshot.Certificates = append(snapshot.Certificates, SnapshotCertificateItem{
+			ResID:       certificateInfo.ResID,
+			Sni:         certificateInfo.Sni,
+			Certificate: certificateInfo.Certificate,
+			PrivateKey:  privateKey,
+			Enable:      certificateInfo.Enable,
+		})
+	}
+
+	return
+}
+
+// SnapshotEncode 按指定格式序列化快照
+func SnapshotEncode(snapshot Snapshot, format string) ([]byte, error) {
+	if strings.ToLower(format) == utils.SnapshotFormatYaml {
+		return yaml.Marshal(snapshot)
+	}
+
+	return json.MarshalIndent(snapshot, "", "  ")
+}
+
+// SnapshotDecode 解析 JSON 或 YAML 格式的快照（JSON 是 YAML 的子集）
+func SnapshotDecode(content []byte) (snapshot Snapshot, err error) {
+	if err = yaml.Unmarshal(content, &snapshot); err != nil {
+		err = errors.New(enums.CodeMessages(enums.SnapshotFormatError))
+		return
+	}
+
+	if (snapshot.Version <= 0) || (snapshot.Version > utils.SnapshotVersion) {
+		err = fmt.Errorf(enums.CodeMessages(enums.SnapshotVersionError), snapshot.Version)
+	}
+
+	return
+}
+
+// snapshotImportState 导入过程中控制面已有数据与导入后数据的 res_id 集合
+type snapshotImportState struct {
+	mode           string
+	services       map[string]models.Services
+	serviceDomains map[string]models.ServiceDomains
+	routers        map[string]models.Routers
+	upstreams      map[string]models.Upstreams
+	upstreamNodes  map[string]models.UpstreamNodes
+	pluginConfigs  map[string]models.PluginConfigs
+	certificates   map[string]models.Certificates
+	plugins        map[string]models.Plugins
+
+	changedServices     map[string]byte
+	changedRouters      map[string]byte
+	changedUpstreams    map[string]byte
+	changedCertificates map[string]byte
+}
+
+// SnapshotImport 按 merge 或 replace 模式导入快照，dryRun 时在事务中执行与实际导入相同的全部变更后回滚，仅返回变更计划
+func (s *SnapshotService) SnapshotImport(snapshot *Snapshot, mode string, dryRun bool) (result SnapshotImportResult, err error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if (mode != utils.SnapshotModeMerge) && (mode != utils.SnapshotModeReplace) {
+		err = errors.New(enums.CodeMessages(enums.ParamsError))
+		return
+	}
+
+	if (snapshot.Version <= 0) || (snapshot.Version > utils.SnapshotVersion) {
+		err = fmt.Errorf(enums.CodeMessages(enums.SnapshotVersionError), snapshot.Version)
+		return
+	}
+
+	if err = checkSnapshotItems(snapshot); err != nil {
+		return
+	}
+
+	result.Mode = mode
+	result.DryRun = dryRun
+	result.Version = snapshot.Version
+
+	// 命令行执行时管理服务可能未启动过，先同步插件基础信息
+	PluginBasicInfoMaintain()
+
+	err = packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		state, err := loadSnapshotImportState(tx, mode)
+		if err != nil {
+			return err
+		}
+
+		if err = checkSnapshotReferences(snapshot, state); err != nil {
+			return err
+		}
+
+		if err = importSnapshotUpstreams(tx, snapshot, state, &result); err != nil {
+			return err
+		}
+		if err = importSnapshotServices(tx, snapshot, state, &result); err != nil {
+			return err
+		}
+		if err = importSnapshotRouters(tx, snapshot, state, &result); err != nil {
+			return err
+		}
+		if err = importSnapshotPluginConfigs(tx, snapshot, state, &result); err != nil {
+			return err
+		}
+		if err = importSnapshotCertificates(tx, snapshot, state, &result); err != nil {
+			return err
+		}
+		if err = markSnapshotChangedTargets(tx, state); err != nil {
+			return err
+		}
+
+		if dryRun {
+			return errSnapshotDryRun
+		}
+
+		return emitSnapshotWebhooks(tx, snapshot, state)
+	})
+
+	if errors.Is(err, errSnapshotDryRun) {
+		err = nil
+	}
+
+	return
+}
+
+func checkSnapshotItems(snapshot *Snapshot) error {
+	resIds := make(map[string]byte)
+	checkResId := func(table string, resId string) error {
+		if len(strings.TrimSpace(resId)) == 0 {
+			return fmt.Errorf(enums.CodeMessages(enums.SnapshotResIdNull), table)
+		}
+		if _, ok := resIds[resId]; ok {
+			return fmt.Errorf(enums.CodeMessages(enums.SnapshotResIdRepeat), resId)
+		}
+		resIds[resId] = 0
+		return nil
+	}
+	checkEnable := func(resId string, enable int) error {
+		if (enable != utils.EnableOn) && (enable != utils.EnableOff) {
+			return fmt.Errorf(enums.CodeMessages(enums.SnapshotFieldError), resId, "enable")
+		}
+		return nil
+	}
+
+	for _, item := range snapshot.Services {
+		if err := checkResId("services", item.ResID); err != nil {
+			return err
+		}
+		if (item.Protocol < utils.ProtocolHTTP) || (item.Protocol > utils.ProtocolHTTPAndHTTPS) {
+			return fmt.Errorf(enums.CodeMessages(enums.SnapshotFieldError), item.ResID, "protocol")
+		}
+		if err := checkEnable(item.ResID, item.Enable); err != nil {
+			return err
+		}
+	}
+	for _, item := range snapshot.ServiceDomains {
+		if err := checkResId("service_domains", item.ResID); err != nil {
+			return err
+		}
+		if len(strings.TrimSpace(item.Domain)) == 0 {
+			return fmt.Errorf(enums.CodeMessages(enums.SnapshotFieldError), item.ResID, "domain")
+		}
+	}
+	for _, item := range snapshot.Routers {
+		if err := checkResId("routers", item.ResID); err != nil {
+			return err
+		}
+		if !strings.HasPrefix(item.RouterPath, "/") {
+			return fmt.Errorf(enums.CodeMessages(enums.SnapshotFieldError), item.ResID, "router_path")
+		}
+		if len(strings.TrimSpace(item.RequestMethods)) == 0 {
+			return fmt.Errorf(enums.CodeMessages(enums.SnapshotFieldError), item.ResID, "request_methods")
+		}
+		if err := checkEnable(item.ResID, item.Enable); err != nil {
+			return err
+		}
+	}
+	for _, item := range snapshot.Upstreams {
+		if err := checkResId("upstreams", item.ResID); err != nil {
+			return err
+		}
+		if err := checkEnable(item.ResID, item.Enable); err != nil {
+			return err
+		}
+		healthCheck := validators.UpstreamHealthCheck(item.HealthCheck)
+		if _, err := packages.ValidateRequestParams(&healthCheck); err != nil {
+			return fmt.Errorf(enums.CodeMessages(enums.SnapshotFieldError), item.ResID, "health_check")
+		}
+		if item.Algorithm == utils.LoadBalanceCHash {
+			switch item.HashOn {
+			case "", utils.HashOnIp, utils.HashOnUri:
+			case utils.HashOnHeader, utils.HashOnCookie, utils.HashOnQuery:
+				if len(strings.TrimSpace(item.HashKey)) == 0 {
+					return fmt.Errorf(enums.CodeMessages(enums.SnapshotFieldError), item.ResID, "hash_key")
+				}
+			default:
+				return fmt.Errorf(enums.CodeMessages(enums.SnapshotFieldError), item.ResID, "hash_on")
+			}
+		}
+	}
+	for _, item := range snapshot.UpstreamNodes {
+		if err := checkResId("upstream_nodes", item.ResID); err != nil {
+			return err
+		}
+		if _, err := utils.DiscernIP(item.NodeIP); err != nil {
+			return fmt.Errorf(enums.CodeMessages(enums.SnapshotFieldError), item.ResID, "node_ip")
+		}
+		if (item.NodePort <= 0) || (item.NodePort > 65535) {
+			return fmt.Errorf(enums.CodeMessages(enums.SnapshotFieldError), item.ResID, "node_port")
+		}
+	}
+	for _, item := range snapshot.PluginConfigs {
+		if err := checkResId("plugin_configs", item.ResID); err != nil {
+			return err
+		}
+		if (item.Type != models.PluginConfigsTypeService) && (item.Type != models.PluginConfigsTypeRouter) {
+			return fmt.Errorf(enums.CodeMessages(enums.SnapshotFieldError), item.ResID, "type")
+		}
+		if err := checkEnable(item.ResID, item.Enable); err != nil {
+			return err
+		}
+	}
+	for _, item := range snapshot.Certificates {
+		if err := checkResId("certificates", item.ResID); err != nil {
+			return err
+		}
+		if err := checkEnable(item.ResID, item.Enable); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func loadSnapshotImportState(tx *gorm.DB, mode string) (state *snapshotImportState, err error) {
+	state = &snapshotImportState{
+		mode:                mode,
+		services:            make(map[string]models.Services),
+		serviceDomains:      make(map[string]models.ServiceDomains),
+		routers:             make(map[string]models.Routers),
+		upstreams:           make(map[string]models.Upstreams),
+		upstreamNodes:       make(map[string]models.UpstreamNodes),
+		pluginConfigs:       make(map[string]models.PluginConfigs),
+		certificates:        make(map[string]models.Certificates),
+		plugins:             make(map[string]models.Plugins),
+		changedServices:     make(map[string]byte),
+		changedRouters:      make(map[string]byte),
+		changedUpstreams:    make(map[string]byte),
+		changedCertificates: make(map[string]byte),
+	}
+
+	serviceList, err := (&models.Services{}).ServiceAllList(tx)
+	if err != nil {
+		return
+	}
+	for _, info := range serviceList {
+		state.services[info.ResID] = info
+	}
+
+	domainList, err := (&models.ServiceDomains{}).DomainAllList(tx)
+	if err != nil {
+		return
+	}
+	for _, info := range domainList {
+		state.serviceDomains[info.ResID] = info
+	}
+
+	routerList, err := (&models.Routers{}).RouterAllList(tx)
+	if err != nil {
+		return
+	}
+	for _, info := range routerList {
+		state.routers[info.ResID] = info
+	}
+
+	upstreamList, err := models.Upstreams{}.UpstreamAllList(tx)
+	if err != nil {
+		return
+	}
+	for _, info := range upstreamList {
+		state.upstreams[info.ResID] = info
+	}
+
+	upstreamNodeList, err := (&models.UpstreamNodes{}).UpstreamNodeAllList(tx)
+	if err != nil {
+		return
+	}
+	for _, info := range upstreamNodeList {
+		state.upstreamNodes[info.ResID] = info
+	}
+
+	pluginConfigList, err := (&models.PluginConfigs{}).PluginConfigAllList(tx)
+	if err != nil {
+		return
+	}
+	for _, info := range pluginConfigList {
+		state.pluginConfigs[info.ResID] = info
+	}
+
+	certificateList, err := (&models.Certificates{}).CertificateAllList(tx)
+	if err != nil {
+		return
+	}
+	for _, info := range certificateList {
+		state.certificates[info.ResID] = info
+	}
+
+	pluginList, err := (&models.Plugins{}).PluginAllList()
+	if err != nil {
+		return
+	}
+	for _, info := range pluginList {
+		state.plugins[info.ResID] = info
+	}
+
+	return
+}
+
+// checkSnapshotReferences 校验快照内的引用关系，replace 模式只能引用快照内的数据，其他模式还可以引用控制面已有数据
+func checkSnapshotReferences(snapshot *Snapshot, state *snapshotImportState) error {
+	serviceResIds := make(map[string]byte)
+	routerResIds := make(map[string]byte)
+	upstreamResIds := make(map[string]byte)
+
+	if state.mode != utils.SnapshotModeReplace {
+		for resId := range state.services {
+			serviceResIds[resId] = 0
+		}
+		for resId := range state.routers {
+			routerResIds[resId] = 0
+		}
+		for resId := range state.upstreams {
+			upstreamResIds[resId] = 0
+		}
+	}
+	for _, item := range snapshot.Services {
+		serviceResIds[item.ResID] = 0
+	}
+	for _, item := range snapshot.Routers {
+		routerResIds[item.ResID] = 0
+	}
+	for _, item := range snapshot.Upstreams {
+		upstreamResIds[item.ResID] = 0
+	}
+
+	referenceNull := func(resId string, field string, value string) error {
+		return fmt.Errorf(enums.CodeMessages(enums.SnapshotReferenceNull), resId, field+":"+value)
+	}
+
+	for _, item := range snapshot.ServiceDomains {
+		if _, ok := serviceResIds[item.ServiceResID]; !ok {
+			return referenceNull(item.ResID, "service_res_id", item.ServiceResID)
+		}
+	}
+	for _, item := range snapshot.Routers {
+		if _, ok := serviceResIds[item.ServiceResID]; !ok {
+			return referenceNull(item.ResID, "service_res_id", item.ServiceResID)
+		}
+		if len(item.UpstreamResID) == 0 {
+			continue
+		}
+		if _, ok := upstreamResIds[item.UpstreamResID]; !ok {
+			return referenceNull(item.ResID, "upstream_res_id", item.UpstreamResID)
+		}
+	}
+	for _, item := range snapshot.UpstreamNodes {
+		if _, ok := upstreamResIds[item.UpstreamResID]; !ok {
+			return referenceNull(item.ResID, "upstream_res_id", item.UpstreamResID)
+		}
+	}
+	for _, item := range snapshot.PluginConfigs {
+		targetResIds := serviceResIds
+		if item.Type == models.PluginConfigsTypeRouter {
+			targetResIds = routerResIds
+		}
+		if _, ok := targetResIds[item.TargetID]; !ok {
+			return referenceNull(item.ResID, "target_id", item.TargetID)
+		}
+
+		pluginInfo, ok := state.plugins[item.PluginResID]
+		if !ok || (pluginInfo.PluginKey != item.PluginKey) {
+			return referenceNull(item.ResID, "plugin_res_id", item.PluginResID)
+		}
+	}
+
+	return nil
+}
+
+func importSnapshotUpstreams(tx *gorm.DB, snapshot *Snapshot, state *snapshotImportState, result *SnapshotImportResult) error {
+	upstreamModel := models.Upstreams{}
+	upstreamNodeModel := models.UpstreamNodes{}
+	ipNameIdMap := utils.IpNameIdMap()
+
+	snapshotUpstreams := make(map[string]byte)
+	for _, item := range snapshot.Upstreams {
+		snapshotUpstreams[item.ResID] = 0
+
+		algorithm := item.Algorithm
+		if algorithm == 0 {
+			algorithm = utils.LoadBalanceRoundRobin
+		}
+		hashOn, hashKey := item.HashOn, item.HashKey
+		validators.CorrectUpstreamHash(algorithm, &hashOn, &hashKey)
+		healthCheck := validators.UpstreamHealthCheck(item.HealthCheck)
+		validators.CorrectUpstreamHealthCheck(&healthCheck)
+
+		exist, ok := state.upstreams[item.ResID]
+		if !ok {
+			result.Upstreams.Create++
+			err := tx.Create(&models.Upstreams{
+				ResID:          item.ResID,
+				Name:           item.Name,
+				Algorithm:      algorithm,
+				HashOn:         hashOn,
+				HashKey:        hashKey,
+				ConnectTimeout: item.ConnectTimeout,
+				WriteTimeout:   item.WriteTimeout,
+				ReadTimeout:    item.ReadTimeout,
+				Enable:         item.Enable,
+				Release:        utils.ReleaseStatusU,
+				CheckType:      healthCheck.Type,
+				CheckMethod:    healthCheck.Method,
+				CheckHost:      healthCheck.Host,
+				CheckUri:       healthCheck.Uri,
+				CheckInterval:  healthCheck.Interval,
+				CheckTimeout:   healthCheck.Timeout,
+				CheckHealthy:   healthCheck.Healthy,
+				CheckUnhealthy: healthCheck.Unhealthy,
+			}).Error
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if (exist.Name == item.Name) && (exist.Algorithm == algorithm) &&
+			(exist.HashOn == hashOn) && (exist.HashKey == hashKey) &&
+			(exist.ConnectTimeout == item.ConnectTimeout) && (exist.WriteTimeout == item.WriteTimeout) &&
+			(exist.ReadTimeout == item.ReadTimeout) && (exist.Enable == item.Enable) &&
+			(upstreamHealthCheckItem(exist) == UpstreamHealthCheckItem(healthCheck)) {
+			result.Upstreams.Unchanged++
+			continue
+		}
+
+		result.Upstreams.Update++
+		state.changedUpstreams[item.ResID] = 0
+		updateUpstreamData := map[string]interface{}{
+			"name":            item.Name,
+			"algorithm":       algorithm,
+			"hash_on":         hashOn,
+			"hash_key":        hashKey,
+			"connect_timeout": item.ConnectTimeout,
+			"write_timeout":   item.WriteTimeout,
+			"read_timeout":    item.ReadTimeout,
+			"enable":          item.Enable,
+		}
+		for column, value := range upstreamHealthCheckColumns(healthCheck) {
+			updateUpstreamData[column] = value
+		}
+		err := tx.Table(upstreamModel.TableName()).Where("res_id = ?", item.ResID).Updates(updateUpstreamData).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	snapshotNodes := make(map[string]byte)
+	for _, item := range snapshot.UpstreamNodes {
+		snapshotNodes[item.ResID] = 0
+
+		ipType, _ := utils.DiscernIP(item.NodeIP)
+		health := item.Health
+		if health == 0 {
+			health = utils.HealthY
+		}
+		healthCheck := item.HealthCheck
+		if healthCheck == 0 {
+			healthCheck = utils.HealthCheckOff
+		}
+
+		exist, ok := state.upstreamNodes[item.ResID]
+		if !ok {
+			result.UpstreamNodes.Create++
+			state.changedUpstreams[item.UpstreamResID] = 0
+			err := tx.Create(&models.UpstreamNodes{
+				ResID:         item.ResID,
+				UpstreamResID: item.UpstreamResID,
+				NodeIP:        item.NodeIP,
+				IPType:        ipNameIdMap[ipType],
+				NodePort:      item.NodePort,
+				NodeWeight:    item.NodeWeight,
+				Health:        health,
+				HealthCheck:   healthCheck,
+			}).Error
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if (exist.UpstreamResID == item.UpstreamResID) && (exist.NodeIP == item.NodeIP) &&
+			(exist.NodePort == item.NodePort) && (exist.NodeWeight == item.NodeWeight) &&
+			(exist.Health == health) && (exist.HealthCheck == healthCheck) {
+			result.UpstreamNodes.Unchanged++
+			continue
+		}
+
+		result.UpstreamNodes.Update++
+		state.changedUpstreams[exist.UpstreamResID] = 0
+		state.changedUpstreams[item.UpstreamResID] = 0
+		err := tx.Table(upstreamNodeModel.TableName()).Where("res_id = ?", item.ResID).Updates(map[string]interface{}{
+			"upstream_res_id": item.UpstreamResID,
+			"node_ip":         item.NodeIP,
+			"ip_type":         ipNameIdMap[ipType],
+			"node_port":       item.NodePort,
+			"node_weight":     item.NodeWeight,
+			"health":          health,
+			"health_check":    healthCheck,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if state.mode == utils.SnapshotModeMerge {
+		return nil
+	}
+
+	deleteNodeResIds := make([]string, 0)
+	for resId, info := range state.upstreamNodes {
+		if _, ok := snapshotNodes[resId]; !ok {
+			deleteNodeResIds = append(deleteNodeResIds, resId)
+			state.changedUpstreams[info.UpstreamResID] = 0
+		}
+	}
+	deleteUpstreamResIds := make([]string, 0)
+	for resId := range state.upstreams {
+		if _, ok := snapshotUpstreams[resId]; !ok {
+			deleteUpstreamResIds = append(deleteUpstreamResIds, resId)
+		}
+	}
+
+	result.UpstreamNodes.Delete = len(deleteNodeResIds)
+	result.Upstreams.Delete = len(deleteUpstreamResIds)
+	if len(deleteNodeResIds) > 0 {
+		if err := tx.Table(upstreamNodeModel.TableName()).Where("res_id IN ?", deleteNodeResIds).
+			Delete(&models.UpstreamNodes{}).Error; err != nil {
+			return err
+		}
+	}
+	if len(deleteUpstreamResIds) > 0 {
+		if err := tx.Table(upstreamModel.TableName()).Where("res_id IN ?", deleteUpstreamResIds).
+			Delete(&models.Upstreams{}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func importSnapshotServices(tx *gorm.DB, snapshot *Snapshot, state *snapshotImportState, result *SnapshotImportResult) error {
+	serviceModel := models.Services{}
+	serviceDomainModel := models.ServiceDomains{}
+
+	snapshotServices := make(map[string]byte)
+	for _, item := range snapshot.Services {
+		snapshotServices[item.ResID] = 0
+
+		exist, ok := state.services[item.ResID]
+		if !ok {
+			result.Services.Create++
+			err := tx.Create(&models.Services{
+				ResID:    item.ResID,
+				Name:     item.Name,
+				Protocol: item.Protocol,
+				Enable:   item.Enable,
+				Release:  utils.ReleaseStatusU,
+			}).Error
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if (exist.Name == item.Name) && (exist.Protocol == item.Protocol) && (exist.Enable == item.Enable) {
+			result.Services.Unchanged++
+			continue
+		}
+
+		result.Services.Update++
+		state.changedServices[item.ResID] = 0
+		err := tx.Table(serviceModel.TableName()).Where("res_id = ?", item.ResID).Updates(map[string]interface{}{
+			"name":     item.Name,
+			"protocol": item.Protocol,
+			"enable":   item.Enable,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	snapshotDomains := make(map[string]byte)
+	for _, item := range snapshot.ServiceDomains {
+		snapshotDomains[item.ResID] = 0
+
+		exist, ok := state.serviceDomains[item.ResID]
+		if !ok {
+			result.ServiceDomains.Create++
+			state.changedServices[item.ServiceResID] = 0
+			err := tx.Create(&models.ServiceDomains{
+				ResID:        item.ResID,
+				ServiceResID: item.ServiceResID,
+				Domain:       item.Domain,
+			}).Error
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if (exist.ServiceResID == item.ServiceResID) && (exist.Domain == item.Domain) {
+			result.ServiceDomains.Unchanged++
+			continue
+		}
+
+		result.ServiceDomains.Update++
+		state.changedServices[exist.ServiceResID] = 0
+		state.changedServices[item.ServiceResID] = 0
+		err := tx.Table(serviceDomainModel.TableName()).Where("res_id = ?", item.ResID).Updates(map[string]interface{}{
+			"service_res_id": item.ServiceResID,
+			"domain":         item.Domain,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if state.mode == utils.SnapshotModeMerge {
+		return nil
+	}
+
+	deleteDomainResIds := make([]string, 0)
+	for resId, info := range state.serviceDomains {
+		if _, ok := snapshotDomains[resId]; !ok {
+			deleteDomainResIds = append(deleteDomainResIds, resId)
+			state.changedServices[info.ServiceResID] = 0
+		}
+	}
+	deleteServiceResIds := make([]string, 0)
+	for resId := range state.services {
+		if _, ok := snapshotServices[resId]; !ok {
+			deleteServiceResIds = append(deleteServiceResIds, resId)
+		}
+	}
+
+	result.ServiceDomains.Delete = len(deleteDomainResIds)
+	result.Services.Delete = len(deleteServiceResIds)
+	if len(deleteDomainResIds) > 0 {
+		if err := tx.Table(serviceDomainModel.TableName()).Where("res_id IN ?", deleteDomainResIds).
+			Delete(&models.ServiceDomains{}).Error; err != nil {
+			return err
+		}
+	}
+	if len(deleteServiceResIds) > 0 {
+		if err := tx.Table(serviceModel.TableName()).Where("res_id IN ?", deleteServiceResIds).
+			Delete(&models.Services{}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func importSnapshotRouters(tx *gorm.DB, snapshot *Snapshot, state *snapshotImportState, result *SnapshotImportResult) error {
+	routerModel := models.Routers{}
+
+	snapshotRouters := make(map[string]byte)
+	for _, item := range snapshot.Routers {
+		snapshotRouters[item.ResID] = 0
+		requestMethods := strings.ToUpper(item.RequestMethods)
+
+		exist, ok := state.routers[item.ResID]
+		if !ok {
+			result.Routers.Create++
+			err := tx.Create(&models.Routers{
+				ResID:          item.ResID,
+				ServiceResID:   item.ServiceResID,
+				UpstreamResID:  item.UpstreamResID,
+				RouterName:     item.RouterName,
+				RequestMethods: requestMethods,
+				RouterPath:     item.RouterPath,
+				Enable:         item.Enable,
+				Release:        utils.ReleaseStatusU,
+			}).Error
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if (exist.ServiceResID == item.ServiceResID) && (exist.UpstreamResID == item.UpstreamResID) &&
+			(exist.RouterName == item.RouterName) && (exist.RequestMethods == requestMethods) &&
+			(exist.RouterPath == item.RouterPath) && (exist.Enable == item.Enable) {
+			result.Routers.Unchanged++
+			continue
+		}
+
+		result.Routers.Update++
+		state.changedRouters[item.ResID] = 0
+		err := tx.Table(routerModel.TableName()).Where("res_id = ?", item.ResID).Updates(map[string]interface{}{
+			"service_res_id":  item.ServiceResID,
+			"upstream_res_id": item.UpstreamResID,
+			"router_name":     item.RouterName,
+			"request_methods": requestMethods,
+			"router_path":     item.RouterPath,
+			"enable":          item.Enable,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if state.mode == utils.SnapshotModeMerge {
+		return nil
+	}
+
+	deleteRouterResIds := make([]string, 0)
+	for resId := range state.routers {
+		if _, ok := snapshotRouters[resId]; !ok {
+			deleteRouterResIds = append(deleteRouterResIds, resId)
+		}
+	}
+
+	result.Routers.Delete = len(deleteRouterResIds)
+	if len(deleteRouterResIds) == 0 {
+		return nil
+	}
+
+	return tx.Table(routerModel.TableName()).Where("res_id IN ?", deleteRouterResIds).
+		Delete(&models.Routers{}).Error
+}
+
+func importSnapshotPluginConfigs(tx *gorm.DB, snapshot *Snapshot, state *snapshotImportState, result *SnapshotImportResult) error {
+	pluginConfigModel := models.PluginConfigs{}
+	markTarget := func(configType int, targetId string) {
+		if configType == models.PluginConfigsTypeService {
+			state.changedServices[targetId] = 0
+		} else {
+			state.changedRouters[targetId] = 0
+		}
+	}
+
+	snapshotPluginConfigs := make(map[string]byte)
+	for _, item := range snapshot.PluginConfigs {
+		snapshotPluginConfigs[item.ResID] = 0
+
+		pluginContext, err := plugins.NewPluginContext(item.PluginKey)
+		if err != nil {
+			return err
+		}
+		// 导出的快照中密钥为密文，导入时同时支持密文与明文
+		config, err := pluginContext.StrategyPluginParse(item.Config)
+		if err != nil {
+			return err
+		}
+		config, err = pluginConfigSecret(config, func(value string) (string, error) {
+			return snapshotDecrypt(item.ResID, value)
+		})
+		if err != nil {
+			return err
+		}
+		if err = pluginContext.StrategyPluginCheck(config); err != nil {
+			return fmt.Errorf("[%s]%s", item.ResID, err.Error())
+		}
+		plaintextJson, err := json.Marshal(config)
+		if err != nil {
+			return err
+		}
+		config, err = pluginConfigSecret(config, utils.Encrypt)
+		if err != nil {
+			return err
+		}
+		configJson, err := json.Marshal(config)
+		if err != nil {
+			return err
+		}
+
+		exist, ok := state.pluginConfigs[item.ResID]
+		if !ok {
+			result.PluginConfigs.Create++
+			markTarget(item.Type, item.TargetID)
+			err = tx.Create(&models.PluginConfigs{
+				ResID:       item.ResID,
+				Name:        item.Name,
+				Type:        item.Type,
+				TargetID:    item.TargetID,
+				PluginResID: item.PluginResID,
+				PluginKey:   item.PluginKey,
+				Config:      string(configJson),
+				Enable:      item.Enable,
+			}).Error
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		existConfigJson, err := pluginConfigPlaintext(exist.PluginKey, exist.Config)
+		if err != nil {
+			return fmt.Errorf("[%s]%s", item.ResID, err.Error())
+		}
+
+		if (exist.Name == item.Name) && (exist.Type == item.Type) && (exist.TargetID == item.TargetID) &&
+			(exist.PluginResID == item.PluginResID) && (exist.PluginKey == item.PluginKey) &&
+			(existConfigJson == string(plaintextJson)) && (exist.Enable == item.Enable) {
+			result.PluginConfigs.Unchanged++
+			continue
+		}
+
+		result.PluginConfigs.Update++
+		markTarget(exist.Type, exist.TargetID)
+		markTarget(item.Type, item.TargetID)
+		err = tx.Table(pluginConfigModel.TableName()).Where("res_id = ?", item.ResID).Updates(map[string]interface{}{
+			"name":          item.Name,
+			"type":          item.Type,
+			"target_id":     item.TargetID,
+			"plugin_res_id": item.PluginResID,
+			"plugin_key":    item.PluginKey,
+			"config":        string(configJson),
+			"enable":        item.Enable,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if state.mode == utils.SnapshotModeMerge {
+		return nil
+	}
+
+	deletePluginConfigResIds := make([]string, 0)
+	for resId, info := range state.pluginConfigs {
+		if _, ok := snapshotPluginConfigs[resId]; !ok {
+			deletePluginConfigResIds = append(deletePluginConfigResIds, resId)
+			markTarget(info.Type, info.TargetID)
+		}
+	}
+
+	result.PluginConfigs.Delete = len(deletePluginConfigResIds)
+	if len(deletePluginConfigResIds) == 0 {
+		return nil
+	}
+
+	return tx.Table(pluginConfigModel.TableName()).Where("res_id IN ?", deletePluginConfigResIds).
+		Delete(&models.PluginConfigs{}).Error
+}
+
+func importSnapshotCertificates(tx *gorm.DB, snapshot *Snapshot, state *snapshotImportState, result *SnapshotImportResult) error {
+	certificateModel := models.Certificates{}
+
+	snapshotCertificates := make(map[string]byte)
+	for _, item := range snapshot.Certificates {
+		snapshotCertificates[item.ResID] = 0
+
+		certificateInfo, err := utils.DiscernCertificate(&item.Certificate)
+		if err != nil {
+			return fmt.Errorf("[%s]%s", item.ResID, err.Error())
+		}
+
+		// 导出的快照中私钥为密文，导入时同时支持密文与明文
+		plaintextKey, err := snapshotDecrypt(item.ResID, item.PrivateKey)
+		if err != nil {
+			return err
+		}
+		privateKey, err := utils.Encrypt(plaintextKey)
+		if err != nil {
+			return err
+		}
+
+		exist, ok := state.certificates[item.ResID]
+		if !ok {
+			result.Certificates.Create++
+			err = tx.Create(&models.Certificates{
+				ResID:       item.ResID,
+				Sni:         item.Sni,
+				Certificate: item.Certificate,
+				PrivateKey:  privateKey,
+				Issuer:      certificateInfo.Issuer,
+				Subject:     certificateInfo.Subject,
+				Sans:        strings.Join(certificateInfo.Sans, ","),
+				Fingerprint: certificateInfo.Fingerprint,
+				Enable:      item.Enable,
+				ExpiredAt:   certificateInfo.NotAfter,
+			}).Error
+			if err != nil {
+				return err
+			}
+			err = (&models.CertificateSnis{}).CertificateSnisReplace(tx, item.ResID, utils.CertificateSnis(item.Sni, certificateInfo.Sans))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		existKey, err := utils.Decrypt(exist.PrivateKey)
+		if err != nil {
+			return fmt.Errorf("[%s]%s", item.ResID, err.Error())
+		}
+
+		if (exist.Sni == item.Sni) && (exist.Certificate == item.Certificate) &&
+			(existKey == plaintextKey) && (exist.Enable == item.Enable) {
+			result.Certificates.Unchanged++
+			continue
+		}
+
+		result.Certificates.Update++
+		state.changedCertificates[item.ResID] = 0
+		err = tx.Table(certificateModel.TableName()).Where("res_id = ?", item.ResID).Updates(map[string]interface{}{
+			"sni":         item.Sni,
+			"certificate": item.Certificate,
+			"private_key": privateKey,
+			"issuer":      certificateInfo.Issuer,
+			"subject":     certificateInfo.Subject,
+			"sans":        strings.Join(certificateInfo.Sans, ","),
+			"fingerprint": certificateInfo.Fingerprint,
+			"enable":      item.Enable,
+			"expired_at":  certificateInfo.NotAfter,
+		}).Error
+		if err != nil {
+			return err
+		}
+		err = (&models.CertificateSnis{}).CertificateSnisReplace(tx, item.ResID, utils.CertificateSnis(item.Sni, certificateInfo.Sans))
+		if err != nil {
+			return err
+		}
+	}
+
+	if state.mode == utils.SnapshotModeMerge {
+		return nil
+	}
+
+	deleteCertificateResIds := make([]string, 0)
+	for resId := range state.certificates {
+		if _, ok := snapshotCertificates[resId]; !ok {
+			deleteCertificateResIds = append(deleteCertificateResIds, resId)
+		}
+	}
+
+	result.Certificates.Delete = len(deleteCertificateResIds)
+	if len(deleteCertificateResIds) == 0 {
+		return nil
+	}
+
+	err := tx.Table((&models.CertificateSnis{}).TableName()).Where("certificate_res_id IN ?", deleteCertificateResIds).
+		Delete(&models.CertificateSnis{}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Table(certificateModel.TableName()).Where("res_id IN ?", deleteCertificateResIds).
+		Delete(&models.Certificates{}).Error
+}
+
+// snapshotDecrypt 解密快照中的密文，主密钥未配置或与导出方不一致时返回密文使用的主密钥版本
+func snapshotDecrypt(resId string, value string) (string, error) {
+	plaintext, err := utils.Decrypt(value)
+	if err != nil {
+		if version := utils.EncryptionKeyVersion(value); version > 0 {
+			return "", fmt.Errorf(enums.CodeMessages(enums.SnapshotKeyError), resId, version)
+		}
+		return "", fmt.Errorf("[%s]%s", resId, err.Error())
+	}
+
+	return plaintext, nil
+}
+
+// markSnapshotChangedTargets 已发布且配置有变更的服务、路由与上游修改为待发布状态
+func markSnapshotChangedTargets(tx *gorm.DB, state *snapshotImportState) error {
+	changedList := []struct {
+		table  string
+		resIds map[string]byte
+	}{
+		{table: (&models.Services{}).TableName(), resIds: state.changedServices},
+		{table: (&models.Routers{}).TableName(), resIds: state.changedRouters},
+		{table: models.Upstreams{}.TableName(), resIds: state.changedUpstreams},
+	}
+
+	for _, changed := range changedList {
+		if len(changed.resIds) == 0 {
+			continue
+		}
+
+		resIds := make([]string, 0, len(changed.resIds))
+		for resId := range changed.resIds {
+			resIds = append(resIds, resId)
+		}
+
+		err := tx.Table(changed.table).
+			Where("res_id IN ?", resIds).
+			Where("`release` = ?", utils.ReleaseStatusY).
+			Update("release", utils.ReleaseStatusT).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// emitSnapshotWebhooks 导入新增、修改与删除的服务、路由、上游与证书，与逐个修改时一样产生对应的事件
+func emitSnapshotWebhooks(tx *gorm.DB, snapshot *Snapshot, state *snapshotImportState) error {
+	type webhookEvent struct {
+		event string
+		data  map[string]interface{}
+	}
+	events := make([]webhookEvent, 0)
+
+	eventAdd := func(resId string, exist bool, changed map[string]byte, created string, updated string, data map[string]interface{}) {
+		if !exist {
+			events = append(events, webhookEvent{event: created, data: data})
+		} else if _, ok := changed[resId]; ok {
+			events = append(events, webhookEvent{event: updated, data: data})
+		}
+	}
+
+	snapshotResIds := make(map[string]byte)
+	for _, item := range snapshot.Upstreams {
+		snapshotResIds[item.ResID] = 0
+		_, exist := state.upstreams[item.ResID]
+		eventAdd(item.ResID, exist, state.changedUpstreams, utils.WebhookEventUpstreamCreated, utils.WebhookEventUpstreamUpdated,
+			map[string]interface{}{"res_id": item.ResID, "name": item.Name})
+	}
+	for _, item := range snapshot.Services {
+		snapshotResIds[item.ResID] = 0
+		_, exist := state.services[item.ResID]
+		eventAdd(item.ResID, exist, state.changedServices, utils.WebhookEventServiceCreated, utils.WebhookEventServiceUpdated,
+			map[string]interface{}{"res_id": item.ResID, "name": item.Name})
+	}
+	for _, item := range snapshot.Routers {
+		snapshotResIds[item.ResID] = 0
+		_, exist := state.routers[item.ResID]
+		eventAdd(item.ResID, exist, state.changedRouters, utils.WebhookEventRouterCreated, utils.WebhookEventRouterUpdated,
+			map[string]interface{}{"res_id": item.ResID, "service_res_id": item.ServiceResID, "name": item.RouterName})
+	}
+	for _, item := range snapshot.Certificates {
+		snapshotResIds[item.ResID] = 0
+		_, exist := state.certificates[item.ResID]
+		eventAdd(item.ResID, exist, state.changedCertificates, utils.WebhookEventCertificateCreated, utils.WebhookEventCertificateUpdated,
+			map[string]interface{}{"res_id": item.ResID, "sni": item.Sni})
+	}
+
+	if state.mode == utils.SnapshotModeReplace {
+		for resId, info := range state.upstreams {
+			if _, ok := snapshotResIds[resId]; !ok {
+				events = append(events, webhookEvent{event: utils.WebhookEventUpstreamDeleted,
+					data: map[string]interface{}{"res_id": resId, "name": info.Name}})
+			}
+		}
+		for resId, info := range state.services {
+			if _, ok := snapshotResIds[resId]; !ok {
+				events = append(events, webhookEvent{event: utils.WebhookEventServiceDeleted,
+					data: map[string]interface{}{"res_id": resId, "name": info.Name}})
+			}
+		}
+		for resId, info := range state.routers {
+			if _, ok := snapshotResIds[resId]; !ok {
+				events = append(events, webhookEvent{event: utils.WebhookEventRouterDeleted,
+					data: map[string]interface{}{"res_id": resId, "service_res_id": info.ServiceResID, "name": info.RouterName}})
+			}
+		}
+		for resId, info := range state.certificates {
+			if _, ok := snapshotResIds[resId]; !ok {
+				events = append(events, webhookEvent{event: utils.WebhookEventCertificateDeleted,
+					data: map[string]interface{}{"res_id": resId, "sni": info.Sni}})
+			}
+		}
+	}
+
+	for _, event := range events {
+		if err := webhookEmit(tx, event.event, event.data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
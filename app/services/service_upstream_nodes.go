@@ -12,18 +12,21 @@ import (
 )
 
 type UpstreamNodeItem struct {
-	ResID         string `json:"res_id"`
-	UpstreamResID string `json:"upstream_res_id"`
-	NodeIP        string `json:"node_ip"`
-	IPType        int    `json:"ip_type"`
-	IPTypeName    string `json:"ip_type_name"`
-	NodePort      int    `json:"node_port"`
-	NodeWeight    int    `json:"node_weight"`
-	Health        int    `json:"health"`
-	HealthName    string `json:"health_name"`
-	HealthCheck   int    `json:"health_check"`
+	ResID           string `json:"res_id"`
+	UpstreamResID   string `json:"upstream_res_id"`
+	NodeIP          string `json:"node_ip"`
+	IPType          int    `json:"ip_type"`
+	IPTypeName      string `json:"ip_type_name"`
+	NodePort        int    `json:"node_port"`
+	NodeWeight      int    `json:"node_weight"`
+	Health          int    `json:"health"`
+	HealthName      string `json:"health_name"`
+	HealthCheck     int    `json:"health_check"`
+	CheckHealth     int    `json:"check_health"`
+	CheckHealthName string `json:"check_health_name"`
 }
 
+// UpstreamNodeListByUpstreamResIds 节点当前的健康状态通过一次数据面请求查询，上游列表与详情共用
 func (n UpstreamNodeItem) UpstreamNodeListByUpstreamResIds(upstreamResIds []string) (nodeList []UpstreamNodeItem, err error) {
 	nodeList = make([]UpstreamNodeItem, 0)
 	upstreamNodeModel := models.UpstreamNodes{}
@@ -34,26 +37,52 @@ func (n UpstreamNodeItem) UpstreamNodeListByUpstreamResIds(upstreamResIds []stri
 
 	iPTypeNameMap := utils.IpIdNameMap()
 	healthTypeNameMap := utils.HealthTypeNameMap()
+	cloudHealthMap := upstreamNodeCloudHealthMap()
 
 	for _, upstreamNodeDetail := range upstreamNodeList {
 
 		nodeList = append(nodeList, UpstreamNodeItem{
-			ResID:         upstreamNodeDetail.ResID,
-			UpstreamResID: upstreamNodeDetail.UpstreamResID,
-			NodeIP:        upstreamNodeDetail.NodeIP,
-			IPType:        upstreamNodeDetail.IPType,
-			IPTypeName:    iPTypeNameMap[upstreamNodeDetail.IPType],
-			NodePort:      upstreamNodeDetail.NodePort,
-			NodeWeight:    upstreamNodeDetail.NodeWeight,
-			Health:        upstreamNodeDetail.Health,
-			HealthName:    healthTypeNameMap[upstreamNodeDetail.Health],
-			HealthCheck:   upstreamNodeDetail.HealthCheck,
+			ResID:           upstreamNodeDetail.ResID,
+			UpstreamResID:   upstreamNodeDetail.UpstreamResID,
+			NodeIP:          upstreamNodeDetail.NodeIP,
+			IPType:          upstreamNodeDetail.IPType,
+			IPTypeName:      iPTypeNameMap[upstreamNodeDetail.IPType],
+			NodePort:        upstreamNodeDetail.NodePort,
+			NodeWeight:      upstreamNodeDetail.NodeWeight,
+			Health:          upstreamNodeDetail.Health,
+			HealthName:      healthTypeNameMap[upstreamNodeDetail.Health],
+			HealthCheck:     upstreamNodeDetail.HealthCheck,
+			CheckHealth:     cloudHealthMap[upstreamNodeDetail.ResID],
+			CheckHealthName: healthTypeNameMap[cloudHealthMap[upstreamNodeDetail.ResID]],
 		})
 	}
 
 	return
 }
 
+// upstreamNodeCloudHealthMap 数据面中节点当前的健康状态，数据面不可用时为空，不影响节点列表
+func upstreamNodeCloudHealthMap() map[string]int {
+	cloudHealthMap := make(map[string]int)
+
+	cloudNodeList, err := rpc.NewApiOak().UpstreamNodeList(nil)
+	if err != nil {
+		return cloudHealthMap
+	}
+
+	configHealthMap := make(map[string]int)
+	for _, configHealthInfo := range utils.ConfigUpstreamNodeHealthList() {
+		configHealthMap[configHealthInfo.Name] = configHealthInfo.Id
+	}
+
+	for _, cloudNodeInfo := range cloudNodeList {
+		if health, ok := configHealthMap[strings.ToUpper(cloudNodeInfo.Health)]; ok {
+			cloudHealthMap[cloudNodeInfo.Name] = health
+		}
+	}
+
+	return cloudHealthMap
+}
+
 func DiffUpstreamNode(upstreamResID string, paramNodeList []validators.UpstreamNodeAddUpdate) (
 	addNodeList []models.UpstreamNodes, updateNodeList []models.UpstreamNodes, delNodeResIds []string) {
 
@@ -82,10 +111,11 @@ func DiffUpstreamNode(upstreamResID string, paramNodeList []validators.UpstreamN
 
 		if ok {
 			updateNodeList = append(updateNodeList, models.UpstreamNodes{
-				ResID:      upstreamNodeInfo.ResID,
-				NodePort:   paramNodeInfo.NodePort,
-				NodeWeight: paramNodeInfo.NodeWeight,
-				Health:     paramNodeInfo.Health,
+				ResID:       upstreamNodeInfo.ResID,
+				NodePort:    paramNodeInfo.NodePort,
+				NodeWeight:  paramNodeInfo.NodeWeight,
+				Health:      paramNodeInfo.Health,
+				HealthCheck: paramNodeInfo.HealthCheck,
 			})
 		} else {
 			delNodeResIds = append(delNodeResIds, upstreamNodeInfo.ResID)
@@ -117,7 +147,7 @@ func DiffUpstreamNode(upstreamResID string, paramNodeList []validators.UpstreamN
 				NodePort:      paramNodeListInfo.NodePort,
 				NodeWeight:    paramNodeListInfo.NodeWeight,
 				Health:        paramNodeListInfo.Health,
-				HealthCheck:   utils.HealthCheckOff,
+				HealthCheck:   paramNodeListInfo.HealthCheck,
 			})
 		}
 	}
@@ -143,7 +173,7 @@ func UpstreamNodeLocalCloudDiff(localNodeList []models.UpstreamNodes, cloudNodeL
 	return
 }
 
-func generateUpstreamNodeConfig(upstreamNodeInfo models.UpstreamNodes) (rpc.UpstreamNodeConfig, error) {
+func generateUpstreamNodeConfig(upstreamInfo models.Upstreams, upstreamNodeInfo models.UpstreamNodes) (rpc.UpstreamNodeConfig, error) {
 	upstreamNodeConfig := rpc.UpstreamNodeConfig{}
 
 	configHealthList := utils.ConfigUpstreamNodeHealthList()
@@ -162,7 +192,20 @@ func generateUpstreamNodeConfig(upstreamNodeInfo models.UpstreamNodes) (rpc.Upst
 	upstreamNodeConfig.Address = upstreamNodeInfo.NodeIP
 	upstreamNodeConfig.Port = upstreamNodeInfo.NodePort
 	upstreamNodeConfig.Weight = upstreamNodeInfo.NodeWeight
-	upstreamNodeConfig.Check.Enabled = false
+
+	// 节点开启健康检查时按上游的健康检查配置检查
+	healthCheck := upstreamHealthCheckItem(upstreamInfo)
+	upstreamNodeConfig.Check = rpc.HealthCheck{
+		Enabled:   upstreamNodeInfo.HealthCheck == utils.HealthCheckOn,
+		Tcp:       healthCheck.Type == utils.HealthCheckTypeTcp,
+		Method:    healthCheck.Method,
+		Host:      healthCheck.Host,
+		Uri:       healthCheck.Uri,
+		Interval:  healthCheck.Interval,
+		Timeout:   healthCheck.Timeout,
+		Healthy:   healthCheck.Healthy,
+		Unhealthy: healthCheck.Unhealthy,
+	}
 
 	return upstreamNodeConfig, nil
 }
@@ -197,10 +240,26 @@ func NodeRelease(nodeResIds []string, releaseType string) (err error) {
 			return
 		}
 
+		upstreamResIds := make([]string, 0)
+		for _, upstreamNodeInfo := range upstreamNodeList {
+			upstreamResIds = append(upstreamResIds, upstreamNodeInfo.UpstreamResID)
+		}
+
+		var upstreamList []models.Upstreams
+		upstreamList, err = (&models.Upstreams{}).UpstreamListByResIds(upstreamResIds)
+		if err != nil {
+			return
+		}
+
+		upstreamMap := make(map[string]models.Upstreams)
+		for _, upstreamInfo := range upstreamList {
+			upstreamMap[upstreamInfo.ResID] = upstreamInfo
+		}
+
 		upstreamNodeConfigList := make([]rpc.UpstreamNodeConfig, 0)
 		for _, upstreamNodeInfo := range upstreamNodeList {
 			var upstreamNodeConfig rpc.UpstreamNodeConfig
-			upstreamNodeConfig, err = generateUpstreamNodeConfig(upstreamNodeInfo)
+			upstreamNodeConfig, err = generateUpstreamNodeConfig(upstreamMap[upstreamNodeInfo.UpstreamResID], upstreamNodeInfo)
 			if err != nil {
 				return err
 			}
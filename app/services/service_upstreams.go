@@ -34,11 +34,58 @@ type UpstreamItem struct {
 	ResID          string `json:"res_id"`
 	Name           string `json:"name"`
 	Algorithm      int    `json:"algorithm"`
+	HashOn         string `json:"hash_on"`
+	HashKey        string `json:"hash_key"`
 	ConnectTimeout int    `json:"connect_timeout"`
 	WriteTimeout   int    `json:"write_timeout"`
 	ReadTimeout    int    `json:"read_timeout"`
 	Enable         int    `json:"enable"`
 	Release        int    `json:"release"`
+
+	HealthCheck UpstreamHealthCheckItem `json:"health_check"`
+}
+
+// UpstreamHealthCheckItem 上游的主动健康检查配置，与 validators.UpstreamHealthCheck 字段相同
+type UpstreamHealthCheckItem struct {
+	Type      string `json:"type" yaml:"type"`
+	Method    string `json:"method" yaml:"method"`
+	Host      string `json:"host" yaml:"host"`
+	Uri       string `json:"uri" yaml:"uri"`
+	Interval  int    `json:"interval" yaml:"interval"`
+	Timeout   int    `json:"timeout" yaml:"timeout"`
+	Healthy   int    `json:"healthy" yaml:"healthy"`
+	Unhealthy int    `json:"unhealthy" yaml:"unhealthy"`
+}
+
+// upstreamHealthCheckItem 上游的健康检查配置，未设置的字段使用默认值
+func upstreamHealthCheckItem(upstreamInfo models.Upstreams) UpstreamHealthCheckItem {
+	healthCheck := validators.UpstreamHealthCheck{
+		Type:      upstreamInfo.CheckType,
+		Method:    upstreamInfo.CheckMethod,
+		Host:      upstreamInfo.CheckHost,
+		Uri:       upstreamInfo.CheckUri,
+		Interval:  upstreamInfo.CheckInterval,
+		Timeout:   upstreamInfo.CheckTimeout,
+		Healthy:   upstreamInfo.CheckHealthy,
+		Unhealthy: upstreamInfo.CheckUnhealthy,
+	}
+	validators.CorrectUpstreamHealthCheck(&healthCheck)
+
+	return UpstreamHealthCheckItem(healthCheck)
+}
+
+// upstreamHealthCheckColumns 健康检查配置对应的上游字段
+func upstreamHealthCheckColumns(healthCheck validators.UpstreamHealthCheck) map[string]interface{} {
+	return map[string]interface{}{
+		"check_type":      healthCheck.Type,
+		"check_method":    healthCheck.Method,
+		"check_host":      healthCheck.Host,
+		"check_uri":       healthCheck.Uri,
+		"check_interval":  healthCheck.Interval,
+		"check_timeout":   healthCheck.Timeout,
+		"check_healthy":   healthCheck.Healthy,
+		"check_unhealthy": healthCheck.Unhealthy,
+	}
 }
 
 type UpstreamListItem struct {
@@ -87,11 +134,14 @@ func (u *ServiceUpstream) UpstreamListPage(request *validators.UpstreamList) (li
 				ResID:          upstreamInfo.ResID,
 				Name:           upstreamInfo.Name,
 				Algorithm:      upstreamInfo.Algorithm,
+				HashOn:         upstreamInfo.HashOn,
+				HashKey:        upstreamInfo.HashKey,
 				ConnectTimeout: upstreamInfo.ConnectTimeout,
 				WriteTimeout:   upstreamInfo.WriteTimeout,
 				ReadTimeout:    upstreamInfo.ReadTimeout,
 				Enable:         upstreamInfo.Enable,
 				Release:        upstreamInfo.Release,
+				HealthCheck:    upstreamHealthCheckItem(upstreamInfo),
 			}
 
 			upstreamListItem := UpstreamListItem{
@@ -178,17 +228,27 @@ func (u *ServiceUpstream) CheckUpstreamUse(resId string) (err error) {
 	return
 }
 
-func (u *ServiceUpstream) UpstreamCreate(request *validators.UpstreamAddUpdate) (err error) {
+func (u *ServiceUpstream) UpstreamCreate(request *validators.UpstreamAddUpdate) (upstreamResId string, err error) {
 	upstreamModel := models.Upstreams{}
 
 	createUpstreamData := models.Upstreams{
 		Name:           request.Name,
 		Algorithm:      request.LoadBalance,
+		HashOn:         request.HashOn,
+		HashKey:        request.HashKey,
 		ConnectTimeout: request.ConnectTimeout,
 		WriteTimeout:   request.WriteTimeout,
 		ReadTimeout:    request.ReadTimeout,
 		Enable:         request.Enable,
 		Release:        utils.ReleaseStatusU,
+		CheckType:      request.HealthCheck.Type,
+		CheckMethod:    request.HealthCheck.Method,
+		CheckHost:      request.HealthCheck.Host,
+		CheckUri:       request.HealthCheck.Uri,
+		CheckInterval:  request.HealthCheck.Interval,
+		CheckTimeout:   request.HealthCheck.Timeout,
+		CheckHealthy:   request.HealthCheck.Healthy,
+		CheckUnhealthy: request.HealthCheck.Unhealthy,
 	}
 
 	createUpstreamNodesData := make([]models.UpstreamNodes, 0)
@@ -212,7 +272,15 @@ func (u *ServiceUpstream) UpstreamCreate(request *validators.UpstreamAddUpdate)
 		}
 	}
 
-	_, err = upstreamModel.UpstreamAdd(createUpstreamData, createUpstreamNodesData)
+	upstreamResId, err = upstreamModel.UpstreamAdd(createUpstreamData, createUpstreamNodesData)
+	if err != nil {
+		return
+	}
+
+	WebhookEmit(utils.WebhookEventUpstreamCreated, map[string]interface{}{
+		"res_id": upstreamResId,
+		"name":   request.Name,
+	})
 
 	return
 }
@@ -229,10 +297,15 @@ func (u *ServiceUpstream) UpstreamUpdate(resId string, request *validators.Upstr
 
 		updateUpstreamData := map[string]interface{}{
 			"algorithm": request.LoadBalance,
+			"hash_on": request.HashOn,
+			"hash_key": request.HashKey,
 			"read_timeout": request.ReadTimeout,
 			"write_timeout": request.WriteTimeout,
 			"connect_timeout": request.ConnectTimeout,
 		}
+		for column, value := range upstreamHealthCheckColumns(request.HealthCheck) {
+			updateUpstreamData[column] = value
+		}
 		if upstreamInfo.Release == utils.ReleaseStatusY {
 			updateUpstreamData["release"] = utils.ReleaseStatusT
 		}
@@ -280,10 +353,31 @@ func (u *ServiceUpstream) UpstreamUpdate(resId string, request *validators.Upstr
 
 		return
 	})
+	if err != nil {
+		return
+	}
+
+	WebhookEmit(utils.WebhookEventUpstreamUpdated, map[string]interface{}{
+		"res_id": resId,
+		"name":   upstreamInfo.Name,
+	})
 
 	return
 }
 
+func (u *ServiceUpstream) UpstreamUpdateName(resId string, name string) error {
+	return packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := (models.Upstreams{}).UpstreamUpdateName(tx, resId, name); err != nil {
+			return err
+		}
+
+		return webhookEmit(tx, utils.WebhookEventUpstreamUpdated, map[string]interface{}{
+			"res_id": resId,
+			"name":   name,
+		})
+	})
+}
+
 func (u *ServiceUpstream) UpstreamDelete(resId string) (err error) {
 	upstreamModel := models.Upstreams{}
 	upstreamInfo, err := upstreamModel.UpstreamDetailByResId(resId)
@@ -311,8 +405,19 @@ func (u *ServiceUpstream) UpstreamDelete(resId string) (err error) {
 
 		return
 	})
+	if err != nil {
+		return
+	}
+
+	err = UpstreamRelease([]string{resId}, utils.ReleaseTypeDelete, "")
+	if err != nil {
+		return
+	}
 
-	err = UpstreamRelease([]string{resId}, utils.ReleaseTypeDelete)
+	WebhookEmit(utils.WebhookEventUpstreamDeleted, map[string]interface{}{
+		"res_id": resId,
+		"name":   upstreamInfo.Name,
+	})
 
 	return
 }
@@ -337,11 +442,20 @@ func (u *ServiceUpstream) UpstreamSwitchEnable(resId string, enable int) (err er
 	}
 
 	err = upstreamModel.UpstreamUpdateColumns(resId, updateData)
+	if err != nil {
+		return
+	}
+
+	WebhookEmit(utils.WebhookEventUpstreamUpdated, map[string]interface{}{
+		"res_id": resId,
+		"name":   upstreamInfo.Name,
+		"enable": enable,
+	})
 
 	return
 }
 
-func (u *ServiceUpstream) UpstreamSwitchRelease(resId string) (err error) {
+func (u *ServiceUpstream) UpstreamSwitchRelease(resId string, operator string) (err error) {
 	upstreamModel := models.Upstreams{}
 	upstreamInfo, err := upstreamModel.UpstreamDetailByResId(resId)
 	if err != nil {
@@ -353,7 +467,7 @@ func (u *ServiceUpstream) UpstreamSwitchRelease(resId string) (err error) {
 		return
 	}
 
-	err = UpstreamRelease([]string{resId}, utils.ReleaseTypePush)
+	err = UpstreamRelease([]string{resId}, utils.ReleaseTypePush, operator)
 	if err != nil {
 		return
 	}
@@ -391,11 +505,14 @@ func (u *ServiceUpstream) UpstreamInfoByResId(resId string) (info UpstreamListIt
 	info.ResID = upstreamInfo.ResID
 	info.Name = upstreamInfo.Name
 	info.Algorithm = upstreamInfo.Algorithm
+	info.HashOn = upstreamInfo.HashOn
+	info.HashKey = upstreamInfo.HashKey
 	info.ConnectTimeout = upstreamInfo.ConnectTimeout
 	info.WriteTimeout = upstreamInfo.WriteTimeout
 	info.ReadTimeout = upstreamInfo.ReadTimeout
 	info.Enable = upstreamInfo.Enable
 	info.Release = upstreamInfo.Release
+	info.HealthCheck = upstreamHealthCheckItem(upstreamInfo)
 	info.NodeList = nodeList
 
 	return
@@ -416,14 +533,17 @@ func (u UpstreamItem) UpstreamDetailByResId(resId string) (upstreamItem Upstream
 	upstreamItem.ResID = upstreamDetail.ResID
 	upstreamItem.Name = upstreamDetail.Name
 	upstreamItem.Algorithm = upstreamDetail.Algorithm
+	upstreamItem.HashOn = upstreamDetail.HashOn
+	upstreamItem.HashKey = upstreamDetail.HashKey
 	upstreamItem.ConnectTimeout = upstreamDetail.ConnectTimeout
 	upstreamItem.WriteTimeout = upstreamDetail.WriteTimeout
 	upstreamItem.ReadTimeout = upstreamDetail.ReadTimeout
+	upstreamItem.HealthCheck = upstreamHealthCheckItem(upstreamDetail)
 
 	return
 }
 
-func UpstreamRelease(upstreamResIds []string, releaseType string) (err error) {
+func UpstreamRelease(upstreamResIds []string, releaseType string, operator string) (err error) {
 	if len(upstreamResIds) == 0 {
 		return
 	}
@@ -519,6 +639,29 @@ func UpstreamRelease(upstreamResIds []string, releaseType string) (err error) {
 			return
 		}
 
+		upstreamNodesMap := make(map[string][]models.UpstreamNodes)
+		for _, upstreamNodeInfo := range upstreamNodeList {
+			upstreamNodesMap[upstreamNodeInfo.UpstreamResID] = append(upstreamNodesMap[upstreamNodeInfo.UpstreamResID], upstreamNodeInfo)
+		}
+
+		// 推送成功后写入发布记录
+		err = packages.GetDb().Transaction(func(tx *gorm.DB) (err error) {
+			for _, upstreamInfo := range upstreamList {
+				for _, upstreamConfig := range upstreamConfigList {
+					if upstreamConfig.Name != upstreamInfo.ResID {
+						continue
+					}
+
+					err = releaseLogUpstream(tx, upstreamInfo, upstreamConfig, upstreamNodesMap[upstreamInfo.ResID], operator)
+					if err != nil {
+						return
+					}
+				}
+			}
+
+			return
+		})
+
 	} else {
 
 		deleteNodeIds := make([]string, 0)
@@ -554,6 +697,15 @@ func generateUpstreamConfig(upstreamInfo models.Upstreams) (config rpc.UpstreamC
 		config.Algorithm = configBalance
 	}
 
+	// 一致性Hash未指定取值来源时按客户端IP计算
+	if config.Algorithm == utils.ConfigBalanceNameCHash {
+		config.HashOn = upstreamInfo.HashOn
+		if len(config.HashOn) == 0 {
+			config.HashOn = utils.HashOnIp
+		}
+		config.HashKey = upstreamInfo.HashKey
+	}
+
 	config.Name = upstreamInfo.ResID
 	config.ConnectTimeout = upstreamInfo.ConnectTimeout
 	config.WriteTimeout = upstreamInfo.WriteTimeout
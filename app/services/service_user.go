@@ -8,6 +8,7 @@ import (
 	"apioak-admin/app/validators"
 	"errors"
 	"fmt"
+	"gorm.io/gorm"
 	"time"
 )
 
@@ -28,10 +29,41 @@ func CheckUserAndPassword(email string, password string) error {
 		return errors.New(enums.CodeMessages(enums.UserNull))
 	}
 
-	if utils.Md5(utils.Md5(password)) != userInfo.Password {
+	if !utils.PasswordVerify(password, userInfo.Password, userInfo.PasswordAlgorithm) {
 		return errors.New(enums.CodeMessages(enums.UserPasswordError))
 	}
 
+	if userInfo.Enable == utils.EnableOff {
+		return errors.New(enums.CodeMessages(enums.UserDisabled))
+	}
+
+	// 旧算法的密码在登录成功后升级，升级失败不影响本次登录
+	if userInfo.PasswordAlgorithm != utils.PasswordAlgorithmBcrypt {
+		if err := userModel.UserUpdatePassword(userInfo.ResID, password); err != nil {
+			packages.Log.Error("user password rehash error", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// CheckUserRegister 校验配置是否允许公开注册
+func CheckUserRegister() error {
+	switch packages.ConfigUser.Register {
+	case utils.UserRegisterOpen:
+		return nil
+	case utils.UserRegisterClosed:
+		return errors.New(enums.CodeMessages(enums.UserRegisterClosed))
+	}
+
+	userCount, err := (&models.Users{}).UserCount()
+	if err != nil {
+		return err
+	}
+	if userCount != 0 {
+		return errors.New(enums.CodeMessages(enums.UserRegisterClosed))
+	}
+
 	return nil
 }
 
@@ -42,12 +74,28 @@ func UserCreate(userData *validators.UserRegister) error {
 		Password: userData.Password,
 	}
 
-	addErr := userModel.UserAdd(userModel)
+	// 第一个注册的用户为管理员，之后注册的用户默认只读，由管理员分配角色。
+	// 统计、添加用户与保存角色在同一事务中完成，并发注册时不会出现多个管理员或没有角色的用户
+	return packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		userCount, err := userModel.UserCountLock(tx)
+		if err != nil {
+			return err
+		}
+		role := utils.UserRoleViewer
+		if userCount == 0 {
+			role = utils.UserRoleAdmin
+		}
+
+		if err = userModel.UserAdd(tx, userModel); err != nil {
+			return err
+		}
 
-	return addErr
+		return (&models.UserRoles{}).UserRoleSave(tx, userModel.ResID, role, []string{})
+	})
 }
 
-func UserLogin(email string) (string, error) {
+// UserLogin 每次登录创建一个新的会话，不影响该用户已有的会话
+func UserLogin(email string, sessionName string, userAgent string, clientIp string) (string, error) {
 	token, tokenErr := utils.GenToken(email)
 	if tokenErr != nil {
 		return "", errors.New(enums.CodeMessages(enums.UserLoggingInError))
@@ -55,8 +103,18 @@ func UserLogin(email string) (string, error) {
 
 	emailExpires, _ := time.ParseDuration(fmt.Sprintf("+%dm", packages.Token.TokenExpire))
 
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
 	userTokensModel := models.UserTokens{}
-	setErr := userTokensModel.SetTokenExpire(email, token, time.Now().Add(emailExpires))
+	setErr := userTokensModel.SessionAdd(&models.UserTokens{
+		Name:      sessionName,
+		UserEmail: email,
+		UserAgent: userAgent,
+		ClientIp:  clientIp,
+		ExpiredAt: time.Now().Add(emailExpires),
+	}, token)
 	if setErr != nil {
 		return "", setErr
 	}
@@ -65,36 +123,24 @@ func UserLogin(email string) (string, error) {
 }
 
 func UserLogout(token string) (bool, error) {
-	email, err := utils.ParseToken(token)
+	session, err := CheckUserLoginStatus(token)
 	if err != nil {
-		return false, errors.New(enums.CodeMessages(enums.UserTokenError))
-	}
-
-	userTokensModel := models.UserTokens{}
-	userTokenExpire := userTokensModel.GetTokenExpireByEmail(email)
-
-	if len(userTokenExpire.UserEmail) == 0 || userTokenExpire.UserEmail != email {
-		return false, errors.New(enums.CodeMessages(enums.UserNoLoggingIn))
+		return false, err
 	}
 
-	delTokenExpireByEmailErr := userTokensModel.DelTokenExpireByEmail(email)
-	if delTokenExpireByEmailErr != nil {
-		return false, delTokenExpireByEmailErr
+	_, delErr := (&models.UserTokens{}).SessionDelete(session.UserEmail, session.ResID)
+	if delErr != nil {
+		return false, delErr
 	}
 
 	return true, nil
 }
 
-func UserLoginRefresh(token string) (bool, error) {
-	email, err := utils.ParseToken(token)
-	if err != nil {
-		return false, errors.New(enums.CodeMessages(enums.UserTokenError))
-	}
-
+func UserLoginRefresh(sessionResId string) (bool, error) {
 	emailExpires, _ := time.ParseDuration(fmt.Sprintf("+%dm", packages.Token.TokenExpire))
 
 	userTokensModel := models.UserTokens{}
-	setErr := userTokensModel.SetTokenExpire(email, token, time.Now().Add(emailExpires))
+	setErr := userTokensModel.SessionRefresh(sessionResId, time.Now().Add(emailExpires))
 	if setErr != nil {
 		return false, setErr
 	}
@@ -102,28 +148,314 @@ func UserLoginRefresh(token string) (bool, error) {
 	return true, nil
 }
 
-func CheckUserLoginStatus(token string) (bool, error) {
+// CheckUserLoginStatus 按 token 查找对应的会话，token 中的邮箱需要与会话一致
+func CheckUserLoginStatus(token string) (models.UserTokens, error) {
 	email, err := utils.ParseToken(token)
 	if err != nil {
-		return false, errors.New(enums.CodeMessages(enums.UserTokenError))
+		return models.UserTokens{}, errors.New(enums.CodeMessages(enums.UserTokenError))
 	}
 
 	userTokensModel := models.UserTokens{}
-	userTokenExpire := userTokensModel.GetTokenExpireByEmail(email)
+	session, err := userTokensModel.SessionByToken(token)
+	if err != nil {
+		return models.UserTokens{}, err
+	}
+
+	if len(session.ResID) == 0 {
+		return models.UserTokens{}, errors.New(enums.CodeMessages(enums.UserNoLoggingIn))
+	}
+
+	if session.UserEmail != email {
+		return models.UserTokens{}, errors.New(enums.CodeMessages(enums.UserTokenError))
+	}
+
+	if session.ExpiredAt.Unix() < time.Now().Unix() {
+		return models.UserTokens{}, errors.New(enums.CodeMessages(enums.UserLoggingInExpire))
+	}
+
+	return session, nil
+}
 
-	if len(userTokenExpire.UserEmail) == 0 || userTokenExpire.UserEmail != email {
+type UserSessionItem struct {
+	ResID      string `json:"res_id"`
+	Name       string `json:"name"`
+	UserAgent  string `json:"user_agent"`
+	ClientIp   string `json:"client_ip"`
+	Current    bool   `json:"current"`
+	LastSeenAt int64  `json:"last_seen_at"`
+	ExpiredAt  int64  `json:"expired_at"`
+	CreatedAt  int64  `json:"created_at"`
+}
 
-		return false, errors.New(enums.CodeMessages(enums.UserNoLoggingIn))
+func UserSessionList(email string, currentSessionResId string) (list []UserSessionItem, err error) {
+	sessionList, err := (&models.UserTokens{}).SessionListByEmail(email)
+	if err != nil {
+		return
+	}
 
-	} else {
-		if userTokenExpire.Token != token {
-			return false, errors.New(enums.CodeMessages(enums.UserTokenError))
+	list = make([]UserSessionItem, 0)
+	for _, session := range sessionList {
+		item := UserSessionItem{
+			ResID:     session.ResID,
+			Name:      session.Name,
+			UserAgent: session.UserAgent,
+			ClientIp:  session.ClientIp,
+			Current:   session.ResID == currentSessionResId,
+			ExpiredAt: session.ExpiredAt.Unix(),
+			CreatedAt: session.CreatedAt.Unix(),
 		}
+		if session.LastSeenAt != nil {
+			item.LastSeenAt = session.LastSeenAt.Unix()
+		}
+
+		list = append(list, item)
+	}
+
+	return
+}
+
+func UserSessionRevoke(email string, sessionResId string) error {
+	rowsAffected, err := (&models.UserTokens{}).SessionDelete(email, sessionResId)
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New(enums.CodeMessages(enums.UserSessionNull))
+	}
+
+	return nil
+}
+
+// UserSessionRevokeAll 注销该用户的全部会话，包括当前会话
+func UserSessionRevokeAll(email string) error {
+	return (&models.UserTokens{}).DelTokenExpireByEmail(email)
+}
+
+type UserItem struct {
+	ResID       string `json:"res_id"`
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	Enable      int    `json:"enable"`
+	Role        string `json:"role"`
+	LockedUntil int64  `json:"locked_until"`
+	CreatedAt   int64  `json:"created_at"`
+}
 
-		if userTokenExpire.ExpiredAt.Unix() < time.Now().Unix() {
-			return false, errors.New(enums.CodeMessages(enums.UserLoggingInExpire))
+func UserList(request *validators.UserList) (list []UserItem, total int, err error) {
+	userList, total, err := (&models.Users{}).UserListPage(request)
+	if err != nil {
+		return
+	}
+
+	userResIds := make([]string, 0)
+	for _, userInfo := range userList {
+		userResIds = append(userResIds, userInfo.ResID)
+	}
+
+	userRoleList, err := (&models.UserRoles{}).UserRoleListByUserResIds(userResIds)
+	if err != nil {
+		return
+	}
+
+	userRoleMap := make(map[string]string)
+	for _, userRole := range userRoleList {
+		userRoleMap[userRole.UserResID] = userRole.Role
+	}
+
+	userEmails := make([]string, 0)
+	for _, userInfo := range userList {
+		userEmails = append(userEmails, userInfo.Email)
+	}
+
+	lockedUntilMap, err := userLoginLockedUntil(userEmails)
+	if err != nil {
+		return
+	}
+
+	list = make([]UserItem, 0)
+	for _, userInfo := range userList {
+		role, ok := userRoleMap[userInfo.ResID]
+		if !ok {
+			role = utils.UserRoleViewer
 		}
+
+		var lockedUntil int64
+		if until, ok := lockedUntilMap[loginFailureAccountTarget(userInfo.Email)]; ok {
+			lockedUntil = until.Unix()
+		}
+
+		list = append(list, UserItem{
+			ResID:       userInfo.ResID,
+			Name:        userInfo.Name,
+			Email:       userInfo.Email,
+			Enable:      userInfo.Enable,
+			Role:        role,
+			LockedUntil: lockedUntil,
+			CreatedAt:   userInfo.CreatedAt.Unix(),
+		})
 	}
 
-	return true, nil
+	return
+}
+
+// UserInvite 管理员创建用户并分配角色，返回的初始密码只展示一次
+func UserInvite(request *validators.UserInvite) (resId string, password string, err error) {
+	if err = CheckUserEmailExist(request.Email, []string{}); err != nil {
+		return
+	}
+
+	roleRequest := &validators.UserRoleUpdate{
+		Role:          request.Role,
+		ServiceResIds: request.ServiceResIds,
+	}
+	serviceResIds, err := userRoleServiceResIds(roleRequest)
+	if err != nil {
+		return
+	}
+
+	password = utils.PasswordGenerate(16)
+	userModel := &models.Users{
+		Name:     request.Name,
+		Email:    request.Email,
+		Password: password,
+	}
+
+	// 添加用户与保存角色在同一事务中完成，不会留下没有角色的用户
+	err = packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := userModel.UserAdd(tx, userModel); err != nil {
+			return err
+		}
+
+		return (&models.UserRoles{}).UserRoleSave(tx, userModel.ResID, roleRequest.Role, serviceResIds)
+	})
+	if err != nil {
+		password = ""
+		return
+	}
+	resId = userModel.ResID
+
+	return
+}
+
+// UserSwitchEnable 禁用用户时同时注销其登录状态
+func UserSwitchEnable(operatorResId string, resId string, enable int) error {
+	userInfo, err := (&models.Users{}).UserInfoByResId(resId)
+	if err != nil {
+		return err
+	}
+
+	if userInfo.Enable == enable {
+		return errors.New(enums.CodeMessages(enums.SwitchNoChange))
+	}
+
+	if enable == utils.EnableOff {
+		if err = checkUserRemovable(operatorResId, resId); err != nil {
+			return err
+		}
+	}
+
+	err = (&models.Users{}).UserUpdateColumns(resId, map[string]interface{}{
+		"enable": enable,
+	})
+	if err != nil {
+		return err
+	}
+
+	if enable == utils.EnableOff {
+		return (&models.UserTokens{}).DelTokenExpireByEmail(userInfo.Email)
+	}
+
+	return nil
+}
+
+// UserPasswordReset 未指定密码时生成随机密码，重置后用户需要重新登录
+func UserPasswordReset(resId string, request *validators.UserPasswordReset) (password string, err error) {
+	userInfo, err := (&models.Users{}).UserInfoByResId(resId)
+	if err != nil {
+		return
+	}
+
+	password = request.Password
+	if len(password) == 0 {
+		password = utils.PasswordGenerate(16)
+	}
+
+	err = (&models.Users{}).UserUpdatePassword(resId, password)
+	if err != nil {
+		return
+	}
+
+	err = (&models.UserTokens{}).DelTokenExpireByEmail(userInfo.Email)
+
+	return
+}
+
+// UserPasswordChange 当前登录用户修改自己的密码
+func UserPasswordChange(email string, request *validators.UserPasswordChange) error {
+	if err := CheckUserAndPassword(email, request.OldPassword); err != nil {
+		return err
+	}
+
+	userInfo := (&models.Users{}).UserInfoByEmail(email)
+
+	return (&models.Users{}).UserUpdatePassword(userInfo.ResID, request.Password)
+}
+
+func UserDelete(operatorResId string, resId string) error {
+	userInfo, err := (&models.Users{}).UserInfoByResId(resId)
+	if err != nil {
+		return err
+	}
+
+	if err = checkUserRemovable(operatorResId, resId); err != nil {
+		return err
+	}
+
+	err = packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := (&models.Users{}).UserDelete(tx, resId); err != nil {
+			return err
+		}
+
+		if err := (&models.UserApiTokens{}).ApiTokenDeleteByUserResId(tx, resId); err != nil {
+			return err
+		}
+
+		if err := (&models.UserTotps{}).TotpDelete(tx, resId); err != nil {
+			return err
+		}
+
+		return (&models.UserRoles{}).UserRoleDelete(tx, resId)
+	})
+	if err != nil {
+		return err
+	}
+
+	return (&models.UserTokens{}).DelTokenExpireByEmail(userInfo.Email)
+}
+
+// checkUserRemovable 不允许禁用或删除当前登录的用户以及最后一个管理员
+func checkUserRemovable(operatorResId string, resId string) error {
+	if operatorResId == resId {
+		return errors.New(enums.CodeMessages(enums.UserOperateSelf))
+	}
+
+	permission, err := userPermissionByUserResId(resId)
+	if err != nil {
+		return err
+	}
+
+	if permission.Role != utils.UserRoleAdmin {
+		return nil
+	}
+
+	adminCount, err := (&models.UserRoles{}).UserRoleCount(utils.UserRoleAdmin)
+	if err != nil {
+		return err
+	}
+	if adminCount <= 1 {
+		return errors.New(enums.CodeMessages(enums.UserRoleLastAdmin))
+	}
+
+	return nil
 }
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"errors"
+	"time"
+)
+
+type UserApiTokenItem struct {
+	ResID       string `json:"res_id"`
+	Name        string `json:"name"`
+	TokenPrefix string `json:"token_prefix"`
+	Scope       string `json:"scope"`
+	LastUsedAt  int64  `json:"last_used_at"`
+	ExpiredAt   int64  `json:"expired_at"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+// UserApiTokenAdd 返回的 token 只展示一次，数据库中只保存其 sha256
+func UserApiTokenAdd(email string, request *validators.UserApiTokenAdd) (resId string, token string, err error) {
+	userInfo := (&models.Users{}).UserInfoByEmail(email)
+	if len(userInfo.ResID) == 0 {
+		err = errors.New(enums.CodeMessages(enums.UserNull))
+		return
+	}
+
+	apiToken := &models.UserApiTokens{
+		UserResID: userInfo.ResID,
+		Name:      request.Name,
+		Scope:     request.Scope,
+	}
+
+	if request.ExpiredAt != 0 {
+		expiredAt := time.Unix(request.ExpiredAt, 0)
+		if expiredAt.Before(time.Now()) {
+			err = errors.New(enums.CodeMessages(enums.ApiTokenExpiredAtError))
+			return
+		}
+		apiToken.ExpiredAt = &expiredAt
+	}
+
+	token = utils.ApiTokenPrefix + utils.RandomStrGenerate(40)
+	resId, err = (&models.UserApiTokens{}).ApiTokenAdd(apiToken, token)
+
+	return
+}
+
+func UserApiTokenList(email string) (list []UserApiTokenItem, err error) {
+	list = make([]UserApiTokenItem, 0)
+
+	userInfo := (&models.Users{}).UserInfoByEmail(email)
+	if len(userInfo.ResID) == 0 {
+		err = errors.New(enums.CodeMessages(enums.UserNull))
+		return
+	}
+
+	apiTokenList, err := (&models.UserApiTokens{}).ApiTokenListByUserResId(userInfo.ResID)
+	if err != nil {
+		return
+	}
+
+	for _, apiToken := range apiTokenList {
+		item := UserApiTokenItem{
+			ResID:       apiToken.ResID,
+			Name:        apiToken.Name,
+			TokenPrefix: apiToken.TokenPrefix,
+			Scope:       apiToken.Scope,
+			CreatedAt:   apiToken.CreatedAt.Unix(),
+		}
+		if apiToken.LastUsedAt != nil {
+			item.LastUsedAt = apiToken.LastUsedAt.Unix()
+		}
+		if apiToken.ExpiredAt != nil {
+			item.ExpiredAt = apiToken.ExpiredAt.Unix()
+		}
+
+		list = append(list, item)
+	}
+
+	return
+}
+
+func UserApiTokenDelete(email string, resId string) error {
+	userInfo := (&models.Users{}).UserInfoByEmail(email)
+	if len(userInfo.ResID) == 0 {
+		return errors.New(enums.CodeMessages(enums.UserNull))
+	}
+
+	rowsAffected, err := (&models.UserApiTokens{}).ApiTokenDelete(userInfo.ResID, resId)
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New(enums.CodeMessages(enums.ApiTokenNull))
+	}
+
+	return nil
+}
+
+// CheckUserApiToken 校验 API Token 并返回所属用户的邮箱
+func CheckUserApiToken(token string) (apiToken models.UserApiTokens, email string, err error) {
+	apiToken, err = (&models.UserApiTokens{}).ApiTokenByToken(token)
+	if err != nil {
+		return
+	}
+
+	if len(apiToken.ResID) == 0 {
+		err = errors.New(enums.CodeMessages(enums.ApiTokenInvalid))
+		return
+	}
+
+	if (apiToken.ExpiredAt != nil) && apiToken.ExpiredAt.Before(time.Now()) {
+		err = errors.New(enums.CodeMessages(enums.ApiTokenExpired))
+		return
+	}
+
+	userInfo, err := (&models.Users{}).UserInfoByResId(apiToken.UserResID)
+	if err != nil {
+		return
+	}
+
+	if userInfo.Enable == utils.EnableOff {
+		err = errors.New(enums.CodeMessages(enums.UserDisabled))
+		return
+	}
+	email = userInfo.Email
+
+	// 最近使用时间精确到分钟即可，避免每个请求都写数据库
+	if (apiToken.LastUsedAt == nil) || (time.Since(*apiToken.LastUsedAt) > time.Minute) {
+		if updateErr := (&models.UserApiTokens{}).ApiTokenUpdateLastUsed(apiToken.ResID); updateErr != nil {
+			packages.Log.Error("api token update last used error", updateErr.Error())
+		}
+	}
+
+	return
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/rpc"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"errors"
+	"strings"
+)
+
+// Authenticator 登录认证方式，由 user.login_provider 配置选择
+type Authenticator interface {
+	// Authenticate 校验登录的邮箱与密码，返回对应的本地用户
+	Authenticate(email string, password string) (models.Users, error)
+}
+
+func NewAuthenticator() Authenticator {
+	switch packages.ConfigUser.LoginProvider {
+	case utils.UserLoginProviderLdap:
+		return LdapAuthenticator{}
+	}
+
+	return LocalAuthenticator{}
+}
+
+// LocalAuthenticator 校验本地用户的密码
+type LocalAuthenticator struct{}
+
+func (a LocalAuthenticator) Authenticate(email string, password string) (models.Users, error) {
+	if err := CheckUserAndPassword(email, password); err != nil {
+		return models.Users{}, err
+	}
+
+	return (&models.Users{}).UserInfoByEmail(email), nil
+}
+
+// LdapAuthenticator 通过 LDAP 绑定校验密码，首次登录的用户自动创建
+type LdapAuthenticator struct{}
+
+func (a LdapAuthenticator) Authenticate(email string, password string) (models.Users, error) {
+	ldapUser, err := rpc.NewLdap().Authenticate(email, password)
+	if err != nil {
+		return models.Users{}, err
+	}
+
+	userInfo, err := userProvision(ldapUser.Email, ldapUser.Name)
+	if err != nil {
+		return models.Users{}, err
+	}
+
+	if userInfo.Enable == utils.EnableOff {
+		return models.Users{}, errors.New(enums.CodeMessages(enums.UserDisabled))
+	}
+
+	userRoleSyncByGroups(userInfo.ResID, ldapUser.Groups, packages.ConfigLdap.AdminGroups, packages.ConfigLdap.OperatorGroups)
+
+	return userInfo, nil
+}
+
+// userProvision 外部账号按邮箱查找用户，不存在时以随机密码创建，角色规则与公开注册一致
+func userProvision(email string, name string) (models.Users, error) {
+	userInfo := (&models.Users{}).UserInfoByEmail(email)
+	if len(userInfo.ResID) != 0 {
+		return userInfo, nil
+	}
+
+	if len(name) == 0 {
+		name = strings.Split(email, "@")[0]
+	}
+	if nameRunes := []rune(name); len(nameRunes) > 20 {
+		name = string(nameRunes[:20])
+	}
+
+	err := UserCreate(&validators.UserRegister{
+		Name:     name,
+		Email:    email,
+		Password: utils.PasswordGenerate(32),
+	})
+	if err != nil {
+		return models.Users{}, err
+	}
+
+	packages.Log.Info("user provisioned ", email)
+
+	return (&models.Users{}).UserInfoByEmail(email), nil
+}
+
+// userRoleSyncByGroups 配置了用户组映射时，每次登录按外部账号的用户组更新角色
+func userRoleSyncByGroups(userResId string, groups []string, adminGroups []string, operatorGroups []string) {
+	if (len(adminGroups) == 0) && (len(operatorGroups) == 0) {
+		return
+	}
+
+	groupExist := make(map[string]bool)
+	for _, group := range groups {
+		groupExist[group] = true
+	}
+
+	role := utils.UserRoleViewer
+	for _, group := range operatorGroups {
+		if groupExist[group] {
+			role = utils.UserRoleOperator
+		}
+	}
+	for _, group := range adminGroups {
+		if groupExist[group] {
+			role = utils.UserRoleAdmin
+		}
+	}
+
+	permission, err := userPermissionByUserResId(userResId)
+	if err != nil {
+		packages.Log.Error("user role sync error", err.Error())
+		return
+	}
+
+	if permission.Role == role {
+		return
+	}
+
+	// 运维保留管理员分配的服务级权限，最后一个管理员不会被降级
+	err = UserRoleUpdate(userResId, &validators.UserRoleUpdate{
+		Role:          role,
+		ServiceResIds: permission.ServiceResIds,
+	})
+	if err != nil {
+		packages.Log.Error("user role sync error", err.Error())
+	}
+}
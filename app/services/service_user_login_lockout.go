@@ -0,0 +1,158 @@
+package services
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	loginFailureAccount = "account"
+	loginFailureIp      = "ip"
+)
+
+// UserLoginAuthenticate 账号或 IP 锁定期间拒绝登录，认证失败时记录失败次数，认证成功后清除账号的失败记录
+func UserLoginAuthenticate(email string, password string, clientIp string) (models.Users, error) {
+	if err := CheckUserLoginLocked(email, clientIp); err != nil {
+		return models.Users{}, err
+	}
+
+	userInfo, err := NewAuthenticator().Authenticate(email, password)
+	if err != nil {
+		// LDAP 等远程服务异常不是账号或密码的问题，不计入失败次数
+		if err.Error() != enums.CodeMessages(enums.RemoteServiceErr) {
+			UserLoginFailed(email, clientIp)
+		}
+		return models.Users{}, err
+	}
+
+	userLoginSucceeded(email)
+
+	return userInfo, nil
+}
+
+// CheckUserLoginLocked 账号与 IP 任意一个在锁定期间都不允许登录
+func CheckUserLoginLocked(email string, clientIp string) error {
+	lockedUntil := time.Time{}
+	for failureType, target := range loginFailureTargets(email, clientIp) {
+		loginFailure, err := (&models.LoginFailures{}).LoginFailureByTarget(failureType, target)
+		if err != nil {
+			return err
+		}
+
+		if (loginFailure.LockedUntil != nil) && loginFailure.LockedUntil.After(lockedUntil) {
+			lockedUntil = *loginFailure.LockedUntil
+		}
+	}
+
+	if lockedUntil.After(time.Now()) {
+		return fmt.Errorf(enums.CodeMessages(enums.UserLoginLocked), int(math.Ceil(time.Until(lockedUntil).Seconds())))
+	}
+
+	return nil
+}
+
+// UserLoginFailed 记录账号与 IP 的失败次数，达到上限后锁定，之后每次失败锁定时长翻倍
+func UserLoginFailed(email string, clientIp string) {
+	resetBefore := time.Now().Add(-time.Second * time.Duration(packages.ConfigLoginLockout.ResetInterval))
+
+	for failureType, target := range loginFailureTargets(email, clientIp) {
+		loginFailure, err := (&models.LoginFailures{}).LoginFailureIncrease(failureType, target, resetBefore)
+		if err != nil {
+			packages.Log.Error("user login failure record error", err.Error())
+			continue
+		}
+
+		maxFailures := packages.ConfigLoginLockout.MaxFailures
+		if failureType == loginFailureIp {
+			maxFailures = packages.ConfigLoginLockout.IpMaxFailures
+		}
+		if loginFailure.Failures < maxFailures {
+			continue
+		}
+
+		duration := loginLockoutDuration(loginFailure.Failures - maxFailures)
+		if err = (&models.LoginFailures{}).LoginFailureLock(loginFailure.ID, time.Now().Add(duration)); err != nil {
+			packages.Log.Error("user login lock error", err.Error())
+			continue
+		}
+
+		packages.Log.Warn("user login locked ", failureType, " ", target, " failures ", loginFailure.Failures, " duration ", duration.String())
+	}
+}
+
+// UserLoginUnlock 管理员解除账号的登录锁定
+func UserLoginUnlock(resId string, operator string) error {
+	userInfo, err := (&models.Users{}).UserInfoByResId(resId)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := (&models.LoginFailures{}).LoginFailureDelete(loginFailureAccount, loginFailureAccountTarget(userInfo.Email))
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected != 0 {
+		packages.Log.Info("user login unlocked ", userInfo.Email, " by ", operator)
+	}
+
+	return nil
+}
+
+// userLoginLockedUntil 返回仍在锁定中的账号及其解锁时间
+func userLoginLockedUntil(emails []string) (map[string]time.Time, error) {
+	targets := make([]string, 0)
+	for _, email := range emails {
+		targets = append(targets, loginFailureAccountTarget(email))
+	}
+
+	loginFailureList, err := (&models.LoginFailures{}).LoginFailureLockedList(loginFailureAccount, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	lockedUntilMap := make(map[string]time.Time)
+	for _, loginFailure := range loginFailureList {
+		lockedUntilMap[loginFailure.Target] = *loginFailure.LockedUntil
+	}
+
+	return lockedUntilMap, nil
+}
+
+func userLoginSucceeded(email string) {
+	_, err := (&models.LoginFailures{}).LoginFailureDelete(loginFailureAccount, loginFailureAccountTarget(email))
+	if err != nil {
+		packages.Log.Error("user login failure clear error", err.Error())
+	}
+}
+
+func loginLockoutDuration(exceeded int) time.Duration {
+	maxDuration := time.Second * time.Duration(packages.ConfigLoginLockout.MaxDuration)
+
+	duration := time.Second * time.Duration(packages.ConfigLoginLockout.Duration)
+	for i := 0; (i < exceeded) && (duration < maxDuration); i++ {
+		duration *= 2
+	}
+
+	if duration > maxDuration {
+		duration = maxDuration
+	}
+
+	return duration
+}
+
+func loginFailureTargets(email string, clientIp string) map[string]string {
+	return map[string]string{
+		loginFailureAccount: loginFailureAccountTarget(email),
+		loginFailureIp:      clientIp,
+	}
+}
+
+func loginFailureAccountTarget(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/rpc"
+	"apioak-admin/app/utils"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	mockOidcClientId     = "apioak-admin"
+	mockOidcClientSecret = "mock-secret"
+	mockOidcRedirectUrl  = "http://127.0.0.1:3000/admin/user/oidc/callback"
+	mockOidcKid          = "mock-key"
+)
+
+type mockOidcCode struct {
+	Nonce         string
+	CodeChallenge string
+	Claims        map[string]interface{}
+}
+
+// mockOidcProvider 最小的身份提供方，支持授权码模式、PKCE 与 jwks
+type mockOidcProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mutex  sync.Mutex
+	claims map[string]interface{}
+	codes  map[string]mockOidcCode
+}
+
+var (
+	mockOidc     *mockOidcProvider
+	mockOidcOnce sync.Once
+)
+
+// testOidcProvider rpc.NewOidc 在进程内只初始化一次，所有测试共用同一个身份提供方
+func testOidcProvider(t *testing.T) *mockOidcProvider {
+	mockOidcOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mockOidc = &mockOidcProvider{
+			key:   key,
+			codes: make(map[string]mockOidcCode),
+		}
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/.well-known/openid-configuration", mockOidc.discovery)
+		mux.HandleFunc("/authorize", mockOidc.authorize)
+		mux.HandleFunc("/token", mockOidc.token)
+		mux.HandleFunc("/jwks", mockOidc.jwks)
+		mockOidc.server = httptest.NewServer(mux)
+
+		packages.SetConfigOidc(true, mockOidc.server.URL, mockOidcClientId, mockOidcClientSecret, mockOidcRedirectUrl,
+			[]string{"openid", "email", "profile"}, "groups", []string{"oak-admins"}, []string{}, "")
+	})
+
+	if mockOidc == nil {
+		t.Fatal("mock oidc provider start failed")
+	}
+
+	return mockOidc
+}
+
+// setClaims 设置下一次登录时 id_token 中的用户声明
+func (p *mockOidcProvider) setClaims(claims map[string]interface{}) {
+	p.mutex.Lock()
+	p.claims = claims
+	p.mutex.Unlock()
+}
+
+func (p *mockOidcProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(rpc.OidcDiscovery{
+		Issuer:                p.server.URL,
+		AuthorizationEndpoint: p.server.URL + "/authorize",
+		TokenEndpoint:         p.server.URL + "/token",
+		JwksUri:               p.server.URL + "/jwks",
+	})
+}
+
+// authorize 不需要用户交互，直接携带授权码跳转回 redirect_uri
+func (p *mockOidcProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if (query.Get("client_id") != mockOidcClientId) || (query.Get("code_challenge_method") != "S256") {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	code := utils.RandomStrGenerate(16)
+
+	p.mutex.Lock()
+	p.codes[code] = mockOidcCode{
+		Nonce:         query.Get("nonce"),
+		CodeChallenge: query.Get("code_challenge"),
+		Claims:        p.claims,
+	}
+	p.mutex.Unlock()
+
+	http.Redirect(w, r, query.Get("redirect_uri")+"?code="+code+"&state="+url.QueryEscape(query.Get("state")), http.StatusFound)
+}
+
+func (p *mockOidcProvider) token(w http.ResponseWriter, r *http.Request) {
+	clientId, clientSecret, _ := r.BasicAuth()
+	if (clientId != mockOidcClientId) || (clientSecret != mockOidcClientSecret) {
+		http.Error(w, "invalid_client", http.StatusUnauthorized)
+		return
+	}
+
+	p.mutex.Lock()
+	code, ok := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	p.mutex.Unlock()
+
+	codeChallenge := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || (base64.RawURLEncoding.EncodeToString(codeChallenge[:]) != code.CodeChallenge) {
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+
+	claims := map[string]interface{}{
+		"iss":   p.server.URL,
+		"aud":   mockOidcClientId,
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": code.Nonce,
+	}
+	for key, value := range code.Claims {
+		claims[key] = value
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"id_token": p.idToken(p.key, mockOidcKid, claims),
+	})
+}
+
+func (p *mockOidcProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": mockOidcKid,
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *mockOidcProvider) idToken(key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// login 走一遍跳转登录，返回回调收到的授权码与 state
+func (p *mockOidcProvider) login(t *testing.T) (code string, state string) {
+	loginUrl, _, _, err := OidcLoginUrl()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(loginUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(location.String(), mockOidcRedirectUrl) {
+		t.Fatalf("unexpected redirect %q", location.String())
+	}
+
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestOidcLogin(t *testing.T) {
+	provider := testOidcProvider(t)
+
+	t.Run("login", func(t *testing.T) {
+		provider.setClaims(map[string]interface{}{
+			"email":          "alice@example.com",
+			"email_verified": true,
+			"name":           "Alice",
+			"groups":         []string{"oak-admins"},
+		})
+		code, state := provider.login(t)
+
+		userInfo, err := OidcAuthenticate(code, state, state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if userInfo.Name != "Alice" {
+			t.Errorf("provisioned name = %q", userInfo.Name)
+		}
+
+		result, err := UserLoginIssue(userInfo, "oidc", "go-test", "127.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		session, err := CheckUserLoginStatus(result.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if session.UserEmail != "alice@example.com" {
+			t.Errorf("session email = %q", session.UserEmail)
+		}
+
+		permission, err := userPermissionByUserResId(userInfo.ResID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if permission.Role != utils.UserRoleAdmin {
+			t.Errorf("role = %q, want %q", permission.Role, utils.UserRoleAdmin)
+		}
+
+		// state 只能使用一次
+		if _, err = OidcAuthenticate(code, state, state); (err == nil) || (err.Error() != enums.CodeMessages(enums.OidcStateError)) {
+			t.Errorf("reused state error = %v", err)
+		}
+	})
+
+	t.Run("totp required", func(t *testing.T) {
+		packages.SetConfigUser(utils.UserRegisterOpen, utils.UserLoginProviderLocal, true)
+		defer packages.SetConfigUser(utils.UserRegisterOpen, utils.UserLoginProviderLocal, false)
+
+		provider.setClaims(map[string]interface{}{"email": "frank@example.com", "email_verified": true})
+		code, state := provider.login(t)
+
+		userInfo, err := OidcAuthenticate(code, state, state)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 单点登录与密码登录一样，需要两步验证时只返回 totp_token
+		result, err := UserLoginIssue(userInfo, "oidc", "go-test", "127.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (len(result.Token) != 0) || !result.TotpRequired || !result.TotpEnroll || (len(result.TotpToken) == 0) {
+			t.Fatalf("result = %+v", result)
+		}
+
+		challenge, err := userTotpChallengeCheck(result.TotpToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (challenge.UserEmail != "frank@example.com") || (challenge.SessionName != "oidc") {
+			t.Errorf("challenge = %+v", challenge)
+		}
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		provider.setClaims(map[string]interface{}{"email": "bob@example.com"})
+		code, _ := provider.login(t)
+
+		state := utils.RandomStrGenerate(32)
+		if _, err := OidcAuthenticate(code, state, state); (err == nil) || (err.Error() != enums.CodeMessages(enums.OidcStateError)) {
+			t.Errorf("error = %v", err)
+		}
+	})
+
+	t.Run("state cookie mismatch", func(t *testing.T) {
+		provider.setClaims(map[string]interface{}{"email": "bob@example.com", "email_verified": true})
+		code, state := provider.login(t)
+
+		// 攻击者自己发起的登录回调，受害者浏览器中没有对应的 state cookie
+		for _, stateCookie := range []string{"", utils.RandomStrGenerate(32)} {
+			if _, err := OidcAuthenticate(code, state, stateCookie); (err == nil) || (err.Error() != enums.CodeMessages(enums.OidcStateError)) {
+				t.Errorf("cookie %q error = %v", stateCookie, err)
+			}
+		}
+		if userInfo := (&models.Users{}).UserInfoByEmail("bob@example.com"); len(userInfo.ResID) != 0 {
+			t.Error("user provisioned without state cookie")
+		}
+	})
+
+	t.Run("email verified claim missing", func(t *testing.T) {
+		provider.setClaims(map[string]interface{}{"email": "alice@example.com"})
+		code, state := provider.login(t)
+
+		// 未明确验证的邮箱不能关联已有账号
+		if _, err := OidcAuthenticate(code, state, state); (err == nil) || (err.Error() != enums.CodeMessages(enums.OidcEmailNull)) {
+			t.Errorf("error = %v", err)
+		}
+	})
+
+	t.Run("email not verified", func(t *testing.T) {
+		provider.setClaims(map[string]interface{}{"email": "carol@example.com", "email_verified": false})
+		code, state := provider.login(t)
+
+		if _, err := OidcAuthenticate(code, state, state); (err == nil) || (err.Error() != enums.CodeMessages(enums.OidcEmailNull)) {
+			t.Errorf("error = %v", err)
+		}
+		if userInfo := (&models.Users{}).UserInfoByEmail("carol@example.com"); len(userInfo.ResID) != 0 {
+			t.Error("user provisioned without verified email")
+		}
+	})
+}
+
+func TestVerifyIdToken(t *testing.T) {
+	provider := testOidcProvider(t)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := func(override map[string]interface{}) map[string]interface{} {
+		result := map[string]interface{}{
+			"iss":   provider.server.URL,
+			"aud":   mockOidcClientId,
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"nonce": "nonce",
+			"email": "dave@example.com",
+		}
+		for key, value := range override {
+			result[key] = value
+		}
+		return result
+	}
+
+	tests := []struct {
+		name    string
+		key     *rsa.PrivateKey
+		kid     string
+		claims  map[string]interface{}
+		wantErr bool
+	}{
+		{"valid", provider.key, mockOidcKid, claims(nil), false},
+		{"audience list", provider.key, mockOidcKid, claims(map[string]interface{}{"aud": []string{"other", mockOidcClientId}}), false},
+		{"within clock skew", provider.key, mockOidcKid, claims(map[string]interface{}{"exp": time.Now().Add(-time.Second * 30).Unix()}), false},
+		{"expired", provider.key, mockOidcKid, claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()}), true},
+		{"nonce mismatch", provider.key, mockOidcKid, claims(map[string]interface{}{"nonce": "other"}), true},
+		{"audience mismatch", provider.key, mockOidcKid, claims(map[string]interface{}{"aud": "other"}), true},
+		{"issuer mismatch", provider.key, mockOidcKid, claims(map[string]interface{}{"iss": "https://idp.example.com"}), true},
+		{"unknown kid", provider.key, "other-key", claims(nil), true},
+		{"wrong signature", otherKey, mockOidcKid, claims(nil), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rpc.NewOidc().VerifyIdToken(provider.idToken(tt.key, tt.kid, tt.claims), "nonce")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (result["email"] != "dave@example.com") {
+				t.Errorf("email claim = %v", result["email"])
+			}
+		})
+	}
+
+	if _, err = rpc.NewOidc().VerifyIdToken("not-a-jwt", "nonce"); err == nil {
+		t.Error("malformed id_token accepted")
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"errors"
+	"gorm.io/gorm"
+)
+
+// userRoleRanks 角色等级，等级高的角色拥有等级低的角色的全部权限
+var userRoleRanks = map[string]int{
+	utils.UserRoleViewer:   1,
+	utils.UserRoleOperator: 2,
+	utils.UserRoleAdmin:    3,
+}
+
+type UserPermission struct {
+	UserResID     string
+	Role          string
+	ServiceResIds []string
+}
+
+// Restricted 分配了服务级权限的运维只能修改对应服务下的资源
+func (p UserPermission) Restricted() bool {
+	return (p.Role == utils.UserRoleOperator) && (len(p.ServiceResIds) != 0)
+}
+
+func (p UserPermission) Allow(role string, serviceResIds []string) bool {
+	if userRoleRanks[p.Role] < userRoleRanks[role] {
+		return false
+	}
+
+	if !p.Restricted() {
+		return true
+	}
+
+	if len(serviceResIds) == 0 {
+		return false
+	}
+
+	permitted := make(map[string]bool)
+	for _, serviceResId := range p.ServiceResIds {
+		permitted[serviceResId] = true
+	}
+
+	for _, serviceResId := range serviceResIds {
+		if !permitted[serviceResId] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type UserRoleInfo struct {
+	UserResID     string   `json:"user_res_id"`
+	Name          string   `json:"name"`
+	Email         string   `json:"email"`
+	Role          string   `json:"role"`
+	ServiceResIds []string `json:"service_res_ids"`
+}
+
+// UserPermissionByEmail 未分配角色的用户按只读处理
+func UserPermissionByEmail(email string) (permission UserPermission, err error) {
+	userInfo := (&models.Users{}).UserInfoByEmail(email)
+	if (len(userInfo.ResID) == 0) || (userInfo.Email != email) {
+		err = errors.New(enums.CodeMessages(enums.UserNull))
+		return
+	}
+
+	if userInfo.Enable == utils.EnableOff {
+		err = errors.New(enums.CodeMessages(enums.UserDisabled))
+		return
+	}
+
+	return userPermissionByUserResId(userInfo.ResID)
+}
+
+func userPermissionByUserResId(userResId string) (permission UserPermission, err error) {
+	userRole, err := (&models.UserRoles{}).UserRoleByUserResId(userResId)
+	if err != nil {
+		return
+	}
+
+	permission = UserPermission{
+		UserResID:     userResId,
+		Role:          userRole.Role,
+		ServiceResIds: []string{},
+	}
+	if _, ok := userRoleRanks[permission.Role]; !ok {
+		permission.Role = utils.UserRoleViewer
+	}
+
+	if permission.Role == utils.UserRoleOperator {
+		permission.ServiceResIds, err = (&models.UserServicePermissions{}).ServiceResIdsByUserResId(userResId)
+	}
+
+	return
+}
+
+func UserRoleInfoByUserResId(userResId string) (info UserRoleInfo, err error) {
+	userInfo, err := (&models.Users{}).UserInfoByResId(userResId)
+	if err != nil {
+		return
+	}
+
+	permission, err := userPermissionByUserResId(userResId)
+	if err != nil {
+		return
+	}
+
+	info = UserRoleInfo{
+		UserResID:     userInfo.ResID,
+		Name:          userInfo.Name,
+		Email:         userInfo.Email,
+		Role:          permission.Role,
+		ServiceResIds: permission.ServiceResIds,
+	}
+
+	return
+}
+
+func UserRoleUpdate(userResId string, request *validators.UserRoleUpdate) error {
+	if _, err := (&models.Users{}).UserInfoByResId(userResId); err != nil {
+		return err
+	}
+
+	permission, err := userPermissionByUserResId(userResId)
+	if err != nil {
+		return err
+	}
+
+	if (permission.Role == utils.UserRoleAdmin) && (request.Role != utils.UserRoleAdmin) {
+		adminCount, err := (&models.UserRoles{}).UserRoleCount(utils.UserRoleAdmin)
+		if err != nil {
+			return err
+		}
+		if adminCount <= 1 {
+			return errors.New(enums.CodeMessages(enums.UserRoleLastAdmin))
+		}
+	}
+
+	serviceResIds, err := userRoleServiceResIds(request)
+	if err != nil {
+		return err
+	}
+
+	return packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		return (&models.UserRoles{}).UserRoleSave(tx, userResId, request.Role, serviceResIds)
+	})
+}
+
+// userRoleServiceResIds 服务级权限只对运维生效，返回去重后的服务ID
+func userRoleServiceResIds(request *validators.UserRoleUpdate) ([]string, error) {
+	serviceResIds := make([]string, 0)
+	serviceExist := make(map[string]bool)
+	if request.Role != utils.UserRoleOperator {
+		return serviceResIds, nil
+	}
+
+	for _, serviceResId := range request.ServiceResIds {
+		if serviceExist[serviceResId] {
+			continue
+		}
+		if _, err := (&models.Services{}).ServiceInfoById(serviceResId); err != nil {
+			return nil, err
+		}
+		serviceExist[serviceResId] = true
+		serviceResIds = append(serviceResIds, serviceResId)
+	}
+
+	return serviceResIds, nil
+}
+
+// ServiceResIdByRouterResId 返回路由所属的服务，路由不存在时返回空字符串
+func ServiceResIdByRouterResId(routerResId string) string {
+	routerInfo, err := (&models.Routers{}).RouterDetailByResId(routerResId)
+	if err != nil {
+		return ""
+	}
+
+	return routerInfo.ServiceResID
+}
+
+// ServiceResIdByPluginConfigResId 返回插件配置所属的服务，路由插件配置返回路由所属的服务
+func ServiceResIdByPluginConfigResId(pluginConfigResId string) string {
+	pluginConfigInfo, err := (&models.PluginConfigs{}).PluginConfigInfoByResId(pluginConfigResId)
+	if (err != nil) || (len(pluginConfigInfo.ResID) == 0) {
+		return ""
+	}
+
+	if pluginConfigInfo.Type == models.PluginConfigsTypeService {
+		return pluginConfigInfo.TargetID
+	}
+
+	return ServiceResIdByRouterResId(pluginConfigInfo.TargetID)
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"errors"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+const (
+	loginChallengeOidc = "oidc"
+	loginChallengeTotp = "totp"
+)
+
+var (
+	// 密码校验通过后完成两步验证的最长时间与最多尝试次数
+	totpChallengeExpire      = time.Minute * 5
+	totpChallengeMaxAttempts = 5
+)
+
+type UserTotpEnrollItem struct {
+	Secret string `json:"secret"`
+	Uri    string `json:"uri"`
+}
+
+type UserTotpInfoItem struct {
+	Enable        bool  `json:"enable"`
+	Required      bool  `json:"required"`
+	RecoveryCodes int64 `json:"recovery_codes"`
+}
+
+type UserLoginItem struct {
+	Token        string `json:"token"`
+	TotpRequired bool   `json:"totp_required,omitempty"`
+	TotpEnroll   bool   `json:"totp_enroll,omitempty"`
+	TotpToken    string `json:"totp_token,omitempty"`
+}
+
+// UserLoginIssue 密码与单点登录认证通过后调用，需要两步验证时不签发 token，由 /login/totp 完成登录
+func UserLoginIssue(userInfo models.Users, sessionName string, userAgent string, clientIp string) (UserLoginItem, error) {
+	totpRequired, totpEnroll, err := UserTotpRequired(userInfo.ResID)
+	if err != nil {
+		return UserLoginItem{}, err
+	}
+
+	if totpRequired {
+		totpToken, err := UserTotpChallenge(userInfo.Email, sessionName)
+		if err != nil {
+			return UserLoginItem{}, err
+		}
+
+		return UserLoginItem{
+			TotpRequired: true,
+			TotpEnroll:   totpEnroll,
+			TotpToken:    totpToken,
+		}, nil
+	}
+
+	token, err := UserLogin(userInfo.Email, sessionName, userAgent, clientIp)
+	if err != nil {
+		return UserLoginItem{}, err
+	}
+
+	return UserLoginItem{
+		Token: token,
+	}, nil
+}
+
+// UserTotpRequired 用户开启了两步验证，或配置要求开启时需要两步验证，enroll 表示需要先绑定
+func UserTotpRequired(userResId string) (required bool, enroll bool, err error) {
+	totp, err := (&models.UserTotps{}).TotpByUserResId(userResId)
+	if err != nil {
+		return
+	}
+
+	if totp.Enable == utils.EnableOn {
+		return true, false, nil
+	}
+
+	if packages.ConfigUser.TotpRequired {
+		return true, true, nil
+	}
+
+	return false, false, nil
+}
+
+// UserTotpChallenge 密码校验通过后记录待完成两步验证的登录，返回用于第二步的 totp_token
+func UserTotpChallenge(email string, sessionName string) (string, error) {
+	totpToken := utils.RandomStrGenerate(32)
+
+	err := (&models.LoginChallenges{}).LoginChallengeAdd(&models.LoginChallenges{
+		Type:        loginChallengeTotp,
+		TokenHash:   utils.Sha256(totpToken),
+		UserEmail:   email,
+		SessionName: sessionName,
+		ExpiredAt:   time.Now().Add(totpChallengeExpire),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return totpToken, nil
+}
+
+// userTotpChallengeCheck 每次校验计入尝试次数，超过次数后需要重新登录
+func userTotpChallengeCheck(totpToken string) (models.LoginChallenges, error) {
+	challenge, err := (&models.LoginChallenges{}).LoginChallengeAttempt(loginChallengeTotp, utils.Sha256(totpToken), totpChallengeMaxAttempts)
+	if err != nil {
+		return models.LoginChallenges{}, err
+	}
+
+	if challenge.ID == 0 {
+		userTotpChallengeDelete(totpToken)
+		return models.LoginChallenges{}, errors.New(enums.CodeMessages(enums.TotpChallengeError))
+	}
+
+	return challenge, nil
+}
+
+func userTotpChallengeDelete(totpToken string) {
+	if err := (&models.LoginChallenges{}).LoginChallengeDelete(loginChallengeTotp, utils.Sha256(totpToken)); err != nil {
+		packages.Log.Error("delete totp challenge error", err.Error())
+	}
+}
+
+// UserTotpLoginEnroll 配置要求两步验证且用户未绑定时，在登录的第二步绑定密钥
+func UserTotpLoginEnroll(request *validators.UserTotpLoginEnroll) (UserTotpEnrollItem, error) {
+	challenge, err := userTotpChallengeCheck(request.TotpToken)
+	if err != nil {
+		return UserTotpEnrollItem{}, err
+	}
+
+	return UserTotpEnroll(challenge.UserEmail)
+}
+
+// UserTotpLogin 登录的第二步，校验验证码或恢复码后签发 auth-token。
+// 登录时绑定的用户在此开启两步验证，并返回恢复码
+func UserTotpLogin(request *validators.UserTotpLogin, userAgent string, clientIp string) (token string, recoveryCodes []string, err error) {
+	challenge, err := userTotpChallengeCheck(request.TotpToken)
+	if err != nil {
+		return
+	}
+
+	if err = CheckUserLoginLocked(challenge.UserEmail, clientIp); err != nil {
+		return
+	}
+
+	userInfo := (&models.Users{}).UserInfoByEmail(challenge.UserEmail)
+	if len(userInfo.ResID) == 0 {
+		err = errors.New(enums.CodeMessages(enums.UserNull))
+		return
+	}
+
+	totp, err := (&models.UserTotps{}).TotpByUserResId(userInfo.ResID)
+	if err != nil {
+		return
+	}
+
+	switch {
+	case totp.Enable == utils.EnableOn:
+		err = userTotpVerify(totp, request.Code)
+	case (totp.ID != 0) && packages.ConfigUser.TotpRequired:
+		recoveryCodes, err = userTotpActivate(totp, request.Code)
+	default:
+		err = errors.New(enums.CodeMessages(enums.TotpNotEnabled))
+	}
+	if err != nil {
+		// 验证码错误与密码错误一样计入登录失败次数
+		if err.Error() == enums.CodeMessages(enums.TotpCodeError) {
+			UserLoginFailed(challenge.UserEmail, clientIp)
+		}
+		return
+	}
+
+	userTotpChallengeDelete(request.TotpToken)
+
+	token, err = UserLogin(challenge.UserEmail, challenge.SessionName, userAgent, clientIp)
+
+	return
+}
+
+func UserTotpInfo(email string) (info UserTotpInfoItem, err error) {
+	userInfo := (&models.Users{}).UserInfoByEmail(email)
+	if len(userInfo.ResID) == 0 {
+		err = errors.New(enums.CodeMessages(enums.UserNull))
+		return
+	}
+
+	totp, err := (&models.UserTotps{}).TotpByUserResId(userInfo.ResID)
+	if err != nil {
+		return
+	}
+
+	info.Enable = totp.Enable == utils.EnableOn
+	info.Required = packages.ConfigUser.TotpRequired
+	if info.Enable {
+		info.RecoveryCodes, err = (&models.UserRecoveryCodes{}).RecoveryCodeUnusedCount(userInfo.ResID)
+	}
+
+	return
+}
+
+// UserTotpEnroll 生成新的密钥，验证首个验证码后才会开启两步验证
+func UserTotpEnroll(email string) (UserTotpEnrollItem, error) {
+	userInfo := (&models.Users{}).UserInfoByEmail(email)
+	if len(userInfo.ResID) == 0 {
+		return UserTotpEnrollItem{}, errors.New(enums.CodeMessages(enums.UserNull))
+	}
+
+	totp, err := (&models.UserTotps{}).TotpByUserResId(userInfo.ResID)
+	if err != nil {
+		return UserTotpEnrollItem{}, err
+	}
+
+	if totp.Enable == utils.EnableOn {
+		return UserTotpEnrollItem{}, errors.New(enums.CodeMessages(enums.TotpEnabled))
+	}
+
+	secret, err := utils.TotpSecretGenerate()
+	if err != nil {
+		return UserTotpEnrollItem{}, err
+	}
+
+	if err = (&models.UserTotps{}).TotpSave(userInfo.ResID, secret); err != nil {
+		return UserTotpEnrollItem{}, err
+	}
+
+	return UserTotpEnrollItem{
+		Secret: secret,
+		Uri:    utils.TotpUri(utils.TotpIssuer, userInfo.Email, secret),
+	}, nil
+}
+
+// UserTotpActivate 校验绑定后的首个验证码并开启两步验证，返回的恢复码只展示一次
+func UserTotpActivate(email string, request *validators.UserTotpCode) ([]string, error) {
+	userInfo := (&models.Users{}).UserInfoByEmail(email)
+	if len(userInfo.ResID) == 0 {
+		return nil, errors.New(enums.CodeMessages(enums.UserNull))
+	}
+
+	totp, err := (&models.UserTotps{}).TotpByUserResId(userInfo.ResID)
+	if err != nil {
+		return nil, err
+	}
+
+	if totp.ID == 0 {
+		return nil, errors.New(enums.CodeMessages(enums.TotpNotEnabled))
+	}
+
+	if totp.Enable == utils.EnableOn {
+		return nil, errors.New(enums.CodeMessages(enums.TotpEnabled))
+	}
+
+	return userTotpActivate(totp, request.Code)
+}
+
+// UserTotpDisable 关闭两步验证需要验证码或恢复码，配置要求两步验证时不允许关闭
+func UserTotpDisable(email string, request *validators.UserTotpCode) error {
+	if packages.ConfigUser.TotpRequired {
+		return errors.New(enums.CodeMessages(enums.TotpRequired))
+	}
+
+	totp, err := userTotpEnabled(email)
+	if err != nil {
+		return err
+	}
+
+	if err = userTotpVerify(totp, request.Code); err != nil {
+		return err
+	}
+
+	return packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		return (&models.UserTotps{}).TotpDelete(tx, totp.UserResID)
+	})
+}
+
+// UserTotpRecoveryCodes 重新生成恢复码，原有的恢复码全部失效
+func UserTotpRecoveryCodes(email string, request *validators.UserTotpCode) ([]string, error) {
+	totp, err := userTotpEnabled(email)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = userTotpVerify(totp, request.Code); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes := userRecoveryCodesGenerate()
+	err = packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		return (&models.UserRecoveryCodes{}).RecoveryCodeSave(tx, totp.UserResID, recoveryCodes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// UserTotpReset 管理员为丢失身份验证器的用户关闭两步验证
+func UserTotpReset(resId string) error {
+	if _, err := (&models.Users{}).UserInfoByResId(resId); err != nil {
+		return err
+	}
+
+	return packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		return (&models.UserTotps{}).TotpDelete(tx, resId)
+	})
+}
+
+func userTotpEnabled(email string) (models.UserTotps, error) {
+	userInfo := (&models.Users{}).UserInfoByEmail(email)
+	if len(userInfo.ResID) == 0 {
+		return models.UserTotps{}, errors.New(enums.CodeMessages(enums.UserNull))
+	}
+
+	totp, err := (&models.UserTotps{}).TotpByUserResId(userInfo.ResID)
+	if err != nil {
+		return models.UserTotps{}, err
+	}
+
+	if totp.Enable != utils.EnableOn {
+		return models.UserTotps{}, errors.New(enums.CodeMessages(enums.TotpNotEnabled))
+	}
+
+	return totp, nil
+}
+
+// userTotpVerify 6 位数字按验证码校验，其他按恢复码校验，恢复码只能使用一次
+func userTotpVerify(totp models.UserTotps, code string) error {
+	code = strings.ToLower(strings.TrimSpace(code))
+
+	if strings.Contains(code, "-") {
+		rowsAffected, err := (&models.UserRecoveryCodes{}).RecoveryCodeUse(totp.UserResID, code)
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return errors.New(enums.CodeMessages(enums.TotpCodeError))
+		}
+
+		packages.Log.Info("user recovery code used ", totp.UserResID)
+		return nil
+	}
+
+	step, ok := utils.TotpVerify(totp.Secret, code, totp.LastStep)
+	if !ok {
+		return errors.New(enums.CodeMessages(enums.TotpCodeError))
+	}
+
+	rowsAffected, err := (&models.UserTotps{}).TotpUpdateLastStep(totp.UserResID, step)
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New(enums.CodeMessages(enums.TotpCodeError))
+	}
+
+	return nil
+}
+
+func userTotpActivate(totp models.UserTotps, code string) ([]string, error) {
+	step, ok := utils.TotpVerify(totp.Secret, strings.TrimSpace(code), totp.LastStep)
+	if !ok {
+		return nil, errors.New(enums.CodeMessages(enums.TotpCodeError))
+	}
+
+	recoveryCodes := userRecoveryCodesGenerate()
+	err := packages.GetDb().Transaction(func(tx *gorm.DB) error {
+		if err := (&models.UserTotps{}).TotpEnable(tx, totp.UserResID, step); err != nil {
+			return err
+		}
+
+		return (&models.UserRecoveryCodes{}).RecoveryCodeSave(tx, totp.UserResID, recoveryCodes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+func userRecoveryCodesGenerate() []string {
+	recoveryCodes := make([]string, 0)
+	for i := 0; i < utils.TotpRecoveryCodeCount; i++ {
+		recoveryCodes = append(recoveryCodes, utils.RecoveryCodeGenerate())
+	}
+
+	return recoveryCodes
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"apioak-admin/app/enums"
+	"testing"
+)
+
+func TestUserTotpChallenge(t *testing.T) {
+	totpToken, err := UserTotpChallenge("erin@example.com", "laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= totpChallengeMaxAttempts; i++ {
+		challenge, err := userTotpChallengeCheck(totpToken)
+		if err != nil {
+			t.Fatalf("attempt %d: %v", i, err)
+		}
+		if (challenge.UserEmail != "erin@example.com") || (challenge.SessionName != "laptop") || (challenge.Attempts != i) {
+			t.Fatalf("attempt %d: challenge = %+v", i, challenge)
+		}
+	}
+
+	// 超过尝试次数后需要重新登录
+	if _, err = userTotpChallengeCheck(totpToken); (err == nil) || (err.Error() != enums.CodeMessages(enums.TotpChallengeError)) {
+		t.Errorf("error = %v", err)
+	}
+
+	if _, err = userTotpChallengeCheck("unknown"); (err == nil) || (err.Error() != enums.CodeMessages(enums.TotpChallengeError)) {
+		t.Errorf("unknown token error = %v", err)
+	}
+
+	totpToken, err = UserTotpChallenge("erin@example.com", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	userTotpChallengeDelete(totpToken)
+	if _, err = userTotpChallengeCheck(totpToken); err == nil {
+		t.Error("deleted challenge accepted")
+	}
+}
@@ -0,0 +1,458 @@
+package services
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/models"
+	"apioak-admin/app/packages"
+	"apioak-admin/app/rpc"
+	"apioak-admin/app/utils"
+	"apioak-admin/app/validators"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// webhookEvents 可订阅的事件，webhook.ping 只在测试时发送
+	webhookEvents = []string{
+		utils.WebhookEventServiceCreated,
+		utils.WebhookEventServiceUpdated,
+		utils.WebhookEventServiceDeleted,
+		utils.WebhookEventServiceReleased,
+		utils.WebhookEventRouterCreated,
+		utils.WebhookEventRouterUpdated,
+		utils.WebhookEventRouterDeleted,
+		utils.WebhookEventRouterReleased,
+		utils.WebhookEventUpstreamCreated,
+		utils.WebhookEventUpstreamUpdated,
+		utils.WebhookEventUpstreamDeleted,
+		utils.WebhookEventUpstreamReleased,
+		utils.WebhookEventCertificateCreated,
+		utils.WebhookEventCertificateUpdated,
+		utils.WebhookEventCertificateDeleted,
+		utils.WebhookEventCertificateExpiring,
+	}
+
+	webhookWakeup = make(chan struct{}, 1)
+
+	// 每轮最多投递的记录数与同时投递的个数
+	webhookDispatchLimit       = 50
+	webhookDispatchConcurrency = 5
+	webhookResponseMaxLength   = 1000
+)
+
+type WebhookItem struct {
+	ResID     string   `json:"res_id"`
+	Name      string   `json:"name"`
+	Url       string   `json:"url"`
+	Secret    string   `json:"secret,omitempty"`
+	Events    []string `json:"events"`
+	Enable    int      `json:"enable"`
+	CreatedAt int64    `json:"created_at"`
+}
+
+type WebhookDeliveryItem struct {
+	ResID           string      `json:"res_id"`
+	WebhookResID    string      `json:"webhook_res_id"`
+	Event           string      `json:"event"`
+	Payload         interface{} `json:"payload"`
+	Status          int         `json:"status"`
+	Attempts        int         `json:"attempts"`
+	NextAttemptAt   int64       `json:"next_attempt_at"`
+	ResponseCode    int         `json:"response_code"`
+	ResponseMessage string      `json:"response_message"`
+	DeliveredAt     int64       `json:"delivered_at"`
+	CreatedAt       int64       `json:"created_at"`
+}
+
+// WebhookPayload 投递的请求体
+type WebhookPayload struct {
+	Event      string      `json:"event"`
+	OccurredAt int64       `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+func WebhookEventList() []string {
+	return webhookEvents
+}
+
+func WebhookCreate(request *validators.WebhookAddUpdate) (resId string, secret string, err error) {
+	if err = checkWebhookRequest(request); err != nil {
+		return
+	}
+
+	secret = request.Secret
+	if len(secret) == 0 {
+		secret = utils.RandomStrGenerate(32)
+	}
+
+	resId, err = (&models.Webhooks{}).WebhookAdd(&models.Webhooks{
+		Name:   request.Name,
+		Url:    request.Url,
+		Secret: secret,
+		Events: strings.Join(request.Events, ","),
+		Enable: request.Enable,
+	})
+
+	return
+}
+
+// WebhookUpdate 未传递签名密钥时保留原有的密钥
+func WebhookUpdate(resId string, request *validators.WebhookAddUpdate) error {
+	if _, err := (&models.Webhooks{}).WebhookInfoByResId(resId); err != nil {
+		return err
+	}
+
+	if err := checkWebhookRequest(request); err != nil {
+		return err
+	}
+
+	updateParams := map[string]interface{}{
+		"name":   request.Name,
+		"url":    request.Url,
+		"events": strings.Join(request.Events, ","),
+		"enable": request.Enable,
+	}
+	if len(request.Secret) != 0 {
+		updateParams["secret"] = request.Secret
+	}
+
+	return (&models.Webhooks{}).WebhookUpdateColumns(resId, updateParams)
+}
+
+func WebhookSwitchEnable(resId string, enable int) error {
+	webhook, err := (&models.Webhooks{}).WebhookInfoByResId(resId)
+	if err != nil {
+		return err
+	}
+
+	if webhook.Enable == enable {
+		return errors.New(enums.CodeMessages(enums.SwitchNoChange))
+	}
+
+	return (&models.Webhooks{}).WebhookUpdateColumns(resId, map[string]interface{}{
+		"enable": enable,
+	})
+}
+
+func WebhookDelete(resId string) error {
+	if _, err := (&models.Webhooks{}).WebhookInfoByResId(resId); err != nil {
+		return err
+	}
+
+	return (&models.Webhooks{}).WebhookDelete(resId)
+}
+
+func WebhookInfo(resId string) (WebhookItem, error) {
+	webhook, err := (&models.Webhooks{}).WebhookInfoByResId(resId)
+	if err != nil {
+		return WebhookItem{}, err
+	}
+
+	item := webhookItem(webhook)
+	item.Secret = webhook.Secret
+
+	return item, nil
+}
+
+func WebhookList(request *validators.WebhookList) (list []WebhookItem, total int, err error) {
+	webhookList, total, err := (&models.Webhooks{}).WebhookListPage(request)
+	if err != nil {
+		return
+	}
+
+	list = make([]WebhookItem, 0)
+	for _, webhook := range webhookList {
+		list = append(list, webhookItem(webhook))
+	}
+
+	return
+}
+
+// WebhookTest 向该地址投递一次 webhook.ping，不受订阅与开关的限制
+func WebhookTest(resId string) (string, error) {
+	webhook, err := (&models.Webhooks{}).WebhookInfoByResId(resId)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := webhookPayload(utils.WebhookEventPing, map[string]interface{}{
+		"webhook_res_id": webhook.ResID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	deliveryResId, err := webhookDeliveryAdd(packages.GetDb(), webhook.ResID, utils.WebhookEventPing, payload)
+	if err != nil {
+		return "", err
+	}
+
+	webhookWake()
+
+	return deliveryResId, nil
+}
+
+func WebhookDeliveryList(webhookResId string, request *validators.WebhookDeliveryList) (list []WebhookDeliveryItem, total int, err error) {
+	if _, err = (&models.Webhooks{}).WebhookInfoByResId(webhookResId); err != nil {
+		return
+	}
+
+	deliveryList, total, err := (&models.WebhookDeliveries{}).WebhookDeliveryListPage(webhookResId, request)
+	if err != nil {
+		return
+	}
+
+	list = make([]WebhookDeliveryItem, 0)
+	for _, delivery := range deliveryList {
+		item := WebhookDeliveryItem{
+			ResID:           delivery.ResID,
+			WebhookResID:    delivery.WebhookResID,
+			Event:           delivery.Event,
+			Status:          delivery.Status,
+			Attempts:        delivery.Attempts,
+			ResponseCode:    delivery.ResponseCode,
+			ResponseMessage: delivery.ResponseMessage,
+			CreatedAt:       delivery.CreatedAt.Unix(),
+		}
+		if delivery.NextAttemptAt != nil {
+			item.NextAttemptAt = delivery.NextAttemptAt.Unix()
+		}
+		if delivery.DeliveredAt != nil {
+			item.DeliveredAt = delivery.DeliveredAt.Unix()
+		}
+		_ = json.Unmarshal([]byte(delivery.Payload), &item.Payload)
+
+		list = append(list, item)
+	}
+
+	return
+}
+
+// WebhookDeliveryRetry 重新投递，重试次数重新计算
+func WebhookDeliveryRetry(resId string) error {
+	delivery, err := (&models.WebhookDeliveries{}).WebhookDeliveryInfoByResId(resId)
+	if err != nil {
+		return err
+	}
+
+	err = (&models.WebhookDeliveries{}).WebhookDeliveryUpdateColumns(delivery.ID, map[string]interface{}{
+		"status":          utils.WebhookDeliveryStatusPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	webhookWake()
+
+	return nil
+}
+
+// WebhookEmit 为订阅了该事件的 Webhook 写入投递记录，写入失败只记录日志，不影响资源的修改
+func WebhookEmit(event string, data interface{}) {
+	if err := webhookEmit(packages.GetDb(), event, data); err != nil {
+		packages.Log.Error("webhook emit error ", event, ": ", err.Error())
+	}
+}
+
+// webhookEmit 在资源修改的事务中写入投递记录，事务提交后由投递任务发送
+func webhookEmit(tx *gorm.DB, event string, data interface{}) error {
+	webhookList, err := (&models.Webhooks{}).WebhookEnableList(tx)
+	if err != nil {
+		return err
+	}
+
+	payload := ""
+	for _, webhook := range webhookList {
+		if !webhookSubscribed(webhook, event) {
+			continue
+		}
+
+		if len(payload) == 0 {
+			if payload, err = webhookPayload(event, data); err != nil {
+				return err
+			}
+		}
+
+		if _, err = webhookDeliveryAdd(tx, webhook.ResID, event, payload); err != nil {
+			return err
+		}
+	}
+
+	if len(payload) != 0 {
+		webhookWake()
+	}
+
+	return nil
+}
+
+// WebhookWakeup 有新的投递记录时收到通知，投递任务无需等待下一个周期
+func WebhookWakeup() <-chan struct{} {
+	return webhookWakeup
+}
+
+// WebhookDispatch 投递到达重试时间的记录，失败后按 retry_interval 翻倍重试，达到 max_attempts 后标记为失败
+func WebhookDispatch() {
+	deliveryList, err := (&models.WebhookDeliveries{}).WebhookDeliveryDueList(webhookDispatchLimit)
+	if err != nil {
+		packages.Log.Error("webhook delivery list error", err.Error())
+		return
+	}
+
+	timeout := time.Second * time.Duration(packages.ConfigWebhook.Timeout)
+
+	var wg sync.WaitGroup
+	concurrency := make(chan struct{}, webhookDispatchConcurrency)
+	for _, delivery := range deliveryList {
+		// 占用到超时之后，投递过程中实例退出时该记录会在之后重新投递
+		rowsAffected, err := (&models.WebhookDeliveries{}).WebhookDeliveryClaim(delivery.ID, *delivery.NextAttemptAt, time.Now().Add(timeout*2))
+		if (err != nil) || (rowsAffected == 0) {
+			continue
+		}
+
+		wg.Add(1)
+		concurrency <- struct{}{}
+		go func(delivery models.WebhookDeliveries) {
+			defer func() {
+				<-concurrency
+				wg.Done()
+			}()
+
+			webhookDeliver(delivery, timeout)
+		}(delivery)
+	}
+
+	wg.Wait()
+}
+
+func webhookDeliver(delivery models.WebhookDeliveries, timeout time.Duration) {
+	delivery.Attempts++
+	updateParams := map[string]interface{}{
+		"attempts": delivery.Attempts,
+	}
+
+	webhook, err := (&models.Webhooks{}).WebhookInfoByResId(delivery.WebhookResID)
+	if err == nil {
+		var httpResp utils.HttpResp
+		httpResp, err = rpc.WebhookSend(webhook.Url, webhook.Secret, delivery.Event, delivery.ResID, delivery.Payload, timeout)
+
+		updateParams["response_code"] = httpResp.StatusCode
+		updateParams["response_message"] = webhookResponseMessage(string(httpResp.Body))
+		if (err == nil) && ((httpResp.StatusCode < 200) || (httpResp.StatusCode >= 300)) {
+			err = fmt.Errorf("response status code %d", httpResp.StatusCode)
+		}
+	}
+
+	if err == nil {
+		updateParams["status"] = utils.WebhookDeliveryStatusSuccess
+		updateParams["delivered_at"] = time.Now()
+	} else {
+		if _, ok := updateParams["response_code"]; !ok || (updateParams["response_message"] == "") {
+			updateParams["response_message"] = webhookResponseMessage(err.Error())
+		}
+
+		if delivery.Attempts >= packages.ConfigWebhook.MaxAttempts {
+			updateParams["status"] = utils.WebhookDeliveryStatusFailed
+			packages.Log.Error("webhook delivery failed ", delivery.ResID, " ", delivery.Event, ": ", err.Error())
+		} else {
+			retryInterval := time.Second * time.Duration(packages.ConfigWebhook.RetryInterval)
+			updateParams["next_attempt_at"] = time.Now().Add(retryInterval << (delivery.Attempts - 1))
+		}
+	}
+
+	if err = (&models.WebhookDeliveries{}).WebhookDeliveryUpdateColumns(delivery.ID, updateParams); err != nil {
+		packages.Log.Error("webhook delivery update error", err.Error())
+	}
+}
+
+func webhookDeliveryAdd(tx *gorm.DB, webhookResId string, event string, payload string) (string, error) {
+	nextAttemptAt := time.Now()
+
+	return (&models.WebhookDeliveries{}).WebhookDeliveryAdd(tx, &models.WebhookDeliveries{
+		WebhookResID:  webhookResId,
+		Event:         event,
+		Payload:       payload,
+		Status:        utils.WebhookDeliveryStatusPending,
+		NextAttemptAt: &nextAttemptAt,
+	})
+}
+
+func webhookPayload(event string, data interface{}) (string, error) {
+	payload, err := json.Marshal(WebhookPayload{
+		Event:      event,
+		OccurredAt: time.Now().Unix(),
+		Data:       data,
+	})
+
+	return string(payload), err
+}
+
+func webhookSubscribed(webhook models.Webhooks, event string) bool {
+	for _, subscribed := range strings.Split(webhook.Events, ",") {
+		if (subscribed == utils.WebhookEventAll) || (subscribed == event) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func webhookWake() {
+	select {
+	case webhookWakeup <- struct{}{}:
+	default:
+	}
+}
+
+func webhookResponseMessage(message string) string {
+	runes := []rune(message)
+	if len(runes) > webhookResponseMaxLength {
+		runes = runes[:webhookResponseMaxLength]
+	}
+
+	return string(runes)
+}
+
+func webhookItem(webhook models.Webhooks) WebhookItem {
+	return WebhookItem{
+		ResID:     webhook.ResID,
+		Name:      webhook.Name,
+		Url:       webhook.Url,
+		Events:    strings.Split(webhook.Events, ","),
+		Enable:    webhook.Enable,
+		CreatedAt: webhook.CreatedAt.Unix(),
+	}
+}
+
+func checkWebhookRequest(request *validators.WebhookAddUpdate) error {
+	webhookUrl, err := url.Parse(request.Url)
+	if (err != nil) || ((webhookUrl.Scheme != "http") && (webhookUrl.Scheme != "https")) {
+		return errors.New(enums.CodeMessages(enums.WebhookUrlError))
+	}
+
+	for _, event := range request.Events {
+		if event == utils.WebhookEventAll {
+			continue
+		}
+
+		known := false
+		for _, webhookEvent := range webhookEvents {
+			if event == webhookEvent {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf(enums.CodeMessages(enums.WebhookEventError), event)
+		}
+	}
+
+	return nil
+}
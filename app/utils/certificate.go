@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/packages"
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// VerifyCertificate 校验上传的证书：私钥与证书匹配，证书链按 证书、中间证书 的顺序排列且能验证到受信任的根证书，
+// sni 在证书的 SAN 中（支持泛域名），证书在有效期内
+func VerifyCertificate(certificate string, privateKey string, sni string) (CertificateInfo, error) {
+	certificateList, err := parseCertificateChain(certificate)
+	if err != nil {
+		return CertificateInfo{}, err
+	}
+
+	leaf := certificateList[0]
+	certificateInfo := certificateInfoByX509(leaf)
+
+	for i := 1; i < len(certificateList); i++ {
+		if err = certificateList[i-1].CheckSignatureFrom(certificateList[i]); err != nil {
+			return certificateInfo, fmt.Errorf(enums.CodeMessages(enums.CertificateChainOrder), i, i+1)
+		}
+	}
+
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return certificateInfo, err
+	}
+
+	publicKey, ok := key.Public().(interface{ Equal(x crypto.PublicKey) bool })
+	if !ok || !publicKey.Equal(leaf.PublicKey) {
+		return certificateInfo, errors.New(enums.CodeMessages(enums.CertificateKeyMismatch))
+	}
+
+	if err = verifyCertificateChain(certificateList); err != nil {
+		return certificateInfo, err
+	}
+
+	if !CertificateSniMatch(leaf.DNSNames, sni) {
+		return certificateInfo, fmt.Errorf(enums.CodeMessages(enums.CertificateSniError), sni, strings.Join(leaf.DNSNames, ","))
+	}
+
+	now := time.Now()
+	if now.After(leaf.NotAfter) {
+		return certificateInfo, fmt.Errorf(enums.CodeMessages(enums.CertificateExpired), leaf.NotAfter.Format(time.RFC3339))
+	}
+	if now.Before(leaf.NotBefore) {
+		return certificateInfo, fmt.Errorf(enums.CodeMessages(enums.CertificateNotYetValid), leaf.NotBefore.Format(time.RFC3339))
+	}
+
+	return certificateInfo, nil
+}
+
+// CertificateSniMatch sni 与某个 SAN 相同，或被泛域名 SAN 覆盖（只匹配一级子域名），泛域名 sni 只能由相同的泛域名 SAN 覆盖
+func CertificateSniMatch(sans []string, sni string) bool {
+	sni = strings.ToLower(strings.TrimSuffix(sni, "."))
+	for _, san := range sans {
+		san = strings.ToLower(strings.TrimSuffix(san, "."))
+		if san == sni {
+			return true
+		}
+
+		if strings.HasPrefix(san, "*.") && !strings.HasPrefix(sni, "*.") {
+			index := strings.Index(sni, ".")
+			if (index > 0) && (sni[index:] == san[1:]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// CertificateSnis 证书覆盖的 SNI：sni 与证书的 SAN，统一为小写并去重
+func CertificateSnis(sni string, sans []string) []string {
+	snis := make([]string, 0)
+	sniMap := make(map[string]byte)
+	for _, item := range append([]string{sni}, sans...) {
+		item = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(item), "."))
+		if len(item) == 0 {
+			continue
+		}
+		if _, ok := sniMap[item]; ok {
+			continue
+		}
+
+		sniMap[item] = 0
+		snis = append(snis, item)
+	}
+
+	return snis
+}
+
+func parseCertificateChain(certificate string) ([]*x509.Certificate, error) {
+	certificateList := make([]*x509.Certificate, 0)
+
+	rest := []byte(certificate)
+	for {
+		var pemBlock *pem.Block
+		pemBlock, rest = pem.Decode(rest)
+		if pemBlock == nil {
+			break
+		}
+		if pemBlock.Type != "CERTIFICATE" {
+			continue
+		}
+
+		parseCert, err := x509.ParseCertificate(pemBlock.Bytes)
+		if err != nil {
+			return nil, errors.New(enums.CodeMessages(enums.CertificateParseError))
+		}
+		certificateList = append(certificateList, parseCert)
+	}
+
+	if len(certificateList) == 0 {
+		return nil, errors.New(enums.CodeMessages(enums.CertificateFormatError))
+	}
+
+	return certificateList, nil
+}
+
+// parsePrivateKey 支持 PKCS#1、PKCS#8 与 EC 格式的私钥，忽略 EC PARAMETERS 等其他内容
+func parsePrivateKey(privateKey string) (crypto.Signer, error) {
+	rest := []byte(privateKey)
+	for {
+		var pemBlock *pem.Block
+		pemBlock, rest = pem.Decode(rest)
+		if pemBlock == nil {
+			break
+		}
+
+		var key interface{}
+		var err error
+		switch pemBlock.Type {
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(pemBlock.Bytes)
+		case "EC PRIVATE KEY":
+			key, err = x509.ParseECPrivateKey(pemBlock.Bytes)
+		case "PRIVATE KEY":
+			key, err = x509.ParsePKCS8PrivateKey(pemBlock.Bytes)
+		default:
+			continue
+		}
+		if err != nil {
+			break
+		}
+
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		break
+	}
+
+	return nil, errors.New(enums.CodeMessages(enums.CertificateKeyError))
+}
+
+// verifyCertificateChain 证书链最后一个证书为自签名的根证书，或由系统根证书与 certificate.ca_file 中的根证书签发
+func verifyCertificateChain(certificateList []*x509.Certificate) error {
+	top := certificateList[len(certificateList)-1]
+	if bytes.Equal(top.RawIssuer, top.RawSubject) && (top.CheckSignatureFrom(top) == nil) {
+		return nil
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+
+	if len(packages.ConfigCertificate.CaFile) != 0 {
+		caPem, err := ioutil.ReadFile(packages.ConfigCertificate.CaFile)
+		if err != nil {
+			return err
+		}
+		roots.AppendCertsFromPEM(caPem)
+	}
+
+	// 只校验签发关系，证书的有效期在 VerifyCertificate 中单独校验
+	_, err = top.Verify(x509.VerifyOptions{
+		Roots:       roots,
+		CurrentTime: top.NotBefore.Add(top.NotAfter.Sub(top.NotBefore) / 2),
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf(enums.CodeMessages(enums.CertificateChainError), top.Issuer.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package utils
+
+import "testing"
+
+func TestCertificateSniMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		sans  []string
+		sni   string
+		match bool
+	}{
+		{name: "same domain", sans: []string{"www.apioak.com"}, sni: "www.apioak.com", match: true},
+		{name: "case and trailing dot", sans: []string{"WWW.apioak.com."}, sni: "www.APIOAK.com", match: true},
+		{name: "other domain", sans: []string{"www.apioak.com"}, sni: "api.apioak.com", match: false},
+		{name: "wildcard subdomain", sans: []string{"*.apioak.com"}, sni: "api.apioak.com", match: true},
+		{name: "wildcard apex", sans: []string{"*.apioak.com"}, sni: "apioak.com", match: false},
+		{name: "wildcard two levels", sans: []string{"*.apioak.com"}, sni: "a.api.apioak.com", match: false},
+		{name: "wildcard sni same wildcard", sans: []string{"*.apioak.com"}, sni: "*.apioak.com", match: true},
+		{name: "wildcard sni plain san", sans: []string{"api.apioak.com"}, sni: "*.apioak.com", match: false},
+		{name: "wildcard sni parent wildcard", sans: []string{"*.apioak.com"}, sni: "*.api.apioak.com", match: false},
+		{name: "second san", sans: []string{"apioak.com", "*.apioak.com"}, sni: "api.apioak.com", match: true},
+		{name: "no sans", sans: []string{}, sni: "apioak.com", match: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if match := CertificateSniMatch(tt.sans, tt.sni); match != tt.match {
+				t.Fatalf("CertificateSniMatch(%v, %q) = %v, want %v", tt.sans, tt.sni, match, tt.match)
+			}
+		})
+	}
+}
@@ -1,18 +1,24 @@
 package utils
 
 const (
-	IdTypeUser          = "us"
-	IdTypeUserToken     = "ut"
-	IdTypeService       = "sv"
-	IdTypeServiceDomain = "sd"
-	IdTypeServiceNode   = "sn"
-	IdTypeRouter        = "rt"
-	IdTypePlugin        = "pl"
-	IdTypePluginConfig  = "pc"
-	IdTypeCertificate   = "ce"
-	IdTypeClusterNode   = "cn"
-	IdTypeUpstream      = "up"
-	IdTypeUpstreamNode  = "un"
+	IdTypeUser            = "us"
+	IdTypeUserToken       = "ut"
+	IdTypeService         = "sv"
+	IdTypeServiceDomain   = "sd"
+	IdTypeServiceNode     = "sn"
+	IdTypeRouter          = "rt"
+	IdTypePlugin          = "pl"
+	IdTypePluginConfig    = "pc"
+	IdTypeCertificate     = "ce"
+	IdTypeClusterNode     = "cn"
+	IdTypeUpstream        = "up"
+	IdTypeUpstreamNode    = "un"
+	IdTypeReleaseLog      = "rl"
+	IdTypeChangeSet       = "cs"
+	IdTypeUserApiToken    = "at"
+	IdTypeWebhook         = "wh"
+	IdTypeWebhookDelivery = "wd"
+	IdTypeAcmeCertificate = "ac"
 
 	IdLength           = 15
 	IdGenerateMaxTimes = 5
@@ -48,12 +54,27 @@ const (
 
 	LoadBalanceRoundRobin = 1 // 加权轮询 (Round Robin)
 	LoadBalanceCHash      = 2 // 一致性Hash（CHash）
+	LoadBalanceLeastConn  = 3 // 最少连接（Least Connections）
+	LoadBalanceEwma       = 4 // 最低延迟（EWMA）
+	LoadBalanceRandom     = 5 // 加权随机（Random）
 
 	LoadBalanceNameRoundRobin = "加权轮询 (Round Robin)"
 	LoadBalanceNameCHash      = "一致性Hash（CHash）"
+	LoadBalanceNameLeastConn  = "最少连接（Least Connections）"
+	LoadBalanceNameEwma       = "最低延迟（EWMA）"
+	LoadBalanceNameRandom     = "加权随机（Random）"
 
 	ConfigBalanceNameRoundRobin = "ROUNDROBIN"
 	ConfigBalanceNameCHash      = "CHASH"
+	ConfigBalanceNameLeastConn  = "LEASTCONN"
+	ConfigBalanceNameEwma       = "EWMA"
+	ConfigBalanceNameRandom     = "RANDOM"
+
+	HashOnIp     = "ip"     // 一致性Hash——客户端IP
+	HashOnHeader = "header" // 一致性Hash——请求头，hash_key 为请求头名称
+	HashOnCookie = "cookie" // 一致性Hash——Cookie，hash_key 为 Cookie 名称
+	HashOnQuery  = "query"  // 一致性Hash——请求参数，hash_key 为参数名称
+	HashOnUri    = "uri"    // 一致性Hash——请求路径
 
 	ProtocolHTTP         = 1
 	ProtocolHTTPS        = 2
@@ -80,6 +101,16 @@ const (
 	ConfigHealthCheckOn  = true  // 健康检查——开
 	ConfigHealthCheckOff = false // 健康检查——关
 
+	HealthCheckTypeHttp = "http" // 健康检查方式——HTTP 请求
+	HealthCheckTypeTcp  = "tcp"  // 健康检查方式——TCP 连接
+
+	HealthCheckDefaultMethod    = "GET" // 健康检查默认请求方法
+	HealthCheckDefaultUri       = "/"   // 健康检查默认请求路径
+	HealthCheckDefaultInterval  = 10    // 健康检查默认间隔（秒）
+	HealthCheckDefaultTimeout   = 3     // 健康检查默认超时（秒）
+	HealthCheckDefaultHealthy   = 2     // 连续成功多少次后标记为健康
+	HealthCheckDefaultUnhealthy = 3     // 连续失败多少次后标记为异常
+
 	// ===================================== route =====================================
 
 	DefaultRouterPath = "/*"
@@ -143,4 +174,151 @@ const (
 
 	ClusterNodeStatusHealth    = 1
 	ClusterNodeStatusUnhealthy = 2
+
+	// ===================================== snapshot =====================================
+
+	SnapshotVersion = 1 // 快照文档版本
+
+	SnapshotFormatJson = "json"
+	SnapshotFormatYaml = "yaml"
+
+	SnapshotModeMerge   = "merge"   // 导入模式——按 res_id 新增或更新，保留快照外的数据
+	SnapshotModeReplace = "replace" // 导入模式——按 res_id 新增或更新，删除快照外的数据
+
+	// ===================================== apply =====================================
+
+	ApplyActionCreate = "create" // 声明式同步——新增
+	ApplyActionUpdate = "update" // 声明式同步——修改
+	ApplyActionDelete = "delete" // 声明式同步——删除
+
+	ApplyResourceUpstream     = "upstream"
+	ApplyResourceService      = "service"
+	ApplyResourceRouter       = "router"
+	ApplyResourcePluginConfig = "plugin_config"
+
+	// ===================================== drift =====================================
+
+	DriftResourceUpstreamNode = "upstream_node"
+	DriftResourceUpstream     = "upstream"
+	DriftResourcePluginConfig = "plugin_config"
+	DriftResourceService      = "service"
+	DriftResourceRouter       = "router"
+	DriftResourceCertificate  = "certificate"
+
+	DriftStatusMissing = "missing" // 数据面缺失该资源
+	DriftStatusChanged = "changed" // 数据面配置与控制面不一致
+	DriftStatusStale   = "stale"   // 控制面已停用的资源仍存在于数据面
+
+	// ===================================== release log =====================================
+
+	ReleaseResourceService  = "service"
+	ReleaseResourceRouter   = "router"
+	ReleaseResourceUpstream = "upstream"
+
+	ReleaseOperatorCli = "cli" // 通过命令行发布时记录的操作人
+
+	ContextKeyUserEmail     = "user_email"      // 登录中间件写入上下文的当前用户邮箱
+	ContextKeyUserSessionId = "user_session_id" // 登录中间件写入上下文的当前会话ID
+	ContextKeyUserApiToken  = "user_api_token"  // 登录中间件写入上下文的当前 API Token ID
+
+	// ===================================== change set =====================================
+
+	ChangeSetStatusDraft     = 1 // 待发布
+	ChangeSetStatusPublished = 2 // 已发布
+	ChangeSetStatusFailed    = 3 // 发布失败
+
+	ChangeSetResourceUpstreamNode = "upstream_node"
+	ChangeSetResourceUpstream     = "upstream"
+	ChangeSetResourcePluginConfig = "plugin_config"
+	ChangeSetResourceService      = "service"
+	ChangeSetResourceRouter       = "router"
+
+	ChangeSetActionCreate = "create"
+	ChangeSetActionUpdate = "update"
+	ChangeSetActionDelete = "delete"
+
+	// ===================================== user role =====================================
+
+	UserRoleAdmin    = "admin"    // 管理员，可管理用户与全部资源
+	UserRoleOperator = "operator" // 运维，可修改与发布资源
+	UserRoleViewer   = "viewer"   // 只读
+
+	ContextKeyUserResId = "user_res_id" // 权限中间件写入上下文的当前用户ID
+	ContextKeyUserRole  = "user_role"   // 权限中间件写入上下文的当前用户角色
+
+	UserRegisterOpen      = "open"      // 开放注册
+	UserRegisterBootstrap = "bootstrap" // 仅允许注册第一个用户
+	UserRegisterClosed    = "closed"    // 关闭注册
+
+	UserLoginProviderLocal = "local" // 使用本地用户的邮箱与密码登录
+	UserLoginProviderLdap  = "ldap"  // 使用 LDAP 账号登录
+
+	PasswordAlgorithmMd5    = "md5"    // 升级前的 md5(md5(password))
+	PasswordAlgorithmBcrypt = "bcrypt" // 当前使用的密码哈希算法
+
+	PasswordMinLength = 8
+	PasswordMaxLength = 72 // bcrypt 只使用密码的前 72 个字节
+
+	// ===================================== totp =====================================
+
+	TotpIssuer            = "APIOAK" // 身份验证器中显示的签发方
+	TotpPeriod            = 30       // 时间步长（秒）
+	TotpSkew              = 1        // 允许前后偏差的时间步长个数
+	TotpRecoveryCodeCount = 10       // 每次生成的恢复码个数
+
+	// ===================================== api token =====================================
+
+	ApiTokenScopeRead  = "read"  // 只读，只允许 GET 请求
+	ApiTokenScopeWrite = "write" // 读写，权限与所属用户的角色一致
+
+	ApiTokenPrefix = "oak_" // API Token 前缀，便于在日志与代码中识别
+
+	// ===================================== webhook =====================================
+
+	WebhookEventAll = "*" // 订阅全部事件
+
+	WebhookEventServiceCreated      = "service.created"
+	WebhookEventServiceUpdated      = "service.updated"
+	WebhookEventServiceDeleted      = "service.deleted"
+	WebhookEventServiceReleased     = "service.released"
+	WebhookEventRouterCreated       = "router.created"
+	WebhookEventRouterUpdated       = "router.updated"
+	WebhookEventRouterDeleted       = "router.deleted"
+	WebhookEventRouterReleased      = "router.released"
+	WebhookEventUpstreamCreated     = "upstream.created"
+	WebhookEventUpstreamUpdated     = "upstream.updated"
+	WebhookEventUpstreamDeleted     = "upstream.deleted"
+	WebhookEventUpstreamReleased    = "upstream.released"
+	WebhookEventCertificateCreated  = "certificate.created"
+	WebhookEventCertificateUpdated  = "certificate.updated"
+	WebhookEventCertificateDeleted  = "certificate.deleted"
+	WebhookEventCertificateExpiring = "certificate.expiring"
+	WebhookEventPing                = "webhook.ping" // 测试 Webhook 时发送，不需要订阅
+
+	WebhookDeliveryStatusPending = 1 // 待投递
+	WebhookDeliveryStatusSuccess = 2 // 投递成功
+	WebhookDeliveryStatusFailed  = 3 // 重试次数用尽后投递失败
+
+	// ===================================== certificate expiry =====================================
+
+	CertificateExpiryNotifierLog     = "log"     // 写入告警日志
+	CertificateExpiryNotifierWebhook = "webhook" // 发送 certificate.expiring 事件
+	CertificateExpiryNotifierSmtp    = "smtp"    // 通过 SMTP 发送邮件
+
+	// ===================================== acme =====================================
+
+	AcmeChallengeHttp01 = "http-01" // 通过 /.well-known/acme-challenge/ 路径验证
+	AcmeChallengeDns01  = "dns-01"  // 通过 _acme-challenge TXT 记录验证，泛域名只能使用该方式
+
+	AcmeDnsProviderExec = "exec" // 执行 acme.dns_exec 配置的命令发布 TXT 记录
+
+	AcmeStatusPending = 1 // 待签发
+	AcmeStatusValid   = 2 // 已签发
+	AcmeStatusInvalid = 3 // 签发失败，等待重试
+
+	// ===================================== encryption =====================================
+
+	EncryptionPrefix = "enc:v1:" // 加密后的字段值前缀，格式为 enc:v1:<主密钥版本>:<加密的数据密钥>:<密文>
+
+	SecretMask = "******" // 列表与详情中替代私钥、插件密钥等敏感字段的内容
 )
@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"apioak-admin/app/enums"
+	"apioak-admin/app/packages"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EncryptionKeyParse 解析 <版本>:<base64 编码的 32 字节主密钥> 格式的主密钥
+func EncryptionKeyParse(encryptionKey string) (int, []byte, error) {
+	index := strings.Index(encryptionKey, ":")
+	if index <= 0 {
+		return 0, nil, errors.New("encryption key must be in the form <version>:<base64 key>")
+	}
+
+	version, err := strconv.Atoi(encryptionKey[:index])
+	if (err != nil) || (version <= 0) {
+		return 0, nil, fmt.Errorf("encryption key version `%s` must be a positive integer", encryptionKey[:index])
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encryptionKey[index+1:])
+	if (err != nil) || (len(key) != 32) {
+		return 0, nil, fmt.Errorf("encryption key version %d must be 32 bytes encoded in base64", version)
+	}
+
+	return version, key, nil
+}
+
+// Encrypt 使用随机生成的数据密钥以 AES-256-GCM 加密内容，数据密钥再由当前版本的主密钥加密，
+// 未配置主密钥、内容为空或已加密（沿用已保存的密钥、快照导入与轮换）时原样返回，接口提交的明文需先经过 EncryptionPlaintextCheck
+func Encrypt(plaintext string) (string, error) {
+	if (len(plaintext) == 0) || Encrypted(plaintext) || (packages.ConfigEncryption.CurrentVersion == 0) {
+		return plaintext, nil
+	}
+
+	version := packages.ConfigEncryption.CurrentVersion
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := encryptionSeal(packages.ConfigEncryption.Keys[version], dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := encryptionSeal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%d:%s:%s", EncryptionPrefix, version, wrappedKey, ciphertext), nil
+}
+
+// Decrypt 解密 Encrypt 的结果，未加密的内容原样返回
+func Decrypt(value string) (string, error) {
+	if !Encrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, EncryptionPrefix), ":")
+	if len(parts) != 3 {
+		return "", errors.New("encrypted value is malformed")
+	}
+
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", errors.New("encrypted value is malformed")
+	}
+
+	key, ok := packages.ConfigEncryption.Keys[version]
+	if !ok {
+		return "", fmt.Errorf("encryption key version %d is not configured", version)
+	}
+
+	dataKey, err := encryptionOpen(key, parts[1])
+	if err != nil {
+		return "", fmt.Errorf("decrypt data key with encryption key version %d is failed", version)
+	}
+
+	plaintext, err := encryptionOpen(dataKey, parts[2])
+	if err != nil {
+		return "", errors.New("decrypt encrypted value is failed")
+	}
+
+	return string(plaintext), nil
+}
+
+// EncryptionPlaintextCheck 通过接口提交的明文密钥不能以 EncryptionPrefix 开头，否则会被当作密文保存且无法解密
+func EncryptionPlaintextCheck(value string) (string, error) {
+	if Encrypted(value) {
+		return value, fmt.Errorf(enums.CodeMessages(enums.SecretPrefixReserved), EncryptionPrefix)
+	}
+
+	return value, nil
+}
+
+// Encrypted 内容是否为 Encrypt 的结果
+func Encrypted(value string) bool {
+	return strings.HasPrefix(value, EncryptionPrefix)
+}
+
+// EncryptionKeyVersion 加密内容使用的主密钥版本，未加密时为 0
+func EncryptionKeyVersion(value string) int {
+	if !Encrypted(value) {
+		return 0
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, EncryptionPrefix), ":", 2)
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0
+	}
+
+	return version
+}
+
+// EncryptionRotateNeeded 配置主密钥后，未加密或未使用当前版本主密钥加密的非空内容需要重新加密
+func EncryptionRotateNeeded(value string) bool {
+	if (len(value) == 0) || (packages.ConfigEncryption.CurrentVersion == 0) {
+		return false
+	}
+
+	return EncryptionKeyVersion(value) != packages.ConfigEncryption.CurrentVersion
+}
+
+// EncryptionRotate 使用当前版本的主密钥重新加密，不需要重新加密时原样返回
+func EncryptionRotate(value string) (string, error) {
+	if !EncryptionRotateNeeded(value) {
+		return value, nil
+	}
+
+	plaintext, err := Decrypt(value)
+	if err != nil {
+		return "", err
+	}
+
+	if len(plaintext) == 0 {
+		return plaintext, nil
+	}
+
+	return Encrypt(plaintext)
+}
+
+// encryptionSeal AES-256-GCM 加密，结果为 base64(nonce|密文)
+func encryptionSeal(key []byte, plaintext []byte) (string, error) {
+	aead, err := encryptionAead(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+func encryptionOpen(key []byte, sealed string) ([]byte, error) {
+	aead, err := encryptionAead(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("sealed data is too short")
+	}
+
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+}
+
+func encryptionAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
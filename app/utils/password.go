@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"crypto/subtle"
+	"golang.org/x/crypto/bcrypt"
+	"unicode"
+)
+
+// PasswordHash 使用 bcrypt 计算密码哈希
+func PasswordHash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// PasswordVerify 按记录的哈希算法校验密码，md5 为升级前的 md5(md5(password))
+func PasswordVerify(password string, hash string, algorithm string) bool {
+	switch algorithm {
+	case PasswordAlgorithmBcrypt:
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case PasswordAlgorithmMd5:
+		return subtle.ConstantTimeCompare([]byte(Md5(Md5(password))), []byte(hash)) == 1
+	}
+
+	return false
+}
+
+// PasswordPolicyMatch 密码长度为 PasswordMinLength-PasswordMaxLength 位，且同时包含字母与数字
+func PasswordPolicyMatch(password string) bool {
+	if (len(password) < PasswordMinLength) || (len(password) > PasswordMaxLength) {
+		return false
+	}
+
+	hasLetter, hasDigit := false, false
+	for _, char := range password {
+		if unicode.IsLetter(char) {
+			hasLetter = true
+		} else if unicode.IsDigit(char) {
+			hasDigit = true
+		}
+	}
+
+	return hasLetter && hasDigit
+}
+
+// PasswordGenerate 生成符合密码策略的随机密码
+func PasswordGenerate(length int) string {
+	for {
+		password := createRandomString(length)
+		if PasswordPolicyMatch(password) {
+			return password
+		}
+	}
+}
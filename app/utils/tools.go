@@ -4,8 +4,10 @@ import (
 	"apioak-admin/app/enums"
 	"apioak-admin/app/packages"
 	"bytes"
+	"crypto/hmac"
 	"crypto/md5"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
@@ -67,6 +69,18 @@ func IdGenerate(idType string) (string, error) {
 		id = IdTypeUpstream + "-" + randomId
 	case IdTypeUpstreamNode:
 		id = IdTypeUpstreamNode + "-" + randomId
+	case IdTypeReleaseLog:
+		id = IdTypeReleaseLog + "-" + randomId
+	case IdTypeChangeSet:
+		id = IdTypeChangeSet + "-" + randomId
+	case IdTypeUserApiToken:
+		id = IdTypeUserApiToken + "-" + randomId
+	case IdTypeWebhook:
+		id = IdTypeWebhook + "-" + randomId
+	case IdTypeWebhookDelivery:
+		id = IdTypeWebhookDelivery + "-" + randomId
+	case IdTypeAcmeCertificate:
+		id = IdTypeAcmeCertificate + "-" + randomId
 	default:
 		return "", fmt.Errorf("id type error")
 	}
@@ -92,9 +106,13 @@ func DiscernIP(s string) (string, error) {
 }
 
 type CertificateInfo struct {
-	CommonName string
-	NotBefore  time.Time
-	NotAfter   time.Time
+	CommonName  string
+	Issuer      string
+	Subject     string
+	Sans        []string
+	Fingerprint string
+	NotBefore   time.Time
+	NotAfter    time.Time
 }
 
 func DiscernCertificate(certificate *string) (CertificateInfo, error) {
@@ -109,12 +127,22 @@ func DiscernCertificate(certificate *string) (CertificateInfo, error) {
 		return certificateInfo, errors.New(enums.CodeMessages(enums.CertificateParseError))
 	}
 
-	// 多域名证书的CommonName需要提取 parseCert.DNSNames（一维数组）中的数据，并且需要过滤出"*"开头的
-	certificateInfo.CommonName = parseCert.Subject.CommonName
-	certificateInfo.NotBefore = parseCert.NotBefore
-	certificateInfo.NotAfter = parseCert.NotAfter
+	return certificateInfoByX509(parseCert), nil
+}
+
+func certificateInfoByX509(parseCert *x509.Certificate) CertificateInfo {
+	fingerprint := sha256.Sum256(parseCert.Raw)
 
-	return certificateInfo, nil
+	// 多域名证书的CommonName需要提取 parseCert.DNSNames（一维数组）中的数据，并且需要过滤出"*"开头的
+	return CertificateInfo{
+		CommonName:  parseCert.Subject.CommonName,
+		Issuer:      parseCert.Issuer.String(),
+		Subject:     parseCert.Subject.String(),
+		Sans:        parseCert.DNSNames,
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		NotBefore:   parseCert.NotBefore,
+		NotAfter:    parseCert.NotAfter,
+	}
 }
 
 type enumInfo struct {
@@ -126,6 +154,9 @@ func LoadBalanceList() []enumInfo {
 	loadBalanceList := []enumInfo{
 		{Id: LoadBalanceRoundRobin, Name: LoadBalanceNameRoundRobin},
 		{Id: LoadBalanceCHash, Name: LoadBalanceNameCHash},
+		{Id: LoadBalanceLeastConn, Name: LoadBalanceNameLeastConn},
+		{Id: LoadBalanceEwma, Name: LoadBalanceNameEwma},
+		{Id: LoadBalanceRandom, Name: LoadBalanceNameRandom},
 	}
 
 	return loadBalanceList
@@ -135,6 +166,9 @@ func ConfigBalanceList() []enumInfo {
 	configBalanceList := []enumInfo{
 		{Id: LoadBalanceRoundRobin, Name: ConfigBalanceNameRoundRobin},
 		{Id: LoadBalanceCHash, Name: ConfigBalanceNameCHash},
+		{Id: LoadBalanceLeastConn, Name: ConfigBalanceNameLeastConn},
+		{Id: LoadBalanceEwma, Name: ConfigBalanceNameEwma},
+		{Id: LoadBalanceRandom, Name: ConfigBalanceNameRandom},
 	}
 
 	return configBalanceList
@@ -271,6 +305,19 @@ func Md5(src string) string {
 	return srcMd5
 }
 
+func Sha256(src string) string {
+	sum := sha256.Sum256([]byte(src))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func HmacSha256(key string, src string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(src))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 type ExpireToken struct {
 	Expire int64
 	Token  string
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// TestTotpCode RFC 6238 附录 B 的 SHA1 测试向量，验证码取 8 位结果的后 6 位
+func TestTotpCode(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+		{unix: 20000000000, code: "353130"},
+	}
+
+	for _, tt := range tests {
+		code, err := TotpCode(secret, TotpStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("%d: %v", tt.unix, err)
+		}
+		if code != tt.code {
+			t.Errorf("%d: code %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func TestTotpVerify(t *testing.T) {
+	secret, err := TotpSecretGenerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	currentStep := TotpStep(time.Now())
+
+	tests := []struct {
+		name     string
+		step     int64
+		lastStep int64
+		ok       bool
+	}{
+		{name: "current step", step: currentStep, lastStep: 0, ok: true},
+		{name: "previous step", step: currentStep - TotpSkew, lastStep: 0, ok: true},
+		{name: "next step", step: currentStep + TotpSkew, lastStep: 0, ok: true},
+		{name: "outside skew", step: currentStep - TotpSkew - 1, lastStep: 0, ok: false},
+		{name: "used step", step: currentStep, lastStep: currentStep, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := TotpCode(secret, tt.step)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			step, ok := TotpVerify(secret, code, tt.lastStep)
+			if ok != tt.ok {
+				t.Fatalf("verify %v, want %v", ok, tt.ok)
+			}
+			if ok && (step != tt.step) {
+				t.Fatalf("step %d, want %d", step, tt.step)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package validators
+
+type AcmeCertificateAdd struct {
+	Domains   []string `json:"domains" zh:"域名" en:"Domains" binding:"required,min=1,max=50,dive,required,max=150"`
+	Challenge string   `json:"challenge" zh:"验证方式" en:"Challenge" binding:"required,oneof=http-01 dns-01"`
+}
+
+type AcmeCertificateList struct {
+	Status int    `form:"status" json:"status" zh:"状态" en:"Status" binding:"omitempty,oneof=1 2 3"`
+	Search string `form:"search" json:"search" zh:"搜索内容" en:"Search content" binding:"omitempty"`
+	BaseListPage
+}
@@ -0,0 +1,11 @@
+package validators
+
+type AuditLogList struct {
+	Operator      string `form:"operator" json:"operator" zh:"操作人" en:"Operator" binding:"omitempty"`
+	ResourceType  string `form:"resource_type" json:"resource_type" zh:"资源类型" en:"Resource type" binding:"omitempty"`
+	ResourceResID string `form:"resource_res_id" json:"resource_res_id" zh:"资源ID" en:"Resource id" binding:"omitempty"`
+	Method        string `form:"method" json:"method" zh:"请求方式" en:"Method" binding:"omitempty,oneof=POST PUT DELETE"`
+	StartAt       int64  `form:"start_at" json:"start_at" zh:"开始时间" en:"Start time" binding:"omitempty,min=0"`
+	EndAt         int64  `form:"end_at" json:"end_at" zh:"结束时间" en:"End time" binding:"omitempty,min=0"`
+	BaseListPage
+}
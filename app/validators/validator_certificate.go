@@ -16,3 +16,7 @@ type CertificateList struct {
 type CertificateSwitchEnable struct {
 	Enable int `form:"enable" json:"enable" zh:"证书开关" en:"Certificate enable" binding:"required,oneof=1 2"`
 }
+
+type CertificateExpiring struct {
+	Days int `form:"days" json:"days" zh:"天数" en:"Days" binding:"omitempty,min=1,max=3650"`
+}
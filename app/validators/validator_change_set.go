@@ -0,0 +1,17 @@
+package validators
+
+type ChangeSetItem struct {
+	ResourceType string `json:"resource_type" zh:"资源类型" en:"Resource type" binding:"required,oneof=upstream service router plugin_config"`
+	ResID        string `json:"res_id" zh:"资源ID" en:"Resource id" binding:"required"`
+}
+
+type ChangeSetAdd struct {
+	Name  string          `json:"name" zh:"变更集名称" en:"Change set name" binding:"required,min=1,max=50"`
+	Items []ChangeSetItem `json:"items" zh:"变更资源" en:"Change items" binding:"required,min=1,dive"`
+}
+
+type ChangeSetList struct {
+	Status int    `form:"status" json:"status" zh:"发布状态" en:"Status" binding:"omitempty,oneof=1 2 3"`
+	Search string `form:"search" json:"search" zh:"搜索内容" en:"Search content" binding:"omitempty"`
+	BaseListPage
+}
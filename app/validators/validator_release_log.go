@@ -0,0 +1,5 @@
+package validators
+
+type ReleaseLogList struct {
+	BaseListPage
+}
@@ -0,0 +1,11 @@
+package validators
+
+type SnapshotExport struct {
+	Format    string `form:"format" json:"format" zh:"导出格式" en:"Export format" binding:"omitempty,oneof=json yaml"`
+	Plaintext bool   `form:"plaintext" json:"plaintext" zh:"明文导出" en:"Export plaintext"`
+}
+
+type SnapshotImport struct {
+	Mode   string `form:"mode" json:"mode" zh:"导入模式" en:"Import mode" binding:"omitempty,oneof=merge replace"`
+	DryRun bool   `form:"dry_run" json:"dry_run" zh:"仅预览" en:"Dry run"`
+}
@@ -15,6 +15,14 @@ var (
 		utils.LocalEn: "%s must be one of [%s]",
 		utils.LocalZh: "%s必须是[%s]中的一个",
 	}
+	hashKeyRequiredErrorMessages = map[string]string{
+		utils.LocalEn: "%s is required when hash_on is %s",
+		utils.LocalZh: "%s在hash_on为%s时为必填字段",
+	}
+	healthCheckTimeoutErrorMessages = map[string]string{
+		utils.LocalEn: "%s must be less than interval %d",
+		utils.LocalZh: "%s必须小于检查间隔%d",
+	}
 )
 
 type UpstreamList struct {
@@ -31,11 +39,25 @@ type UpstreamTimeout struct {
 	ConnectTimeout int `json:"connect_timeout" zh:"连接超时" en:"Connect timeout" binding:"omitempty,min=1,max=600000"`
 }
 
+type UpstreamHealthCheck struct {
+	Type      string `json:"type" zh:"健康检查方式" en:"Health check type" binding:"omitempty,oneof=http tcp"`
+	Method    string `json:"method" zh:"健康检查请求方法" en:"Health check method" binding:"omitempty,oneof=GET HEAD POST"`
+	Host      string `json:"host" zh:"健康检查请求域名" en:"Health check host" binding:"omitempty,max=150"`
+	Uri       string `json:"uri" zh:"健康检查请求路径" en:"Health check uri" binding:"omitempty,startswith=/,max=200"`
+	Interval  int    `json:"interval" zh:"健康检查间隔" en:"Health check interval" binding:"omitempty,min=1,max=3600"`
+	Timeout   int    `json:"timeout" zh:"健康检查超时" en:"Health check timeout" binding:"CheckUpstreamHealthCheckTimeout,min=0,max=60"`
+	Healthy   int    `json:"healthy" zh:"健康阈值" en:"Healthy threshold" binding:"omitempty,min=1,max=10"`
+	Unhealthy int    `json:"unhealthy" zh:"异常阈值" en:"Unhealthy threshold" binding:"omitempty,min=1,max=10"`
+}
+
 type UpstreamAddUpdate struct {
 	Name        string `json:"name" zh:"上游名称" en:"Upstream name" binding:"omitempty,min=1,max=30"`
 	LoadBalance int    `json:"load_balance" zh:"负载均衡算法" en:"Load balancing algorithm" binding:"omitempty,CheckLoadBalanceOneOf"`
+	HashOn      string `json:"hash_on" zh:"Hash取值来源" en:"Hash on" binding:"omitempty,oneof=ip header cookie query uri"`
+	HashKey     string `json:"hash_key" zh:"Hash取值名称" en:"Hash key" binding:"CheckUpstreamHashKey,max=100"`
 	Enable      int    `json:"enable" zh:"上游开关" en:"Upstream enable" binding:"omitempty,oneof=1 2"`
 	UpstreamTimeout
+	HealthCheck   UpstreamHealthCheck     `json:"health_check" zh:"健康检查" en:"Health check"`
 	UpstreamNodes []UpstreamNodeAddUpdate `json:"upstream_nodes" zh:"上游节点" en:"Upstream nodes" binding:"required,min=1,CheckUpstreamNode"`
 }
 
@@ -76,10 +98,48 @@ func CheckLoadBalanceOneOf(fl validator.FieldLevel) bool {
 	return true
 }
 
+// CheckUpstreamHashKey 按请求头、Cookie 或请求参数计算一致性Hash时需要指定名称
+func CheckUpstreamHashKey(fl validator.FieldLevel) bool {
+	hashOn := fl.Parent().FieldByName("HashOn").String()
+	if !UpstreamHashKeyNeeded(hashOn) || (len(strings.TrimSpace(fl.Field().String())) != 0) {
+		return true
+	}
+
+	errMsg := fmt.Sprintf(hashKeyRequiredErrorMessages[strings.ToLower(packages.GetValidatorLocale())], fl.FieldName(), hashOn)
+	packages.SetAllCustomizeValidatorErrMsgs("CheckUpstreamHashKey", errMsg)
+
+	return false
+}
+
+// CheckUpstreamHealthCheckTimeout 健康检查超时时间必须小于检查间隔，未设置时按默认值比较
+func CheckUpstreamHealthCheckTimeout(fl validator.FieldLevel) bool {
+	timeout := int(fl.Field().Int())
+	if timeout == 0 {
+		timeout = utils.HealthCheckDefaultTimeout
+	}
+	interval := int(fl.Parent().FieldByName("Interval").Int())
+	if interval == 0 {
+		interval = utils.HealthCheckDefaultInterval
+	}
+	if timeout < interval {
+		return true
+	}
+
+	errMsg := fmt.Sprintf(healthCheckTimeoutErrorMessages[strings.ToLower(packages.GetValidatorLocale())], fl.FieldName(), interval)
+	packages.SetAllCustomizeValidatorErrMsgs("CheckUpstreamHealthCheckTimeout", errMsg)
+
+	return false
+}
+
+func UpstreamHashKeyNeeded(hashOn string) bool {
+	return (hashOn == utils.HashOnHeader) || (hashOn == utils.HashOnCookie) || (hashOn == utils.HashOnQuery)
+}
+
 func CorrectUpstreamDefault(upstreamData *UpstreamAddUpdate) {
 	if upstreamData.LoadBalance == 0 {
 		upstreamData.LoadBalance = utils.LoadBalanceRoundRobin
 	}
+	CorrectUpstreamHash(upstreamData.LoadBalance, &upstreamData.HashOn, &upstreamData.HashKey)
 	if upstreamData.ConnectTimeout == 0 {
 		upstreamData.ConnectTimeout = defaultTimeout
 	}
@@ -89,4 +149,46 @@ func CorrectUpstreamDefault(upstreamData *UpstreamAddUpdate) {
 	if upstreamData.ReadTimeout == 0 {
 		upstreamData.ReadTimeout = defaultTimeout
 	}
+	CorrectUpstreamHealthCheck(&upstreamData.HealthCheck)
+}
+
+// CorrectUpstreamHash 只有一致性Hash需要 hash_on，默认按客户端IP计算，hash_key 只用于请求头、Cookie 与请求参数
+func CorrectUpstreamHash(loadBalance int, hashOn *string, hashKey *string) {
+	*hashKey = strings.TrimSpace(*hashKey)
+	if loadBalance != utils.LoadBalanceCHash {
+		*hashOn = ""
+		*hashKey = ""
+		return
+	}
+
+	if *hashOn == "" {
+		*hashOn = utils.HashOnIp
+	}
+	if !UpstreamHashKeyNeeded(*hashOn) {
+		*hashKey = ""
+	}
+}
+
+func CorrectUpstreamHealthCheck(healthCheck *UpstreamHealthCheck) {
+	if healthCheck.Type == "" {
+		healthCheck.Type = utils.HealthCheckTypeHttp
+	}
+	if healthCheck.Method == "" {
+		healthCheck.Method = utils.HealthCheckDefaultMethod
+	}
+	if healthCheck.Uri == "" {
+		healthCheck.Uri = utils.HealthCheckDefaultUri
+	}
+	if healthCheck.Interval == 0 {
+		healthCheck.Interval = utils.HealthCheckDefaultInterval
+	}
+	if healthCheck.Timeout == 0 {
+		healthCheck.Timeout = utils.HealthCheckDefaultTimeout
+	}
+	if healthCheck.Healthy == 0 {
+		healthCheck.Healthy = utils.HealthCheckDefaultHealthy
+	}
+	if healthCheck.Unhealthy == 0 {
+		healthCheck.Unhealthy = utils.HealthCheckDefaultUnhealthy
+	}
 }
@@ -1,13 +1,86 @@
 package validators
 
+import (
+	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"strings"
+)
+
+var (
+	passwordPolicyMessages = map[string]string{
+		utils.LocalEn: "%s must be %d-%d characters long and contain both letters and digits",
+		utils.LocalZh: "%s长度必须为%d-%d位，且同时包含字母与数字",
+	}
+)
+
 type UserRegister struct {
 	RePassword string `json:"re_password" zh:"确认密码" en:"Confirm Password" binding:"required,eqfield=Password"`
-	Password   string `json:"password" zh:"密码" en:"Password" binding:"required,min=8"`
+	Password   string `json:"password" zh:"密码" en:"Password" binding:"required,CheckPasswordPolicy"`
 	Email      string `json:"email" zh:"邮箱" en:"Email" binding:"required,email"`
 	Name       string `json:"name" zh:"昵称" en:"User name" binding:"required,min=1,max=20"`
 }
 
 type UserLogin struct {
-	Password string `json:"password" zh:"密码" en:"Password" binding:"required,min=8"`
-	Email    string `json:"email" zh:"邮箱" en:"Email" binding:"required,email"`
+	Password    string `json:"password" zh:"密码" en:"Password" binding:"required"`
+	Email       string `json:"email" zh:"邮箱" en:"Email" binding:"required,email"`
+	SessionName string `json:"session_name" zh:"会话名称" en:"Session name" binding:"omitempty,max=50"`
+}
+
+type UserList struct {
+	Enable int    `form:"enable" json:"enable" zh:"用户开关" en:"User enable" binding:"omitempty,oneof=1 2"`
+	Search string `form:"search" json:"search" zh:"搜索内容" en:"Search content" binding:"omitempty"`
+	BaseListPage
+}
+
+type UserInvite struct {
+	Email         string   `json:"email" zh:"邮箱" en:"Email" binding:"required,email"`
+	Name          string   `json:"name" zh:"昵称" en:"User name" binding:"required,min=1,max=20"`
+	Role          string   `json:"role" zh:"角色" en:"Role" binding:"required,oneof=admin operator viewer"`
+	ServiceResIds []string `json:"service_res_ids" zh:"服务权限" en:"Service permissions" binding:"omitempty,dive,required"`
+}
+
+type UserSwitchEnable struct {
+	Enable int `json:"enable" zh:"用户开关" en:"User enable" binding:"required,oneof=1 2"`
+}
+
+type UserPasswordReset struct {
+	Password string `json:"password" zh:"密码" en:"Password" binding:"omitempty,CheckPasswordPolicy"`
+}
+
+type UserTotpLogin struct {
+	TotpToken string `json:"totp_token" zh:"两步验证凭证" en:"Totp token" binding:"required"`
+	Code      string `json:"code" zh:"验证码" en:"Code" binding:"required,max=20"`
+}
+
+type UserTotpLoginEnroll struct {
+	TotpToken string `json:"totp_token" zh:"两步验证凭证" en:"Totp token" binding:"required"`
+}
+
+type UserTotpCode struct {
+	Code string `json:"code" zh:"验证码" en:"Code" binding:"required,max=20"`
+}
+
+type UserApiTokenAdd struct {
+	Name      string `json:"name" zh:"名称" en:"Name" binding:"required,min=1,max=50"`
+	Scope     string `json:"scope" zh:"权限范围" en:"Scope" binding:"required,oneof=read write"`
+	ExpiredAt int64  `json:"expired_at" zh:"过期时间" en:"Expired time" binding:"omitempty,gt=0"`
+}
+
+type UserPasswordChange struct {
+	OldPassword string `json:"old_password" zh:"原密码" en:"Old password" binding:"required"`
+	RePassword  string `json:"re_password" zh:"确认密码" en:"Confirm Password" binding:"required,eqfield=Password"`
+	Password    string `json:"password" zh:"密码" en:"Password" binding:"required,CheckPasswordPolicy"`
+}
+
+func CheckPasswordPolicy(fl validator.FieldLevel) bool {
+	if !utils.PasswordPolicyMatch(fl.Field().String()) {
+		var errMsg string
+		errMsg = fmt.Sprintf(passwordPolicyMessages[strings.ToLower(packages.GetValidatorLocale())], fl.FieldName(), utils.PasswordMinLength, utils.PasswordMaxLength)
+		packages.SetAllCustomizeValidatorErrMsgs("CheckPasswordPolicy", errMsg)
+		return false
+	}
+
+	return true
 }
@@ -0,0 +1,6 @@
+package validators
+
+type UserRoleUpdate struct {
+	Role          string   `json:"role" zh:"角色" en:"Role" binding:"required,oneof=admin operator viewer"`
+	ServiceResIds []string `json:"service_res_ids" zh:"服务权限" en:"Service permissions" binding:"omitempty,dive,required"`
+}
@@ -0,0 +1,25 @@
+package validators
+
+type WebhookAddUpdate struct {
+	Name   string   `json:"name" zh:"名称" en:"Name" binding:"required,min=1,max=50"`
+	Url    string   `json:"url" zh:"地址" en:"Url" binding:"required,url,max=500"`
+	Secret string   `json:"secret" zh:"签名密钥" en:"Secret" binding:"omitempty,min=16,max=100"`
+	Events []string `json:"events" zh:"订阅事件" en:"Events" binding:"required,min=1,dive,required"`
+	Enable int      `json:"enable" zh:"开关" en:"Enable" binding:"required,oneof=1 2"`
+}
+
+type WebhookList struct {
+	Enable int    `form:"enable" json:"enable" zh:"开关" en:"Enable" binding:"omitempty,oneof=1 2"`
+	Search string `form:"search" json:"search" zh:"搜索内容" en:"Search content" binding:"omitempty"`
+	BaseListPage
+}
+
+type WebhookSwitchEnable struct {
+	Enable int `json:"enable" zh:"开关" en:"Enable" binding:"required,oneof=1 2"`
+}
+
+type WebhookDeliveryList struct {
+	Status int    `form:"status" json:"status" zh:"投递状态" en:"Status" binding:"omitempty,oneof=1 2 3"`
+	Event  string `form:"event" json:"event" zh:"事件" en:"Event" binding:"omitempty"`
+	BaseListPage
+}
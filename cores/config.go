@@ -2,18 +2,22 @@ package cores
 
 import (
 	"apioak-admin/app/packages"
+	"apioak-admin/app/utils"
 	"fmt"
 	"github.com/fsnotify/fsnotify"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 	"gorm.io/gorm"
+	"io/ioutil"
+	"sort"
 	"strings"
 )
 
 type ConfigServer struct {
-	Host string `yaml:"host" mapstructure:"host"`
-	Port int    `yaml:"port" mapstructure:"port"`
-	Mode string `yaml:"mode" mapstructure:"mode"`
+	Host           string   `yaml:"host" mapstructure:"host"`
+	Port           int      `yaml:"port" mapstructure:"port"`
+	Mode           string   `yaml:"mode" mapstructure:"mode"`
+	TrustedProxies []string `yaml:"trusted_proxies" mapstructure:"trusted_proxies"`
 }
 
 type Logger struct {
@@ -33,6 +37,7 @@ type ConfigDatabase struct {
 	MaxIdelConnections int    `yaml:"max_idel_connections" mapstructure:"max_idel_connections"`
 	MaxOpenConnections int    `yaml:"max_open_connections" mapstructure:"max_open_connections"`
 	SqlMode            bool   `yaml:"sql_mode" mapstructure:"sql_mode"`
+	AutoMigrate        bool   `yaml:"auto_migrate" mapstructure:"auto_migrate"`
 }
 
 type ConfigToken struct {
@@ -53,19 +58,129 @@ type ConfigApiOak struct {
 	Secret   string `yaml:"secret" mapstructure:"secret"`
 }
 
+type ConfigDrift struct {
+	Interval int  `yaml:"interval" mapstructure:"interval"`
+	AutoHeal bool `yaml:"auto_heal" mapstructure:"auto_heal"`
+}
+
+type ConfigUser struct {
+	Register      string `yaml:"register" mapstructure:"register"`
+	LoginProvider string `yaml:"login_provider" mapstructure:"login_provider"`
+	TotpRequired  bool   `yaml:"totp_required" mapstructure:"totp_required"`
+}
+
+type ConfigLoginLockout struct {
+	MaxFailures   int `yaml:"max_failures" mapstructure:"max_failures"`
+	IpMaxFailures int `yaml:"ip_max_failures" mapstructure:"ip_max_failures"`
+	Duration      int `yaml:"duration" mapstructure:"duration"`
+	MaxDuration   int `yaml:"max_duration" mapstructure:"max_duration"`
+	ResetInterval int `yaml:"reset_interval" mapstructure:"reset_interval"`
+}
+
+type ConfigAudit struct {
+	File string `yaml:"file" mapstructure:"file"`
+}
+
+type ConfigWebhook struct {
+	MaxAttempts   int `yaml:"max_attempts" mapstructure:"max_attempts"`
+	Timeout       int `yaml:"timeout" mapstructure:"timeout"`
+	RetryInterval int `yaml:"retry_interval" mapstructure:"retry_interval"`
+}
+
+type ConfigCertificate struct {
+	CaFile string `yaml:"ca_file" mapstructure:"ca_file"`
+}
+
+type ConfigEncryption struct {
+	Keys           []string `yaml:"keys" mapstructure:"keys"`
+	KeyFile        string   `yaml:"key_file" mapstructure:"key_file"`
+	CurrentVersion int      `yaml:"current_version" mapstructure:"current_version"`
+}
+
+type ConfigCertificateExpiry struct {
+	Interval   int      `yaml:"interval" mapstructure:"interval"`
+	Thresholds []int    `yaml:"thresholds" mapstructure:"thresholds"`
+	Notifiers  []string `yaml:"notifiers" mapstructure:"notifiers"`
+	EmailTo    []string `yaml:"email_to" mapstructure:"email_to"`
+}
+
+type ConfigSmtp struct {
+	Host     string `yaml:"host" mapstructure:"host"`
+	Port     int    `yaml:"port" mapstructure:"port"`
+	Username string `yaml:"username" mapstructure:"username"`
+	Password string `yaml:"password" mapstructure:"password"`
+	From     string `yaml:"from" mapstructure:"from"`
+}
+
+type ConfigAcme struct {
+	Enable             bool   `yaml:"enable" mapstructure:"enable"`
+	DirectoryUrl       string `yaml:"directory_url" mapstructure:"directory_url"`
+	Email              string `yaml:"email" mapstructure:"email"`
+	CaFile             string `yaml:"ca_file" mapstructure:"ca_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" mapstructure:"insecure_skip_verify"`
+	RenewBefore        int    `yaml:"renew_before" mapstructure:"renew_before"`
+	CheckInterval      int    `yaml:"check_interval" mapstructure:"check_interval"`
+	RetryInterval      int    `yaml:"retry_interval" mapstructure:"retry_interval"`
+	Timeout            int    `yaml:"timeout" mapstructure:"timeout"`
+	Http01Listen       string `yaml:"http01_listen" mapstructure:"http01_listen"`
+	DnsProvider        string `yaml:"dns_provider" mapstructure:"dns_provider"`
+	DnsExec            string `yaml:"dns_exec" mapstructure:"dns_exec"`
+	DnsPropagationWait int    `yaml:"dns_propagation_wait" mapstructure:"dns_propagation_wait"`
+}
+
+type ConfigOidc struct {
+	Enable         bool     `yaml:"enable" mapstructure:"enable"`
+	Issuer         string   `yaml:"issuer" mapstructure:"issuer"`
+	ClientId       string   `yaml:"client_id" mapstructure:"client_id"`
+	ClientSecret   string   `yaml:"client_secret" mapstructure:"client_secret"`
+	RedirectUrl    string   `yaml:"redirect_url" mapstructure:"redirect_url"`
+	Scopes         []string `yaml:"scopes" mapstructure:"scopes"`
+	GroupsClaim    string   `yaml:"groups_claim" mapstructure:"groups_claim"`
+	AdminGroups    []string `yaml:"admin_groups" mapstructure:"admin_groups"`
+	OperatorGroups []string `yaml:"operator_groups" mapstructure:"operator_groups"`
+	LoginRedirect  string   `yaml:"login_redirect" mapstructure:"login_redirect"`
+}
+
+type ConfigLdap struct {
+	Url                string   `yaml:"url" mapstructure:"url"`
+	StartTls           bool     `yaml:"start_tls" mapstructure:"start_tls"`
+	InsecureSkipVerify bool     `yaml:"insecure_skip_verify" mapstructure:"insecure_skip_verify"`
+	BindDn             string   `yaml:"bind_dn" mapstructure:"bind_dn"`
+	BindPassword       string   `yaml:"bind_password" mapstructure:"bind_password"`
+	BaseDn             string   `yaml:"base_dn" mapstructure:"base_dn"`
+	UserFilter         string   `yaml:"user_filter" mapstructure:"user_filter"`
+	EmailAttribute     string   `yaml:"email_attribute" mapstructure:"email_attribute"`
+	NameAttribute      string   `yaml:"name_attribute" mapstructure:"name_attribute"`
+	GroupAttribute     string   `yaml:"group_attribute" mapstructure:"group_attribute"`
+	AdminGroups        []string `yaml:"admin_groups" mapstructure:"admin_groups"`
+	OperatorGroups     []string `yaml:"operator_groups" mapstructure:"operator_groups"`
+}
+
 type ConfigRuntime struct {
 	DB  *gorm.DB
 	Gin *gin.Engine
 }
 
 type ConfigGlobal struct {
-	Server    ConfigServer    `yaml:"server" mapstructure:"server"`
-	Logger    Logger          `yaml:"logger" mapstructure:"logger"`
-	Database  ConfigDatabase  `yaml:"database" mapstructure:"database"`
-	Validator ConfigValidator `yaml:"validator" mapstructure:"validator"`
-	Token     ConfigToken     `yaml:"token"`
-	Apioak    ConfigApiOak    `yaml:"apioak" mapstructure:"apioak"`
-	Runtime   ConfigRuntime
+	Server            ConfigServer            `yaml:"server" mapstructure:"server"`
+	Logger            Logger                  `yaml:"logger" mapstructure:"logger"`
+	Database          ConfigDatabase          `yaml:"database" mapstructure:"database"`
+	Validator         ConfigValidator         `yaml:"validator" mapstructure:"validator"`
+	Token             ConfigToken             `yaml:"token"`
+	Apioak            ConfigApiOak            `yaml:"apioak" mapstructure:"apioak"`
+	Drift             ConfigDrift             `yaml:"drift" mapstructure:"drift"`
+	User              ConfigUser              `yaml:"user" mapstructure:"user"`
+	LoginLockout      ConfigLoginLockout      `yaml:"login_lockout" mapstructure:"login_lockout"`
+	Audit             ConfigAudit             `yaml:"audit" mapstructure:"audit"`
+	Webhook           ConfigWebhook           `yaml:"webhook" mapstructure:"webhook"`
+	Certificate       ConfigCertificate       `yaml:"certificate" mapstructure:"certificate"`
+	Encryption        ConfigEncryption        `yaml:"encryption" mapstructure:"encryption"`
+	CertificateExpiry ConfigCertificateExpiry `yaml:"certificate_expiry" mapstructure:"certificate_expiry"`
+	Smtp              ConfigSmtp              `yaml:"smtp" mapstructure:"smtp"`
+	Acme              ConfigAcme              `yaml:"acme" mapstructure:"acme"`
+	Oidc              ConfigOidc              `yaml:"oidc" mapstructure:"oidc"`
+	Ldap              ConfigLdap              `yaml:"ldap" mapstructure:"ldap"`
+	Runtime           ConfigRuntime
 }
 
 // InitConfig 全局配置初始化
@@ -102,6 +217,188 @@ func InitConfig(conf *ConfigGlobal) error {
 	}
 
 	packages.SetConfigApiOak(protocol, conf.Apioak.Ip, conf.Apioak.Port, conf.Apioak.Domain, conf.Apioak.Secret)
+	packages.SetConfigDrift(conf.Drift.Interval, conf.Drift.AutoHeal)
+
+	// 未配置或配置错误的注册方式按只允许注册第一个用户处理
+	register := strings.ToLower(conf.User.Register)
+	if (register != utils.UserRegisterOpen) && (register != utils.UserRegisterClosed) {
+		register = utils.UserRegisterBootstrap
+	}
+	loginProvider := strings.ToLower(conf.User.LoginProvider)
+	if loginProvider != utils.UserLoginProviderLdap {
+		loginProvider = utils.UserLoginProviderLocal
+	}
+	packages.SetConfigUser(register, loginProvider, conf.User.TotpRequired)
+
+	// 未配置的登录锁定参数使用默认值，时间单位为秒
+	loginLockout := conf.LoginLockout
+	if loginLockout.MaxFailures <= 0 {
+		loginLockout.MaxFailures = 5
+	}
+	if loginLockout.IpMaxFailures <= 0 {
+		loginLockout.IpMaxFailures = 20
+	}
+	if loginLockout.Duration <= 0 {
+		loginLockout.Duration = 60
+	}
+	if loginLockout.MaxDuration <= 0 {
+		loginLockout.MaxDuration = 3600
+	}
+	if loginLockout.MaxDuration < loginLockout.Duration {
+		loginLockout.MaxDuration = loginLockout.Duration
+	}
+	if loginLockout.ResetInterval <= 0 {
+		loginLockout.ResetInterval = 900
+	}
+	packages.SetConfigLoginLockout(loginLockout.MaxFailures, loginLockout.IpMaxFailures, loginLockout.Duration,
+		loginLockout.MaxDuration, loginLockout.ResetInterval)
+
+	packages.SetConfigAudit(strings.TrimSpace(conf.Audit.File))
+
+	// 未配置的 Webhook 投递参数使用默认值，时间单位为秒
+	webhook := conf.Webhook
+	if webhook.MaxAttempts <= 0 {
+		webhook.MaxAttempts = 5
+	}
+	if webhook.Timeout <= 0 {
+		webhook.Timeout = 10
+	}
+	if webhook.RetryInterval <= 0 {
+		webhook.RetryInterval = 30
+	}
+	packages.SetConfigWebhook(webhook.MaxAttempts, webhook.Timeout, webhook.RetryInterval)
+
+	packages.SetConfigCertificate(strings.TrimSpace(conf.Certificate.CaFile))
+
+	// 主密钥来自 encryption.keys 与 encryption.key_file（每行一个），未指定当前版本时使用最大的版本加密
+	encryptionKeys := conf.Encryption.Keys
+	if len(conf.Encryption.KeyFile) != 0 {
+		keyFile, err := ioutil.ReadFile(conf.Encryption.KeyFile)
+		if err != nil {
+			return fmt.Errorf("read encryption key file is failed err: `%s`", err)
+		}
+		encryptionKeys = append(encryptionKeys, strings.Split(string(keyFile), "\n")...)
+	}
+	keys := make(map[int][]byte)
+	currentVersion := 0
+	for _, encryptionKey := range encryptionKeys {
+		encryptionKey = strings.TrimSpace(encryptionKey)
+		if (len(encryptionKey) == 0) || strings.HasPrefix(encryptionKey, "#") {
+			continue
+		}
+		version, key, err := utils.EncryptionKeyParse(encryptionKey)
+		if err != nil {
+			return err
+		}
+		if _, ok := keys[version]; ok {
+			return fmt.Errorf("encryption key version %d is duplicated", version)
+		}
+		keys[version] = key
+		if version > currentVersion {
+			currentVersion = version
+		}
+	}
+	if conf.Encryption.CurrentVersion > 0 {
+		if _, ok := keys[conf.Encryption.CurrentVersion]; !ok {
+			return fmt.Errorf("encryption current_version %d has no key", conf.Encryption.CurrentVersion)
+		}
+		currentVersion = conf.Encryption.CurrentVersion
+	}
+	packages.SetConfigEncryption(keys, currentVersion)
+
+	// 未配置的证书过期检查参数使用默认值，检查间隔单位为秒，提醒阈值单位为天
+	certificateExpiry := conf.CertificateExpiry
+	if certificateExpiry.Interval <= 0 {
+		certificateExpiry.Interval = 3600
+	}
+	thresholds := make([]int, 0)
+	for _, threshold := range certificateExpiry.Thresholds {
+		if threshold > 0 {
+			thresholds = append(thresholds, threshold)
+		}
+	}
+	if len(thresholds) == 0 {
+		thresholds = []int{30, 14, 7, 1}
+	}
+	sort.Ints(thresholds)
+	notifiers := make([]string, 0)
+	for _, notifier := range certificateExpiry.Notifiers {
+		notifiers = append(notifiers, strings.ToLower(strings.TrimSpace(notifier)))
+	}
+	if len(certificateExpiry.Notifiers) == 0 {
+		notifiers = []string{utils.CertificateExpiryNotifierLog, utils.CertificateExpiryNotifierWebhook}
+	}
+	packages.SetConfigCertificateExpiry(certificateExpiry.Interval, thresholds, notifiers, certificateExpiry.EmailTo)
+
+	smtpConfig := conf.Smtp
+	if len(smtpConfig.Host) == 0 {
+		smtpConfig.Host = "127.0.0.1"
+	}
+	if smtpConfig.Port <= 0 {
+		smtpConfig.Port = 25
+	}
+	packages.SetConfigSmtp(smtpConfig.Host, smtpConfig.Port, smtpConfig.Username, smtpConfig.Password, smtpConfig.From)
+
+	// 未配置的 ACME 参数使用默认值，renew_before 单位为天，其他时间单位为秒
+	acmeConfig := conf.Acme
+	if len(acmeConfig.DirectoryUrl) == 0 {
+		acmeConfig.DirectoryUrl = "https://acme-v02.api.letsencrypt.org/directory"
+	}
+	if acmeConfig.RenewBefore <= 0 {
+		acmeConfig.RenewBefore = 30
+	}
+	if acmeConfig.CheckInterval <= 0 {
+		acmeConfig.CheckInterval = 3600
+	}
+	if acmeConfig.RetryInterval <= 0 {
+		acmeConfig.RetryInterval = 3600
+	}
+	if acmeConfig.Timeout <= 0 {
+		acmeConfig.Timeout = 300
+	}
+	dnsProvider := strings.ToLower(acmeConfig.DnsProvider)
+	if dnsProvider != utils.AcmeDnsProviderExec {
+		dnsProvider = ""
+	}
+	packages.SetConfigAcme(acmeConfig.Enable, acmeConfig.DirectoryUrl, strings.TrimSpace(acmeConfig.Email),
+		acmeConfig.CaFile, acmeConfig.InsecureSkipVerify, acmeConfig.RenewBefore, acmeConfig.CheckInterval,
+		acmeConfig.RetryInterval, acmeConfig.Timeout, acmeConfig.Http01Listen, dnsProvider, acmeConfig.DnsExec,
+		acmeConfig.DnsPropagationWait)
+
+	ldapConfig := conf.Ldap
+	if len(ldapConfig.UserFilter) == 0 {
+		ldapConfig.UserFilter = "(mail=%s)"
+	}
+	if len(ldapConfig.EmailAttribute) == 0 {
+		ldapConfig.EmailAttribute = "mail"
+	}
+	if len(ldapConfig.NameAttribute) == 0 {
+		ldapConfig.NameAttribute = "cn"
+	}
+	if len(ldapConfig.GroupAttribute) == 0 {
+		ldapConfig.GroupAttribute = "memberOf"
+	}
+	packages.SetConfigLdap(ldapConfig.Url, ldapConfig.StartTls, ldapConfig.InsecureSkipVerify, ldapConfig.BindDn,
+		ldapConfig.BindPassword, ldapConfig.BaseDn, ldapConfig.UserFilter, ldapConfig.EmailAttribute,
+		ldapConfig.NameAttribute, ldapConfig.GroupAttribute, ldapConfig.AdminGroups, ldapConfig.OperatorGroups)
+
+	// scope 中必须包含 openid 才会返回 id_token
+	scopes := []string{"openid"}
+	for _, scope := range conf.Oidc.Scopes {
+		if scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(conf.Oidc.Scopes) == 0 {
+		scopes = append(scopes, "email", "profile")
+	}
+	groupsClaim := conf.Oidc.GroupsClaim
+	if len(groupsClaim) == 0 {
+		groupsClaim = "groups"
+	}
+	packages.SetConfigOidc(conf.Oidc.Enable, strings.TrimRight(conf.Oidc.Issuer, "/"), conf.Oidc.ClientId,
+		conf.Oidc.ClientSecret, conf.Oidc.RedirectUrl, scopes, groupsClaim, conf.Oidc.AdminGroups,
+		conf.Oidc.OperatorGroups, conf.Oidc.LoginRedirect)
 
 	return nil
 }
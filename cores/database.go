@@ -3,6 +3,7 @@ package cores
 import (
 	"apioak-admin/app/packages"
 	"fmt"
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
 	_ "gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -29,7 +30,7 @@ func InitDataBase(conf *ConfigGlobal) error {
 
 	switch dataBaseDriver {
 	case DriverSQLite:
-
+		db, err = gorm.Open(sqlite.Open(sqliteDsn(conf.Database.DbName)))
 	default:
 		db, err = gorm.Open(mysql.Open(fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 			conf.Database.Username,
@@ -0,0 +1,24 @@
+package cores
+
+import (
+	"strings"
+)
+
+// sqliteDsn 使用 db_name 作为数据库文件路径，并附加并发访问所需的 pragma
+func sqliteDsn(dbName string) string {
+	dsn := strings.TrimSpace(dbName)
+	if len(dsn) == 0 {
+		dsn = "apioak.db"
+	}
+
+	if strings.Contains(dsn, "_pragma=") {
+		return dsn
+	}
+
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+
+	return dsn + separator + "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+}
@@ -17,6 +17,10 @@ func InitFramework(conf *ConfigGlobal) error {
 	}
 
 	conf.Runtime.Gin = gin.Default()
+
+	// 只有来自可信代理的请求才使用 X-Forwarded-For 与 X-Real-IP 中的客户端 IP，
+	// 未配置时使用连接的对端地址，避免登录锁定与审计日志中的 IP 被伪造
+	conf.Runtime.Gin.TrustedProxies = conf.Server.TrustedProxies
 	return nil
 }
 
@@ -1,12 +1,21 @@
 package cores
 
 import (
+	"apioak-admin/app/packages"
 	"apioak-admin/app/services"
+	"apioak-admin/routers"
+	"github.com/gin-gonic/gin"
 	"time"
 )
 
 func InitGoroutineFunc() {
 	go dynamicValidationPluginData()
+	go dynamicDriftReconcile()
+	go dynamicWebhookDispatch()
+	go dynamicCertificateExpiryCheck()
+	go dynamicAcmeRenew()
+	go dynamicAcmeHttp01Listen()
+	go certificateSniMaintain()
 }
 
 func dynamicValidationPluginData() {
@@ -20,3 +29,97 @@ func dynamicValidationPluginData() {
 		<-timer.C
 	}
 }
+
+func dynamicDriftReconcile() {
+
+	interval := packages.ConfigDrift.Interval
+	if interval <= 0 {
+		return
+	}
+
+	timer := time.NewTicker(time.Duration(interval) * time.Second)
+	defer timer.Stop()
+
+	for {
+		<-timer.C
+
+		report, err := services.DriftCheck(packages.ConfigDrift.AutoHeal)
+		if err != nil {
+			packages.Log.Error("drift check error", err.Error())
+			continue
+		}
+
+		if report.Drifted != 0 {
+			packages.Log.Warn("drift detected", report.Drifted, "healed", report.Healed)
+		}
+	}
+}
+
+func dynamicWebhookDispatch() {
+
+	timer := time.NewTicker(5 * time.Second)
+	defer timer.Stop()
+
+	for {
+		services.WebhookDispatch()
+
+		select {
+		case <-timer.C:
+		case <-services.WebhookWakeup():
+		}
+	}
+}
+
+func dynamicCertificateExpiryCheck() {
+
+	timer := time.NewTicker(time.Duration(packages.ConfigCertificateExpiry.Interval) * time.Second)
+	defer timer.Stop()
+
+	for {
+		services.CertificateExpiryCheck()
+
+		<-timer.C
+	}
+}
+
+func dynamicAcmeRenew() {
+
+	if !packages.ConfigAcme.Enable {
+		return
+	}
+
+	timer := time.NewTicker(time.Duration(packages.ConfigAcme.CheckInterval) * time.Second)
+	defer timer.Stop()
+
+	for {
+		services.AcmeRenew()
+
+		select {
+		case <-timer.C:
+		case <-services.AcmeWakeup():
+		}
+	}
+}
+
+// dynamicAcmeHttp01Listen 在 acme.http01_listen 上单独响应 http-01 验证，例如监听 80 端口
+func dynamicAcmeHttp01Listen() {
+
+	if !packages.ConfigAcme.Enable || (len(packages.ConfigAcme.Http01Listen) == 0) {
+		return
+	}
+
+	routerEngine := gin.New()
+	routerEngine.Use(gin.Recovery())
+	routers.AcmeRouterRegister(routerEngine)
+
+	if err := routerEngine.Run(packages.ConfigAcme.Http01Listen); err != nil {
+		packages.Log.Error("acme http-01 listen error", err.Error())
+	}
+}
+
+func certificateSniMaintain() {
+
+	if err := services.CertificateSniMaintain(); err != nil {
+		packages.Log.Error("certificate sni maintain error", err.Error())
+	}
+}
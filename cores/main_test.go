@@ -0,0 +1,189 @@
+package cores
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+const testConfig = `server:
+  host: 127.0.0.1
+  port: 3000
+  mode: test
+
+logger:
+  log_path: %[1]s/logs
+  log_file_info: info.log
+  log_file_error: error.log
+  log_reserve: 1
+
+database:
+  driver: sqlite
+  db_name: %[1]s/oak.db
+  max_idel_connections: 10
+  max_open_connections: 100
+
+token:
+  token_issuer: apioak-admin-test
+  token_secret: apioak-admin-test-secret
+  token_expire: 60
+
+validator:
+  locale: en
+
+apioak:
+  protocol: http
+  ip: 127.0.0.1
+  port: 1
+
+user:
+  register: open
+`
+
+const (
+	testEmail    = "admin@apioak.com"
+	testPassword = "Oak-Test-2468"
+)
+
+var (
+	testServer   *httptest.Server
+	testRegister sync.Once
+)
+
+// TestMain 在临时目录中写入配置文件，按 main 的顺序初始化并启动完整的管理接口，数据库为临时目录中的 SQLite 文件
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "apioak-admin-cores")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	code, err := testMainRun(m, dir)
+	os.RemoveAll(dir)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	os.Exit(code)
+}
+
+func testMainRun(m *testing.M, dir string) (int, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "config"), 0755); err != nil {
+		return 0, err
+	}
+	err := ioutil.WriteFile(filepath.Join(dir, "config", "app.yaml"), []byte(fmt.Sprintf(testConfig, dir)), 0644)
+	if err != nil {
+		return 0, err
+	}
+	if err = os.Chdir(dir); err != nil {
+		return 0, err
+	}
+
+	var conf ConfigGlobal
+	initFuncs := []func(conf *ConfigGlobal) error{
+		InitConfig,
+		InitFramework,
+		InitLogger,
+		InitDataBase,
+		InitToken,
+		InitValidator,
+		InitMigration,
+		InitRouter,
+	}
+	for _, initFunc := range initFuncs {
+		if err = initFunc(&conf); err != nil {
+			return 0, err
+		}
+	}
+
+	testServer = httptest.NewServer(conf.Runtime.Gin)
+	defer testServer.Close()
+
+	return m.Run(), nil
+}
+
+type testResult struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// testRequest 请求管理接口，token 不为空时作为 auth-token 请求头
+func testRequest(t *testing.T, method string, path string, token string, body interface{}) testResult {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body == nil {
+		reader = bytes.NewReader(nil)
+	} else {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, testServer.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(token) != 0 {
+		req.Header.Set("auth-token", token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	result := testResult{}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+
+	return result
+}
+
+// testLogin 以第一个注册的用户（管理员）登录，每次登录都是新的会话，返回登录的 token
+func testLogin(t *testing.T) string {
+	t.Helper()
+
+	testRegister.Do(func() {
+		result := testRequest(t, http.MethodPost, "/admin/user/register", "", map[string]string{
+			"name":        "test",
+			"email":       testEmail,
+			"password":    testPassword,
+			"re_password": testPassword,
+		})
+		if result.Code != 0 {
+			t.Fatalf("register: %s", result.Msg)
+		}
+	})
+
+	result := testRequest(t, http.MethodPost, "/admin/user/login", "", map[string]string{
+		"email":    testEmail,
+		"password": testPassword,
+	})
+	if result.Code != 0 {
+		t.Fatalf("login: %s", result.Msg)
+	}
+
+	login := struct {
+		Token string `json:"token"`
+	}{}
+	if err := json.Unmarshal(result.Data, &login); err != nil {
+		t.Fatal(err)
+	}
+
+	return login.Token
+}
@@ -0,0 +1,25 @@
+package cores
+
+import (
+	"apioak-admin/app/migrations"
+	"apioak-admin/app/packages"
+	"strings"
+)
+
+// InitMigration 启动时执行未执行的数据表迁移，SQLite 数据库文件为空时无法使用，因此始终执行
+func InitMigration(conf *ConfigGlobal) error {
+	if !conf.Database.AutoMigrate && (strings.ToUpper(conf.Database.Driver) != DriverSQLite) {
+		return nil
+	}
+
+	applied, err := migrations.Up(conf.Runtime.DB)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range applied {
+		packages.Log.Infof("schema migration %04d_%s applied", migration.Version, migration.Name)
+	}
+
+	return nil
+}
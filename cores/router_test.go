@@ -0,0 +1,123 @@
+package cores
+
+import (
+	"apioak-admin/app/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRouterUserLogin(t *testing.T) {
+	token := testLogin(t)
+
+	result := testRequest(t, http.MethodGet, "/admin/user/session/list", "", nil)
+	if result.Code == 0 {
+		t.Fatal("session list without token succeeded")
+	}
+
+	result = testRequest(t, http.MethodGet, "/admin/user/session/list", token, nil)
+	if result.Code != 0 {
+		t.Fatalf("session list: %s", result.Msg)
+	}
+
+	result = testRequest(t, http.MethodPost, "/admin/user/logout", token, nil)
+	if result.Code != 0 {
+		t.Fatalf("logout: %s", result.Msg)
+	}
+
+	result = testRequest(t, http.MethodGet, "/admin/user/session/list", token, nil)
+	if result.Code == 0 {
+		t.Fatal("session list after logout succeeded")
+	}
+}
+
+func TestRouterUpstream(t *testing.T) {
+	token := testLogin(t)
+	prefix := utils.RandomStrGenerate(8)
+
+	tests := []struct {
+		name        string
+		healthCheck map[string]interface{}
+		errContains string
+	}{
+		{name: "default", healthCheck: map[string]interface{}{}},
+		{name: "timeout below interval", healthCheck: map[string]interface{}{"interval": 5, "timeout": 4}},
+		{name: "timeout equals interval", healthCheck: map[string]interface{}{"interval": 5, "timeout": 5}, errContains: "interval 5"},
+		{name: "timeout above default interval", healthCheck: map[string]interface{}{"timeout": 20}, errContains: "interval 10"},
+		{name: "interval below default timeout", healthCheck: map[string]interface{}{"interval": 2}, errContains: "interval 2"},
+		{name: "unknown type", healthCheck: map[string]interface{}{"type": "udp"}, errContains: "type"},
+	}
+
+	for i, tt := range tests {
+		name := fmt.Sprintf("%s-%d", prefix, i)
+		t.Run(tt.name, func(t *testing.T) {
+			result := testRequest(t, http.MethodPost, "/admin/upstream/add", token, map[string]interface{}{
+				"name":         name,
+				"enable":       1,
+				"health_check": tt.healthCheck,
+				"upstream_nodes": []map[string]interface{}{
+					{"node_ip": "10.0.0.1", "node_port": 80, "node_weight": 1, "health": 1, "health_check": 1},
+				},
+			})
+
+			if len(tt.errContains) == 0 {
+				if result.Code != 0 {
+					t.Fatalf("add: %s", result.Msg)
+				}
+				return
+			}
+			if result.Code == 0 {
+				t.Fatal("add succeeded")
+			}
+			if !strings.Contains(result.Msg, tt.errContains) {
+				t.Fatalf("add error %q does not contain %q", result.Msg, tt.errContains)
+			}
+		})
+	}
+
+	result := testRequest(t, http.MethodGet, "/admin/upstream/list?page=1&page_size=10&search="+prefix, token, nil)
+	if result.Code != 0 {
+		t.Fatalf("list: %s", result.Msg)
+	}
+
+	list := struct {
+		Data []struct {
+			ResID       string `json:"res_id"`
+			Name        string `json:"name"`
+			HealthCheck struct {
+				Interval int `json:"interval"`
+				Timeout  int `json:"timeout"`
+			} `json:"health_check"`
+			NodeList []struct {
+				CheckHealth int `json:"check_health"`
+			} `json:"node_list"`
+		} `json:"data"`
+	}{}
+	if err := json.Unmarshal(result.Data, &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 2 {
+		t.Fatalf("list returned %d upstreams, want 2", len(list.Data))
+	}
+
+	for _, upstream := range list.Data {
+		// 测试中的数据面不可用，列表仍然返回节点，健康状态为空
+		if len(upstream.NodeList) != 1 {
+			t.Fatalf("%s list returned %d nodes, want 1", upstream.Name, len(upstream.NodeList))
+		}
+		if upstream.NodeList[0].CheckHealth != 0 {
+			t.Fatalf("%s node check_health = %d without a data plane", upstream.Name, upstream.NodeList[0].CheckHealth)
+		}
+
+		result = testRequest(t, http.MethodGet, "/admin/upstream/info/"+upstream.ResID, token, nil)
+		if result.Code != 0 {
+			t.Fatalf("info %s: %s", upstream.Name, result.Msg)
+		}
+		if upstream.HealthCheck.Timeout >= upstream.HealthCheck.Interval {
+			t.Fatalf("%s health check timeout %d is not below interval %d", upstream.Name,
+				upstream.HealthCheck.Timeout, upstream.HealthCheck.Interval)
+		}
+	}
+}
@@ -42,6 +42,12 @@ func RegisterCustomizeValidator(validatorEngine *validator.Validate) error {
 	if err := validatorEngine.RegisterValidation("CheckLoadBalanceOneOf", validators.CheckLoadBalanceOneOf); err != nil {
 		return err
 	}
+	if err := validatorEngine.RegisterValidation("CheckUpstreamHashKey", validators.CheckUpstreamHashKey); err != nil {
+		return err
+	}
+	if err := validatorEngine.RegisterValidation("CheckUpstreamHealthCheckTimeout", validators.CheckUpstreamHealthCheckTimeout); err != nil {
+		return err
+	}
 
 	if err := validatorEngine.RegisterValidation("CheckRouterPathPrefix", validators.CheckRouterPathPrefix); err != nil {
 		return err
@@ -57,6 +63,10 @@ func RegisterCustomizeValidator(validatorEngine *validator.Validate) error {
 		return err
 	}
 
+	if err := validatorEngine.RegisterValidation("CheckPasswordPolicy", validators.CheckPasswordPolicy); err != nil {
+		return err
+	}
+
 	packages.SetCustomizeValidator(validatorEngine)
 	return nil
 }
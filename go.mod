@@ -5,6 +5,8 @@ go 1.16
 require (
 	github.com/fsnotify/fsnotify v1.5.1
 	github.com/gin-gonic/gin v1.7.2
+	github.com/glebarez/sqlite v1.7.0
+	github.com/go-ldap/ldap/v3 v3.4.2
 	github.com/go-playground/locales v0.13.0
 	github.com/go-playground/universal-translator v0.17.0
 	github.com/go-playground/validator/v10 v10.8.0
@@ -15,7 +17,8 @@ require (
 	go.uber.org/atomic v1.9.0 // indirect
 	go.uber.org/multierr v1.7.0 // indirect
 	go.uber.org/zap v1.19.1
-	golang.org/x/sys v0.0.0-20211102061401-a2f17f7b995c // indirect
-	gorm.io/driver/mysql v1.1.1
-	gorm.io/gorm v1.21.12
+	golang.org/x/crypto v0.0.0-20210817164053-32db794688a5
+	gopkg.in/yaml.v3 v3.0.1
+	gorm.io/driver/mysql v1.4.7
+	gorm.io/gorm v1.24.5
 )
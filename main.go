@@ -1,12 +1,15 @@
 package main
 
 import (
+	"apioak-admin/app/commands"
 	"apioak-admin/cores"
 	"embed"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"html/template"
 	"io/fs"
 	"net/http"
+	"os"
 )
 
 //go:embed html/*
@@ -47,6 +50,22 @@ func main() {
 		panic(err)
 	}
 
+	// 执行数据表迁移，migrate 子命令自行管理迁移
+	if (len(os.Args) <= 1) || commands.MigrationRequired(os.Args[1:]) {
+		if err := cores.InitMigration(&conf); err != nil {
+			panic(err)
+		}
+	}
+
+	// 执行子命令（如 migrate up），执行完成后退出
+	if len(os.Args) > 1 {
+		if err := commands.Run(os.Args[1:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// 初始化路由
 	if err := cores.InitRouter(&conf); err != nil {
 		panic(err)
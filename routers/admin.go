@@ -3,30 +3,73 @@ package routers
 import (
 	"apioak-admin/app/controllers/admin"
 	"apioak-admin/app/middlewares"
+	"apioak-admin/app/utils"
 	"github.com/gin-gonic/gin"
 )
 
 func RouterRegister(routerEngine *gin.Engine) {
 
+	AcmeRouterRegister(routerEngine)
+
 	noLoginRouter := routerEngine.Group("admin")
 	{
 		user := noLoginRouter.Group("user")
 		{
 			user.POST("/register", admin.UserRegister)
 			user.POST("/login", admin.UserLogin)
+			user.POST("/login/totp", admin.UserTotpLogin)
+			user.POST("/login/totp/enroll", admin.UserTotpLoginEnroll)
+			user.GET("/oidc/login", admin.UserOidcLogin)
+			user.GET("/oidc/callback", admin.UserOidcCallback)
 		}
 	}
 
-	adminRouter := routerEngine.Group("admin", middlewares.CheckUserLogin)
+	adminRouter := routerEngine.Group("admin", middlewares.CheckUserLogin, middlewares.AuditLog)
 	{
 		// user
 		user := adminRouter.Group("user")
 		{
 			user.POST("/logout", admin.UserLogout)
+			user.PUT("/password", admin.UserPasswordChange)
+			user.GET("/session/list", admin.UserSessionList)
+			user.DELETE("/session/delete/:res_id", admin.UserSessionRevoke)
+			user.DELETE("/session/clear", admin.UserSessionRevokeAll)
+			user.GET("/totp/info", admin.UserTotpInfo)
+			user.POST("/totp/enroll", admin.UserTotpEnroll)
+			user.PUT("/totp/activate", admin.UserTotpActivate)
+			user.PUT("/totp/disable", admin.UserTotpDisable)
+			user.PUT("/totp/recovery-codes", admin.UserTotpRecoveryCodes)
+		}
+
+		// user api token
+		userApiToken := adminRouter.Group("user/api-token")
+		{
+			userApiToken.POST("/add", admin.UserApiTokenAdd)
+			userApiToken.GET("/list", admin.UserApiTokenList)
+			userApiToken.DELETE("/delete/:res_id", admin.UserApiTokenDelete)
+		}
+
+		// user manage
+		userManage := adminRouter.Group("user/manage", middlewares.CheckUserAdmin)
+		{
+			userManage.GET("/list", admin.UserList)
+			userManage.POST("/invite", admin.UserInvite)
+			userManage.PUT("/switch/enable/:res_id", admin.UserSwitchEnable)
+			userManage.PUT("/reset/password/:res_id", admin.UserPasswordReset)
+			userManage.PUT("/reset/totp/:res_id", admin.UserTotpReset)
+			userManage.PUT("/unlock/:res_id", admin.UserLoginUnlock)
+			userManage.DELETE("/delete/:res_id", admin.UserDelete)
+		}
+
+		// user role
+		userRole := adminRouter.Group("user/role", middlewares.CheckUserAdmin)
+		{
+			userRole.GET("/info/:res_id", admin.UserRoleInfo)
+			userRole.PUT("/update/:res_id", admin.UserRoleUpdate)
 		}
 
 		// service
-		service := adminRouter.Group("service")
+		service := adminRouter.Group("service", middlewares.CheckUserPermission(utils.UserRoleOperator, middlewares.ServiceFromParam("res_id")))
 		{
 			service.POST("/add", admin.ServiceAdd)
 			service.GET("/list", admin.ServiceList)
@@ -37,9 +80,11 @@ func RouterRegister(routerEngine *gin.Engine) {
 			service.PUT("/update/name/:res_id", admin.ServiceUpdateName)
 			service.PUT("/switch/enable/:res_id", admin.ServiceSwitchEnable)
 			service.PUT("/switch/release/:res_id", admin.ServiceSwitchRelease)
+			service.GET("/releases/:res_id", admin.ServiceReleaseLogList)
+			service.PUT("/rollback/:res_id/:release_id", admin.ServiceRollback)
 		}
 
-		servicePlugin := adminRouter.Group("service/plugin/config")
+		servicePlugin := adminRouter.Group("service/plugin/config", middlewares.CheckUserPermission(utils.UserRoleOperator, middlewares.ServiceFromServicePluginConfig))
 		{
 			servicePlugin.POST("/add", admin.ServicePluginConfigAdd)
 			servicePlugin.GET("/list/:service_res_id", admin.ServicePluginConfigList)
@@ -50,7 +95,7 @@ func RouterRegister(routerEngine *gin.Engine) {
 		}
 
 		// router
-		router := adminRouter.Group("router")
+		router := adminRouter.Group("router", middlewares.CheckUserPermission(utils.UserRoleOperator, middlewares.ServiceFromRouter))
 		{
 			// router
 			router.POST("/add", admin.RouterAdd)
@@ -62,10 +107,12 @@ func RouterRegister(routerEngine *gin.Engine) {
 			router.PUT("/switch/enable/:service_res_id/:router_res_id", admin.RouterSwitchEnable)
 			router.PUT("/switch/release/:service_res_id/:router_res_id", admin.RouterSwitchRelease)
 			router.POST("/copy/:service_res_id/:router_res_id", admin.RouterCopy)
+			router.GET("/releases/:res_id", admin.RouterReleaseLogList)
+			router.PUT("/rollback/:res_id/:release_id", admin.RouterRollback)
 		}
 
 		// router plugin
-		routerPlugin := adminRouter.Group("router/plugin/config")
+		routerPlugin := adminRouter.Group("router/plugin/config", middlewares.CheckUserPermission(utils.UserRoleOperator, middlewares.ServiceFromRouterPluginConfig))
 		{
 			// router plugin
 			routerPlugin.POST("/add", admin.RouterPluginConfigAdd)
@@ -77,7 +124,7 @@ func RouterRegister(routerEngine *gin.Engine) {
 		}
 
 		// upstream
-		upstream := adminRouter.Group("upstream")
+		upstream := adminRouter.Group("upstream", middlewares.CheckUserPermission(utils.UserRoleOperator, nil))
 		{
 			upstream.POST("/add", admin.UpstreamAdd)
 			upstream.GET("/list", admin.UpstreamList)
@@ -88,10 +135,12 @@ func RouterRegister(routerEngine *gin.Engine) {
 			upstream.PUT("/update/name/:res_id", admin.UpstreamUpdateName)
 			upstream.PUT("/switch/enable/:res_id", admin.UpstreamSwitchEnable)
 			upstream.PUT("/switch/release/:res_id", admin.UpstreamSwitchRelease)
+			upstream.GET("/releases/:res_id", admin.UpstreamReleaseLogList)
+			upstream.PUT("/rollback/:res_id/:release_id", admin.UpstreamRollback)
 		}
 
 		// plugin
-		plugin := adminRouter.Group("plugin")
+		plugin := adminRouter.Group("plugin", middlewares.CheckUserPermission(utils.UserRoleOperator, nil))
 		{
 			plugin.GET("/type-list", admin.PluginTypeList)
 			plugin.GET("/add-list", admin.PluginAddList)
@@ -102,9 +151,10 @@ func RouterRegister(routerEngine *gin.Engine) {
 		}
 
 		// certificate
-		certificate := adminRouter.Group("certificate")
+		certificate := adminRouter.Group("certificate", middlewares.CheckUserPermission(utils.UserRoleOperator, nil))
 		{
 			certificate.GET("/list", admin.CertificateList)
+			certificate.GET("/expiring", admin.CertificateExpiringList)
 			certificate.POST("/add", admin.CertificateAdd)
 			certificate.GET("/info/:id", admin.CertificateInfo)
 			certificate.PUT("/update/:id", admin.CertificateUpdate)
@@ -113,11 +163,72 @@ func RouterRegister(routerEngine *gin.Engine) {
 		}
 
 		// cluster node
-		clusterNode := adminRouter.Group("cluster-node")
+		clusterNode := adminRouter.Group("cluster-node", middlewares.CheckUserPermission(utils.UserRoleOperator, nil))
 		{
 			clusterNode.POST("/add", admin.ClusterNodeAdd)
 			clusterNode.GET("/list", admin.ClusterNodeList)
 			clusterNode.DELETE("/delete/:id", admin.ClusterNodeDelete)
 		}
+
+		// snapshot
+		snapshot := adminRouter.Group("snapshot", middlewares.CheckUserAdmin)
+		{
+			snapshot.GET("/export", admin.SnapshotExport)
+			snapshot.POST("/import", admin.SnapshotImport)
+		}
+
+		// drift
+		drift := adminRouter.Group("drift", middlewares.CheckUserPermission(utils.UserRoleOperator, nil))
+		{
+			drift.GET("", admin.DriftReport)
+			drift.POST("/check", admin.DriftCheck)
+			drift.POST("/heal", admin.DriftHeal)
+		}
+
+		// change set
+		changeSet := adminRouter.Group("change-set", middlewares.CheckUserPermission(utils.UserRoleOperator, nil))
+		{
+			changeSet.POST("/add", admin.ChangeSetAdd)
+			changeSet.GET("/list", admin.ChangeSetList)
+			changeSet.GET("/info/:res_id", admin.ChangeSetInfo)
+			changeSet.DELETE("/delete/:res_id", admin.ChangeSetDelete)
+			changeSet.GET("/preview/:res_id", admin.ChangeSetPreview)
+			changeSet.PUT("/publish/:res_id", admin.ChangeSetPublish)
+		}
+
+		// audit
+		audit := adminRouter.Group("audit", middlewares.CheckUserAdmin)
+		{
+			audit.GET("/list", admin.AuditLogList)
+		}
+
+		// webhook
+		webhook := adminRouter.Group("webhook", middlewares.CheckUserAdmin)
+		{
+			webhook.GET("/events", admin.WebhookEventList)
+			webhook.POST("/add", admin.WebhookAdd)
+			webhook.GET("/list", admin.WebhookList)
+			webhook.GET("/info/:res_id", admin.WebhookInfo)
+			webhook.PUT("/update/:res_id", admin.WebhookUpdate)
+			webhook.DELETE("/delete/:res_id", admin.WebhookDelete)
+			webhook.PUT("/switch/enable/:res_id", admin.WebhookSwitchEnable)
+			webhook.POST("/test/:res_id", admin.WebhookTest)
+			webhook.GET("/delivery/list/:res_id", admin.WebhookDeliveryList)
+			webhook.PUT("/delivery/retry/:res_id", admin.WebhookDeliveryRetry)
+		}
+
+		// acme
+		acme := adminRouter.Group("acme", middlewares.CheckUserPermission(utils.UserRoleOperator, nil))
+		{
+			acme.POST("/add", admin.AcmeCertificateAdd)
+			acme.GET("/list", admin.AcmeCertificateList)
+			acme.PUT("/renew/:res_id", admin.AcmeCertificateRenew)
+			acme.DELETE("/delete/:res_id", admin.AcmeCertificateDelete)
+		}
 	}
 }
+
+// AcmeRouterRegister http-01 验证路径，管理端口与 acme.http01_listen 共用
+func AcmeRouterRegister(routerEngine *gin.Engine) {
+	routerEngine.GET("/.well-known/acme-challenge/:token", admin.AcmeHttp01Challenge)
+}